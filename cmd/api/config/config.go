@@ -19,14 +19,22 @@ func Load() (*Configuration, error) {
 		return nil, fmt.Errorf("unable to decode into struct, %v", err)
 	}
 
+	if cfg.Realtime.Heartbeat <= 0 {
+		return nil, fmt.Errorf("WS_HEARTBEAT must be positive, got %d", cfg.Realtime.Heartbeat)
+	}
+
 	return cfg, nil
 }
 
 // Configuration holds data necessery for configuring application
 type Configuration struct {
-	Server *Server
-	DB     *Database
-	JWT    *JWT
+	Server    *Server
+	DB        *Database
+	JWT       *JWT
+	RBAC      *RBAC
+	Realtime  *Realtime
+	Retention *Retention
+	Storage   *Storage
 }
 
 // Database holds data necessery for database configuration
@@ -54,3 +62,36 @@ type JWT struct {
 	MaxRefresh       int
 	SigningAlgorithm string `envconfig:"JWT_ALGORITHM" default:"HS256"`
 }
+
+// RBAC holds data necessery for access control configuration
+type RBAC struct {
+	LogDecisions bool `envconfig:"RBAC_LOG" default:"false"`
+}
+
+// Realtime holds data necessery for realtime gateway configuration
+type Realtime struct {
+	Heartbeat int `envconfig:"WS_HEARTBEAT" default:"30"`
+	Buffer    int `envconfig:"WS_BUFFER" default:"64"`
+	History   int `envconfig:"EVENT_HISTORY" default:"100"`
+	Retention int `envconfig:"EVENT_RETENTION" default:"24"`
+	Presence  int `envconfig:"PRESENCE_SYNC" default:"30"`
+}
+
+// Retention holds data necessery for purging deleted data
+type Retention struct {
+	Users int `envconfig:"USER_RETENTION" default:"30"`
+	Purge int `envconfig:"PURGE_INTERVAL" default:"60"`
+}
+
+// Storage holds data necessery for file storage configuration
+type Storage struct {
+	Driver     string `envconfig:"STORAGE" default:"local"`
+	Dir        string `envconfig:"STORAGE_DIR" default:"uploads"`
+	URL        string `envconfig:"STORAGE_URL" default:"/uploads"`
+	Endpoint   string `envconfig:"S3_ENDPOINT"`
+	Region     string `envconfig:"S3_REGION" default:"us-east-1"`
+	Bucket     string `envconfig:"S3_BUCKET"`
+	AccessKey  string `envconfig:"S3_ACCESS_KEY"`
+	SecretKey  string `envconfig:"S3_SECRET_KEY"`
+	AvatarSize int    `envconfig:"AVATAR_MAX_SIZE" default:"5"`
+}
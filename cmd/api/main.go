@@ -26,15 +26,35 @@
 package main
 
 import (
+	"strings"
+	"time"
+
 	"github.com/artistomin/friend4me/cmd/api/config"
 	"github.com/artistomin/friend4me/cmd/api/mw"
 	"github.com/artistomin/friend4me/cmd/api/server"
 	"github.com/artistomin/friend4me/cmd/api/service"
 	_ "github.com/artistomin/friend4me/cmd/api/swagger"
+	"github.com/artistomin/friend4me/internal"
 	"github.com/artistomin/friend4me/internal/account"
+	"github.com/artistomin/friend4me/internal/activity"
 	"github.com/artistomin/friend4me/internal/auth"
+	"github.com/artistomin/friend4me/internal/avatar"
+	"github.com/artistomin/friend4me/internal/block"
+	"github.com/artistomin/friend4me/internal/friend"
+	"github.com/artistomin/friend4me/internal/grant"
+	"github.com/artistomin/friend4me/internal/group"
+	"github.com/artistomin/friend4me/internal/meetup"
+	"github.com/artistomin/friend4me/internal/message"
+	"github.com/artistomin/friend4me/internal/notification"
 	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/artistomin/friend4me/internal/platform/storage"
+	"github.com/artistomin/friend4me/internal/preference"
+	"github.com/artistomin/friend4me/internal/presence"
+	"github.com/artistomin/friend4me/internal/privacy"
 	"github.com/artistomin/friend4me/internal/rbac"
+	"github.com/artistomin/friend4me/internal/realtime"
+	"github.com/artistomin/friend4me/internal/search"
+	"github.com/artistomin/friend4me/internal/tag"
 	"github.com/artistomin/friend4me/internal/user"
 	"github.com/go-pg/pg"
 	"github.com/labstack/echo"
@@ -61,26 +81,103 @@ func addV1Services(cfg *config.Configuration, e *echo.Echo, db *pg.DB) {
 
 	userDB := pgsql.NewUserDB(db, e.Logger)
 	accDB := pgsql.NewAccountDB(db, e.Logger)
+	grantDB := pgsql.NewGrantDB(db, e.Logger)
+	friendDB := pgsql.NewFriendDB(db, e.Logger)
+	blockDB := pgsql.NewBlockDB(db, e.Logger)
+	suggestionDB := pgsql.NewSuggestionDB(db, e.Logger)
+	messageDB := pgsql.NewMessageDB(db, e.Logger)
+	notificationDB := pgsql.NewNotificationDB(db, e.Logger)
+	presenceDB := pgsql.NewPresenceDB(db, e.Logger)
+	activityDB := pgsql.NewActivityDB(db, e.Logger)
+	meetupDB := pgsql.NewMeetupDB(db, e.Logger)
+	tagDB := pgsql.NewTagDB(db, e.Logger)
+	groupDB := pgsql.NewGroupDB(db, e.Logger)
+	privacyDB := pgsql.NewPrivacyDB(db, e.Logger)
+	preferenceDB := pgsql.NewPreferenceDB(db, e.Logger)
+
+	// Users are searched with Postgres full-text search, another model.Searcher can take its place
+	var searcher model.Searcher = pgsql.NewSearcher(db, e.Logger)
+
+	// Files are kept in a local directory, served by the API when their URL is a path, or in an S3 bucket,
+	// served by the bucket itself unless their URL points elsewhere, e.g. to a CDN
+	var store model.Storage = storage.NewLocal(cfg.Storage.Dir, cfg.Storage.URL)
+	local := strings.HasPrefix(cfg.Storage.URL, "/")
+	if cfg.Storage.Driver == "s3" {
+		publicURL := cfg.Storage.URL
+		if local {
+			publicURL = ""
+		}
+		store = storage.NewS3(cfg.Storage.Endpoint, cfg.Storage.Region, cfg.Storage.Bucket,
+			cfg.Storage.AccessKey, cfg.Storage.SecretKey, publicURL)
+	} else if local {
+		e.Static(cfg.Storage.URL, cfg.Storage.Dir)
+	}
 
 	// Initalize services
 
+	var rbacLog echo.Logger
+	if cfg.RBAC.LogDecisions {
+		rbacLog = e.Logger
+	}
+	rbacSvc := rbac.New(userDB, grantDB, rbacLog)
+
+	// Events are stored and relayed between instances through Postgres, local hub only fans them out
+	broker := pgsql.NewBroker(db, realtime.NewHub(cfg.Realtime.Buffer, 0), cfg.Realtime.History, e.Logger)
+	broker.Listen()
+	if cfg.Realtime.Retention > 0 {
+		go broker.RunPrune(time.Duration(cfg.Realtime.Retention)*time.Hour, time.Duration(cfg.Retention.Purge)*time.Minute)
+	}
+	// Realtime gateways subscribe through presence tracker, so that it sees who is connected
+	presenceSvc := presence.New(presenceDB, broker)
+	go presenceSvc.Run(time.Duration(cfg.Realtime.Presence)*time.Second, e.Logger)
+
 	jwt := mw.NewJWT(cfg.JWT)
+	jwt.Resolver = rbacSvc
 	authSvc := auth.New(userDB, jwt)
-	service.NewAuth(authSvc, e, jwt.MWFunc())
+	notificationSvc := notification.New(notificationDB, broker, authSvc)
+	activitySvc := activity.New(activityDB, authSvc)
+	preferenceSvc := preference.New(preferenceDB, rbacSvc, authSvc)
+	tenant := pgsql.NewTenant(db)
+	service.NewAuth(authSvc, e, mw.Unscoped(tenant), jwt.MWFunc(), mw.Tenant(tenant))
+	heartbeat := time.Duration(cfg.Realtime.Heartbeat) * time.Second
+	service.NewRealtime(presenceSvc, authSvc, heartbeat, e, mw.QueryToken("token"), jwt.MWFunc())
+	// Registered outside of v1 group, so that long-lived streams don't hold a transaction open
+	service.NewEvents(presenceSvc, authSvc, heartbeat, time.Duration(cfg.Server.WriteTimeout)*time.Minute, e, mw.QueryToken("token"), jwt.MWFunc())
 
 	e.Static("/swaggerui", "cmd/api/swaggerui")
 
-	rbacSvc := rbac.New(userDB)
-
 	v1Router := e.Group("/v1")
 
-	v1Router.Use(jwt.MWFunc())
+	v1Router.Use(jwt.MWFunc(), mw.Presence(presenceSvc), mw.Tenant(tenant))
 
 	// Workaround for Echo's issue with routing.
 	// v1Router should be passed to service normally, and then the group name created there
 	uR := v1Router.Group("/users")
-	service.NewAccount(account.New(accDB, userDB, rbacSvc), uR)
-	service.NewUser(user.New(userDB, rbacSvc, authSvc), uR)
+	service.NewAccount(account.New(accDB, userDB, rbacSvc, notificationSvc, activitySvc), uR)
+	userSvc := user.New(userDB, blockDB, tagDB, rbacSvc, notificationSvc, activitySvc, authSvc, store)
+	service.NewUser(userSvc, uR)
+	service.NewAvatar(avatar.New(userDB, store, rbacSvc), uR, int64(cfg.Storage.AvatarSize)<<20)
+	// Deleted users are purged once retention passes, or kept forever when it is 0
+	if cfg.Retention.Users > 0 {
+		go userSvc.RunPurge(time.Duration(cfg.Retention.Users)*24*time.Hour, time.Duration(cfg.Retention.Purge)*time.Minute, e.Logger)
+	}
+
+	service.NewAuthz(rbacSvc, v1Router.Group("/authz"))
+	service.NewGrant(grant.New(grantDB, userDB, rbacSvc, notificationSvc, authSvc), v1Router.Group("/grants"))
+	service.NewFriend(friend.New(friendDB, userDB, blockDB, suggestionDB, broker, notificationSvc, presenceSvc, activitySvc, preferenceSvc, authSvc), v1Router.Group("/friends"))
+	service.NewBlock(block.New(blockDB, friendDB, userDB, suggestionDB, authSvc), v1Router.Group("/blocks"))
+	service.NewMessage(message.New(messageDB, userDB, blockDB, broker, rbacSvc, authSvc), v1Router.Group("/conversations"))
+	service.NewNotification(notificationSvc, v1Router.Group("/notifications"))
+	service.NewActivity(activitySvc, v1Router.Group("/feed"))
+	service.NewMeetup(meetup.New(meetupDB, notificationSvc, rbacSvc, authSvc), v1Router.Group("/meetups"))
+	service.NewTag(tag.New(tagDB, rbacSvc, authSvc), v1Router.Group("/tags"))
+	service.NewGroup(group.New(groupDB, userDB, rbacSvc, authSvc), v1Router.Group("/groups"))
+	service.NewSearch(search.New(searcher, blockDB, authSvc), v1Router.Group("/search"))
+	// Data export and erasure requests of the current user are registered next to /me, outside of v1 group,
+	// and run in transactions scoped to the user's company like v1 requests
+	service.NewPrivacy(privacy.New(privacyDB, store, rbacSvc, authSvc), e, v1Router.Group("/erasures"), jwt.MWFunc(), mw.Tenant(tenant))
+	// Preferences of the current user are registered next to /me as well
+	service.NewPreference(preferenceSvc, e, v1Router.Group("/companies"), jwt.MWFunc(), mw.Tenant(tenant))
 }
 
 func checkErr(err error) {
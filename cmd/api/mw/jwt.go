@@ -36,6 +36,14 @@ type JWT struct {
 
 	// JWT signing algorithm
 	Algo string
+
+	// Resolver, if set, builds the effective user from token claims, e.g. by applying temporary role grants
+	Resolver Resolver
+}
+
+// Resolver represents effective user resolver interface
+type Resolver interface {
+	Effective(*model.AuthUser) (*model.AuthUser, error)
 }
 
 // MWFunc makes JWT implement the Middleware interface.
@@ -50,25 +58,57 @@ func (j *JWT) MWFunc() echo.MiddlewareFunc {
 
 			claims := token.Claims.(jwt.MapClaims)
 
-			id := int(claims["id"].(float64))
-			companyID := int(claims["c"].(float64))
-			locationID := int(claims["l"].(float64))
-			username := claims["u"].(string)
-			email := claims["e"].(string)
-			role := int8(claims["r"].(float64))
+			u := &model.AuthUser{
+				ID:         int(claims["id"].(float64)),
+				CompanyID:  int(claims["c"].(float64)),
+				LocationID: int(claims["l"].(float64)),
+				Username:   claims["u"].(string),
+				Email:      claims["e"].(string),
+				Role:       model.AccessRole(claims["r"].(float64)),
+			}
+
+			if j.Resolver != nil {
+				if u, err = j.Resolver.Effective(u); err != nil {
+					return err
+				}
+			}
 
-			c.Set("id", id)
-			c.Set("company_id", companyID)
-			c.Set("location_id", locationID)
-			c.Set("username", username)
-			c.Set("email", email)
-			c.Set("role", role)
+			c.Set("id", u.ID)
+			c.Set("company_id", u.CompanyID)
+			c.Set("location_id", u.LocationID)
+			c.Set("username", u.Username)
+			c.Set("email", u.Email)
+			c.Set("role", int8(u.Role))
+			if u.GrantID != 0 {
+				c.Set("grant_id", u.GrantID)
+			}
 
 			return next(c)
 		}
 	}
 }
 
+// QueryToken moves token from the query parameter to Authorization header, unless the header is already set.
+// Used for clients that cannot set headers, e.g. browser WebSocket and EventSource.
+// The parameter is removed from request URI, so that the token is not logged
+func QueryToken(param string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			q := req.URL.Query()
+			if t := q.Get(param); t != "" {
+				if req.Header.Get("Authorization") == "" {
+					req.Header.Set("Authorization", "Bearer "+t)
+				}
+				q.Del(param)
+				req.URL.RawQuery = q.Encode()
+				req.RequestURI = req.URL.RequestURI()
+			}
+			return next(c)
+		}
+	}
+}
+
 // ParseToken parses token from Authorization header
 func (j *JWT) ParseToken(c echo.Context) (*jwt.Token, error) {
 
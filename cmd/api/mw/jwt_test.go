@@ -1,6 +1,7 @@
 package mw_test
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -76,6 +77,39 @@ func TestMWFunc(t *testing.T) {
 	}
 }
 
+func TestQueryToken(t *testing.T) {
+	jwtCfg := &config.JWT{Realm: "testRealm", Secret: "jwtsecret", Duration: 60, SigningAlgorithm: "HS256"}
+	jwtMW := mw.NewJWT(jwtCfg)
+	// Request logger reads the URI once the request is handled
+	var logged string
+	logger := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			logged = c.Request().RequestURI
+			return err
+		}
+	}
+	ts := httptest.NewServer(echoHandler(logger, mw.QueryToken("token"), jwtMW.MWFunc()))
+	defer ts.Close()
+	token := strings.TrimPrefix(mock.HeaderValid(), "Bearer ")
+
+	res, err := http.Get(ts.URL + "/hello?token=" + token + "&last_event_id=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "/hello?last_event_id=3", logged)
+
+	// Authorization header takes precedence
+	req, _ := http.NewRequest("GET", ts.URL+"/hello?token="+token, nil)
+	req.Header.Set("Authorization", mock.HeaderInvalid())
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
 func TestGenerateToken(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -110,3 +144,55 @@ func TestGenerateToken(t *testing.T) {
 		})
 	}
 }
+
+func TestMWFuncResolver(t *testing.T) {
+	cases := []struct {
+		name       string
+		resolver   *mock.Resolver
+		wantStatus int
+		wantRole   string
+	}{
+		{
+			name: "Fail on resolving effective user",
+			resolver: &mock.Resolver{
+				EffectiveFn: func(*model.AuthUser) (*model.AuthUser, error) {
+					return nil, model.ErrGeneric
+				},
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "Role elevated by grant",
+			resolver: &mock.Resolver{
+				EffectiveFn: func(u *model.AuthUser) (*model.AuthUser, error) {
+					eff := *u
+					eff.Role = model.CompanyAdminRole
+					eff.GrantID = 3
+					return &eff, nil
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantRole:   "3/3",
+		},
+	}
+	jwtCfg := &config.JWT{Realm: "testRealm", Secret: "jwtsecret", Duration: 60, SigningAlgorithm: "HS256"}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtMW := mw.NewJWT(jwtCfg)
+			jwtMW.Resolver = tt.resolver
+			e := echo.New()
+			e.GET("/role", func(c echo.Context) error {
+				return c.String(http.StatusOK, fmt.Sprintf("%d/%d", c.Get("role"), c.Get("grant_id")))
+			}, jwtMW.MWFunc())
+			req := httptest.NewRequest("GET", "/role", nil)
+			req.Header.Set("Authorization", mock.HeaderValid())
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, req)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantRole != "" {
+				assert.Equal(t, tt.wantRole, w.Body.String())
+			}
+		})
+	}
+}
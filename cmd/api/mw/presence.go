@@ -0,0 +1,20 @@
+package mw
+
+import (
+	"time"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+)
+
+// Presence records activity of the requesting user. Must be used after JWT middleware
+func Presence(t model.PresenceTracker) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if id, ok := c.Get("id").(int); ok {
+				t.Seen(id, time.Now())
+			}
+			return next(c)
+		}
+	}
+}
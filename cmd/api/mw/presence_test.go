@@ -0,0 +1,56 @@
+package mw_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal/mock"
+
+	"github.com/artistomin/friend4me/cmd/api/mw"
+)
+
+func TestPresence(t *testing.T) {
+	cases := []struct {
+		name     string
+		id       interface{}
+		wantSeen []int
+	}{
+		{
+			name: "Anonymous request",
+		},
+		{
+			name:     "Authenticated request",
+			id:       3,
+			wantSeen: []int{3},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen []int
+			tracker := &mock.Presence{
+				SeenFn: func(id int, at time.Time) {
+					seen = append(seen, id)
+				}}
+			e := echo.New()
+			e.GET("/hello", func(c echo.Context) error {
+				return c.String(http.StatusOK, "Hello World")
+			}, func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					if tt.id != nil {
+						c.Set("id", tt.id)
+					}
+					return next(c)
+				}
+			}, mw.Presence(tracker))
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest("GET", "/hello", nil))
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.wantSeen, seen)
+		})
+	}
+}
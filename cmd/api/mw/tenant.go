@@ -0,0 +1,144 @@
+package mw
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+)
+
+// Tenant runs the request in a transaction isolated to the requesting user's company.
+// Must be used after JWT middleware. Admins are not isolated to any company.
+// The transaction is rolled back if the handler returns an error.
+// Responses are held back until the transaction commits, so that clients are not told about writes
+// which failed to commit. Functions registered with model.AfterCommit run once the response is sent,
+// and their failures are only logged. Flushed responses are streamed instead, so streaming handlers must not write
+// anything that depends on the transaction committing
+func Tenant(db model.TenantDB) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			role, ok := c.Get("role").(int8)
+			if !ok {
+				return echo.ErrUnauthorized
+			}
+			companyID, _ := c.Get("company_id").(int)
+			if model.AccessRole(role) <= model.AdminRole {
+				companyID = 0
+			}
+			return inTx(c, db, companyID, next)
+		}
+	}
+}
+
+// Unscoped runs the request in a transaction not isolated to any company,
+// for requests which have to find their user first, e.g. logging in
+func Unscoped(db model.TenantDB) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return inTx(c, db, 0, next)
+		}
+	}
+}
+
+func inTx(c echo.Context, db model.TenantDB, companyID int, next echo.HandlerFunc) error {
+	tx, err := db.Begin(companyID)
+	if err != nil {
+		return err
+	}
+	model.SetConn(c, tx)
+	committed := model.HoldCommitted(c)
+	res := c.Response()
+	w := &txWriter{ResponseWriter: res.Writer, header: http.Header{}, status: http.StatusOK}
+	for k, v := range res.Header() {
+		w.header[k] = v
+	}
+	res.Writer = w
+	if err := next(c); err != nil {
+		tx.Rollback()
+		return discard(res, w, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return discard(res, w, err)
+	}
+	err = w.flush()
+	for _, e := range committed() {
+		c.Logger().Warnf("After commit: %v", e)
+	}
+	return err
+}
+
+// discard drops the held back response, so that the error is sent in its place
+func discard(res *echo.Response, w *txWriter, err error) error {
+	if w.streaming {
+		return err
+	}
+	res.Writer = w.ResponseWriter
+	res.Committed, res.Status, res.Size = false, http.StatusOK, 0
+	return err
+}
+
+// txWriter holds the response back until the transaction commits, or until it is flushed
+type txWriter struct {
+	http.ResponseWriter
+	header    http.Header
+	status    int
+	body      bytes.Buffer
+	streaming bool
+}
+
+func (w *txWriter) Header() http.Header {
+	if w.streaming {
+		return w.ResponseWriter.Header()
+	}
+	return w.header
+}
+
+func (w *txWriter) WriteHeader(code int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *txWriter) Write(b []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.body.Write(b)
+}
+
+// Flush sends the held back response and streams the rest of it
+func (w *txWriter) Flush() {
+	if err := w.flush(); err == nil {
+		w.ResponseWriter.(http.Flusher).Flush()
+	}
+}
+
+// Hijack takes over the connection of a streamed response, e.g. to abort it.
+// Held back responses can not be hijacked, as they are yet to be sent
+func (w *txWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !w.streaming || !ok {
+		return nil, nil, errors.New("response can not be hijacked")
+	}
+	return h.Hijack()
+}
+
+func (w *txWriter) flush() error {
+	if w.streaming {
+		return nil
+	}
+	w.streaming = true
+	h := w.ResponseWriter.Header()
+	for k, v := range w.header {
+		h[k] = v
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.body.Bytes())
+	return err
+}
@@ -0,0 +1,181 @@
+package mw_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+
+	"github.com/artistomin/friend4me/cmd/api/mw"
+)
+
+func TestTenant(t *testing.T) {
+	cases := []struct {
+		name         string
+		role         model.AccessRole
+		noRole       bool
+		handlerErr   error
+		beginErr     error
+		commitErr    error
+		wantCompany  int
+		wantStatus   int
+		wantCommit   bool
+		wantRollback bool
+		wantBody     string
+	}{
+		{
+			name:       "Fail on missing role",
+			noRole:     true,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "Fail on begin",
+			role:        model.CompanyAdminRole,
+			beginErr:    errors.New("connection refused"),
+			wantCompany: 2,
+			wantStatus:  http.StatusInternalServerError,
+		},
+		{
+			name:         "Rollback on handler error",
+			role:         model.UserRole,
+			handlerErr:   echo.ErrForbidden,
+			wantCompany:  2,
+			wantStatus:   http.StatusForbidden,
+			wantRollback: true,
+		},
+		{
+			name:        "Fail on commit",
+			role:        model.UserRole,
+			commitErr:   errors.New("could not serialize access"),
+			wantCompany: 2,
+			wantStatus:  http.StatusInternalServerError,
+		},
+		{
+			name:        "Scoped to company",
+			role:        model.CompanyAdminRole,
+			wantCompany: 2,
+			wantStatus:  http.StatusOK,
+			wantCommit:  true,
+			wantBody:    "Hello World",
+		},
+		{
+			name:       "Admin is not scoped",
+			role:       model.AdminRole,
+			wantStatus: http.StatusOK,
+			wantCommit: true,
+			wantBody:   "Hello World",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var company int
+			var committed, rolledBack, ranCommitted bool
+			tx := &mockdb.Tx{
+				CommitFn: func() error {
+					committed = tt.commitErr == nil
+					return tt.commitErr
+				},
+				RollbackFn: func() error {
+					rolledBack = true
+					return nil
+				},
+			}
+			tdb := &mockdb.Tenant{
+				BeginFn: func(id int) (model.TenantTx, error) {
+					company = id
+					if tt.beginErr != nil {
+						return nil, tt.beginErr
+					}
+					return tx, nil
+				},
+			}
+			e := echo.New()
+			e.GET("/hello", func(c echo.Context) error {
+				assert.Equal(t, tx, model.Conn(c))
+				model.AfterCommit(c, func() error {
+					ranCommitted = true
+					return nil
+				})
+				if tt.handlerErr != nil {
+					return tt.handlerErr
+				}
+				return c.String(http.StatusOK, "Hello World")
+			}, func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.Set("company_id", 2)
+					if !tt.noRole {
+						c.Set("role", int8(tt.role))
+					}
+					return next(c)
+				}
+			}, mw.Tenant(tdb))
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest("GET", "/hello", nil))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantCompany, company)
+			assert.Equal(t, tt.wantCommit, committed)
+			assert.Equal(t, tt.wantRollback, rolledBack)
+			assert.Equal(t, tt.wantCommit, ranCommitted, "functions run only after commit")
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rec.Body.String())
+			} else {
+				assert.NotContains(t, rec.Body.String(), "Hello World")
+			}
+		})
+	}
+}
+
+func TestUnscoped(t *testing.T) {
+	company := -1
+	tdb := &mockdb.Tenant{
+		BeginFn: func(id int) (model.TenantTx, error) {
+			company = id
+			return &mockdb.Tx{CommitFn: func() error { return nil }}, nil
+		},
+	}
+	e := echo.New()
+	e.GET("/hello", func(c echo.Context) error {
+		return c.String(http.StatusOK, "Hello World")
+	}, mw.Unscoped(tdb))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/hello", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 0, company)
+	assert.Equal(t, "Hello World", rec.Body.String())
+}
+
+func TestTenantStreaming(t *testing.T) {
+	tdb := &mockdb.Tenant{
+		BeginFn: func(id int) (model.TenantTx, error) {
+			return &mockdb.Tx{CommitFn: func() error { return nil }}, nil
+		},
+	}
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	e.GET("/stream", func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlain)
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write([]byte("first"))
+		_, _, err := c.Response().Hijack()
+		assert.NotNil(t, err)
+		c.Response().Flush()
+		// Flushed part of the response is sent before the handler returns
+		assert.Equal(t, "first", rec.Body.String())
+		c.Response().Write([]byte(" second"))
+		return nil
+	}, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("role", int8(model.UserRole))
+			return next(c)
+		}
+	}, mw.Tenant(tdb))
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/stream", nil))
+	assert.Equal(t, "first second", rec.Body.String())
+	assert.Equal(t, echo.MIMETextPlain, rec.Header().Get(echo.HeaderContentType))
+}
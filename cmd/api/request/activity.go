@@ -0,0 +1,15 @@
+package request
+
+import (
+	"github.com/labstack/echo"
+)
+
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+)
+
+// FeedCursor validates activity feed cursor, from before and limit query parameters
+func FeedCursor(c echo.Context) (*Cursor, error) {
+	return cursor(c, defaultFeedLimit, maxFeedLimit)
+}
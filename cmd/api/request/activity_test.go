@@ -0,0 +1,46 @@
+package request_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestFeedCursor(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.Cursor
+	}{
+		{
+			name:    "Invalid before",
+			req:     "?before=-3",
+			wantErr: true,
+		},
+		{
+			name:     "Default",
+			wantData: &request.Cursor{Limit: 20},
+		},
+		{
+			name:     "Limit too large",
+			req:      "?before=20&limit=500",
+			wantData: &request.Cursor{Before: 20, Limit: 100},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/"+tt.req, nil)
+			c := mock.EchoCtx(req, w)
+			cur, err := request.FeedCursor(c)
+			assert.Equal(t, tt.wantData, cur)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package request
+
+import (
+	"github.com/labstack/echo"
+)
+
+// Explain contains authorization explain request
+type Explain struct {
+	Action   string          `json:"action" validate:"required"`
+	Subject  ExplainSubject  `json:"subject"`
+	Resource ExplainResource `json:"resource"`
+}
+
+// ExplainSubject contains the hypothetical user performing the action
+type ExplainSubject struct {
+	ID         int `json:"id"`
+	Role       int `json:"role" validate:"required,min=1,max=5"`
+	CompanyID  int `json:"company_id"`
+	LocationID int `json:"location_id"`
+}
+
+// ExplainResource contains the resource the action is performed on
+type ExplainResource struct {
+	ID           int   `json:"id"`
+	Role         int   `json:"role" validate:"omitempty,min=1,max=5"`
+	CompanyID    int   `json:"company_id"`
+	LocationID   int   `json:"location_id"`
+	Participants []int `json:"participants"`
+
+	GroupRole         string `json:"group_role" validate:"omitempty,oneof=owner moderator member"`
+	RequiredGroupRole string `json:"required_group_role" validate:"omitempty,oneof=owner moderator member"`
+}
+
+// AuthzExplain validates authorization explain request
+func AuthzExplain(c echo.Context) (*Explain, error) {
+	e := new(Explain)
+	if err := c.Bind(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
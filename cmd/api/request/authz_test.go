@@ -0,0 +1,56 @@
+package request_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthzExplain(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.Explain
+	}{
+		{
+			name:    "Fail on missing action",
+			wantErr: true,
+			req:     `{"subject":{"role":3}}`,
+		},
+		{
+			name:    "Fail on invalid subject role",
+			wantErr: true,
+			req:     `{"action":"enforce_role","subject":{"role":9}}`,
+		},
+		{
+			name:    "Fail on invalid group role",
+			wantErr: true,
+			req:     `{"action":"enforce_group","subject":{"role":5},"resource":{"required_group_role":"admin"}}`,
+		},
+		{
+			name: "Success",
+			req:  `{"action":"enforce_company","subject":{"id":2,"role":3,"company_id":1},"resource":{"company_id":4}}`,
+			wantData: &request.Explain{
+				Action:   "enforce_company",
+				Subject:  request.ExplainSubject{ID: 2, Role: 3, CompanyID: 1},
+				Resource: request.ExplainResource{CompanyID: 4},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.AuthzExplain(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
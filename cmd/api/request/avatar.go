@@ -0,0 +1,44 @@
+package request
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+// multipartOverhead allows for boundaries and headers of multipart form around the avatar file
+const multipartOverhead = 64 << 10
+
+// Avatar reads image uploaded as avatar field of multipart form, of at most maxSize bytes.
+// Body is limited before it is parsed, so that oversized uploads are not read whole
+func Avatar(c echo.Context, maxSize int64) ([]byte, error) {
+	req := c.Request()
+	tooLarge := echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("avatar must be at most %d bytes", maxSize))
+	if req.ContentLength > maxSize+multipartOverhead {
+		return nil, tooLarge
+	}
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, maxSize+multipartOverhead)
+	fh, err := c.FormFile("avatar")
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "avatar file is required")
+	}
+	if fh.Size > maxSize {
+		return nil, tooLarge
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, tooLarge
+	}
+	return data, nil
+}
@@ -0,0 +1,73 @@
+package request_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestAvatar(t *testing.T) {
+	form := func(field string, size int) (*bytes.Buffer, string) {
+		body := new(bytes.Buffer)
+		mw := multipart.NewWriter(body)
+		fw, _ := mw.CreateFormFile(field, "me.jpg")
+		fw.Write(bytes.Repeat([]byte{'a'}, size))
+		mw.Close()
+		return body, mw.FormDataContentType()
+	}
+	cases := []struct {
+		name       string
+		field      string
+		size       int
+		wantStatus int
+	}{
+		{
+			name:       "Missing file",
+			field:      "picture",
+			size:       10,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Too large",
+			field:      "avatar",
+			size:       1025,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "Too large body",
+			field:      "avatar",
+			size:       200 << 10,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:  "Success",
+			field: "avatar",
+			size:  1024,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType := form(tt.field, tt.size)
+			req, _ := http.NewRequest("PUT", "", body)
+			c := mock.EchoCtx(req, httptest.NewRecorder())
+			req.Header.Set("Content-Type", contentType)
+			data, err := request.Avatar(c, 1024)
+			if tt.wantStatus != 0 {
+				if he, ok := err.(*echo.HTTPError); assert.True(t, ok) {
+					assert.Equal(t, tt.wantStatus, he.Code)
+				}
+				return
+			}
+			assert.Nil(t, err)
+			assert.Len(t, data, tt.size)
+		})
+	}
+}
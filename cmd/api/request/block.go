@@ -0,0 +1,37 @@
+package request
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+// Block contains block or mute request
+// Kind defaults to block
+type Block struct {
+	UserID int    `json:"user_id" validate:"required"`
+	Kind   string `json:"kind" validate:"omitempty,oneof=block mute"`
+}
+
+// BlockCreate validates block request
+func BlockCreate(c echo.Context) (*Block, error) {
+	b := new(Block)
+	if err := c.Bind(b); err != nil {
+		return nil, err
+	}
+	if b.Kind == "" {
+		b.Kind = "block"
+	}
+	return b, nil
+}
+
+// BlockKind returns kind query parameter, defaulting to block
+func BlockKind(c echo.Context) (string, error) {
+	switch k := c.QueryParam("kind"); k {
+	case "":
+		return "block", nil
+	case "block", "mute":
+		return k, nil
+	}
+	return "", echo.NewHTTPError(http.StatusBadRequest, "kind must be block or mute")
+}
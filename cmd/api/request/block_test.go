@@ -0,0 +1,87 @@
+package request_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestBlockCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.Block
+	}{
+		{
+			name:    "Fail on validating JSON",
+			wantErr: true,
+			req:     `{"kind":"block"}`,
+		},
+		{
+			name:    "Fail on unknown kind",
+			wantErr: true,
+			req:     `{"user_id":2,"kind":"ignore"}`,
+		},
+		{
+			name:     "Default kind",
+			req:      `{"user_id":2}`,
+			wantData: &request.Block{UserID: 2, Kind: "block"},
+		},
+		{
+			name:     "Mute",
+			req:      `{"user_id":2,"kind":"mute"}`,
+			wantData: &request.Block{UserID: 2, Kind: "mute"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.BlockCreate(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestBlockKind(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantKind string
+	}{
+		{
+			name:    "Invalid kind",
+			req:     "?kind=ignore",
+			wantErr: true,
+		},
+		{
+			name:     "Default",
+			wantKind: "block",
+		},
+		{
+			name:     "Mute",
+			req:      "?kind=mute",
+			wantKind: "mute",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/"+tt.req, nil)
+			c := mock.EchoCtx(req, w)
+			kind, err := request.BlockKind(c)
+			assert.Equal(t, tt.wantKind, kind)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
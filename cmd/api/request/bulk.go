@@ -0,0 +1,71 @@
+package request
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// BulkFilter contains filters picking users of a bulk operation, as accepted by user list
+type BulkFilter struct {
+	Active          *bool      `json:"active,omitempty"`
+	Role            int        `json:"role,omitempty" validate:"omitempty,min=1,max=5"`
+	CompanyID       int        `json:"company_id,omitempty" validate:"omitempty,min=1"`
+	LocationID      int        `json:"location_id,omitempty" validate:"omitempty,min=1"`
+	CreatedAfter    *time.Time `json:"created_after,omitempty"`
+	CreatedBefore   *time.Time `json:"created_before,omitempty"`
+	LastLoginAfter  *time.Time `json:"last_login_after,omitempty"`
+	LastLoginBefore *time.Time `json:"last_login_before,omitempty"`
+	Q               string     `json:"q,omitempty" validate:"max=100"`
+}
+
+// Bulk contains bulk user operation request
+type Bulk struct {
+	IDs        []int       `json:"ids,omitempty" validate:"omitempty,max=1000,dive,min=1"`
+	Filter     *BulkFilter `json:"filter,omitempty"`
+	Action     string      `json:"action" validate:"required,oneof=activate deactivate move_location change_role delete"`
+	LocationID int         `json:"location_id,omitempty" validate:"omitempty,min=1"`
+	RoleID     int         `json:"role_id,omitempty" validate:"omitempty,min=1,max=5"`
+
+	Users *model.UserFilter `json:"-"`
+}
+
+// UserBulk validates bulk user operation request. Users are given either by ids or by a filter.
+// Moving users needs location_id, and changing their role needs role_id
+func UserBulk(c echo.Context) (*Bulk, error) {
+	b := new(Bulk)
+	if err := c.Bind(b); err != nil {
+		return nil, err
+	}
+	if (len(b.IDs) > 0) == (b.Filter != nil) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "either ids or filter is required")
+	}
+	switch model.BulkAction(b.Action) {
+	case model.BulkMoveLocation:
+		if b.LocationID == 0 {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "location_id is required")
+		}
+	case model.BulkChangeRole:
+		if b.RoleID == 0 {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "role_id is required")
+		}
+	}
+	if f := b.Filter; f != nil {
+		b.Users = &model.UserFilter{
+			Active:          f.Active,
+			RoleID:          f.Role,
+			CompanyID:       f.CompanyID,
+			LocationID:      f.LocationID,
+			CreatedAfter:    f.CreatedAfter,
+			CreatedBefore:   f.CreatedBefore,
+			LastLoginAfter:  f.LastLoginAfter,
+			LastLoginBefore: f.LastLoginBefore,
+			Search:          strings.TrimSpace(f.Q),
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,80 @@
+package request_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestUserBulk(t *testing.T) {
+	active := false
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.Bulk
+	}{
+		{
+			name:    "Fail on unknown action",
+			req:     `{"ids":[1],"action":"archive"}`,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on missing users",
+			req:     `{"action":"delete"}`,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on both ids and filter",
+			req:     `{"ids":[1],"filter":{},"action":"delete"}`,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on invalid id",
+			req:     `{"ids":[1,0],"action":"delete"}`,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on missing location",
+			req:     `{"ids":[1],"action":"move_location"}`,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on invalid role",
+			req:     `{"ids":[1],"action":"change_role","role_id":7}`,
+			wantErr: true,
+		},
+		{
+			name:     "By ids",
+			req:      `{"ids":[1,2],"action":"change_role","role_id":4}`,
+			wantData: &request.Bulk{IDs: []int{1, 2}, Action: "change_role", RoleID: 4},
+		},
+		{
+			name: "By filter",
+			req:  `{"filter":{"active":false,"location_id":3,"q":" doe "},"action":"move_location","location_id":2}`,
+			wantData: &request.Bulk{
+				Filter:     &request.BulkFilter{Active: &active, LocationID: 3, Q: " doe "},
+				Action:     "move_location",
+				LocationID: 2,
+				Users:      &model.UserFilter{Active: &active, LocationID: 3, Search: "doe"},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.UserBulk(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
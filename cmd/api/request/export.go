@@ -0,0 +1,62 @@
+package request
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/platform/export"
+)
+
+// Export contains user export request
+type Export struct {
+	Filter  *model.UserFilter
+	Format  export.Format
+	Columns []string
+}
+
+// UserExport validates user export request, taking the filters of user list together with format and columns query parameters.
+// Format is csv, jsonl or xlsx, defaulting to csv. Columns is a comma separated list, defaulting to all exportable columns
+func UserExport(c echo.Context) (*Export, error) {
+	f, err := UserList(c)
+	if err != nil {
+		return nil, err
+	}
+	e := &Export{Filter: f, Format: export.CSV, Columns: model.UserExportColumns}
+	if v := c.QueryParam("format"); v != "" {
+		var ok bool
+		if e.Format, ok = export.Formats[v]; !ok {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "format must be csv, jsonl or xlsx")
+		}
+	}
+	if v := c.QueryParam("columns"); v != "" {
+		if e.Columns, err = exportColumns(v); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+func exportColumns(v string) ([]string, error) {
+	known := make(map[string]bool)
+	for _, col := range model.UserExportColumns {
+		known[col] = true
+	}
+	var columns []string
+	seen := make(map[string]bool)
+	for _, col := range strings.Split(v, ",") {
+		col = strings.TrimSpace(col)
+		if !known[col] {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "users can not be exported with column "+strconv.Quote(col))
+		}
+		if seen[col] {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "column "+strconv.Quote(col)+" is repeated")
+		}
+		seen[col] = true
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
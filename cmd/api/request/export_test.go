@@ -0,0 +1,68 @@
+package request_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/platform/export"
+)
+
+func TestUserExport(t *testing.T) {
+	active := true
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.Export
+	}{
+		{
+			name:    "Invalid filter",
+			req:     "?role=9",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown format",
+			req:     "?format=pdf",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown column",
+			req:     "?columns=id,password",
+			wantErr: true,
+		},
+		{
+			name:    "Repeated column",
+			req:     "?columns=id,email,id",
+			wantErr: true,
+		},
+		{
+			name:     "Defaults",
+			wantData: &request.Export{Filter: &model.UserFilter{}, Format: export.CSV, Columns: model.UserExportColumns},
+		},
+		{
+			name: "Success",
+			req:  "?format=xlsx&columns=email,%20last_name&active=true&sort=last_name",
+			wantData: &request.Export{
+				Filter:  &model.UserFilter{Active: &active, Sort: []model.UserSort{{Field: "last_name"}}},
+				Format:  export.XLSX,
+				Columns: []string{"email", "last_name"},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/"+tt.req, nil)
+			c := mock.EchoCtx(req, w)
+			resp, err := request.UserExport(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package request
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+// FriendRequest contains friend request
+type FriendRequest struct {
+	UserID int `json:"user_id" validate:"required"`
+}
+
+// FriendCreate validates friend request
+func FriendCreate(c echo.Context) (*FriendRequest, error) {
+	f := new(FriendRequest)
+	if err := c.Bind(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// FriendRequests returns whether incoming friend requests are listed, from direction query parameter.
+// Incoming requests are listed by default
+func FriendRequests(c echo.Context) (bool, error) {
+	switch c.QueryParam("direction") {
+	case "", "incoming":
+		return true, nil
+	case "outgoing":
+		return false, nil
+	}
+	return false, echo.NewHTTPError(http.StatusBadRequest, "direction must be incoming or outgoing")
+}
@@ -0,0 +1,81 @@
+package request_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestFriendCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.FriendRequest
+	}{
+		{
+			name:    "Fail on validating JSON",
+			wantErr: true,
+			req:     `{}`,
+		},
+		{
+			name:     "Success",
+			req:      `{"user_id":2}`,
+			wantData: &request.FriendRequest{UserID: 2},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.FriendCreate(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestFriendRequests(t *testing.T) {
+	cases := []struct {
+		name         string
+		req          string
+		wantErr      bool
+		wantIncoming bool
+	}{
+		{
+			name:    "Invalid direction",
+			req:     "?direction=sideways",
+			wantErr: true,
+		},
+		{
+			name:         "Default",
+			wantIncoming: true,
+		},
+		{
+			name:         "Incoming",
+			req:          "?direction=incoming",
+			wantIncoming: true,
+		},
+		{
+			name: "Outgoing",
+			req:  "?direction=outgoing",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/"+tt.req, nil)
+			c := mock.EchoCtx(req, w)
+			incoming, err := request.FriendRequests(c)
+			assert.Equal(t, tt.wantIncoming, incoming)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
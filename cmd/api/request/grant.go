@@ -0,0 +1,64 @@
+package request
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// maxGrantDuration is the longest period a temporary role can be granted for
+const maxGrantDuration = 90 * 24 * time.Hour
+
+// Grant contains temporary role grant request
+type Grant struct {
+	UserID      int       `json:"user_id" validate:"required"`
+	AccessLevel int       `json:"access_level" validate:"required,min=1,max=5"`
+	CompanyID   int       `json:"company_id" validate:"required"`
+	LocationID  int       `json:"location_id" validate:"required"`
+	StartsAt    time.Time `json:"starts_at" validate:"required"`
+	EndsAt      time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"`
+}
+
+// GrantCreate validates temporary role grant request
+func GrantCreate(c echo.Context) (*Grant, error) {
+	g := new(Grant)
+	if err := c.Bind(g); err != nil {
+		return nil, err
+	}
+	if g.EndsAt.Sub(g.StartsAt) > maxGrantDuration {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "grant period is too long")
+	}
+	return g, nil
+}
+
+// Delegation contains role delegation request
+// If access level is omitted, delegator's own role is delegated
+type Delegation struct {
+	UserID      int       `json:"user_id" validate:"required"`
+	AccessLevel int       `json:"access_level" validate:"omitempty,min=1,max=5"`
+	StartsAt    time.Time `json:"starts_at" validate:"required"`
+	EndsAt      time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"`
+}
+
+// GrantDelegate validates role delegation request
+func GrantDelegate(c echo.Context) (*Delegation, error) {
+	d := new(Delegation)
+	if err := c.Bind(d); err != nil {
+		return nil, err
+	}
+	if d.EndsAt.Sub(d.StartsAt) > maxGrantDuration {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "grant period is too long")
+	}
+	return d, nil
+}
+
+// GrantList returns user_id query parameter for listing grants
+func GrantList(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.QueryParam("user_id"))
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest)
+	}
+	return id, nil
+}
@@ -0,0 +1,129 @@
+package request_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestGrantCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.Grant
+	}{
+		{
+			name:    "Fail on validating JSON",
+			wantErr: true,
+			req:     `{"user_id":2,"access_level":4,"company_id":1,"location_id":1,"starts_at":"2018-05-19T01:02:03Z"}`,
+		},
+		{
+			name:    "Fail on end before start",
+			wantErr: true,
+			req:     `{"user_id":2,"access_level":4,"company_id":1,"location_id":1,"starts_at":"2018-05-19T01:02:03Z","ends_at":"2018-05-18T01:02:03Z"}`,
+		},
+		{
+			name:    "Fail on too long period",
+			wantErr: true,
+			req:     `{"user_id":2,"access_level":4,"company_id":1,"location_id":1,"starts_at":"2018-05-19T01:02:03Z","ends_at":"2019-05-19T01:02:03Z"}`,
+		},
+		{
+			name: "Success",
+			req:  `{"user_id":2,"access_level":4,"company_id":1,"location_id":1,"starts_at":"2018-05-19T01:02:03.000000004Z","ends_at":"2018-06-02T01:02:03.000000004Z"}`,
+			wantData: &request.Grant{
+				UserID:      2,
+				AccessLevel: 4,
+				CompanyID:   1,
+				LocationID:  1,
+				StartsAt:    mock.TestTime(2018),
+				EndsAt:      mock.TestTime(2018).AddDate(0, 0, 14),
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.GrantCreate(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestGrantDelegate(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.Delegation
+	}{
+		{
+			name:    "Fail on validating JSON",
+			wantErr: true,
+			req:     `{"starts_at":"2018-05-19T01:02:03Z","ends_at":"2018-05-20T01:02:03Z"}`,
+		},
+		{
+			name:    "Fail on too long period",
+			wantErr: true,
+			req:     `{"user_id":2,"starts_at":"2018-05-19T01:02:03Z","ends_at":"2019-05-19T01:02:03Z"}`,
+		},
+		{
+			name: "Success",
+			req:  `{"user_id":2,"starts_at":"2018-05-19T01:02:03.000000004Z","ends_at":"2018-06-02T01:02:03.000000004Z"}`,
+			wantData: &request.Delegation{
+				UserID:   2,
+				StartsAt: mock.TestTime(2018),
+				EndsAt:   mock.TestTime(2018).AddDate(0, 0, 14),
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.GrantDelegate(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestGrantList(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData int
+	}{
+		{
+			name:    "Missing user_id",
+			req:     "/",
+			wantErr: true,
+		},
+		{
+			name:     "Success",
+			req:      "/?user_id=5",
+			wantData: 5,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.req, nil)
+			c := mock.EchoCtx(req, w)
+			resp, err := request.GrantList(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package request
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+)
+
+// Group contains group create request
+type Group struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=2000"`
+	Visibility  string `json:"visibility" validate:"required,oneof=public private invite_only"`
+}
+
+// GroupCreate validates group create request
+func GroupCreate(c echo.Context) (*Group, error) {
+	g := new(Group)
+	if err := c.Bind(g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// UpdateGroup contains group update data from json request
+type UpdateGroup struct {
+	ID          int     `json:"-"`
+	Name        *string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=2000"`
+	Visibility  *string `json:"visibility,omitempty" validate:"omitempty,oneof=public private invite_only"`
+}
+
+// GroupUpdate validates group update request
+func GroupUpdate(c echo.Context) (*UpdateGroup, error) {
+	id, err := ID(c)
+	if err != nil {
+		return nil, err
+	}
+	g := new(UpdateGroup)
+	if err := c.Bind(g); err != nil {
+		return nil, err
+	}
+	g.ID = id
+	return g, nil
+}
+
+// GroupInvite contains group invitation request
+type GroupInvite struct {
+	UserID int `json:"user_id" validate:"required"`
+}
+
+// GroupInviteCreate validates group invitation request
+func GroupInviteCreate(c echo.Context) (*GroupInvite, error) {
+	g := new(GroupInvite)
+	if err := c.Bind(g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// GroupRole contains group member's role change request
+type GroupRole struct {
+	Role string `json:"role" validate:"required,oneof=owner moderator member"`
+}
+
+// GroupRoleChange validates group member's role change request
+func GroupRoleChange(c echo.Context) (*GroupRole, error) {
+	g := new(GroupRole)
+	if err := c.Bind(g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// GroupPost contains group post create request
+type GroupPost struct {
+	Body string `json:"body" validate:"required,max=5000"`
+}
+
+// GroupPostCreate validates group post create request
+func GroupPostCreate(c echo.Context) (*GroupPost, error) {
+	g := new(GroupPost)
+	if err := c.Bind(g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// GroupMembers returns status query parameter for listing group members, defaulting to active
+func GroupMembers(c echo.Context) (string, error) {
+	switch s := c.QueryParam("status"); s {
+	case "":
+		return "active", nil
+	case "active", "requested", "invited":
+		return s, nil
+	}
+	return "", echo.NewHTTPError(http.StatusBadRequest, "status must be active, requested or invited")
+}
+
+// GroupChild returns group id and the id of its member or post from url parameter with the given name
+func GroupChild(c echo.Context, name string) (int, int, error) {
+	id, err := ID(c)
+	if err != nil {
+		return 0, 0, err
+	}
+	child, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		return 0, 0, echo.NewHTTPError(http.StatusBadRequest)
+	}
+	return id, child, nil
+}
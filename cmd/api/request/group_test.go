@@ -0,0 +1,200 @@
+package request_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestGroupCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.Group
+	}{
+		{
+			name:    "Fail on missing name",
+			req:     `{"visibility":"public"}`,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on unknown visibility",
+			req:     `{"name":"runners","visibility":"secret"}`,
+			wantErr: true,
+		},
+		{
+			name:     "Success",
+			req:      `{"name":"runners","description":"Morning runs","visibility":"invite_only"}`,
+			wantData: &request.Group{Name: "runners", Description: "Morning runs", Visibility: "invite_only"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.GroupCreate(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestGroupUpdate(t *testing.T) {
+	cases := []struct {
+		name     string
+		id       string
+		req      string
+		wantErr  bool
+		wantData *request.UpdateGroup
+	}{
+		{
+			name:    "Fail on ID param",
+			id:      "NaN",
+			req:     `{}`,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on empty name",
+			id:      "1",
+			req:     `{"name":""}`,
+			wantErr: true,
+		},
+		{
+			name: "Success",
+			id:   "1",
+			req:  `{"visibility":"private"}`,
+			wantData: &request.UpdateGroup{
+				ID:         1,
+				Visibility: mock.Str2Ptr("private"),
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PATCH", "/", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+			resp, err := request.GroupUpdate(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestGroupRoleChange(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.GroupRole
+	}{
+		{
+			name:    "Fail on unknown role",
+			req:     `{"role":"admin"}`,
+			wantErr: true,
+		},
+		{
+			name:     "Success",
+			req:      `{"role":"moderator"}`,
+			wantData: &request.GroupRole{Role: "moderator"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.GroupRoleChange(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestGroupMembers(t *testing.T) {
+	cases := []struct {
+		name     string
+		query    string
+		wantErr  bool
+		wantData string
+	}{
+		{
+			name:     "Default",
+			wantData: "active",
+		},
+		{
+			name:     "Requested",
+			query:    "?status=requested",
+			wantData: "requested",
+		},
+		{
+			name:    "Fail on unknown status",
+			query:   "?status=banned",
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/"+tt.query, nil)
+			c := mock.EchoCtx(req, w)
+			resp, err := request.GroupMembers(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestGroupChild(t *testing.T) {
+	cases := []struct {
+		name      string
+		id        string
+		child     string
+		wantErr   bool
+		wantID    int
+		wantChild int
+	}{
+		{
+			name:    "Fail on group ID",
+			id:      "NaN",
+			child:   "2",
+			wantErr: true,
+		},
+		{
+			name:    "Fail on child ID",
+			id:      "1",
+			child:   "NaN",
+			wantErr: true,
+		},
+		{
+			name:      "Success",
+			id:        "1",
+			child:     "2",
+			wantID:    1,
+			wantChild: 2,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", "/", nil)
+			c := mock.EchoCtx(req, w)
+			c.SetParamNames("id", "user_id")
+			c.SetParamValues(tt.id, tt.child)
+			id, child, err := request.GroupChild(c, "user_id")
+			assert.Equal(t, tt.wantID, id)
+			assert.Equal(t, tt.wantChild, child)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package request
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// importFields lists fields of imported users. Columns are mapped to fields of the same name unless map says otherwise
+var importFields = map[string]bool{
+	"first_name":       true,
+	"last_name":        true,
+	"username":         true,
+	"password":         true,
+	"password_confirm": true,
+	"email":            true,
+	"company_id":       true,
+	"location_id":      true,
+	"role_id":          true,
+}
+
+// Import contains user import request, with rows of the file checked against registration rules
+type Import struct {
+	DryRun    bool
+	Atomic    bool
+	CSVReport bool
+	Rows      []model.ImportRow
+}
+
+// UserImport validates user import request, a CSV file with a header row or JSON lines, from request body.
+// Format is taken from format query parameter, or Content-Type of the body. Columns are mapped to fields
+// by map query parameter, e.g. map=first_name:Given name,email:E-mail, and company_id, location_id and role_id
+// query parameters fill in rows leaving them empty. Mode is atomic (default) or best_effort, and report json or csv
+func UserImport(c echo.Context) (*Import, error) {
+	r := new(Import)
+	var err error
+	if r.DryRun, err = queryBool(c, "dry_run"); err != nil {
+		return nil, err
+	}
+	switch c.QueryParam("mode") {
+	case "", "atomic":
+		r.Atomic = true
+	case "best_effort":
+	default:
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "mode must be atomic or best_effort")
+	}
+	switch c.QueryParam("report") {
+	case "", "json":
+	case "csv":
+		r.CSVReport = true
+	default:
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "report must be json or csv")
+	}
+	columns, err := importColumns(c.QueryParam("map"))
+	if err != nil {
+		return nil, err
+	}
+	defaults := make(map[string]string)
+	for _, field := range []string{"company_id", "location_id", "role_id"} {
+		id, err := queryID(c, field)
+		if err != nil {
+			return nil, err
+		}
+		if id > 0 {
+			defaults[field] = strconv.Itoa(id)
+		}
+	}
+	records, err := importRecords(c)
+	if err != nil {
+		return nil, err
+	}
+	for i, rec := range records {
+		row := model.ImportRow{Row: i + 1}
+		if rec == nil {
+			row.Error = "row is not a JSON object"
+			r.Rows = append(r.Rows, row)
+			continue
+		}
+		values := make(map[string]string)
+		for field := range importFields {
+			values[field] = strings.TrimSpace(rec[columns[field]])
+			if values[field] == "" {
+				values[field] = defaults[field]
+			}
+		}
+		// Files hold no confirmation of passwords typed in
+		if values["password_confirm"] == "" {
+			values["password_confirm"] = values["password"]
+		}
+		row.User, row.Error = importUser(c, values)
+		r.Rows = append(r.Rows, row)
+	}
+	return r, nil
+}
+
+// importColumns returns columns fields are read from, mapped by field:column pairs
+func importColumns(mapping string) (map[string]string, error) {
+	columns := make(map[string]string)
+	for field := range importFields {
+		columns[field] = field
+	}
+	if mapping == "" {
+		return columns, nil
+	}
+	for _, pair := range strings.Split(mapping, ",") {
+		kv := strings.SplitN(pair, ":", 2)
+		if len(kv) != 2 || !importFields[strings.TrimSpace(kv[0])] || strings.TrimSpace(kv[1]) == "" {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "map must consist of field:column pairs of known fields")
+		}
+		columns[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return columns, nil
+}
+
+// importRecords reads rows of the request body as maps of column names to values.
+// JSON lines which are not objects are returned as nil
+func importRecords(c echo.Context) ([]map[string]string, error) {
+	format := c.QueryParam("format")
+	if format == "" {
+		switch ct := c.Request().Header.Get(echo.HeaderContentType); {
+		case strings.HasPrefix(ct, "text/csv"):
+			format = "csv"
+		case strings.HasPrefix(ct, "application/x-ndjson"), strings.HasPrefix(ct, "application/jsonl"):
+			format = "jsonl"
+		}
+	}
+	switch format {
+	case "csv":
+		return csvRecords(c.Request().Body)
+	case "jsonl":
+		return jsonRecords(c.Request().Body)
+	}
+	return nil, echo.NewHTTPError(http.StatusUnsupportedMediaType, "body must be text/csv or application/x-ndjson")
+}
+
+func csvRecords(body io.Reader) ([]map[string]string, error) {
+	cr := csv.NewReader(body)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "CSV file must start with a header row")
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+	var records []map[string]string
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if len(records) == model.MaxImportRows {
+			return nil, tooManyRows()
+		}
+		rec := make(map[string]string)
+		for i, v := range fields {
+			if i < len(header) {
+				rec[header[i]] = v
+			}
+		}
+		records = append(records, rec)
+	}
+}
+
+func jsonRecords(body io.Reader) ([]map[string]string, error) {
+	dec := json.NewDecoder(body)
+	dec.UseNumber()
+	var records []map[string]string
+	for {
+		var obj map[string]interface{}
+		err := dec.Decode(&obj)
+		if err == io.EOF {
+			return records, nil
+		}
+		if _, ok := err.(*json.SyntaxError); ok || err == io.ErrUnexpectedEOF {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "body must consist of JSON objects")
+		}
+		if len(records) == model.MaxImportRows {
+			return nil, tooManyRows()
+		}
+		if err != nil || obj == nil {
+			records = append(records, nil)
+			continue
+		}
+		rec := make(map[string]string)
+		for k, v := range obj {
+			if v != nil {
+				rec[k] = fmt.Sprint(v)
+			}
+		}
+		records = append(records, rec)
+	}
+}
+
+func tooManyRows() error {
+	return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("at most %d users can be imported at once", model.MaxImportRows))
+}
+
+// importUser validates imported user against registration rules, returning the reason it is invalid
+func importUser(c echo.Context, values map[string]string) (model.User, string) {
+	r := &Register{
+		FirstName:       values["first_name"],
+		LastName:        values["last_name"],
+		Username:        values["username"],
+		Password:        values["password"],
+		PasswordConfirm: values["password_confirm"],
+		Email:           values["email"],
+	}
+	u := model.User{FirstName: r.FirstName, LastName: r.LastName, Username: r.Username, Email: r.Email}
+	for _, id := range []struct {
+		field string
+		dst   *int
+	}{{"company_id", &r.CompanyID}, {"location_id", &r.LocationID}, {"role_id", &r.RoleID}} {
+		if v := values[id.field]; v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return u, id.field + " must be a number"
+			}
+			*id.dst = n
+		}
+	}
+	if err := c.Validate(r); err != nil {
+		verrs, ok := err.(validator.ValidationErrors)
+		if !ok {
+			return u, err.Error()
+		}
+		var msgs []string
+		for _, v := range verrs {
+			msgs = append(msgs, v.Field()+" failed on "+v.ActualTag()+" validation")
+		}
+		return u, strings.Join(msgs, "; ")
+	}
+	if r.Password != r.PasswordConfirm {
+		return u, "passwords do not match"
+	}
+	if r.RoleID < int(model.SuperAdminRole) || r.RoleID > int(model.UserRole) {
+		return u, "role_id must be between 1 and 5"
+	}
+	u.Password, u.CompanyID, u.LocationID, u.RoleID = r.Password, r.CompanyID, r.LocationID, r.RoleID
+	return u, ""
+}
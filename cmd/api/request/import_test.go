@@ -0,0 +1,95 @@
+package request_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+func TestUserImport(t *testing.T) {
+	anna := model.User{FirstName: "Anna", LastName: "Smith", Username: "anna", Email: "anna@mail.com",
+		Password: "Thranduil8822", CompanyID: 1, LocationID: 2, RoleID: 5}
+	cases := []struct {
+		name     string
+		query    string
+		req      string
+		wantErr  bool
+		wantData *request.Import
+	}{
+		{
+			name:    "Unknown format",
+			req:     "{}",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown mode",
+			query:   "?format=csv&mode=some",
+			req:     "username\nanna",
+			wantErr: true,
+		},
+		{
+			name:    "Map of unknown field",
+			query:   "?format=csv&map=nickname:Nick",
+			req:     "username\nanna",
+			wantErr: true,
+		},
+		{
+			name:    "Malformed CSV",
+			query:   "?format=csv",
+			req:     "username\n\"anna",
+			wantErr: true,
+		},
+		{
+			name:    "Too many rows",
+			query:   "?format=csv",
+			req:     "username\n" + strings.Repeat("anna\n", model.MaxImportRows+1),
+			wantErr: true,
+		},
+		{
+			name:  "CSV",
+			query: "?format=csv&mode=best_effort&report=csv&map=first_name:Given%20name,role_id:Role&company_id=1&location_id=2",
+			req: "Given name,last_name,username,email,password,Role\n" +
+				"Anna,Smith,anna,anna@mail.com,Thranduil8822,5\n" +
+				"Bob,Jones,bob,bob,Thranduil8822,5\n" +
+				"Carl,Johnson,carl,carl@mail.com,Thranduil8822,admin\n" +
+				"Dan,Brown,dan,dan@mail.com,Thranduil8822,7\n",
+			wantData: &request.Import{CSVReport: true, Rows: []model.ImportRow{
+				{Row: 1, User: anna},
+				{Row: 2, User: model.User{FirstName: "Bob", LastName: "Jones", Username: "bob", Email: "bob"}, Error: "Email failed on email validation"},
+				{Row: 3, User: model.User{FirstName: "Carl", LastName: "Johnson", Username: "carl", Email: "carl@mail.com"}, Error: "role_id must be a number"},
+				{Row: 4, User: model.User{FirstName: "Dan", LastName: "Brown", Username: "dan", Email: "dan@mail.com"}, Error: "role_id must be between 1 and 5"},
+			}},
+		},
+		{
+			name:  "JSON lines",
+			query: "?format=jsonl&dry_run=true&location_id=2",
+			req: `{"first_name":"Anna","last_name":"Smith","username":"anna","email":"anna@mail.com","password":"Thranduil8822","company_id":1,"role_id":5}
+				null
+				{"username":"bob","password":"Thranduil8822","password_confirm":"Thranduil"}`,
+			wantData: &request.Import{DryRun: true, Atomic: true, Rows: []model.ImportRow{
+				{Row: 1, User: anna},
+				{Row: 2, Error: "row is not a JSON object"},
+				{Row: 3, User: model.User{Username: "bob"},
+					Error: "FirstName failed on required validation; LastName failed on required validation; Email failed on required validation; CompanyID failed on required validation; RoleID failed on required validation"},
+			}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/"+tt.query, bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.UserImport(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package request
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// Meetup contains meetup create request
+type Meetup struct {
+	LocationID  int       `json:"location_id" validate:"required"`
+	Title       string    `json:"title" validate:"required,max=100"`
+	Description string    `json:"description" validate:"max=2000"`
+	StartsAt    time.Time `json:"starts_at" validate:"required"`
+	EndsAt      time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"`
+	Capacity    int       `json:"capacity" validate:"min=0"`
+}
+
+// MeetupCreate validates meetup create request
+func MeetupCreate(c echo.Context) (*Meetup, error) {
+	m := new(Meetup)
+	if err := c.Bind(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// UpdateMeetup contains meetup update data from json request
+type UpdateMeetup struct {
+	ID          int        `json:"-"`
+	Title       *string    `json:"title,omitempty" validate:"omitempty,min=1,max=100"`
+	Description *string    `json:"description,omitempty" validate:"omitempty,max=2000"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"`
+	Capacity    *int       `json:"capacity,omitempty" validate:"omitempty,min=0"`
+}
+
+// MeetupUpdate validates meetup update request
+func MeetupUpdate(c echo.Context) (*UpdateMeetup, error) {
+	id, err := ID(c)
+	if err != nil {
+		return nil, err
+	}
+	m := new(UpdateMeetup)
+	if err := c.Bind(m); err != nil {
+		return nil, err
+	}
+	m.ID = id
+	return m, nil
+}
+
+// RSVP contains user's response to a meetup
+type RSVP struct {
+	Status string `json:"status" validate:"required,oneof=going maybe declined"`
+}
+
+// MeetupRSVP validates meetup response request
+func MeetupRSVP(c echo.Context) (*RSVP, error) {
+	r := new(RSVP)
+	if err := c.Bind(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// MeetupList returns optional location_id query parameter for listing meetups
+func MeetupList(c echo.Context) (int, error) {
+	v := c.QueryParam("location_id")
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id < 1 {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "location_id must be an id")
+	}
+	return id, nil
+}
@@ -0,0 +1,169 @@
+package request_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestMeetupCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.Meetup
+	}{
+		{
+			name:    "Fail on missing title",
+			req:     `{"location_id":1,"starts_at":"2018-06-01T18:00:00Z","ends_at":"2018-06-01T20:00:00Z"}`,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on ending before start",
+			req:     `{"location_id":1,"title":"Meetup","starts_at":"2018-06-01T18:00:00Z","ends_at":"2018-06-01T17:00:00Z"}`,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on negative capacity",
+			req:     `{"location_id":1,"title":"Meetup","starts_at":"2018-06-01T18:00:00Z","ends_at":"2018-06-01T20:00:00Z","capacity":-1}`,
+			wantErr: true,
+		},
+		{
+			name: "Success",
+			req:  `{"location_id":1,"title":"Meetup","starts_at":"2018-06-01T18:00:00Z","ends_at":"2018-06-01T20:00:00Z","capacity":10}`,
+			wantData: &request.Meetup{
+				LocationID: 1,
+				Title:      "Meetup",
+				StartsAt:   time.Date(2018, 6, 1, 18, 0, 0, 0, time.UTC),
+				EndsAt:     time.Date(2018, 6, 1, 20, 0, 0, 0, time.UTC),
+				Capacity:   10,
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.MeetupCreate(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestMeetupUpdate(t *testing.T) {
+	capacity := 0
+	cases := []struct {
+		name     string
+		id       string
+		req      string
+		wantErr  bool
+		wantData *request.UpdateMeetup
+	}{
+		{
+			name:    "Fail on ID param",
+			id:      "NaN",
+			req:     `{}`,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on empty title",
+			id:      "1",
+			req:     `{"title":""}`,
+			wantErr: true,
+		},
+		{
+			name: "Success",
+			id:   "1",
+			req:  `{"title":"Meetup","capacity":0}`,
+			wantData: &request.UpdateMeetup{
+				ID:       1,
+				Title:    mock.Str2Ptr("Meetup"),
+				Capacity: &capacity,
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PATCH", "/", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+			resp, err := request.MeetupUpdate(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestMeetupRSVP(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.RSVP
+	}{
+		{
+			name:    "Fail on waitlisted",
+			req:     `{"status":"waitlisted"}`,
+			wantErr: true,
+		},
+		{
+			name:     "Success",
+			req:      `{"status":"maybe"}`,
+			wantData: &request.RSVP{Status: "maybe"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.MeetupRSVP(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestMeetupList(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData int
+	}{
+		{
+			name: "All locations",
+		},
+		{
+			name:    "Invalid location",
+			req:     "?location_id=abc",
+			wantErr: true,
+		},
+		{
+			name:     "Location",
+			req:      "?location_id=3",
+			wantData: 3,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/"+tt.req, nil)
+			c := mock.EchoCtx(req, w)
+			resp, err := request.MeetupList(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
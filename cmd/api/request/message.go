@@ -0,0 +1,106 @@
+package request
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
+)
+
+// Conversation contains conversation start request
+type Conversation struct {
+	UserIDs []int  `json:"user_ids" validate:"required,min=1"`
+	Title   string `json:"title" validate:"max=100"`
+}
+
+// ConversationCreate validates conversation start request
+func ConversationCreate(c echo.Context) (*Conversation, error) {
+	cv := new(Conversation)
+	if err := c.Bind(cv); err != nil {
+		return nil, err
+	}
+	return cv, nil
+}
+
+// Attachment contains file attached to a message
+type Attachment struct {
+	Name        string `json:"name" validate:"required"`
+	URL         string `json:"url" validate:"required,url"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size" validate:"min=0"`
+}
+
+// Message contains message send request
+type Message struct {
+	Body        string       `json:"body" validate:"max=4000"`
+	Attachments []Attachment `json:"attachments" validate:"max=10,dive"`
+}
+
+// MessageSend validates message send request.
+// Message must have either body or attachments, which have to be linked with http or https URLs
+func MessageSend(c echo.Context) (*Message, error) {
+	m := new(Message)
+	if err := c.Bind(m); err != nil {
+		return nil, err
+	}
+	if m.Body == "" && len(m.Attachments) == 0 {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "message must have body or attachments")
+	}
+	for _, a := range m.Attachments {
+		if u, err := url.Parse(a.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "attachment url must be an http or https url")
+		}
+	}
+	return m, nil
+}
+
+// Read contains read receipt request
+type Read struct {
+	MessageID int `json:"message_id" validate:"required"`
+}
+
+// MessageRead validates read receipt request
+func MessageRead(c echo.Context) (*Read, error) {
+	r := new(Read)
+	if err := c.Bind(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Cursor contains message history cursor request
+type Cursor struct {
+	Before int
+	Limit  int
+}
+
+// MessageCursor validates message history cursor, from before and limit query parameters
+func MessageCursor(c echo.Context) (*Cursor, error) {
+	return cursor(c, defaultHistoryLimit, maxHistoryLimit)
+}
+
+// cursor validates before and limit query parameters, capping limit at max
+func cursor(c echo.Context, limit, max int) (*Cursor, error) {
+	cur := &Cursor{Limit: limit}
+	var err error
+	if v := c.QueryParam("before"); v != "" {
+		if cur.Before, err = strconv.Atoi(v); err != nil || cur.Before < 0 {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "before must be an id")
+		}
+	}
+	if v := c.QueryParam("limit"); v != "" {
+		if cur.Limit, err = strconv.Atoi(v); err != nil || cur.Limit < 1 {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "limit must be a positive number")
+		}
+	}
+	if cur.Limit > max {
+		cur.Limit = max
+	}
+	return cur, nil
+}
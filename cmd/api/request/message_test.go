@@ -0,0 +1,140 @@
+package request_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestConversationCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.Conversation
+	}{
+		{
+			name:    "Fail on validating JSON",
+			wantErr: true,
+			req:     `{"user_ids":[]}`,
+		},
+		{
+			name:     "Success",
+			req:      `{"user_ids":[2,3],"title":"lunch"}`,
+			wantData: &request.Conversation{UserIDs: []int{2, 3}, Title: "lunch"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.ConversationCreate(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestMessageSend(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.Message
+	}{
+		{
+			name:    "Empty message",
+			wantErr: true,
+			req:     `{"body":""}`,
+		},
+		{
+			name:    "Invalid attachment",
+			wantErr: true,
+			req:     `{"attachments":[{"name":"cat.png","url":"not a url"}]}`,
+		},
+		{
+			name:    "Script attachment",
+			wantErr: true,
+			req:     `{"attachments":[{"name":"cat.png","url":"javascript:alert(1)"}]}`,
+		},
+		{
+			name:    "Data attachment",
+			wantErr: true,
+			req:     `{"attachments":[{"name":"cat.png","url":"data:text/html;base64,PHNjcmlwdD4="}]}`,
+		},
+		{
+			name: "Success",
+			req:  `{"body":"hi","attachments":[{"name":"cat.png","url":"https://example.com/cat.png","size":10}]}`,
+			wantData: &request.Message{Body: "hi", Attachments: []request.Attachment{
+				{Name: "cat.png", URL: "https://example.com/cat.png", Size: 10}}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.MessageSend(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestMessageRead(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "", bytes.NewBufferString(`{}`))
+	_, err := request.MessageRead(mock.EchoCtx(req, w))
+	assert.NotNil(t, err)
+
+	req, _ = http.NewRequest("POST", "", bytes.NewBufferString(`{"message_id":5}`))
+	r, err := request.MessageRead(mock.EchoCtx(req, w))
+	assert.Nil(t, err)
+	assert.Equal(t, &request.Read{MessageID: 5}, r)
+}
+
+func TestMessageCursor(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.Cursor
+	}{
+		{
+			name:    "Invalid before",
+			req:     "?before=abc",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid limit",
+			req:     "?limit=-1",
+			wantErr: true,
+		},
+		{
+			name:     "Default",
+			wantData: &request.Cursor{Limit: 50},
+		},
+		{
+			name:     "Limit too large",
+			req:      "?before=20&limit=5000",
+			wantData: &request.Cursor{Before: 20, Limit: 200},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/"+tt.req, nil)
+			c := mock.EchoCtx(req, w)
+			cur, err := request.MessageCursor(c)
+			assert.Equal(t, tt.wantData, cur)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package request
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+)
+
+// NotificationFilter contains notification list filters
+type NotificationFilter struct {
+	Unread bool
+	Type   string
+}
+
+// NotificationList validates notification list filters, from unread and type query parameters
+func NotificationList(c echo.Context) (*NotificationFilter, error) {
+	f := &NotificationFilter{Type: c.QueryParam("type")}
+	if v := c.QueryParam("unread"); v != "" {
+		unread, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "unread must be true or false")
+		}
+		f.Unread = unread
+	}
+	switch f.Type {
+	case "", "friend_request", "password_changed", "role_changed", "invitation_accepted",
+		"meetup_updated", "meetup_canceled", "meetup_confirmed":
+		return f, nil
+	}
+	return nil, echo.NewHTTPError(http.StatusBadRequest, "unknown notification type")
+}
+
+// NotificationPreference contains user's choice to receive notifications of a type
+type NotificationPreference struct {
+	Type    string `json:"type" validate:"required,oneof=friend_request password_changed role_changed invitation_accepted meetup_updated meetup_canceled meetup_confirmed"`
+	Enabled bool   `json:"enabled"`
+}
+
+// NotificationPreferences contains notification preferences update request
+type NotificationPreferences struct {
+	Preferences []NotificationPreference `json:"preferences" validate:"required,min=1,dive"`
+}
+
+// NotificationPreferencesUpdate validates notification preferences update request
+func NotificationPreferencesUpdate(c echo.Context) (*NotificationPreferences, error) {
+	p := new(NotificationPreferences)
+	if err := c.Bind(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
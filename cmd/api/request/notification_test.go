@@ -0,0 +1,90 @@
+package request_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestNotificationList(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.NotificationFilter
+	}{
+		{
+			name:    "Invalid unread",
+			req:     "?unread=maybe",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown type",
+			req:     "?type=newsletter",
+			wantErr: true,
+		},
+		{
+			name:     "Default",
+			wantData: &request.NotificationFilter{},
+		},
+		{
+			name:     "Unread of a type",
+			req:      "?unread=true&type=role_changed",
+			wantData: &request.NotificationFilter{Unread: true, Type: "role_changed"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/"+tt.req, nil)
+			c := mock.EchoCtx(req, w)
+			resp, err := request.NotificationList(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestNotificationPreferencesUpdate(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.NotificationPreferences
+	}{
+		{
+			name:    "Fail on empty preferences",
+			req:     `{"preferences":[]}`,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on unknown type",
+			req:     `{"preferences":[{"type":"newsletter","enabled":false}]}`,
+			wantErr: true,
+		},
+		{
+			name: "Success",
+			req:  `{"preferences":[{"type":"friend_request","enabled":false},{"type":"role_changed","enabled":true}]}`,
+			wantData: &request.NotificationPreferences{Preferences: []request.NotificationPreference{
+				{Type: "friend_request"},
+				{Type: "role_changed", Enabled: true},
+			}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.NotificationPreferencesUpdate(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
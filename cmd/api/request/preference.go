@@ -0,0 +1,30 @@
+package request
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// PreferencePatch contains preferences to set and keys of preferences to reset, taken from a JSON merge patch
+type PreferencePatch struct {
+	Set   model.Preferences
+	Reset []string
+}
+
+// Preferences validates JSON merge patch of preferences against model.PreferenceSchema.
+// Preferences of a group are nested under its name, and null resets a preference, or a whole group, to inherit it again
+func Preferences(c echo.Context) (*PreferencePatch, error) {
+	var patch map[string]interface{}
+	if err := json.NewDecoder(c.Request().Body).Decode(&patch); err != nil || patch == nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "preferences must be a JSON object")
+	}
+	set, reset, err := model.ParsePreferencePatch(patch)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return &PreferencePatch{Set: set, Reset: reset}, nil
+}
@@ -0,0 +1,57 @@
+package request_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestPreferences(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.PreferencePatch
+	}{
+		{
+			name:    "Not an object",
+			req:     `["language"]`,
+			wantErr: true,
+		},
+		{
+			name:    "Null",
+			req:     `null`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid preference",
+			req:     `{"timezone":"Nowhere"}`,
+			wantErr: true,
+		},
+		{
+			name: "Success",
+			req:  `{"timezone":"Europe/Paris","privacy":{"show_email":true,"searchable":null}}`,
+			wantData: &request.PreferencePatch{
+				Set:   model.Preferences{"timezone": "Europe/Paris", "privacy.show_email": true},
+				Reset: []string{"privacy.searchable"},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PATCH", "", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.Preferences(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
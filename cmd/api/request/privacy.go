@@ -0,0 +1,37 @@
+package request
+
+import (
+	"github.com/labstack/echo"
+)
+
+// ErasureRequest contains request of a user to have its personal data erased
+type ErasureRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// RequestErasure validates erasure request of requesting user. The body is optional
+func RequestErasure(c echo.Context) (*ErasureRequest, error) {
+	r := new(ErasureRequest)
+	if c.Request().ContentLength == 0 {
+		return r, nil
+	}
+	if err := c.Bind(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Erasure contains request to erase a user
+type Erasure struct {
+	UserID int    `json:"user_id" validate:"required"`
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// Erase validates erasure of a user
+func Erase(c echo.Context) (*Erasure, error) {
+	e := new(Erasure)
+	if err := c.Bind(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
@@ -0,0 +1,78 @@
+package request_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestRequestErasure(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.ErasureRequest
+	}{
+		{
+			name:     "Empty body",
+			wantData: &request.ErasureRequest{},
+		},
+		{
+			name:    "Fail on reason length",
+			wantErr: true,
+			req:     `{"reason":"` + strings.Repeat("a", 501) + `"}`,
+		},
+		{
+			name:     "Success",
+			req:      `{"reason":"Leaving"}`,
+			wantData: &request.ErasureRequest{Reason: "Leaving"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.RequestErasure(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestErase(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.Erasure
+	}{
+		{
+			name:    "Fail on validating JSON",
+			wantErr: true,
+			req:     `{"reason":"Court order"}`,
+		},
+		{
+			name:     "Success",
+			req:      `{"user_id":2,"reason":"Court order"}`,
+			wantData: &request.Erasure{UserID: 2, Reason: "Court order"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.Erase(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
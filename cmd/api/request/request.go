@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/artistomin/friend4me/internal"
 	"github.com/labstack/echo"
 )
 
@@ -12,15 +13,53 @@ const (
 	maxLimit     = 1000
 )
 
-// Pagination contains pagination request
+// Pagination contains pagination request.
+// Cursor is an opaque cursor returned by a list, and takes the place of page for keyset pagination
 type Pagination struct {
-	Limit  int `query:"limit"`
-	Page   int `query:"page" validate:"min=0"`
-	Offset int `json:"-"`
+	Limit  int           `query:"limit"`
+	Page   int           `query:"page" validate:"min=0"`
+	Cursor string        `query:"cursor"`
+	Count  bool          `query:"count"`
+	Offset int           `json:"-"`
+	Keyset *model.Cursor `json:"-" query:"-"`
 }
 
-// Paginate validates pagination requests
+// Paginate validates pagination requests of lists paged by page number only.
+// Cursors and counts are rejected rather than ignored, as such lists don't support them
 func Paginate(c echo.Context) (*Pagination, error) {
+	p, err := bindPagination(c)
+	if err != nil {
+		return nil, err
+	}
+	if p.Cursor != "" || p.Count {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "cursor and count are not supported by this list")
+	}
+	p.Offset = p.Limit * p.Page
+	return p, nil
+}
+
+// PaginateKeyset validates pagination requests of lists paged by page number or cursor, and counted when asked to
+func PaginateKeyset(c echo.Context) (*Pagination, error) {
+	p, err := bindPagination(c)
+	if err != nil {
+		return nil, err
+	}
+	if p.Cursor != "" {
+		if p.Page > 0 {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "page can not be combined with cursor")
+		}
+		cur, err := model.ParseCursor(p.Cursor)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		p.Keyset = cur
+		return p, nil
+	}
+	p.Offset = p.Limit * p.Page
+	return p, nil
+}
+
+func bindPagination(c echo.Context) (*Pagination, error) {
 	p := new(Pagination)
 	if err := c.Bind(p); err != nil {
 		return nil, err
@@ -31,7 +70,6 @@ func Paginate(c echo.Context) (*Pagination, error) {
 	if p.Limit > 1000 {
 		p.Limit = maxLimit
 	}
-	p.Offset = p.Limit * p.Page
 	return p, nil
 }
 
@@ -6,15 +6,19 @@ import (
 	"testing"
 
 	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal"
 	"github.com/artistomin/friend4me/internal/mock"
 	"github.com/labstack/echo"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestPaginate(t *testing.T) {
+	id := "7"
+	cursor := &model.Cursor{Order: "-id", Values: []*string{&id}, Prev: true}
 	cases := []struct {
 		name     string
 		req      string
+		keyset   bool
 		wantErr  bool
 		wantData *request.Pagination
 	}{
@@ -39,6 +43,39 @@ func TestPaginate(t *testing.T) {
 				Page:   2,
 			},
 		},
+		{
+			name:    "Fail on cursor of list paged by page number",
+			req:     `/?cursor=` + cursor.Encode(),
+			wantErr: true,
+		},
+		{
+			name:    "Fail on count of list paged by page number",
+			req:     `/?count=true`,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on invalid cursor",
+			req:     `/?cursor=bm9wZQ`,
+			keyset:  true,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on cursor with page",
+			req:     `/?page=1&cursor=` + cursor.Encode(),
+			keyset:  true,
+			wantErr: true,
+		},
+		{
+			name:   "Cursor",
+			req:    `/?limit=20&count=true&cursor=` + cursor.Encode(),
+			keyset: true,
+			wantData: &request.Pagination{
+				Limit:  20,
+				Cursor: cursor.Encode(),
+				Count:  true,
+				Keyset: cursor,
+			},
+		},
 		{
 			name: "Test default",
 			req:  `/?limit=200&page=2`,
@@ -48,6 +85,17 @@ func TestPaginate(t *testing.T) {
 				Page:   2,
 			},
 		},
+		{
+			name:   "Page of list paged by cursor",
+			req:    `/?limit=200&page=2&count=true`,
+			keyset: true,
+			wantData: &request.Pagination{
+				Limit:  200,
+				Offset: 400,
+				Page:   2,
+				Count:  true,
+			},
+		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -57,7 +105,11 @@ func TestPaginate(t *testing.T) {
 				t.Error("Could not create http request")
 			}
 			c := mock.EchoCtx(req, w)
-			resp, err := request.Paginate(c)
+			paginate := request.Paginate
+			if tt.keyset {
+				paginate = request.PaginateKeyset
+			}
+			resp, err := paginate(c)
 			assert.Equal(t, tt.wantData, resp)
 			assert.Equal(t, tt.wantErr, err != nil)
 		})
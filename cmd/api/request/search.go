@@ -0,0 +1,24 @@
+package request
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+)
+
+// maxSearchLength is the maximum length of search query text
+const maxSearchLength = 100
+
+// SearchText validates search query text, from q query parameter
+func SearchText(c echo.Context) (string, error) {
+	q := strings.TrimSpace(c.QueryParam("q"))
+	if len(q) > maxSearchLength {
+		return "", echo.NewHTTPError(http.StatusBadRequest, "q must be at most 100 characters long")
+	}
+	if len(model.SearchTerms(q)) == 0 {
+		return "", echo.NewHTTPError(http.StatusBadRequest, "q must contain letters or digits")
+	}
+	return q, nil
+}
@@ -0,0 +1,52 @@
+package request_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+func TestSearchText(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData string
+	}{
+		{
+			name:    "Missing query",
+			wantErr: true,
+		},
+		{
+			name:    "No terms",
+			req:     "?q=%26%20!",
+			wantErr: true,
+		},
+		{
+			name:    "Too long",
+			req:     "?q=" + strings.Repeat("a", 101),
+			wantErr: true,
+		},
+		{
+			name:     "Success",
+			req:      "?q=%20ann%20smi%20",
+			wantData: "ann smi",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/"+tt.req, nil)
+			c := mock.EchoCtx(req, w)
+			resp, err := request.SearchText(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package request
+
+import (
+	"github.com/labstack/echo"
+)
+
+// Tag contains tag create request
+type Tag struct {
+	Name      string `json:"name" validate:"required,max=50"`
+	CompanyID int    `json:"company_id"`
+}
+
+// TagCreate validates tag create request
+func TagCreate(c echo.Context) (*Tag, error) {
+	t := new(Tag)
+	if err := c.Bind(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,48 @@
+package request_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestTagCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *request.Tag
+	}{
+		{
+			name:    "Fail on missing name",
+			req:     `{"company_id":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on long name",
+			req:     `{"name":"` + string(bytes.Repeat([]byte("a"), 51)) + `"}`,
+			wantErr: true,
+		},
+		{
+			name:     "Success",
+			req:      `{"name":"Board games","company_id":2}`,
+			wantData: &request.Tag{Name: "Board games", CompanyID: 2},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(tt.req))
+			c := mock.EchoCtx(req, w)
+			resp, err := request.TagCreate(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -1,6 +1,12 @@
 package request
 
 import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/artistomin/friend4me/internal"
 	"github.com/labstack/echo"
 )
 
@@ -12,6 +18,11 @@ type UpdateUser struct {
 	Mobile    *string `json:"mobile,omitempty"`
 	Phone     *string `json:"phone,omitempty"`
 	Address   *string `json:"address,omitempty"`
+
+	HidePresence *bool `json:"hide_presence,omitempty"`
+
+	Bio  *string  `json:"bio,omitempty" validate:"omitempty,max=500"`
+	Tags []string `json:"tags" validate:"omitempty,max=20,dive,max=50"`
 }
 
 // UserUpdate validates user update request
@@ -27,3 +38,114 @@ func UserUpdate(c echo.Context) (*UpdateUser, error) {
 	u.ID = id
 	return u, nil
 }
+
+const (
+	maxUserSearch = 100
+	maxUserSort   = 3
+)
+
+// UserList validates user list filters, from active, role, company_id, location_id, created_after, created_before,
+// last_login_after, last_login_before, q and sort query parameters.
+// Sort is a comma separated list of fields, descending when prefixed with '-'
+func UserList(c echo.Context) (*model.UserFilter, error) {
+	f := &model.UserFilter{Search: strings.TrimSpace(c.QueryParam("q"))}
+	if len(f.Search) > maxUserSearch {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "q must be at most 100 characters long")
+	}
+	if v := c.QueryParam("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "active must be true or false")
+		}
+		f.Active = &active
+	}
+	if v := c.QueryParam("role"); v != "" {
+		role, err := strconv.Atoi(v)
+		if err != nil || role < int(model.SuperAdminRole) || role > int(model.UserRole) {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "role must be between 1 and 5")
+		}
+		f.RoleID = role
+	}
+	var err error
+	if f.CompanyID, err = queryID(c, "company_id"); err != nil {
+		return nil, err
+	}
+	if f.LocationID, err = queryID(c, "location_id"); err != nil {
+		return nil, err
+	}
+	for name, t := range map[string]**time.Time{
+		"created_after":     &f.CreatedAfter,
+		"created_before":    &f.CreatedBefore,
+		"last_login_after":  &f.LastLoginAfter,
+		"last_login_before": &f.LastLoginBefore,
+	} {
+		if *t, err = queryTime(c, name); err != nil {
+			return nil, err
+		}
+	}
+	if f.Sort, err = userSort(c.QueryParam("sort")); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func queryBool(c echo.Context, name string) (bool, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, echo.NewHTTPError(http.StatusBadRequest, name+" must be true or false")
+	}
+	return b, nil
+}
+
+func queryID(c echo.Context, name string) (int, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id < 1 {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, name+" must be an id")
+	}
+	return id, nil
+}
+
+func queryTime(c echo.Context, name string) (*time.Time, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, name+" must be an RFC3339 timestamp")
+	}
+	return &t, nil
+}
+
+func userSort(v string) ([]model.UserSort, error) {
+	if v == "" {
+		return nil, nil
+	}
+	fields := strings.Split(v, ",")
+	if len(fields) > maxUserSort {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "sort accepts at most 3 fields")
+	}
+	var sort []model.UserSort
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		s := model.UserSort{Field: strings.TrimPrefix(field, "-")}
+		s.Desc = s.Field != field
+		if !model.UserSortFields[s.Field] {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "users can not be sorted by "+strconv.Quote(s.Field))
+		}
+		if seen[s.Field] {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "sort field "+strconv.Quote(s.Field)+" is repeated")
+		}
+		seen[s.Field] = true
+		sort = append(sort, s)
+	}
+	return sort, nil
+}
@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/artistomin/friend4me/internal"
 	"github.com/artistomin/friend4me/internal/mock"
 	"github.com/stretchr/testify/assert"
 
@@ -13,6 +15,7 @@ import (
 )
 
 func TestUserUpdate(t *testing.T) {
+	hide := true
 	cases := []struct {
 		name     string
 		id       string
@@ -45,6 +48,31 @@ func TestUserUpdate(t *testing.T) {
 				Address:   mock.Str2Ptr("home"),
 			},
 		},
+		{
+			name: "Hide presence",
+			id:   "1",
+			req:  `{"hide_presence":true}`,
+			wantData: &request.UpdateUser{
+				ID:           1,
+				HidePresence: &hide,
+			},
+		},
+		{
+			name:    "Fail on too many tags",
+			wantErr: true,
+			id:      "1",
+			req:     `{"tags":["a","b","c","d","e","f","g","h","i","j","k","l","m","n","o","p","q","r","s","t","u"]}`,
+		},
+		{
+			name: "Bio and tags",
+			id:   "1",
+			req:  `{"bio":"Plays chess","tags":["chess","jazz"]}`,
+			wantData: &request.UpdateUser{
+				ID:   1,
+				Bio:  mock.Str2Ptr("Plays chess"),
+				Tags: []string{"chess", "jazz"},
+			},
+		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -59,3 +87,76 @@ func TestUserUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestUserList(t *testing.T) {
+	active := false
+	after := time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		req      string
+		wantErr  bool
+		wantData *model.UserFilter
+	}{
+		{
+			name:    "Invalid active",
+			req:     "?active=sometimes",
+			wantErr: true,
+		},
+		{
+			name:    "Role out of range",
+			req:     "?role=6",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid company id",
+			req:     "?company_id=-1",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid timestamp",
+			req:     "?created_after=2018-01-01",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown sort field",
+			req:     "?sort=password",
+			wantErr: true,
+		},
+		{
+			name:    "Repeated sort field",
+			req:     "?sort=last_name,-last_name",
+			wantErr: true,
+		},
+		{
+			name:    "Too many sort fields",
+			req:     "?sort=last_name,first_name,email,id",
+			wantErr: true,
+		},
+		{
+			name:     "Default",
+			wantData: &model.UserFilter{},
+		},
+		{
+			name: "Success",
+			req:  "?active=false&role=4&location_id=3&created_after=2018-01-01T00:00:00Z&q=%20doe%20&sort=-last_login,first_name",
+			wantData: &model.UserFilter{
+				Active:       &active,
+				RoleID:       4,
+				LocationID:   3,
+				CreatedAfter: &after,
+				Search:       "doe",
+				Sort:         []model.UserSort{{Field: "last_login", Desc: true}, {Field: "first_name"}},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/"+tt.req, nil)
+			c := mock.EchoCtx(req, w)
+			resp, err := request.UserList(c)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package service
 
 import (
+	"bytes"
+	"encoding/csv"
 	"net/http"
+	"strconv"
 
 	"github.com/artistomin/friend4me/internal"
 	"github.com/labstack/echo"
@@ -26,6 +29,7 @@ func NewAccount(svc *account.Service, ar *echo.Group) {
 	//  400: errMsg
 	//  401: err
 	//  403: errMsg
+	//  409: errMsg
 	//  500: err
 	ar.POST("", a.create)
 	// swagger:operation PATCH /v1/users/{id}/password users pwChange
@@ -50,6 +54,72 @@ func NewAccount(svc *account.Service, ar *echo.Group) {
 	//   "500":
 	//     "$ref": "#/responses/err"
 	ar.PATCH("/:id/password", a.changePassword)
+	// swagger:operation POST /v1/users/import users usersImport
+	// ---
+	// summary: Imports user accounts.
+	// description: Creates user accounts from a CSV file with a header row, or from JSON lines. Every row is validated
+	//   and checked like a single account creation. Atomic imports create accounts only if all rows succeed, while best effort
+	//   imports skip failing rows. Dry runs check rows without creating anything. Rows that failed are listed in the report.
+	// consumes:
+	// - text/csv
+	// - application/x-ndjson
+	// produces:
+	// - application/json
+	// - text/csv
+	// parameters:
+	// - name: format
+	//   in: query
+	//   description: csv or jsonl, defaults to Content-Type of the body
+	//   type: string
+	//   required: false
+	// - name: map
+	//   in: query
+	//   description: comma separated field:column pairs, mapping columns to first_name, last_name, username, password,
+	//     password_confirm, email, company_id, location_id and role_id. Columns named as fields need not be mapped
+	//   type: string
+	//   required: false
+	// - name: company_id
+	//   in: query
+	//   description: company of rows leaving it empty
+	//   type: int
+	//   required: false
+	// - name: location_id
+	//   in: query
+	//   description: location of rows leaving it empty
+	//   type: int
+	//   required: false
+	// - name: role_id
+	//   in: query
+	//   description: role of rows leaving it empty
+	//   type: int
+	//   required: false
+	// - name: mode
+	//   in: query
+	//   description: atomic (default) or best_effort
+	//   type: string
+	//   required: false
+	// - name: dry_run
+	//   in: query
+	//   description: whether to only check the rows
+	//   type: boolean
+	//   required: false
+	// - name: report
+	//   in: query
+	//   description: json (default), or csv to download failed rows as a file
+	//   type: string
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/importResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "415":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ar.POST("/import", a.importUsers)
 }
 
 func (a *Account) create(c echo.Context) error {
@@ -83,3 +153,26 @@ func (a *Account) changePassword(c echo.Context) error {
 	}
 	return c.NoContent(http.StatusOK)
 }
+
+func (a *Account) importUsers(c echo.Context) error {
+	r, err := request.UserImport(c)
+	if err != nil {
+		return err
+	}
+	report, err := a.svc.Import(c, r.Rows, r.DryRun, r.Atomic)
+	if err != nil {
+		return err
+	}
+	if !r.CSVReport {
+		return c.JSON(http.StatusOK, report)
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"row", "username", "message"})
+	for _, e := range report.Errors {
+		w.Write([]string{strconv.Itoa(e.Row), e.Username, e.Message})
+	}
+	w.Flush()
+	c.Response().Header().Set("Content-Disposition", `attachment; filename="import-report.csv"`)
+	return c.Blob(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
@@ -3,10 +3,13 @@ package service_test
 import (
 	"bytes"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-pg/pg/orm"
+
 	"github.com/labstack/echo"
 	"github.com/stretchr/testify/assert"
 
@@ -54,7 +57,7 @@ func TestCreate(t *testing.T) {
 				},
 			},
 			adb: &mockdb.Account{
-				CreateFn: func(usr model.User) (*model.User, error) {
+				CreateFn: func(db orm.DB, usr model.User) (*model.User, error) {
 					usr.ID = 1
 					usr.CreatedAt = mock.TestTime(2018)
 					usr.UpdatedAt = mock.TestTime(2018)
@@ -82,7 +85,7 @@ func TestCreate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("/v1/users")
-			service.NewAccount(account.New(tt.adb, nil, tt.rbac), rg)
+			service.NewAccount(account.New(tt.adb, nil, tt.rbac, nil, activityLogger), rg)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/v1/users"
@@ -140,14 +143,14 @@ func TestChangePassword(t *testing.T) {
 			},
 			id: "1",
 			udb: &mockdb.User{
-				ViewFn: func(id int) (*model.User, error) {
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
 					return &model.User{
 						Password: auth.HashPassword("oldpassw"),
 					}, nil
 				},
 			},
 			adb: &mockdb.Account{
-				ChangePasswordFn: func(usr *model.User) error {
+				ChangePasswordFn: func(db orm.DB, usr *model.User) error {
 					return nil
 				},
 			},
@@ -161,7 +164,7 @@ func TestChangePassword(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("/v1/users")
-			service.NewAccount(account.New(tt.adb, tt.udb, tt.rbac), rg)
+			service.NewAccount(account.New(tt.adb, tt.udb, tt.rbac, notifier, nil), rg)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/v1/users/" + tt.id + "/password"
@@ -179,3 +182,88 @@ func TestChangePassword(t *testing.T) {
 		})
 	}
 }
+
+func TestImportUsers(t *testing.T) {
+	cases := []struct {
+		name        string
+		query       string
+		contentType string
+		req         string
+		wantStatus  int
+		wantResp    *model.ImportReport
+		wantCSV     string
+	}{
+		{
+			name:        "Unsupported format",
+			contentType: "text/plain",
+			req:         "username\njohndoe",
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "Invalid mode",
+			query:       "?mode=some",
+			contentType: "text/csv",
+			req:         "username\njohndoe",
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "Atomic import with failed row",
+			query:       "?company_id=1&location_id=2&role_id=5",
+			contentType: "text/csv",
+			req:         "first_name,last_name,username,password,email\nJohn,Doe,johndoe,hunter123,johndoe@gmail.com\nJane,Doe,jd,hunter123,janedoe@gmail.com\n",
+			wantStatus:  http.StatusOK,
+			wantResp: &model.ImportReport{Atomic: true, Rows: 2, Imported: 1,
+				Errors: []model.ImportError{{Row: 2, Username: "jd", Message: "Username failed on min validation"}}},
+		},
+		{
+			name:        "CSV report",
+			query:       "?company_id=1&location_id=2&role_id=5&mode=best_effort&report=csv",
+			contentType: "text/csv",
+			req:         "first_name,last_name,username,password,email\nJohn,Doe,johndoe,hunter123,johndoe@gmail.com\nJane,Doe,jd,hunter123,janedoe@gmail.com\n",
+			wantStatus:  http.StatusOK,
+			wantCSV:     "row,username,message\n2,jd,Username failed on min validation\n",
+		},
+	}
+	adb := &mockdb.Account{
+		ImportFn: func(db orm.DB, users []model.User, mode model.ImportMode) ([]error, error) {
+			for i := range users {
+				users[i].ID = i + 1
+			}
+			return make([]error, len(users)), nil
+		},
+	}
+	rbac := &mock.RBAC{
+		AccountCreateFn: func(c echo.Context, roleID, companyID, locationID int) error {
+			return nil
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			service.NewAccount(account.New(adb, nil, rbac, nil, activityLogger), r.Group("/v1/users"))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/users/import"+tt.query, tt.contentType, bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantResp != nil {
+				response := new(model.ImportReport)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			if tt.wantCSV != "" {
+				body, err := ioutil.ReadAll(res.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, "text/csv; charset=utf-8", res.Header.Get("Content-Type"))
+				assert.Equal(t, tt.wantCSV, string(body))
+			}
+		})
+	}
+}
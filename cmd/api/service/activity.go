@@ -0,0 +1,68 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/internal/activity"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+// Activity represents activity feed http service
+type Activity struct {
+	svc *activity.Service
+}
+
+// NewActivity creates new activity feed http service
+func NewActivity(svc *activity.Service, ar *echo.Group) {
+	a := Activity{svc: svc}
+	// swagger:operation GET /v1/feed feed feed
+	// ---
+	// summary: Returns activity feed of the current user.
+	// description: Returns what friends of the current user did, newest first. Repeated activities of a friend are aggregated.
+	//   Activities of blocked and muted users are left out. Pass next_cursor of the response as before to get older activities.
+	// parameters:
+	// - name: before
+	//   in: query
+	//   description: return activities older than the activity with this id
+	//   type: int
+	//   required: false
+	// - name: limit
+	//   in: query
+	//   description: number of activities
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/feedResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ar.GET("", a.feed)
+}
+
+type feedResponse struct {
+	Items      []model.FeedItem `json:"items"`
+	NextCursor int              `json:"next_cursor,omitempty"`
+}
+
+func (a *Activity) feed(c echo.Context) error {
+	cur, err := request.FeedCursor(c)
+	if err != nil {
+		return err
+	}
+	items, next, err := a.svc.Feed(c, &model.FeedCursor{
+		Before: cur.Before, Limit: cur.Limit,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, feedResponse{items, next})
+}
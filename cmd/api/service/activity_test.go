@@ -0,0 +1,92 @@
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/activity"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+// activityLogger is activity logger mock accepting every activity
+var activityLogger = &mock.ActivityLogger{
+	LogFn: func(echo.Context, model.Activity) error {
+		return nil
+	}}
+
+func TestFeed(t *testing.T) {
+	type feedResponse struct {
+		Items      []model.FeedItem `json:"items"`
+		NextCursor int              `json:"next_cursor"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *feedResponse
+		adb        *mockdb.Activity
+	}{
+		{
+			name:       "Invalid cursor",
+			req:        `?before=abc`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `?before=10&limit=2`,
+			adb: &mockdb.Activity{
+				FeedFn: func(db orm.DB, id int, cur *model.FeedCursor) ([]model.Activity, error) {
+					if id != 1 || cur.Before != 10 || cur.Limit != 2 {
+						return nil, model.ErrGeneric
+					}
+					return []model.Activity{
+						{Base: model.Base{ID: 8}, ActorID: 2, Type: model.ActivityBecameFriends, ObjectID: 3},
+						{Base: model.Base{ID: 7}, ActorID: 2, Type: model.ActivityBecameFriends, ObjectID: 4},
+					}, nil
+				}},
+			wantStatus: http.StatusOK,
+			wantResp: &feedResponse{
+				Items:      []model.FeedItem{{ID: 8, ActorID: 2, Type: model.ActivityBecameFriends, ObjectIDs: []int{3, 4}, Count: 2}},
+				NextCursor: 7,
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			auth := &mock.Auth{
+				UserFn: func(echo.Context) *model.AuthUser {
+					return &model.AuthUser{ID: 1, CompanyID: 1}
+				}}
+			service.NewActivity(activity.New(tt.adb, auth), r.Group("/v1/feed"))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/feed" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(feedResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -14,8 +14,9 @@ type Auth struct {
 	svc *auth.Service
 }
 
-// NewAuth creates new auth http service
-func NewAuth(svc *auth.Service, e *echo.Echo, mw echo.MiddlewareFunc) {
+// NewAuth creates new auth http service.
+// Logging in and refreshing run unscoped, as the user's company is not known yet
+func NewAuth(svc *auth.Service, e *echo.Echo, unscoped echo.MiddlewareFunc, mw ...echo.MiddlewareFunc) {
 	a := Auth{svc}
 	// swagger:route POST /login auth login
 	// Logs in user by username and password.
@@ -26,7 +27,7 @@ func NewAuth(svc *auth.Service, e *echo.Echo, mw echo.MiddlewareFunc) {
 	// 	403: err
 	//  404: errMsg
 	//  500: err
-	e.POST("/login", a.login)
+	e.POST("/login", a.login, unscoped)
 	// swagger:operation GET /refresh/{token} auth refresh
 	// ---
 	// summary: Refreshes jwt token.
@@ -46,14 +47,14 @@ func NewAuth(svc *auth.Service, e *echo.Echo, mw echo.MiddlewareFunc) {
 	//     "$ref": "#/responses/err"
 	//   "500":
 	//     "$ref": "#/responses/err"
-	e.GET("/refresh/:token", a.refresh)
+	e.GET("/refresh/:token", a.refresh, unscoped)
 
 	// swagger:route GET /me auth meReq
 	// Gets user's info from session
 	// responses:
 	//  200: userResp
 	//  500: err
-	e.GET("/me", a.me, mw)
+	e.GET("/me", a.me, mw...)
 }
 
 func (a *Auth) login(c echo.Context) error {
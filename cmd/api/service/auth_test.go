@@ -8,6 +8,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+
 	"github.com/artistomin/friend4me/internal"
 	"github.com/stretchr/testify/assert"
 
@@ -20,6 +23,11 @@ import (
 	"github.com/artistomin/friend4me/internal/mock/mockdb"
 )
 
+// pass stands in for transaction middleware
+func pass(next echo.HandlerFunc) echo.HandlerFunc {
+	return next
+}
+
 func TestLogin(t *testing.T) {
 	cases := []struct {
 		name       string
@@ -39,7 +47,7 @@ func TestLogin(t *testing.T) {
 			req:        `{"username":"juzernejm","password":"hunter123"}`,
 			wantStatus: http.StatusInternalServerError,
 			udb: &mockdb.User{
-				FindByUsernameFn: func(string) (*model.User, error) {
+				FindByUsernameFn: func(orm.DB, string) (*model.User, error) {
 					return nil, model.ErrGeneric
 				},
 			},
@@ -49,13 +57,13 @@ func TestLogin(t *testing.T) {
 			req:        `{"username":"juzernejm","password":"hunter123"}`,
 			wantStatus: http.StatusOK,
 			udb: &mockdb.User{
-				FindByUsernameFn: func(string) (*model.User, error) {
+				FindByUsernameFn: func(orm.DB, string) (*model.User, error) {
 					return &model.User{
 						Password: auth.HashPassword("hunter123"),
 						Active:   true,
 					}, nil
 				},
-				UpdateFn: func(u *model.User) (*model.User, error) {
+				UpdateFn: func(db orm.DB, u *model.User) (*model.User, error) {
 					return u, nil
 				},
 			},
@@ -71,7 +79,7 @@ func TestLogin(t *testing.T) {
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
-			service.NewAuth(auth.New(tt.udb, tt.jwt), r, nil)
+			service.NewAuth(auth.New(tt.udb, tt.jwt), r, pass)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/login"
@@ -107,7 +115,7 @@ func TestRefresh(t *testing.T) {
 			req:        "refreshtoken",
 			wantStatus: http.StatusInternalServerError,
 			udb: &mockdb.User{
-				FindByTokenFn: func(string) (*model.User, error) {
+				FindByTokenFn: func(orm.DB, string) (*model.User, error) {
 					return nil, model.ErrGeneric
 				},
 			},
@@ -117,7 +125,7 @@ func TestRefresh(t *testing.T) {
 			req:        "refreshtoken",
 			wantStatus: http.StatusOK,
 			udb: &mockdb.User{
-				FindByTokenFn: func(string) (*model.User, error) {
+				FindByTokenFn: func(orm.DB, string) (*model.User, error) {
 					return &model.User{
 						Username: "johndoe",
 						Active:   true,
@@ -136,7 +144,7 @@ func TestRefresh(t *testing.T) {
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
-			service.NewAuth(auth.New(tt.udb, tt.jwt), r, nil)
+			service.NewAuth(auth.New(tt.udb, tt.jwt), r, pass)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/refresh/" + tt.req
@@ -169,7 +177,7 @@ func TestMe(t *testing.T) {
 			name:       "Fail on user view",
 			wantStatus: http.StatusInternalServerError,
 			udb: &mockdb.User{
-				ViewFn: func(int) (*model.User, error) {
+				ViewFn: func(orm.DB, int) (*model.User, error) {
 					return nil, model.ErrGeneric
 				},
 			},
@@ -179,7 +187,7 @@ func TestMe(t *testing.T) {
 			name:       "Success",
 			wantStatus: http.StatusOK,
 			udb: &mockdb.User{
-				ViewFn: func(i int) (*model.User, error) {
+				ViewFn: func(db orm.DB, i int) (*model.User, error) {
 					return &model.User{
 						Base: model.Base{
 							ID: i,
@@ -213,7 +221,7 @@ func TestMe(t *testing.T) {
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
-			service.NewAuth(auth.New(tt.udb, nil), r, jwtMW.MWFunc())
+			service.NewAuth(auth.New(tt.udb, nil), r, pass, jwtMW.MWFunc())
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/me"
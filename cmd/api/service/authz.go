@@ -0,0 +1,58 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/internal/rbac"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+// Authz represents authorization http service
+type Authz struct {
+	svc *rbac.Service
+}
+
+// NewAuthz creates new authorization http service
+func NewAuthz(svc *rbac.Service, ar *echo.Group) {
+	a := Authz{svc: svc}
+	// swagger:route POST /v1/authz/explain authz authzExplain
+	// Explains the authorization decision for a hypothetical subject, action and resource.
+	// responses:
+	//  200: authzTraceResp
+	//  400: errMsg
+	//  401: err
+	//  403: err
+	//  500: err
+	ar.POST("/explain", a.explain)
+}
+
+func (a *Authz) explain(c echo.Context) error {
+	r, err := request.AuthzExplain(c)
+	if err != nil {
+		return err
+	}
+	trace, err := a.svc.Explain(c, model.AuthUser{
+		ID:         r.Subject.ID,
+		CompanyID:  r.Subject.CompanyID,
+		LocationID: r.Subject.LocationID,
+		Role:       model.AccessRole(r.Subject.Role),
+	}, r.Action, model.AuthzResource{
+		ID:           r.Resource.ID,
+		Role:         model.AccessRole(r.Resource.Role),
+		CompanyID:    r.Resource.CompanyID,
+		LocationID:   r.Resource.LocationID,
+		Participants: r.Resource.Participants,
+
+		GroupRole:         model.GroupRole(r.Resource.GroupRole),
+		RequiredGroupRole: model.GroupRole(r.Resource.RequiredGroupRole),
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, trace)
+}
@@ -0,0 +1,99 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/config"
+	"github.com/artistomin/friend4me/cmd/api/mw"
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/rbac"
+)
+
+func TestExplain(t *testing.T) {
+	jwtCfg := &config.JWT{Realm: "testRealm", Secret: "jwtsecret", Duration: 60, SigningAlgorithm: "HS256"}
+	jwtMW := mw.NewJWT(jwtCfg)
+	userToken, _, err := jwtMW.GenerateToken(&model.User{Base: model.Base{ID: 4}, Role: &model.Role{AccessLevel: model.UserRole}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		req        string
+		header     string
+		wantStatus int
+		wantResp   *model.AuthzTrace
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"subject":{"role":3}}`,
+			header:     mock.HeaderValid(),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Not an admin",
+			req:        `{"action":"enforce_role","subject":{"role":3},"resource":{"role":2}}`,
+			header:     "Bearer " + userToken,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Unknown action",
+			req:        `{"action":"fly","subject":{"role":3}}`,
+			header:     mock.HeaderValid(),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success",
+			req:        `{"action":"enforce_user","subject":{"id":3,"role":5},"resource":{"id":3}}`,
+			header:     mock.HeaderValid(),
+			wantStatus: http.StatusOK,
+			wantResp: &model.AuthzTrace{
+				Action:  rbac.ActionUser,
+				Subject: 3,
+				Allowed: true,
+				Decisions: []model.AuthzDecision{
+					{Check: "role", Role: model.UserRole, Required: model.AdminRole},
+					{Check: "scope", Role: model.UserRole, Scope: "user", Have: 3, Want: 3, Allowed: true},
+				},
+			},
+		},
+	}
+
+	client := &http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("/v1/authz", jwtMW.MWFunc())
+			service.NewAuthz(rbac.New(nil, nil, nil), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, _ := http.NewRequest("POST", ts.URL+"/v1/authz/explain", bytes.NewBufferString(tt.req))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", tt.header)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(model.AuthzTrace)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal/avatar"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+// Avatar represents profile picture http service
+type Avatar struct {
+	svc     *avatar.Service
+	maxSize int64
+}
+
+// NewAvatar creates new profile picture http service, accepting images of at most maxSize bytes
+func NewAvatar(svc *avatar.Service, ur *echo.Group, maxSize int64) {
+	a := Avatar{svc: svc, maxSize: maxSize}
+	// swagger:operation PUT /v1/users/{id}/avatar users avatarUpload
+	// ---
+	// summary: Uploads user's avatar.
+	// description: Replaces user's avatar with a JPEG, PNG or GIF image, stored as square JPEG thumbnails without its EXIF data.
+	// consumes:
+	// - multipart/form-data
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of user
+	//   type: int
+	//   required: true
+	// - name: avatar
+	//   in: formData
+	//   description: image file
+	//   type: file
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/avatarResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "413":
+	//     "$ref": "#/responses/errMsg"
+	//   "415":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.PUT("/:id/avatar", a.upload)
+	// swagger:operation DELETE /v1/users/{id}/avatar users avatarDelete
+	// ---
+	// summary: Deletes user's avatar.
+	// description: Removes user's avatar together with all of its thumbnails.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of user
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.DELETE("/:id/avatar", a.delete)
+}
+
+func (a *Avatar) upload(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	data, err := request.Avatar(c, a.maxSize)
+	if err != nil {
+		return err
+	}
+	result, err := a.svc.Upload(c, id, data)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (a *Avatar) delete(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	if err := a.svc.Delete(c, id); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,169 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/avatar"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+func avatarServer(udb *mockdb.User, files map[string][]byte) *httptest.Server {
+	r := server.New()
+	rbac := &mock.RBAC{
+		EnforceUserFn: func(c echo.Context, id int) error {
+			if id != 1 {
+				return echo.ErrForbidden
+			}
+			return nil
+		}}
+	st := &mock.Storage{
+		PutFn: func(key, contentType string, data []byte) error {
+			files[key] = data
+			return nil
+		},
+		DeleteFn: func(key string) error {
+			delete(files, key)
+			return nil
+		},
+		URLFn: func(key string) string {
+			return "/uploads/" + key
+		}}
+	service.NewAvatar(avatar.New(udb, st, rbac), r.Group("/v1/users"), 1<<20)
+	return httptest.NewServer(r)
+}
+
+func TestUploadAvatar(t *testing.T) {
+	var img bytes.Buffer
+	png.Encode(&img, image.NewGray(image.Rect(0, 0, 20, 20)))
+	cases := []struct {
+		name       string
+		id         string
+		file       []byte
+		wantStatus int
+	}{
+		{
+			name:       "Invalid id",
+			id:         "a",
+			file:       img.Bytes(),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on RBAC",
+			id:         "2",
+			file:       img.Bytes(),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Not an image",
+			id:         "1",
+			file:       []byte("plain text"),
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:       "Success",
+			id:         "1",
+			file:       img.Bytes(),
+			wantStatus: http.StatusOK,
+		},
+	}
+	udb := &mockdb.User{
+		ViewFn: func(db orm.DB, id int) (*model.User, error) {
+			return &model.User{Base: model.Base{ID: id}}, nil
+		},
+		UpdateFn: func(db orm.DB, u *model.User) (*model.User, error) {
+			return u, nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string][]byte{}
+			ts := avatarServer(udb, files)
+			defer ts.Close()
+			body := new(bytes.Buffer)
+			mw := multipart.NewWriter(body)
+			fw, _ := mw.CreateFormFile("avatar", "me.png")
+			fw.Write(tt.file)
+			mw.Close()
+			req, _ := http.NewRequest("PUT", ts.URL+"/v1/users/"+tt.id+"/avatar", body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantStatus != http.StatusOK {
+				assert.Len(t, files, 0)
+				return
+			}
+			response := new(model.Avatar)
+			if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+				t.Fatal(err)
+			}
+			assert.Len(t, files, len(model.AvatarSizes))
+			assert.Equal(t, "/uploads/"+model.AvatarKey(1, response.ID, "small"), response.URLs["small"])
+		})
+	}
+}
+
+func TestDeleteAvatar(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+	}{
+		{
+			name:       "Invalid id",
+			id:         "a",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on RBAC",
+			id:         "2",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Success",
+			id:         "1",
+			wantStatus: http.StatusOK,
+		},
+	}
+	udb := &mockdb.User{
+		ViewFn: func(db orm.DB, id int) (*model.User, error) {
+			return &model.User{Base: model.Base{ID: id}, Avatar: &model.Avatar{ID: "old"}}, nil
+		},
+		UpdateFn: func(db orm.DB, u *model.User) (*model.User, error) {
+			return u, nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string][]byte{"avatars/1/old/small.jpg": nil}
+			ts := avatarServer(udb, files)
+			defer ts.Close()
+			req, _ := http.NewRequest("DELETE", ts.URL+"/v1/users/"+tt.id+"/avatar", nil)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			assert.Equal(t, tt.wantStatus != http.StatusOK, len(files) == 1)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/internal/block"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+// Block represents block and mute http service
+type Block struct {
+	svc *block.Service
+}
+
+// NewBlock creates new block and mute http service
+func NewBlock(svc *block.Service, br *echo.Group) {
+	b := Block{svc: svc}
+	// swagger:operation GET /v1/blocks blocks listBlocks
+	// ---
+	// summary: Returns users blocked or muted by the current user.
+	// description: Returns paginated list of users blocked or muted by the current user.
+	// parameters:
+	// - name: kind
+	//   in: query
+	//   description: block (default) or mute
+	//   type: string
+	//   required: false
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/blockListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	br.GET("", b.list)
+	// swagger:route POST /v1/blocks blocks blockCreate
+	// Blocks or mutes a user. Blocking ends friendship with the user.
+	// responses:
+	//  200: blockResp
+	//  400: errMsg
+	//  401: err
+	//  404: err
+	//  409: errMsg
+	//  500: err
+	br.POST("", b.create)
+	// swagger:operation DELETE /v1/blocks/{id} blocks blockDelete
+	// ---
+	// summary: Unblocks or unmutes a user.
+	// description: Lifts a block or mute put by the current user on a user.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of blocked user
+	//   type: int
+	//   required: true
+	// - name: kind
+	//   in: query
+	//   description: block (default) or mute
+	//   type: string
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	br.DELETE("/:id", b.delete)
+}
+
+type blockListResponse struct {
+	Users []model.User `json:"users"`
+	Page  int          `json:"page"`
+}
+
+func (b *Block) list(c echo.Context) error {
+	kind, err := request.BlockKind(c)
+	if err != nil {
+		return err
+	}
+	p, err := request.Paginate(c)
+	if err != nil {
+		return err
+	}
+	result, err := b.svc.List(c, model.BlockKind(kind), &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, blockListResponse{result, p.Page})
+}
+
+func (b *Block) create(c echo.Context) error {
+	r, err := request.BlockCreate(c)
+	if err != nil {
+		return err
+	}
+	result, err := b.svc.Create(c, r.UserID, model.BlockKind(r.Kind))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (b *Block) delete(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	kind, err := request.BlockKind(c)
+	if err != nil {
+		return err
+	}
+	if err := b.svc.Delete(c, id, model.BlockKind(kind)); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
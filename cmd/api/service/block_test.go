@@ -0,0 +1,203 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/block"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+func blockServer(bdb *mockdb.Block, fdb *mockdb.Friend, udb *mockdb.User) *httptest.Server {
+	r := server.New()
+	auth := &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, CompanyID: 1}
+		}}
+	sdb := &mockdb.Suggestion{
+		RefreshFn: func(orm.DB, ...int) error {
+			return nil
+		}}
+	service.NewBlock(block.New(bdb, fdb, udb, sdb, auth), r.Group("/v1/blocks"))
+	return httptest.NewServer(r)
+}
+
+func TestListBlocks(t *testing.T) {
+	type listResponse struct {
+		Users []model.User `json:"users"`
+		Page  int          `json:"page"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *listResponse
+		bdb        *mockdb.Block
+	}{
+		{
+			name:       "Invalid kind",
+			req:        `?kind=ignore`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `?kind=mute`,
+			bdb: &mockdb.Block{
+				ListFn: func(db orm.DB, id int, kind model.BlockKind, p *model.Pagination) ([]model.User, error) {
+					if kind != model.BlockMute {
+						return nil, model.ErrGeneric
+					}
+					return []model.User{{Base: model.Base{ID: 2}}}, nil
+				}},
+			wantStatus: http.StatusOK,
+			wantResp:   &listResponse{Users: []model.User{{Base: model.Base{ID: 2}}}},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := blockServer(tt.bdb, nil, nil)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/blocks" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestCreateBlock(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *model.Block
+		bdb        *mockdb.Block
+		udb        *mockdb.User
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"user_id":2,"kind":"ignore"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Block yourself",
+			req:        `{"user_id":1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `{"user_id":2}`,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, CompanyID: 1}, nil
+				}},
+			bdb: &mockdb.Block{
+				CreateFn: func(db orm.DB, b model.Block) (*model.Block, error) {
+					b.ID = 1
+					return &b, nil
+				}},
+			wantStatus: http.StatusOK,
+			wantResp:   &model.Block{Base: model.Base{ID: 1}, UserID: 1, TargetID: 2, CompanyID: 1, Kind: model.BlockFull},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			fdb := &mockdb.Friend{
+				DeleteBetweenFn: func(orm.DB, int, int) error {
+					return nil
+				}}
+			ts := blockServer(tt.bdb, fdb, tt.udb)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/blocks", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(model.Block)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				tt.wantResp.CreatedAt = response.CreatedAt
+				tt.wantResp.UpdatedAt = response.UpdatedAt
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestDeleteBlock(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		bdb        *mockdb.Block
+	}{
+		{
+			name:       "Invalid request",
+			req:        `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid kind",
+			req:        `2?kind=ignore`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `2?kind=mute`,
+			bdb: &mockdb.Block{
+				FindFn: func(db orm.DB, userID, targetID int, kind model.BlockKind) (*model.Block, error) {
+					if kind != model.BlockMute {
+						return nil, model.ErrGeneric
+					}
+					return &model.Block{UserID: userID, TargetID: targetID, Kind: kind}, nil
+				},
+				DeleteFn: func(orm.DB, *model.Block) error {
+					return nil
+				}},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := blockServer(tt.bdb, nil, nil)
+			defer ts.Close()
+			req, _ := http.NewRequest("DELETE", ts.URL+"/v1/blocks/"+tt.req, nil)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
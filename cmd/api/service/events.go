@@ -0,0 +1,164 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+const (
+	// streamMargin is time left before server's write timeout to end the stream cleanly
+	streamMargin = 5 * time.Second
+
+	// streamRetry is reconnection delay suggested to clients, in milliseconds
+	streamRetry = 1000
+)
+
+// Events represents Server-Sent Events stream http service
+type Events struct {
+	broker    model.Broker
+	auth      model.AuthService
+	heartbeat time.Duration
+	lifetime  time.Duration
+}
+
+// NewEvents creates new Server-Sent Events stream http service.
+// Streams end shortly before server's write timeout elapses, and clients resume them using Last-Event-ID
+func NewEvents(broker model.Broker, auth model.AuthService, heartbeat, writeTimeout time.Duration, e *echo.Echo, mw ...echo.MiddlewareFunc) {
+	ev := Events{broker: broker, auth: auth, heartbeat: heartbeat, lifetime: streamLifetime(writeTimeout)}
+	// swagger:operation GET /v1/events/stream events stream
+	// ---
+	// summary: Streams realtime events as Server-Sent Events.
+	// description: Delivers the same events as the WebSocket gateway over text/event-stream.
+	//   Streams are periodically ended by the server, and clients should reconnect passing the last received event ID.
+	//   Events missed while disconnected are replayed from a bounded per-user log.
+	// produces:
+	// - text/event-stream
+	// parameters:
+	// - name: Last-Event-ID
+	//   in: header
+	//   description: ID of the last received event
+	//   type: int
+	//   required: false
+	// - name: last_event_id
+	//   in: query
+	//   description: ID of the last received event, if the header cannot be set
+	//   type: int
+	//   required: false
+	// - name: token
+	//   in: query
+	//   description: JWT, if Authorization header cannot be set
+	//   type: string
+	//   required: false
+	// responses:
+	//   "200":
+	//     description: Event stream
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	e.GET("/v1/events/stream", ev.stream, mw...)
+}
+
+// streamLifetime returns how long a stream may last with the given write timeout, or zero if unlimited
+func streamLifetime(writeTimeout time.Duration) time.Duration {
+	if writeTimeout <= 0 {
+		return 0
+	}
+	if writeTimeout <= 2*streamMargin {
+		return writeTimeout / 2
+	}
+	return writeTimeout - streamMargin
+}
+
+func lastEventID(c echo.Context) (int64, error) {
+	v := c.Request().Header.Get("Last-Event-ID")
+	if v == "" {
+		v = c.QueryParam("last_event_id")
+	}
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id < 0 {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "invalid last event id")
+	}
+	return id, nil
+}
+
+func (ev *Events) stream(c echo.Context) error {
+	last, err := lastEventID(c)
+	if err != nil {
+		return err
+	}
+	// Subscribing before replaying the log ensures no event is missed in between
+	sub := ev.broker.Subscribe(ev.auth.User(c).ID)
+	defer ev.broker.Unsubscribe(sub)
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetry); err != nil {
+		return nil
+	}
+
+	send := func(e model.Event) error {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+		return err
+	}
+	// Events may be both replayed and received from the subscription, but are not sent twice.
+	// Events committed out of ID order are still delivered, so received events are not filtered by ID
+	replayed := make(map[int64]bool)
+	for _, e := range ev.broker.Since(sub.UserID, last) {
+		replayed[e.ID] = true
+		if err := send(e); err != nil {
+			return nil
+		}
+	}
+	w.Flush()
+
+	var end <-chan time.Time
+	if ev.lifetime > 0 {
+		timer := time.NewTimer(ev.lifetime)
+		defer timer.Stop()
+		end = timer.C
+	}
+	ticker := time.NewTicker(ev.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case e, ok := <-sub.Events:
+			if !ok {
+				return nil
+			}
+			if replayed[e.ID] {
+				continue
+			}
+			if err := send(e); err != nil {
+				return nil
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		case <-end:
+			return nil
+		case <-c.Request().Context().Done():
+			return nil
+		}
+		w.Flush()
+	}
+}
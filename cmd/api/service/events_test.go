@@ -0,0 +1,122 @@
+package service_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/realtime"
+)
+
+func eventsServer(hub *realtime.Hub, writeTimeout time.Duration) *httptest.Server {
+	r := server.New()
+	auth := &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, CompanyID: 1}
+		}}
+	service.NewEvents(hub, auth, time.Hour, writeTimeout, r)
+	return httptest.NewServer(r)
+}
+
+// readStream reads the stream until it ends, or n lines starting with prefix are read
+func readStream(t *testing.T, res *http.Response, prefix string, n int) []string {
+	var lines []string
+	sc := bufio.NewScanner(res.Body)
+	for sc.Scan() && len(lines) < n {
+		if strings.HasPrefix(sc.Text(), prefix) {
+			lines = append(lines, sc.Text())
+		}
+	}
+	return lines
+}
+
+func TestStreamEvents(t *testing.T) {
+	cases := []struct {
+		name       string
+		lastID     string
+		wantStatus int
+		wantIDs    []string
+	}{
+		{
+			name:       "Invalid last event id",
+			lastID:     "x",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Resume",
+			lastID:     "1",
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"id: 2", "id: 3"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := realtime.NewHub(8, 10)
+			// Events published while the client was disconnected
+			hub.Publish(nil, model.Event{Type: model.EventMessage, UserID: 1})
+			hub.Publish(nil, model.Event{Type: model.EventMessage, UserID: 1})
+			ts := eventsServer(hub, 0)
+			defer ts.Close()
+
+			req, _ := http.NewRequest("GET", ts.URL+"/v1/events/stream", nil)
+			req.Header.Set("Last-Event-ID", tt.lastID)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantIDs == nil {
+				return
+			}
+			assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+			hub.Publish(nil, model.Event{Type: model.EventFriendRequest, UserID: 1})
+			assert.Equal(t, tt.wantIDs, readStream(t, res, "id: ", 2))
+		})
+	}
+}
+
+func TestStreamEventsOutOfOrder(t *testing.T) {
+	hub := realtime.NewHub(8, 10)
+	ts := eventsServer(hub, 0)
+	defer ts.Close()
+	req, _ := http.NewRequest("GET", ts.URL+"/v1/events/stream", nil)
+	req.Header.Set("Last-Event-ID", "3")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	// Events committed by other instances may arrive out of ID order
+	hub.Publish(nil, model.Event{ID: 5, Type: model.EventMessage, UserID: 1})
+	hub.Publish(nil, model.Event{ID: 4, Type: model.EventMessage, UserID: 1})
+	assert.Equal(t, []string{"id: 5", "id: 4"}, readStream(t, res, "id: ", 2))
+}
+
+func TestStreamEventsWriteTimeout(t *testing.T) {
+	ts := eventsServer(realtime.NewHub(8, 10), 200*time.Millisecond)
+	defer ts.Close()
+	start := time.Now()
+	res, err := http.Get(ts.URL + "/v1/events/stream?last_event_id=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	// Stream ends cleanly before the write timeout, suggesting when to reconnect
+	assert.Equal(t, []string{"retry: 1000"}, readStream(t, res, "retry: ", 10))
+	assert.True(t, time.Since(start) < 200*time.Millisecond)
+}
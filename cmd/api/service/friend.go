@@ -0,0 +1,315 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/internal/friend"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+// Friend represents friendship http service
+type Friend struct {
+	svc *friend.Service
+}
+
+// NewFriend creates new friendship http service
+func NewFriend(svc *friend.Service, fr *echo.Group) {
+	f := Friend{svc: svc}
+	// swagger:operation GET /v1/friends friends listFriends
+	// ---
+	// summary: Returns friends of the current user.
+	// description: Returns paginated list of friends of the current user, most recent friendships first.
+	// parameters:
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/friendListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	fr.GET("", f.list)
+	// swagger:operation GET /v1/friends/requests friends listFriendRequests
+	// ---
+	// summary: Returns pending friend requests.
+	// description: Returns paginated list of pending friend requests sent to or by the current user.
+	// parameters:
+	// - name: direction
+	//   in: query
+	//   description: incoming (default) or outgoing
+	//   type: string
+	//   required: false
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/friendRequestListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	fr.GET("/requests", f.requests)
+	// swagger:operation GET /v1/friends/suggestions friends listFriendSuggestions
+	// ---
+	// summary: Returns friend suggestions for the current user.
+	// description: Returns paginated list of users the current user may know, ranked by mutual friends, shared location and shared company.
+	// parameters:
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/friendSuggestionListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	fr.GET("/suggestions", f.suggestions)
+	// swagger:route POST /v1/friends/requests friends friendRequestCreate
+	// Sends a friend request to a user of the same company.
+	// responses:
+	//  200: friendshipResp
+	//  400: errMsg
+	//  401: err
+	//  404: err
+	//  409: errMsg
+	//  500: err
+	fr.POST("/requests", f.create)
+	// swagger:operation POST /v1/friends/requests/{id}/accept friends friendRequestAccept
+	// ---
+	// summary: Accepts a friend request.
+	// description: Accepts a pending friend request sent to the current user.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of friend request
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/friendshipResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	fr.POST("/requests/:id/accept", f.accept)
+	// swagger:operation POST /v1/friends/requests/{id}/decline friends friendRequestDecline
+	// ---
+	// summary: Declines a friend request.
+	// description: Declines a pending friend request sent to the current user.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of friend request
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/friendshipResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	fr.POST("/requests/:id/decline", f.decline)
+	// swagger:operation DELETE /v1/friends/requests/{id} friends friendRequestCancel
+	// ---
+	// summary: Cancels a friend request.
+	// description: Withdraws a pending friend request sent by the current user.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of friend request
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/friendshipResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	fr.DELETE("/requests/:id", f.cancel)
+	// swagger:operation DELETE /v1/friends/{id} friends unfriend
+	// ---
+	// summary: Removes a friend.
+	// description: Ends friendship between the current user and the given user.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of friend
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	fr.DELETE("/:id", f.unfriend)
+}
+
+type friendListResponse struct {
+	Friends []model.Friend `json:"friends"`
+	Page    int            `json:"page"`
+}
+
+type friendRequestListResponse struct {
+	Requests []model.Friendship `json:"requests"`
+	Page     int                `json:"page"`
+}
+
+type friendSuggestionListResponse struct {
+	Suggestions []model.FriendSuggestion `json:"suggestions"`
+	Page        int                      `json:"page"`
+}
+
+func (f *Friend) list(c echo.Context) error {
+	p, err := request.Paginate(c)
+	if err != nil {
+		return err
+	}
+	result, err := f.svc.Friends(c, &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, friendListResponse{result, p.Page})
+}
+
+func (f *Friend) requests(c echo.Context) error {
+	incoming, err := request.FriendRequests(c)
+	if err != nil {
+		return err
+	}
+	p, err := request.Paginate(c)
+	if err != nil {
+		return err
+	}
+	result, err := f.svc.Requests(c, incoming, &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, friendRequestListResponse{result, p.Page})
+}
+
+func (f *Friend) suggestions(c echo.Context) error {
+	p, err := request.Paginate(c)
+	if err != nil {
+		return err
+	}
+	result, err := f.svc.Suggestions(c, &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, friendSuggestionListResponse{result, p.Page})
+}
+
+func (f *Friend) create(c echo.Context) error {
+	r, err := request.FriendCreate(c)
+	if err != nil {
+		return err
+	}
+	result, err := f.svc.Request(c, r.UserID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (f *Friend) accept(c echo.Context) error {
+	return f.respond(c, f.svc.Accept)
+}
+
+func (f *Friend) decline(c echo.Context) error {
+	return f.respond(c, f.svc.Decline)
+}
+
+func (f *Friend) cancel(c echo.Context) error {
+	return f.respond(c, f.svc.Cancel)
+}
+
+func (f *Friend) respond(c echo.Context, fn func(echo.Context, int) (*model.Friendship, error)) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	result, err := fn(c, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (f *Friend) unfriend(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	if err := f.svc.Unfriend(c, id); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,413 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/friend"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+func friendServer(fdb *mockdb.Friend, udb *mockdb.User, sdb *mockdb.Suggestion) *httptest.Server {
+	r := server.New()
+	auth := &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, CompanyID: 1}
+		}}
+	bdb := &mockdb.Block{
+		BlockedFn: func(orm.DB, int, int) (bool, error) {
+			return false, nil
+		}}
+	if sdb == nil {
+		sdb = &mockdb.Suggestion{
+			RefreshFn: func(orm.DB, ...int) error {
+				return nil
+			}}
+	}
+	broker := &mock.Broker{
+		PublishFn: func(orm.DB, model.Event) error {
+			return nil
+		}}
+	presence := &mock.Presence{
+		PresenceFn: func(u *model.User, t time.Time) *model.Presence {
+			return nil
+		}}
+	prefs := &mock.Preferences{
+		PreferencesFn: func(echo.Context, int) (model.Preferences, error) {
+			return model.DefaultPreferences(), nil
+		}}
+	service.NewFriend(friend.New(fdb, udb, bdb, sdb, broker, notifier, presence, activityLogger, prefs, auth), r.Group("/v1/friends"))
+	return httptest.NewServer(r)
+}
+
+func TestListFriends(t *testing.T) {
+	type listResponse struct {
+		Friends []model.Friend `json:"friends"`
+		Page    int            `json:"page"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *listResponse
+		fdb        *mockdb.Friend
+	}{
+		{
+			name:       "Invalid request",
+			req:        `?limit=2222&page=-1`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on query",
+			fdb: &mockdb.Friend{
+				ListFriendsFn: func(orm.DB, int, *model.Pagination) ([]model.Friend, error) {
+					return nil, model.ErrGeneric
+				}},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "Success",
+			req:  `?limit=10&page=1`,
+			fdb: &mockdb.Friend{
+				ListFriendsFn: func(db orm.DB, id int, p *model.Pagination) ([]model.Friend, error) {
+					if p.Limit != 10 || p.Offset != 10 {
+						return nil, model.ErrGeneric
+					}
+					return []model.Friend{{PublicUser: model.PublicUser{ID: 2, FirstName: "Friend"}}}, nil
+				}},
+			wantStatus: http.StatusOK,
+			wantResp:   &listResponse{Friends: []model.Friend{{PublicUser: model.PublicUser{ID: 2, FirstName: "Friend"}}}, Page: 1},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := friendServer(tt.fdb, nil, nil)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/friends" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestListFriendRequests(t *testing.T) {
+	type listResponse struct {
+		Requests []model.Friendship `json:"requests"`
+		Page     int                `json:"page"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *listResponse
+		fdb        *mockdb.Friend
+	}{
+		{
+			name:       "Invalid direction",
+			req:        `?direction=up`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `?direction=outgoing`,
+			fdb: &mockdb.Friend{
+				ListRequestsFn: func(db orm.DB, id int, incoming bool, p *model.Pagination) ([]model.Friendship, error) {
+					if incoming {
+						return nil, model.ErrGeneric
+					}
+					return []model.Friendship{{Base: model.Base{ID: 1}, RequesterID: id, AddresseeID: 2, Status: model.FriendshipPending}}, nil
+				}},
+			wantStatus: http.StatusOK,
+			wantResp: &listResponse{Requests: []model.Friendship{
+				{Base: model.Base{ID: 1}, RequesterID: 1, AddresseeID: 2, Status: model.FriendshipPending},
+			}},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := friendServer(tt.fdb, nil, nil)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/friends/requests" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestListFriendSuggestions(t *testing.T) {
+	type listResponse struct {
+		Suggestions []model.FriendSuggestion `json:"suggestions"`
+		Page        int                      `json:"page"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *listResponse
+		sdb        *mockdb.Suggestion
+	}{
+		{
+			name:       "Invalid request",
+			req:        `?limit=2222&page=-1`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on query",
+			sdb: &mockdb.Suggestion{
+				ListFn: func(orm.DB, *model.User, *model.Pagination) ([]model.FriendSuggestion, error) {
+					return nil, model.ErrGeneric
+				}},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "Success",
+			req:  `?limit=10&page=1`,
+			sdb: &mockdb.Suggestion{
+				ListFn: func(db orm.DB, u *model.User, p *model.Pagination) ([]model.FriendSuggestion, error) {
+					if u.ID != 1 || p.Limit != 10 || p.Offset != 10 {
+						return nil, model.ErrGeneric
+					}
+					return []model.FriendSuggestion{{PublicUser: model.PublicUser{ID: 3}, Mutual: 1, SameCompany: true, Score: 4}}, nil
+				}},
+			wantStatus: http.StatusOK,
+			wantResp: &listResponse{Suggestions: []model.FriendSuggestion{
+				{PublicUser: model.PublicUser{ID: 3}, Mutual: 1, SameCompany: true, Score: 4}}, Page: 1},
+		},
+	}
+
+	udb := &mockdb.User{
+		ViewFn: func(db orm.DB, id int) (*model.User, error) {
+			return &model.User{Base: model.Base{ID: id}}, nil
+		}}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := friendServer(nil, udb, tt.sdb)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/friends/suggestions" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestCreateFriendRequest(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		fdb        *mockdb.Friend
+		udb        *mockdb.User
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"user_id":0}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Request to yourself",
+			req:        `{"user_id":1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Already requested",
+			req:  `{"user_id":2}`,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, CompanyID: 1}, nil
+				}},
+			fdb: &mockdb.Friend{
+				CreateFn: func(orm.DB, model.Friendship) (*model.Friendship, error) {
+					return nil, echo.NewHTTPError(http.StatusConflict)
+				}},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "Success",
+			req:  `{"user_id":2}`,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, CompanyID: 1}, nil
+				}},
+			fdb: &mockdb.Friend{
+				CreateFn: func(db orm.DB, f model.Friendship) (*model.Friendship, error) {
+					f.ID = 1
+					return &f, nil
+				}},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := friendServer(tt.fdb, tt.udb, nil)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/friends/requests", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestRespondFriendRequest(t *testing.T) {
+	incoming := &mockdb.Friend{
+		ViewFn: func(db orm.DB, id int) (*model.Friendship, error) {
+			return &model.Friendship{Base: model.Base{ID: id}, RequesterID: 2, AddresseeID: 1, Status: model.FriendshipPending}, nil
+		},
+		UpdateFn: func(orm.DB, *model.Friendship) error {
+			return nil
+		}}
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantResp   model.FriendshipStatus
+		fdb        *mockdb.Friend
+	}{
+		{
+			name:       "Invalid request",
+			method:     "POST",
+			path:       "/requests/a/accept",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Accept",
+			method:     "POST",
+			path:       "/requests/1/accept",
+			fdb:        incoming,
+			wantStatus: http.StatusOK,
+			wantResp:   model.FriendshipAccepted,
+		},
+		{
+			name:       "Decline",
+			method:     "POST",
+			path:       "/requests/1/decline",
+			fdb:        incoming,
+			wantStatus: http.StatusOK,
+			wantResp:   model.FriendshipDeclined,
+		},
+		{
+			name:       "Cancel request sent by someone else",
+			method:     "DELETE",
+			path:       "/requests/1",
+			fdb:        incoming,
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := friendServer(tt.fdb, nil, nil)
+			defer ts.Close()
+			req, _ := http.NewRequest(tt.method, ts.URL+"/v1/friends"+tt.path, nil)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != "" {
+				response := new(model.Friendship)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response.Status)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestUnfriend(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+		fdb        *mockdb.Friend
+	}{
+		{
+			name:       "Invalid request",
+			id:         `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			id:   `2`,
+			fdb: &mockdb.Friend{
+				FindBetweenFn: func(db orm.DB, userID, otherID int) (*model.Friendship, error) {
+					return &model.Friendship{RequesterID: userID, AddresseeID: otherID, Status: model.FriendshipAccepted}, nil
+				},
+				DeleteFn: func(orm.DB, *model.Friendship) error {
+					return nil
+				}},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := friendServer(tt.fdb, nil, nil)
+			defer ts.Close()
+			req, _ := http.NewRequest("DELETE", ts.URL+"/v1/friends/"+tt.id, nil)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
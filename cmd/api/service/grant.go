@@ -0,0 +1,148 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/internal/grant"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+// Grant represents role grant http service
+type Grant struct {
+	svc *grant.Service
+}
+
+// NewGrant creates new role grant http service
+func NewGrant(svc *grant.Service, gr *echo.Group) {
+	g := Grant{svc: svc}
+	// swagger:operation GET /v1/grants grants listGrants
+	// ---
+	// summary: Returns temporary role grants of a user.
+	// description: Returns all temporary role grants given to a user, including expired ones.
+	// parameters:
+	// - name: user_id
+	//   in: query
+	//   description: id of user
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/grantListResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.GET("", g.list)
+	// swagger:route POST /v1/grants grants grantCreate
+	// Grants a temporary role to a user.
+	// responses:
+	//  200: grantResp
+	//  400: errMsg
+	//  401: err
+	//  403: err
+	//  500: err
+	gr.POST("", g.create)
+	// swagger:route POST /v1/grants/delegate grants grantDelegate
+	// Delegates requesting user's own role, limited to its scope, to a colleague.
+	// responses:
+	//  200: grantResp
+	//  400: errMsg
+	//  401: err
+	//  403: err
+	//  500: err
+	gr.POST("/delegate", g.delegate)
+	// swagger:operation DELETE /v1/grants/{id} grants grantRevoke
+	// ---
+	// summary: Revokes a temporary role grant
+	// description: Revokes a temporary role grant before it expires.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of grant
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.DELETE("/:id", g.revoke)
+}
+
+type grantListResponse struct {
+	Grants []model.RoleGrant `json:"grants"`
+}
+
+func (g *Grant) list(c echo.Context) error {
+	id, err := request.GrantList(c)
+	if err != nil {
+		return err
+	}
+	result, err := g.svc.List(c, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, grantListResponse{result})
+}
+
+func (g *Grant) create(c echo.Context) error {
+	r, err := request.GrantCreate(c)
+	if err != nil {
+		return err
+	}
+	result, err := g.svc.Create(c, model.RoleGrant{
+		UserID:      r.UserID,
+		AccessLevel: model.AccessRole(r.AccessLevel),
+		CompanyID:   r.CompanyID,
+		LocationID:  r.LocationID,
+		StartsAt:    r.StartsAt,
+		EndsAt:      r.EndsAt,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (g *Grant) delegate(c echo.Context) error {
+	r, err := request.GrantDelegate(c)
+	if err != nil {
+		return err
+	}
+	result, err := g.svc.Delegate(c, model.RoleGrant{
+		UserID:      r.UserID,
+		AccessLevel: model.AccessRole(r.AccessLevel),
+		StartsAt:    r.StartsAt,
+		EndsAt:      r.EndsAt,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (g *Grant) revoke(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	if err := g.svc.Revoke(c, id); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
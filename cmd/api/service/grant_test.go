@@ -0,0 +1,255 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/grant"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+func TestListGrants(t *testing.T) {
+	type listResponse struct {
+		Grants []model.RoleGrant `json:"grants"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *listResponse
+		gdb        *mockdb.Grant
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			req:        `?user_id=a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			req:  `?user_id=2`,
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(echo.Context, int) error {
+					return echo.ErrForbidden
+				}},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			req:  `?user_id=2`,
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(echo.Context, int) error {
+					return nil
+				}},
+			gdb: &mockdb.Grant{
+				ListFn: func(db orm.DB, id int) ([]model.RoleGrant, error) {
+					return []model.RoleGrant{{Base: model.Base{ID: 1}, UserID: id, AccessLevel: model.LocationAdminRole}}, nil
+				}},
+			wantStatus: http.StatusOK,
+			wantResp:   &listResponse{Grants: []model.RoleGrant{{Base: model.Base{ID: 1}, UserID: 2, AccessLevel: model.LocationAdminRole}}},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("/v1/grants")
+			service.NewGrant(grant.New(tt.gdb, nil, tt.rbac, nil, nil), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/grants" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestCreateGrant(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		gdb        *mockdb.Grant
+		udb        *mockdb.User
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"user_id":2,"access_level":4,"company_id":1,"location_id":1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			req:  `{"user_id":2,"access_level":4,"company_id":1,"location_id":1,"starts_at":"2018-05-19T01:02:03Z","ends_at":"2018-05-20T01:02:03Z"}`,
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, int, int, int) error {
+					return echo.ErrForbidden
+				}},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			req:  `{"user_id":2,"access_level":4,"company_id":1,"location_id":1,"starts_at":"` + time.Now().Format(time.RFC3339) + `","ends_at":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`,
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, int, int, int) error {
+					return nil
+				},
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return nil
+				}},
+			udb: &mockdb.User{
+				LocationFn: func(db orm.DB, id int) (*model.Location, error) {
+					return &model.Location{Base: model.Base{ID: id}, CompanyID: 1}, nil
+				},
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, CompanyID: 1}, nil
+				}},
+			gdb: &mockdb.Grant{
+				CreateFn: func(db orm.DB, g model.RoleGrant) (*model.RoleGrant, error) {
+					g.ID = 1
+					return &g, nil
+				}},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("/v1/grants")
+			service.NewGrant(grant.New(tt.gdb, tt.udb, tt.rbac, notifier, nil), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/grants", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestDelegateGrant(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		gdb        *mockdb.Grant
+		udb        *mockdb.User
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"starts_at":"2018-05-19T01:02:03Z","ends_at":"2018-05-20T01:02:03Z"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `{"user_id":2,"starts_at":"` + time.Now().Format(time.RFC3339) + `","ends_at":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, CompanyID: 1, LocationID: id, Role: &model.Role{AccessLevel: model.LocationAdminRole}}, nil
+				}},
+			gdb: &mockdb.Grant{
+				CreateFn: func(db orm.DB, g model.RoleGrant) (*model.RoleGrant, error) {
+					g.ID = 1
+					return &g, nil
+				}},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("/v1/grants")
+			auth := &mock.Auth{
+				UserFn: func(echo.Context) *model.AuthUser {
+					return &model.AuthUser{ID: 1}
+				}}
+			service.NewGrant(grant.New(tt.gdb, tt.udb, nil, notifier, auth), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/grants/delegate", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestRevokeGrant(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+		gdb        *mockdb.Grant
+	}{
+		{
+			name:       "Invalid request",
+			id:         `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			id:   `1`,
+			gdb: &mockdb.Grant{
+				ViewFn: func(db orm.DB, id int) (*model.RoleGrant, error) {
+					return &model.RoleGrant{Base: model.Base{ID: id}, UserID: 1}, nil
+				},
+				DeleteFn: func(orm.DB, *model.RoleGrant) error {
+					return nil
+				}},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("/v1/grants")
+			auth := &mock.Auth{
+				UserFn: func(echo.Context) *model.AuthUser {
+					return &model.AuthUser{ID: 1}
+				}}
+			service.NewGrant(grant.New(tt.gdb, nil, nil, nil, auth), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, _ := http.NewRequest("DELETE", ts.URL+"/v1/grants/"+tt.id, nil)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,677 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/internal/group"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+// Group represents group http service
+type Group struct {
+	svc *group.Service
+}
+
+// NewGroup creates new group http service
+func NewGroup(svc *group.Service, gr *echo.Group) {
+	g := Group{svc: svc}
+	// swagger:operation GET /v1/groups groups listGroups
+	// ---
+	// summary: Returns groups.
+	// description: Returns paginated list of groups of the current user's company. Invite-only groups are listed
+	//   only to users invited to or belonging to them, and to company admins.
+	// parameters:
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/groupListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.GET("", g.list)
+	// swagger:route POST /v1/groups groups groupCreate
+	// Creates a group in the current user's company, owned by the user.
+	// responses:
+	//  200: groupResp
+	//  400: errMsg
+	//  401: err
+	//  500: err
+	gr.POST("", g.create)
+	// swagger:operation GET /v1/groups/{id} groups getGroup
+	// ---
+	// summary: Returns a single group.
+	// description: Returns a group with the number of its members.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of group
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/groupResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.GET("/:id", g.view)
+	// swagger:operation PATCH /v1/groups/{id} groups groupUpdate
+	// ---
+	// summary: Updates group details.
+	// description: Updates name, description and visibility of a group. Only group owner and company admins can update it.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of group
+	//   type: int
+	//   required: true
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/UpdateGroup"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/groupResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.PATCH("/:id", g.update)
+	// swagger:operation DELETE /v1/groups/{id} groups groupDelete
+	// ---
+	// summary: Deletes a group.
+	// description: Deletes a group. Only group owner and company admins can delete it.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of group
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.DELETE("/:id", g.delete)
+	// swagger:operation POST /v1/groups/{id}/join groups groupJoin
+	// ---
+	// summary: Joins a group.
+	// description: Joins a public group, or a group the current user is invited to. Joining a private group
+	//   requests approval of its moderators. Invite-only groups cannot be joined without invitation.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of group
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/groupMemberResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.POST("/:id/join", g.join)
+	// swagger:operation POST /v1/groups/{id}/leave groups groupLeave
+	// ---
+	// summary: Leaves a group.
+	// description: Leaves a group, withdraws the request to join it or declines the invitation to it.
+	//   Owner has to transfer the ownership before leaving.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of group
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.POST("/:id/leave", g.leave)
+	// swagger:operation GET /v1/groups/{id}/members groups listGroupMembers
+	// ---
+	// summary: Returns members of a group.
+	// description: Returns paginated list of members, join requests or invitations of a group.
+	//   Members of groups which are not public are listed only to members, join requests and invitations only to moderators.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of group
+	//   type: int
+	//   required: true
+	// - name: status
+	//   in: query
+	//   description: active (default), requested or invited
+	//   type: string
+	//   required: false
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/groupMemberListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.GET("/:id/members", g.members)
+	// swagger:operation POST /v1/groups/{id}/members groups groupInvite
+	// ---
+	// summary: Invites a user to a group.
+	// description: Invites a user of the group's company to a group. Inviting a user who requested to join approves the request.
+	//   Only moderators can invite users.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of group
+	//   type: int
+	//   required: true
+	// - name: request
+	//   in: body
+	//   description: Invited user
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/GroupInvite"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/groupMemberResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.POST("/:id/members", g.invite)
+	// swagger:operation POST /v1/groups/{id}/members/{user_id}/approve groups groupApprove
+	// ---
+	// summary: Approves a request to join a group.
+	// description: Approves user's request to join a group. Only moderators can approve requests.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of group
+	//   type: int
+	//   required: true
+	// - name: user_id
+	//   in: path
+	//   description: id of user requesting to join
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/groupMemberResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.POST("/:id/members/:user_id/approve", g.approve)
+	// swagger:operation PUT /v1/groups/{id}/members/{user_id}/role groups groupRole
+	// ---
+	// summary: Changes role of a group member.
+	// description: Changes role of a member within a group. Making a member the owner transfers the ownership,
+	//   leaving the previous owner a moderator. Only group owner and company admins can change roles.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of group
+	//   type: int
+	//   required: true
+	// - name: user_id
+	//   in: path
+	//   description: id of member
+	//   type: int
+	//   required: true
+	// - name: request
+	//   in: body
+	//   description: New role
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/GroupRole"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/groupMemberResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.PUT("/:id/members/:user_id/role", g.role)
+	// swagger:operation DELETE /v1/groups/{id}/members/{user_id} groups groupRemoveMember
+	// ---
+	// summary: Removes a member from a group.
+	// description: Removes a member, rejects a join request or revokes an invitation. Moderators remove members,
+	//   while only group owner and company admins remove moderators. Owner cannot be removed.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of group
+	//   type: int
+	//   required: true
+	// - name: user_id
+	//   in: path
+	//   description: id of member
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.DELETE("/:id/members/:user_id", g.removeMember)
+	// swagger:operation GET /v1/groups/{id}/posts groups listGroupPosts
+	// ---
+	// summary: Returns posts of a group.
+	// description: Returns paginated list of posts of a group, newest first. Posts of groups which are not public
+	//   are listed only to members.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of group
+	//   type: int
+	//   required: true
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/groupPostListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.GET("/:id/posts", g.posts)
+	// swagger:operation POST /v1/groups/{id}/posts groups groupPostCreate
+	// ---
+	// summary: Posts to a group.
+	// description: Creates a post in a group. Only members can post.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of group
+	//   type: int
+	//   required: true
+	// - name: request
+	//   in: body
+	//   description: Post
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/GroupPost"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/groupPostResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.POST("/:id/posts", g.createPost)
+	// swagger:operation DELETE /v1/groups/{id}/posts/{post_id} groups groupPostDelete
+	// ---
+	// summary: Deletes a group post.
+	// description: Deletes a post in a group. Posts are deleted by their authors, moderators and company admins.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of group
+	//   type: int
+	//   required: true
+	// - name: post_id
+	//   in: path
+	//   description: id of post
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	gr.DELETE("/:id/posts/:post_id", g.deletePost)
+}
+
+type groupListResponse struct {
+	Groups []model.Group `json:"groups"`
+	Page   int           `json:"page"`
+}
+
+type groupMemberListResponse struct {
+	Members []model.GroupMember `json:"members"`
+	Page    int                 `json:"page"`
+}
+
+type groupPostListResponse struct {
+	Posts []model.GroupPost `json:"posts"`
+	Page  int               `json:"page"`
+}
+
+func (g *Group) list(c echo.Context) error {
+	p, err := request.Paginate(c)
+	if err != nil {
+		return err
+	}
+	result, err := g.svc.List(c, &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, groupListResponse{result, p.Page})
+}
+
+func (g *Group) create(c echo.Context) error {
+	r, err := request.GroupCreate(c)
+	if err != nil {
+		return err
+	}
+	result, err := g.svc.Create(c, model.Group{
+		Name:        r.Name,
+		Description: r.Description,
+		Visibility:  model.GroupVisibility(r.Visibility),
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (g *Group) view(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	result, err := g.svc.View(c, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (g *Group) update(c echo.Context) error {
+	r, err := request.GroupUpdate(c)
+	if err != nil {
+		return err
+	}
+	u := &group.Update{
+		ID:          r.ID,
+		Name:        r.Name,
+		Description: r.Description,
+	}
+	if r.Visibility != nil {
+		v := model.GroupVisibility(*r.Visibility)
+		u.Visibility = &v
+	}
+	result, err := g.svc.Update(c, u)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (g *Group) delete(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	if err := g.svc.Delete(c, id); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (g *Group) join(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	result, err := g.svc.Join(c, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (g *Group) leave(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	if err := g.svc.Leave(c, id); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (g *Group) members(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	status, err := request.GroupMembers(c)
+	if err != nil {
+		return err
+	}
+	p, err := request.Paginate(c)
+	if err != nil {
+		return err
+	}
+	result, err := g.svc.Members(c, id, model.MembershipStatus(status), &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, groupMemberListResponse{result, p.Page})
+}
+
+func (g *Group) invite(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	r, err := request.GroupInviteCreate(c)
+	if err != nil {
+		return err
+	}
+	result, err := g.svc.Invite(c, id, r.UserID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (g *Group) approve(c echo.Context) error {
+	id, userID, err := request.GroupChild(c, "user_id")
+	if err != nil {
+		return err
+	}
+	result, err := g.svc.Approve(c, id, userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (g *Group) role(c echo.Context) error {
+	id, userID, err := request.GroupChild(c, "user_id")
+	if err != nil {
+		return err
+	}
+	r, err := request.GroupRoleChange(c)
+	if err != nil {
+		return err
+	}
+	result, err := g.svc.SetRole(c, id, userID, model.GroupRole(r.Role))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (g *Group) removeMember(c echo.Context) error {
+	id, userID, err := request.GroupChild(c, "user_id")
+	if err != nil {
+		return err
+	}
+	if err := g.svc.RemoveMember(c, id, userID); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (g *Group) posts(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	p, err := request.Paginate(c)
+	if err != nil {
+		return err
+	}
+	result, err := g.svc.Posts(c, id, &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, groupPostListResponse{result, p.Page})
+}
+
+func (g *Group) createPost(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	r, err := request.GroupPostCreate(c)
+	if err != nil {
+		return err
+	}
+	result, err := g.svc.CreatePost(c, id, r.Body)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (g *Group) deletePost(c echo.Context) error {
+	id, postID, err := request.GroupChild(c, "post_id")
+	if err != nil {
+		return err
+	}
+	if err := g.svc.DeletePost(c, id, postID); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,276 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/group"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+func groupServer(gdb *mockdb.Group) *httptest.Server {
+	r := server.New()
+	auth := &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, CompanyID: 1}
+		}}
+	rbac := &mock.RBAC{
+		EnforceGroupFn: func(c echo.Context, companyID int, role, required model.GroupRole) error {
+			if role.Level() < required.Level() {
+				return echo.ErrForbidden
+			}
+			return nil
+		},
+		EnforceCompanyFn: func(echo.Context, int) error {
+			return echo.ErrForbidden
+		}}
+	service.NewGroup(group.New(gdb, nil, rbac, auth), r.Group("/v1/groups"))
+	return httptest.NewServer(r)
+}
+
+// ownedGroup mocks database holding a private group owned by the user, with user 2 requesting to join
+func ownedGroup() *mockdb.Group {
+	return &mockdb.Group{
+		ViewFn: func(db orm.DB, id int) (*model.Group, error) {
+			if id != 1 {
+				return nil, echo.ErrNotFound
+			}
+			return &model.Group{Base: model.Base{ID: 1}, CompanyID: 1, OwnerID: 1, Name: "runners", Visibility: model.GroupPrivate, Members: 1}, nil
+		},
+		MemberFn: func(db orm.DB, groupID, userID int) (*model.GroupMember, error) {
+			switch userID {
+			case 1:
+				return &model.GroupMember{GroupID: groupID, UserID: 1, Role: model.GroupRoleOwner, Status: model.MembershipActive}, nil
+			case 2:
+				return &model.GroupMember{GroupID: groupID, UserID: 2, Role: model.GroupRoleMember, Status: model.MembershipRequested}, nil
+			}
+			return nil, nil
+		},
+		SetMemberFn: func(orm.DB, *model.GroupMember) error {
+			return nil
+		},
+		RemoveMemberFn: func(orm.DB, *model.GroupMember) error {
+			return nil
+		},
+		UpdateFn: func(orm.DB, *model.Group) error {
+			return nil
+		}}
+}
+
+func TestListGroups(t *testing.T) {
+	type listResponse struct {
+		Groups []model.Group `json:"groups"`
+		Page   int           `json:"page"`
+	}
+	ts := groupServer(&mockdb.Group{
+		ListFn: func(db orm.DB, f *model.GroupFilter, p *model.Pagination) ([]model.Group, error) {
+			if f.CompanyID != 1 || f.UserID != 1 || f.All || p.Limit != 10 {
+				return nil, model.ErrGeneric
+			}
+			return []model.Group{{Base: model.Base{ID: 1}, Name: "runners", Visibility: model.GroupPublic, Members: 3}}, nil
+		}})
+	defer ts.Close()
+	res, err := http.Get(ts.URL + "/v1/groups?limit=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	response := new(listResponse)
+	if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, &listResponse{Groups: []model.Group{{Base: model.Base{ID: 1}, Name: "runners", Visibility: model.GroupPublic, Members: 3}}}, response)
+}
+
+func TestCreateGroup(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *model.Group
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"name":"runners","visibility":"secret"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success",
+			req:        `{"name":"runners","visibility":"private"}`,
+			wantStatus: http.StatusOK,
+			wantResp:   &model.Group{Base: model.Base{ID: 1}, CompanyID: 1, OwnerID: 1, Name: "runners", Visibility: model.GroupPrivate, Members: 1},
+		},
+	}
+	gdb := &mockdb.Group{
+		CreateFn: func(db orm.DB, gr model.Group) (*model.Group, error) {
+			gr.ID = 1
+			return &gr, nil
+		},
+		SetMemberFn: func(orm.DB, *model.GroupMember) error {
+			return nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := groupServer(gdb)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/groups", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(model.Group)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestUpdateGroup(t *testing.T) {
+	client := &http.Client{}
+	ts := groupServer(ownedGroup())
+	defer ts.Close()
+	req, _ := http.NewRequest("PATCH", ts.URL+"/v1/groups/1", bytes.NewBufferString(`{"visibility":"invite_only"}`))
+	req.Header.Set("Content-Type", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	response := new(model.Group)
+	if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, model.GroupInviteOnly, response.Visibility)
+	assert.Equal(t, "runners", response.Name)
+}
+
+func TestGroupMembership(t *testing.T) {
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		req        string
+		wantStatus int
+	}{
+		{
+			name:       "Owner leaving",
+			method:     "POST",
+			path:       "/1/leave",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown group",
+			method:     "POST",
+			path:       "/2/join",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Approve request",
+			method:     "POST",
+			path:       "/1/members/2/approve",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Invalid member id",
+			method:     "POST",
+			path:       "/1/members/a/approve",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid role",
+			method:     "PUT",
+			path:       "/1/members/2/role",
+			req:        `{"role":"admin"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Reject request",
+			method:     "DELETE",
+			path:       "/1/members/2",
+			wantStatus: http.StatusOK,
+		},
+	}
+	client := &http.Client{}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := groupServer(ownedGroup())
+			defer ts.Close()
+			req, _ := http.NewRequest(tt.method, ts.URL+"/v1/groups"+tt.path, bytes.NewBufferString(tt.req))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestGroupPosts(t *testing.T) {
+	type listResponse struct {
+		Posts []model.GroupPost `json:"posts"`
+		Page  int               `json:"page"`
+	}
+	gdb := ownedGroup()
+	gdb.PostsFn = func(db orm.DB, id int, p *model.Pagination) ([]model.GroupPost, error) {
+		return []model.GroupPost{{Base: model.Base{ID: 3}, GroupID: id, AuthorID: 1, Body: "hello"}}, nil
+	}
+	gdb.CreatePostFn = func(db orm.DB, p model.GroupPost) (*model.GroupPost, error) {
+		p.ID = 4
+		return &p, nil
+	}
+	ts := groupServer(gdb)
+	defer ts.Close()
+
+	res, err := http.Post(ts.URL+"/v1/groups/1/posts", "application/json", bytes.NewBufferString(`{"body":""}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res, err = http.Post(ts.URL+"/v1/groups/1/posts", "application/json", bytes.NewBufferString(`{"body":"hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := new(model.GroupPost)
+	if err := json.NewDecoder(res.Body).Decode(post); err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, &model.GroupPost{Base: model.Base{ID: 4}, GroupID: 1, AuthorID: 1, Body: "hi"}, post)
+
+	res, err = http.Get(ts.URL + "/v1/groups/1/posts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	response := new(listResponse)
+	if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, &listResponse{Posts: []model.GroupPost{{Base: model.Base{ID: 3}, GroupID: 1, AuthorID: 1, Body: "hello"}}}, response)
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/internal/meetup"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+// Meetup represents meetup http service
+type Meetup struct {
+	svc *meetup.Service
+}
+
+// NewMeetup creates new meetup http service
+func NewMeetup(svc *meetup.Service, mr *echo.Group) {
+	m := Meetup{svc: svc}
+	// swagger:operation GET /v1/meetups meetups listMeetups
+	// ---
+	// summary: Returns upcoming meetups.
+	// description: Returns paginated list of meetups of the current user's company which have not ended or been canceled, soonest first.
+	// parameters:
+	// - name: location_id
+	//   in: query
+	//   description: return only meetups at this location
+	//   type: int
+	//   required: false
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/meetupListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	mr.GET("", m.list)
+	// swagger:route POST /v1/meetups meetups meetupCreate
+	// Creates a meetup organized by the current user at a location of its company.
+	// responses:
+	//  200: meetupResp
+	//  400: errMsg
+	//  401: err
+	//  404: err
+	//  500: err
+	mr.POST("", m.create)
+	// swagger:operation GET /v1/meetups/{id} meetups getMeetup
+	// ---
+	// summary: Returns a single meetup.
+	// description: Returns a meetup with the number of attendees and waitlisted users.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of meetup
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/meetupResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	mr.GET("/:id", m.view)
+	// swagger:operation PATCH /v1/meetups/{id} meetups meetupUpdate
+	// ---
+	// summary: Updates meetup details.
+	// description: Updates a meetup and notifies users attending it. Only organizer and location admins can update it.
+	//   Raising the capacity moves waitlisted users to attendees.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of meetup
+	//   type: int
+	//   required: true
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/UpdateMeetup"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/meetupResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	mr.PATCH("/:id", m.update)
+	// swagger:operation POST /v1/meetups/{id}/cancel meetups meetupCancel
+	// ---
+	// summary: Cancels a meetup.
+	// description: Cancels a meetup and notifies users attending it. Only organizer and location admins can cancel it.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of meetup
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/meetupResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	mr.POST("/:id/cancel", m.cancel)
+	// swagger:operation GET /v1/meetups/{id}/rsvps meetups listRSVPs
+	// ---
+	// summary: Returns responses to a meetup.
+	// description: Returns going, maybe, declined and waitlisted responses to a meetup.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of meetup
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/rsvpListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	mr.GET("/:id/rsvps", m.rsvps)
+	// swagger:operation PUT /v1/meetups/{id}/rsvp meetups meetupRSVP
+	// ---
+	// summary: Responds to a meetup.
+	// description: Sets the current user's response to a meetup. Users going to a full meetup are waitlisted,
+	//   and move to attendees in order when places free up.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of meetup
+	//   type: int
+	//   required: true
+	// - name: request
+	//   in: body
+	//   description: Response
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/RSVP"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/rsvpResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	mr.PUT("/:id/rsvp", m.rsvp)
+	// swagger:operation GET /v1/meetups/{id}/ics meetups meetupICS
+	// ---
+	// summary: Exports a meetup to calendar.
+	// description: Returns a meetup as iCalendar file.
+	// produces:
+	// - text/calendar
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of meetup
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     description: iCalendar file
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	mr.GET("/:id/ics", m.ics)
+}
+
+type meetupListResponse struct {
+	Meetups []model.Meetup `json:"meetups"`
+	Page    int            `json:"page"`
+}
+
+type rsvpListResponse struct {
+	RSVPs []model.RSVP `json:"rsvps"`
+}
+
+func (m *Meetup) list(c echo.Context) error {
+	locationID, err := request.MeetupList(c)
+	if err != nil {
+		return err
+	}
+	p, err := request.Paginate(c)
+	if err != nil {
+		return err
+	}
+	result, err := m.svc.List(c, locationID, &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, meetupListResponse{result, p.Page})
+}
+
+func (m *Meetup) create(c echo.Context) error {
+	r, err := request.MeetupCreate(c)
+	if err != nil {
+		return err
+	}
+	result, err := m.svc.Create(c, model.Meetup{
+		LocationID:  r.LocationID,
+		Title:       r.Title,
+		Description: r.Description,
+		StartsAt:    r.StartsAt,
+		EndsAt:      r.EndsAt,
+		Capacity:    r.Capacity,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (m *Meetup) view(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	result, err := m.svc.View(c, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (m *Meetup) update(c echo.Context) error {
+	r, err := request.MeetupUpdate(c)
+	if err != nil {
+		return err
+	}
+	result, err := m.svc.Update(c, &meetup.Update{
+		ID:          r.ID,
+		Title:       r.Title,
+		Description: r.Description,
+		StartsAt:    r.StartsAt,
+		EndsAt:      r.EndsAt,
+		Capacity:    r.Capacity,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (m *Meetup) cancel(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	result, err := m.svc.Cancel(c, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (m *Meetup) rsvps(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	result, err := m.svc.RSVPs(c, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, rsvpListResponse{result})
+}
+
+func (m *Meetup) rsvp(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	r, err := request.MeetupRSVP(c)
+	if err != nil {
+		return err
+	}
+	result, err := m.svc.RSVP(c, id, model.RSVPStatus(r.Status))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (m *Meetup) ics(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	result, err := m.svc.ICS(c, id)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="meetup-%d.ics"`, id))
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", []byte(result))
+}
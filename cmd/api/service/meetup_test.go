@@ -0,0 +1,321 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/meetup"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+func meetupServer(mdb *mockdb.Meetup) *httptest.Server {
+	r := server.New()
+	auth := &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, CompanyID: 1}
+		}}
+	service.NewMeetup(meetup.New(mdb, notifier, nil, auth), r.Group("/v1/meetups"))
+	return httptest.NewServer(r)
+}
+
+// upcomingMeetup mocks database holding a single upcoming meetup organized by the user without responses
+func upcomingMeetup() *mockdb.Meetup {
+	view := func(db orm.DB, id int) (*model.Meetup, error) {
+		if id != 1 {
+			return nil, echo.ErrNotFound
+		}
+		return &model.Meetup{
+			Base:        model.Base{ID: 1},
+			CompanyID:   1,
+			LocationID:  1,
+			OrganizerID: 1,
+			Title:       "Meetup",
+			StartsAt:    time.Now().Add(time.Hour),
+			EndsAt:      time.Now().Add(2 * time.Hour),
+		}, nil
+	}
+	return &mockdb.Meetup{
+		ViewFn: view,
+		LockFn: view,
+		RSVPsFn: func(orm.DB, int) ([]model.RSVP, error) {
+			return nil, nil
+		},
+		SetRSVPFn: func(orm.DB, *model.RSVP) error {
+			return nil
+		},
+		UpdateFn: func(orm.DB, *model.Meetup) error {
+			return nil
+		},
+		LocationFn: func(db orm.DB, id int) (*model.Location, error) {
+			return &model.Location{Base: model.Base{ID: id}, Name: "HQ", CompanyID: 1}, nil
+		}}
+}
+
+func TestListMeetups(t *testing.T) {
+	type listResponse struct {
+		Meetups []model.Meetup `json:"meetups"`
+		Page    int            `json:"page"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *listResponse
+		mdb        *mockdb.Meetup
+	}{
+		{
+			name:       "Invalid location",
+			req:        `?location_id=abc`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `?location_id=2&limit=10&page=1`,
+			mdb: &mockdb.Meetup{
+				ListFn: func(db orm.DB, f *model.MeetupFilter, p *model.Pagination) ([]model.Meetup, error) {
+					if f.CompanyID != 1 || f.LocationID != 2 || p.Limit != 10 || p.Offset != 10 {
+						return nil, model.ErrGeneric
+					}
+					return []model.Meetup{{Base: model.Base{ID: 3}, LocationID: 2, Title: "Meetup", Going: 4}}, nil
+				}},
+			wantStatus: http.StatusOK,
+			wantResp: &listResponse{
+				Meetups: []model.Meetup{{Base: model.Base{ID: 3}, LocationID: 2, Title: "Meetup", Going: 4}},
+				Page:    1,
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := meetupServer(tt.mdb)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/meetups" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestCreateMeetup(t *testing.T) {
+	starts := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *model.Meetup
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"location_id":1,"title":"Meetup"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Location of another company",
+			req:        `{"location_id":2,"title":"Meetup","starts_at":"` + starts.Format(time.RFC3339) + `","ends_at":"` + starts.Add(time.Hour).Format(time.RFC3339) + `"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success",
+			req:        `{"location_id":1,"title":"Meetup","starts_at":"` + starts.Format(time.RFC3339) + `","ends_at":"` + starts.Add(time.Hour).Format(time.RFC3339) + `","capacity":5}`,
+			wantStatus: http.StatusOK,
+			wantResp: &model.Meetup{
+				Base:        model.Base{ID: 1},
+				CompanyID:   1,
+				LocationID:  1,
+				OrganizerID: 1,
+				Title:       "Meetup",
+				StartsAt:    starts,
+				EndsAt:      starts.Add(time.Hour),
+				Capacity:    5,
+			},
+		},
+	}
+	mdb := &mockdb.Meetup{
+		LocationFn: func(db orm.DB, id int) (*model.Location, error) {
+			return &model.Location{Base: model.Base{ID: id}, CompanyID: id}, nil
+		},
+		CreateFn: func(db orm.DB, m model.Meetup) (*model.Meetup, error) {
+			m.ID = 1
+			return &m, nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := meetupServer(mdb)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/meetups", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(model.Meetup)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestUpdateMeetup(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		req        string
+		wantStatus int
+		wantTitle  string
+	}{
+		{
+			name:       "Invalid request",
+			id:         "1",
+			req:        `{"capacity":-1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Ends before start",
+			id:         "1",
+			req:        `{"ends_at":"2000-01-01T00:00:00Z"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success",
+			id:         "1",
+			req:        `{"title":"Renamed"}`,
+			wantStatus: http.StatusOK,
+			wantTitle:  "Renamed",
+		},
+	}
+	client := &http.Client{}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := meetupServer(upcomingMeetup())
+			defer ts.Close()
+			req, _ := http.NewRequest("PATCH", ts.URL+"/v1/meetups/"+tt.id, bytes.NewBufferString(tt.req))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantTitle != "" {
+				response := new(model.Meetup)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantTitle, response.Title)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestCancelMeetup(t *testing.T) {
+	ts := meetupServer(upcomingMeetup())
+	defer ts.Close()
+	res, err := http.Post(ts.URL+"/v1/meetups/1/cancel", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	response := new(model.Meetup)
+	if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.NotNil(t, response.CanceledAt)
+}
+
+func TestRSVPMeetup(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		req        string
+		wantStatus int
+		wantResp   model.RSVPStatus
+	}{
+		{
+			name:       "Invalid status",
+			id:         "1",
+			req:        `{"status":"waitlisted"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown meetup",
+			id:         "2",
+			req:        `{"status":"going"}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Success",
+			id:         "1",
+			req:        `{"status":"going"}`,
+			wantStatus: http.StatusOK,
+			wantResp:   model.RSVPGoing,
+		},
+	}
+	client := &http.Client{}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := meetupServer(upcomingMeetup())
+			defer ts.Close()
+			req, _ := http.NewRequest("PUT", ts.URL+"/v1/meetups/"+tt.id+"/rsvp", bytes.NewBufferString(tt.req))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != "" {
+				response := new(model.RSVP)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response.Status)
+				assert.Equal(t, 1, response.UserID)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestMeetupICS(t *testing.T) {
+	ts := meetupServer(upcomingMeetup())
+	defer ts.Close()
+	res, err := http.Get(ts.URL + "/v1/meetups/1/ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/calendar; charset=utf-8", res.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="meetup-1.ics"`, res.Header.Get("Content-Disposition"))
+	var buf bytes.Buffer
+	buf.ReadFrom(res.Body)
+	assert.Contains(t, buf.String(), "UID:meetup-1@friend4me\r\n")
+}
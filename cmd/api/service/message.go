@@ -0,0 +1,282 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/internal/message"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+// Message represents direct messaging http service
+type Message struct {
+	svc *message.Service
+}
+
+// NewMessage creates new direct messaging http service
+func NewMessage(svc *message.Service, mr *echo.Group) {
+	m := Message{svc: svc}
+	// swagger:operation GET /v1/conversations messages listConversations
+	// ---
+	// summary: Returns conversations of the current user.
+	// description: Returns paginated list of conversations of the current user with unread message counts, most recently active first.
+	// parameters:
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/conversationListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	mr.GET("", m.list)
+	// swagger:route POST /v1/conversations messages conversationCreate
+	// Starts a conversation with one or more users. One-to-one conversations are reused.
+	// responses:
+	//  200: conversationResp
+	//  400: errMsg
+	//  401: err
+	//  403: err
+	//  404: err
+	//  500: err
+	mr.POST("", m.create)
+	// swagger:operation GET /v1/conversations/{id} messages getConversation
+	// ---
+	// summary: Returns a single conversation.
+	// description: Returns a conversation with its participants and their read receipts. Only participants can view it.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of conversation
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/conversationResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	mr.GET("/:id", m.view)
+	// swagger:operation GET /v1/conversations/{id}/messages messages listMessages
+	// ---
+	// summary: Returns message history of a conversation.
+	// description: Returns messages of a conversation, newest first. Pass next_cursor of the response as before to get older messages.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of conversation
+	//   type: int
+	//   required: true
+	// - name: before
+	//   in: query
+	//   description: return messages older than the message with this id
+	//   type: int
+	//   required: false
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/messageListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	mr.GET("/:id/messages", m.history)
+	// swagger:operation POST /v1/conversations/{id}/messages messages messageSend
+	// ---
+	// summary: Sends a message to a conversation.
+	// description: Sends a message with text and/or attachments. Messages cannot be sent to a blocked user.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of conversation
+	//   type: int
+	//   required: true
+	// - name: request
+	//   in: body
+	//   description: Message
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/Message"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/messageResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	mr.POST("/:id/messages", m.send)
+	// swagger:operation POST /v1/conversations/{id}/read messages messageRead
+	// ---
+	// summary: Marks messages as read.
+	// description: Marks messages of a conversation up to the given one as read by the current user.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of conversation
+	//   type: int
+	//   required: true
+	// - name: request
+	//   in: body
+	//   description: Read receipt
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/Read"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/participantResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	mr.POST("/:id/read", m.read)
+}
+
+type conversationListResponse struct {
+	Conversations []model.Conversation `json:"conversations"`
+	Page          int                  `json:"page"`
+}
+
+type messageListResponse struct {
+	Messages   []model.Message `json:"messages"`
+	NextCursor int             `json:"next_cursor,omitempty"`
+}
+
+func (m *Message) list(c echo.Context) error {
+	p, err := request.Paginate(c)
+	if err != nil {
+		return err
+	}
+	result, err := m.svc.List(c, &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, conversationListResponse{result, p.Page})
+}
+
+func (m *Message) create(c echo.Context) error {
+	r, err := request.ConversationCreate(c)
+	if err != nil {
+		return err
+	}
+	result, err := m.svc.Start(c, r.UserIDs, r.Title)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (m *Message) view(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	result, err := m.svc.View(c, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (m *Message) history(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	cur, err := request.MessageCursor(c)
+	if err != nil {
+		return err
+	}
+	result, err := m.svc.History(c, id, &model.MessageCursor{
+		Before: cur.Before, Limit: cur.Limit,
+	})
+	if err != nil {
+		return err
+	}
+	resp := messageListResponse{Messages: result}
+	if len(result) == cur.Limit {
+		resp.NextCursor = result[len(result)-1].ID
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (m *Message) send(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	r, err := request.MessageSend(c)
+	if err != nil {
+		return err
+	}
+	attachments := make([]model.Attachment, len(r.Attachments))
+	for i, a := range r.Attachments {
+		attachments[i] = model.Attachment{Name: a.Name, URL: a.URL, ContentType: a.ContentType, Size: a.Size}
+	}
+	result, err := m.svc.Send(c, id, r.Body, attachments)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (m *Message) read(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	r, err := request.MessageRead(c)
+	if err != nil {
+		return err
+	}
+	result, err := m.svc.Read(c, id, r.MessageID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,375 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/message"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+func messageServer(mdb *mockdb.Message) *httptest.Server {
+	r := server.New()
+	auth := &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, CompanyID: 1}
+		}}
+	rbac := &mock.RBAC{
+		EnforceParticipantFn: func(c echo.Context, ids []int) error {
+			for _, id := range ids {
+				if id == 1 {
+					return nil
+				}
+			}
+			return echo.ErrForbidden
+		}}
+	bdb := &mockdb.Block{
+		BlockedFn: func(orm.DB, int, int) (bool, error) {
+			return false, nil
+		}}
+	udb := &mockdb.User{
+		ViewFn: func(db orm.DB, id int) (*model.User, error) {
+			return &model.User{Base: model.Base{ID: id}, CompanyID: 1}, nil
+		}}
+	broker := &mock.Broker{
+		PublishFn: func(orm.DB, model.Event) error {
+			return nil
+		}}
+	service.NewMessage(message.New(mdb, udb, bdb, broker, rbac, auth), r.Group("/v1/conversations"))
+	return httptest.NewServer(r)
+}
+
+func directConversation(db orm.DB, id int) (*model.Conversation, error) {
+	return &model.Conversation{Base: model.Base{ID: id}, CompanyID: 1,
+		Participants: []model.Participant{{ConversationID: id, UserID: 1}, {ConversationID: id, UserID: 2}}}, nil
+}
+
+func TestListConversations(t *testing.T) {
+	type listResponse struct {
+		Conversations []model.Conversation `json:"conversations"`
+		Page          int                  `json:"page"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *listResponse
+		mdb        *mockdb.Message
+	}{
+		{
+			name:       "Invalid request",
+			req:        `?limit=2222&page=-1`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `?limit=10`,
+			mdb: &mockdb.Message{
+				ListConversationsFn: func(db orm.DB, id int, p *model.Pagination) ([]model.Conversation, error) {
+					if id != 1 || p.Limit != 10 {
+						return nil, model.ErrGeneric
+					}
+					return []model.Conversation{{Base: model.Base{ID: 3}, Unread: 2}}, nil
+				}},
+			wantStatus: http.StatusOK,
+			wantResp:   &listResponse{Conversations: []model.Conversation{{Base: model.Base{ID: 3}, Unread: 2}}},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := messageServer(tt.mdb)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/conversations" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestCreateConversation(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *model.Conversation
+		mdb        *mockdb.Message
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"user_ids":[]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Conversation with yourself",
+			req:        `{"user_ids":[1]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `{"user_ids":[2,3],"title":"lunch"}`,
+			mdb: &mockdb.Message{
+				CreateConversationFn: func(db orm.DB, cv model.Conversation, ids []int) (*model.Conversation, error) {
+					cv.ID = 1
+					for _, id := range ids {
+						cv.Participants = append(cv.Participants, model.Participant{ConversationID: 1, UserID: id})
+					}
+					return &cv, nil
+				}},
+			wantStatus: http.StatusOK,
+			wantResp: &model.Conversation{Base: model.Base{ID: 1}, CompanyID: 1, CreatorID: 1, Title: "lunch", IsGroup: true,
+				Participants: []model.Participant{{UserID: 1}, {UserID: 2}, {UserID: 3}}},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := messageServer(tt.mdb)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/conversations", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(model.Conversation)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				tt.wantResp.CreatedAt = response.CreatedAt
+				tt.wantResp.UpdatedAt = response.UpdatedAt
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestViewConversation(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		mdb        *mockdb.Message
+	}{
+		{
+			name:       "Invalid request",
+			req:        `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Not a participant",
+			req:  `1`,
+			mdb: &mockdb.Message{
+				ViewConversationFn: func(db orm.DB, id int) (*model.Conversation, error) {
+					return &model.Conversation{Base: model.Base{ID: id}, Participants: []model.Participant{{UserID: 2}, {UserID: 3}}}, nil
+				}},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Success",
+			req:        `1`,
+			mdb:        &mockdb.Message{ViewConversationFn: directConversation},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := messageServer(tt.mdb)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/conversations/" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestListMessages(t *testing.T) {
+	type listResponse struct {
+		Messages   []model.Message `json:"messages"`
+		NextCursor int             `json:"next_cursor"`
+	}
+	history := func(db orm.DB, id int, cur *model.MessageCursor) ([]model.Message, error) {
+		var msgs []model.Message
+		for i := cur.Before - 1; i > 0 && len(msgs) < cur.Limit; i-- {
+			msgs = append(msgs, model.Message{Base: model.Base{ID: i}, ConversationID: id})
+		}
+		return msgs, nil
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *listResponse
+	}{
+		{
+			name:       "Invalid cursor",
+			req:        `?before=x`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "More messages",
+			req:        `?before=10&limit=2`,
+			wantStatus: http.StatusOK,
+			wantResp: &listResponse{Messages: []model.Message{
+				{Base: model.Base{ID: 9}, ConversationID: 1}, {Base: model.Base{ID: 8}, ConversationID: 1}}, NextCursor: 8},
+		},
+		{
+			name:       "Last page",
+			req:        `?before=2&limit=2`,
+			wantStatus: http.StatusOK,
+			wantResp:   &listResponse{Messages: []model.Message{{Base: model.Base{ID: 1}, ConversationID: 1}}},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := messageServer(&mockdb.Message{ViewConversationFn: directConversation, HistoryFn: history})
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/conversations/1/messages" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				for i := range response.Messages {
+					tt.wantResp.Messages[i].CreatedAt = response.Messages[i].CreatedAt
+					tt.wantResp.Messages[i].UpdatedAt = response.Messages[i].UpdatedAt
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *model.Message
+	}{
+		{
+			name:       "Empty message",
+			req:        `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success",
+			req:        `{"body":"hi","attachments":[{"name":"cat.png","url":"https://example.com/cat.png"}]}`,
+			wantStatus: http.StatusOK,
+			wantResp: &model.Message{Base: model.Base{ID: 4}, ConversationID: 1, SenderID: 1, Body: "hi",
+				Attachments: []model.Attachment{{Name: "cat.png", URL: "https://example.com/cat.png"}}},
+		},
+	}
+
+	mdb := &mockdb.Message{
+		ViewConversationFn: directConversation,
+		CreateMessageFn: func(db orm.DB, m model.Message) (*model.Message, error) {
+			m.ID = 4
+			return &m, nil
+		},
+		UpdateParticipantFn: func(orm.DB, *model.Participant) error {
+			return nil
+		}}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := messageServer(mdb)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/conversations/1/messages", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(model.Message)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				tt.wantResp.CreatedAt = response.CreatedAt
+				tt.wantResp.UpdatedAt = response.UpdatedAt
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestReadMessages(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Message from another conversation",
+			req:        `{"message_id":6}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Success",
+			req:        `{"message_id":5}`,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	mdb := &mockdb.Message{
+		ViewConversationFn: directConversation,
+		ViewMessageFn: func(db orm.DB, id int) (*model.Message, error) {
+			return &model.Message{Base: model.Base{ID: id}, ConversationID: id - 4}, nil
+		},
+		UpdateParticipantFn: func(orm.DB, *model.Participant) error {
+			return nil
+		}}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := messageServer(mdb)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/conversations/1/read", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
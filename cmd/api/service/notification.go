@@ -0,0 +1,182 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/internal/notification"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+// Notification represents notification center http service
+type Notification struct {
+	svc *notification.Service
+}
+
+// NewNotification creates new notification center http service
+func NewNotification(svc *notification.Service, nr *echo.Group) {
+	n := Notification{svc: svc}
+	// swagger:operation GET /v1/notifications notifications listNotifications
+	// ---
+	// summary: Returns notifications of the current user.
+	// description: Returns paginated list of notifications of the current user, newest first, along with the number of unread ones.
+	// parameters:
+	// - name: unread
+	//   in: query
+	//   description: return only unread notifications
+	//   type: bool
+	//   required: false
+	// - name: type
+	//   in: query
+	//   description: friend_request, password_changed, role_changed, invitation_accepted, meetup_updated, meetup_canceled or meetup_confirmed
+	//   type: string
+	//   required: false
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/notificationListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	nr.GET("", n.list)
+	// swagger:route POST /v1/notifications/read notifications notificationReadAll
+	// Marks all notifications of the current user as read.
+	// responses:
+	//  200: notificationReadAllResp
+	//  401: err
+	//  500: err
+	nr.POST("/read", n.readAll)
+	// swagger:operation POST /v1/notifications/{id}/read notifications notificationRead
+	// ---
+	// summary: Marks a notification as read.
+	// description: Marks a notification of the current user as read.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of notification
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/notificationResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	nr.POST("/:id/read", n.read)
+	// swagger:route GET /v1/notifications/preferences notifications notificationPreferences
+	// Returns whether the current user receives notifications of each type.
+	// responses:
+	//  200: notificationPreferencesResp
+	//  401: err
+	//  500: err
+	nr.GET("/preferences", n.preferences)
+	// swagger:route PUT /v1/notifications/preferences notifications notificationPreferencesUpdate
+	// Enables or disables notification types for the current user.
+	// responses:
+	//  200: notificationPreferencesResp
+	//  400: errMsg
+	//  401: err
+	//  500: err
+	nr.PUT("/preferences", n.updatePreferences)
+}
+
+type notificationListResponse struct {
+	Notifications []model.Notification `json:"notifications"`
+	Unread        int                  `json:"unread"`
+	Page          int                  `json:"page"`
+}
+
+type notificationReadAllResponse struct {
+	Read int `json:"read"`
+}
+
+type notificationPreferencesResponse struct {
+	Preferences []model.NotificationPreference `json:"preferences"`
+}
+
+func (n *Notification) list(c echo.Context) error {
+	f, err := request.NotificationList(c)
+	if err != nil {
+		return err
+	}
+	p, err := request.Paginate(c)
+	if err != nil {
+		return err
+	}
+	result, unread, err := n.svc.List(c, &model.NotificationFilter{
+		Unread: f.Unread, Type: model.NotificationType(f.Type),
+	}, &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, notificationListResponse{result, unread, p.Page})
+}
+
+func (n *Notification) read(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	result, err := n.svc.Read(c, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (n *Notification) readAll(c echo.Context) error {
+	result, err := n.svc.ReadAll(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, notificationReadAllResponse{result})
+}
+
+func (n *Notification) preferences(c echo.Context) error {
+	result, err := n.svc.Preferences(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, notificationPreferencesResponse{result})
+}
+
+func (n *Notification) updatePreferences(c echo.Context) error {
+	r, err := request.NotificationPreferencesUpdate(c)
+	if err != nil {
+		return err
+	}
+	prefs := make(map[model.NotificationType]bool, len(r.Preferences))
+	for _, p := range r.Preferences {
+		prefs[model.NotificationType(p.Type)] = p.Enabled
+	}
+	result, err := n.svc.SetPreferences(c, prefs)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, notificationPreferencesResponse{result})
+}
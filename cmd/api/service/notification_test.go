@@ -0,0 +1,221 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+	"github.com/artistomin/friend4me/internal/notification"
+)
+
+// notifier is notifier mock accepting every notification
+var notifier = &mock.Notifier{
+	NotifyFn: func(echo.Context, model.Notification) error {
+		return nil
+	}}
+
+func notificationServer(ndb *mockdb.Notification) *httptest.Server {
+	r := server.New()
+	auth := &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, CompanyID: 1}
+		}}
+	service.NewNotification(notification.New(ndb, nil, auth), r.Group("/v1/notifications"))
+	return httptest.NewServer(r)
+}
+
+func TestListNotifications(t *testing.T) {
+	type listResponse struct {
+		Notifications []model.Notification `json:"notifications"`
+		Unread        int                  `json:"unread"`
+		Page          int                  `json:"page"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *listResponse
+		ndb        *mockdb.Notification
+	}{
+		{
+			name:       "Invalid type",
+			req:        `?type=newsletter`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `?unread=true&type=friend_request`,
+			ndb: &mockdb.Notification{
+				ListFn: func(db orm.DB, id int, f *model.NotificationFilter, p *model.Pagination) ([]model.Notification, error) {
+					if id != 1 || !f.Unread || f.Type != model.NotificationFriendRequest {
+						return nil, model.ErrGeneric
+					}
+					return []model.Notification{{Base: model.Base{ID: 2}, UserID: 1, Type: model.NotificationFriendRequest}}, nil
+				},
+				CountUnreadFn: func(orm.DB, int) (int, error) {
+					return 3, nil
+				}},
+			wantStatus: http.StatusOK,
+			wantResp: &listResponse{
+				Notifications: []model.Notification{{Base: model.Base{ID: 2}, UserID: 1, Type: model.NotificationFriendRequest}},
+				Unread:        3,
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := notificationServer(tt.ndb)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/notifications" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestReadNotification(t *testing.T) {
+	cases := []struct {
+		name       string
+		path       string
+		wantStatus int
+		ndb        *mockdb.Notification
+	}{
+		{
+			name:       "Invalid id",
+			path:       "/a/read",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Notification of another user",
+			path:       "/2/read",
+			wantStatus: http.StatusForbidden,
+			ndb: &mockdb.Notification{
+				ViewFn: func(db orm.DB, id int) (*model.Notification, error) {
+					return &model.Notification{Base: model.Base{ID: id}, UserID: 5}, nil
+				}},
+		},
+		{
+			name:       "Read one",
+			path:       "/2/read",
+			wantStatus: http.StatusOK,
+			ndb: &mockdb.Notification{
+				ViewFn: func(db orm.DB, id int) (*model.Notification, error) {
+					return &model.Notification{Base: model.Base{ID: id}, UserID: 1}, nil
+				},
+				UpdateFn: func(orm.DB, *model.Notification) error {
+					return nil
+				}},
+		},
+		{
+			name:       "Read all",
+			path:       "/read",
+			wantStatus: http.StatusOK,
+			ndb: &mockdb.Notification{
+				ReadAllFn: func(orm.DB, int, time.Time) (int, error) {
+					return 4, nil
+				}},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := notificationServer(tt.ndb)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/notifications"+tt.path, "application/json", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestUpdateNotificationPreferences(t *testing.T) {
+	type prefsResponse struct {
+		Preferences []model.NotificationPreference `json:"preferences"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *prefsResponse
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"preferences":[{"type":"newsletter"}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success",
+			req:        `{"preferences":[{"type":"password_changed","enabled":false}]}`,
+			wantStatus: http.StatusOK,
+			wantResp: &prefsResponse{Preferences: []model.NotificationPreference{
+				{Type: model.NotificationFriendRequest, Enabled: true},
+				{Type: model.NotificationPasswordChanged, Enabled: false},
+				{Type: model.NotificationRoleChanged, Enabled: true},
+				{Type: model.NotificationInvitationAccepted, Enabled: true},
+				{Type: model.NotificationMeetupUpdated, Enabled: true},
+				{Type: model.NotificationMeetupCanceled, Enabled: true},
+				{Type: model.NotificationMeetupConfirmed, Enabled: true},
+			}},
+		},
+	}
+
+	client := &http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var set []model.NotificationPreference
+			ts := notificationServer(&mockdb.Notification{
+				PreferencesFn: func(orm.DB, int) ([]model.NotificationPreference, error) {
+					return set, nil
+				},
+				SetPreferenceFn: func(db orm.DB, p model.NotificationPreference) error {
+					set = append(set, p)
+					return nil
+				}})
+			defer ts.Close()
+			req, _ := http.NewRequest("PUT", ts.URL+"/v1/notifications/preferences", bytes.NewBufferString(tt.req))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(prefsResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// page holds cursors of pages around a keyset paginated list, and its total count when asked for
+type page struct {
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Total *int   `json:"total,omitempty"`
+}
+
+// paginate returns cursors of the pages next to the listed one, setting them in RFC 8288 Link header.
+// Lists shorter than the limit are the last page in the direction they were read in.
+// cursor returns cursor pointing at i-th listed row
+func paginate(c echo.Context, p *model.Pagination, n int, cursor func(i int, prev bool) *model.Cursor) page {
+	var res page
+	if p.Count {
+		res.Total = &p.Total
+	}
+	if n == 0 {
+		return res
+	}
+	backward := p.Cursor != nil && p.Cursor.Prev
+	var links []string
+	if n == p.Limit || backward {
+		res.Next = cursor(n-1, false).Encode()
+		links = append(links, pageLink(c, res.Next, "next"))
+	}
+	if (n == p.Limit && backward) || (!backward && (p.Cursor != nil || p.Offset > 0)) {
+		res.Prev = cursor(0, true).Encode()
+		links = append(links, pageLink(c, res.Prev, "prev"))
+	}
+	if len(links) > 0 {
+		c.Response().Header().Set("Link", strings.Join(links, ", "))
+	}
+	return res
+}
+
+// pageLink returns Link header value pointing at the request URL with the cursor in place of the page
+func pageLink(c echo.Context, cursor, rel string) string {
+	u := *c.Request().URL
+	q := u.Query()
+	q.Del("page")
+	q.Set("cursor", cursor)
+	u.RawQuery = q.Encode()
+	return "<" + c.Scheme() + "://" + c.Request().Host + u.RequestURI() + `>; rel="` + rel + `"`
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal/preference"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+// Preference represents preferences http service
+type Preference struct {
+	svc *preference.Service
+}
+
+// NewPreference creates new preferences http service.
+// Preferences of the current user are registered outside of v1 group, next to /me, while company preferences are under cr
+func NewPreference(svc *preference.Service, e *echo.Echo, cr *echo.Group, mw ...echo.MiddlewareFunc) {
+	p := Preference{svc: svc}
+	// swagger:route GET /me/preferences preferences myPreferences
+	// Returns all preferences of the current user: its own choices, falling back to ones set by its company, and then to defaults.
+	// responses:
+	//  200: preferencesResp
+	//  401: err
+	//  404: err
+	//  500: err
+	e.GET("/me/preferences", p.me, mw...)
+	// swagger:route PATCH /me/preferences preferences updateMyPreferences
+	// Updates preferences of the current user with a JSON merge patch. Preferences of a group are nested under its name,
+	// preferences left out are kept, and null resets a preference, or a whole group, to the company's or default value.
+	// responses:
+	//  200: preferencesResp
+	//  400: errMsg
+	//  401: err
+	//  404: err
+	//  500: err
+	e.PATCH("/me/preferences", p.update, mw...)
+	// swagger:operation GET /v1/companies/{id}/preferences preferences companyPreferences
+	// ---
+	// summary: Returns company preferences.
+	// description: Returns preferences set for users of the company in place of defaults. Preferences not set are left out.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of company
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/preferencesResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	cr.GET("/:id/preferences", p.company)
+	// swagger:operation PATCH /v1/companies/{id}/preferences preferences updateCompanyPreferences
+	// ---
+	// summary: Updates company preferences.
+	// description: Updates preferences set for users of the company with a JSON merge patch, as PATCH /me/preferences does. Users keep preferences they set themselves.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of company
+	//   type: int
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: JSON merge patch of preferences
+	//   schema:
+	//     type: object
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/preferencesResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	cr.PATCH("/:id/preferences", p.updateCompany)
+}
+
+func (p *Preference) me(c echo.Context) error {
+	result, err := p.svc.Me(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result.Nested())
+}
+
+func (p *Preference) update(c echo.Context) error {
+	r, err := request.Preferences(c)
+	if err != nil {
+		return err
+	}
+	result, err := p.svc.Update(c, r.Set, r.Reset)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result.Nested())
+}
+
+func (p *Preference) company(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	result, err := p.svc.Company(c, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result.Nested())
+}
+
+func (p *Preference) updateCompany(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	r, err := request.Preferences(c)
+	if err != nil {
+		return err
+	}
+	result, err := p.svc.UpdateCompany(c, id, r.Set, r.Reset)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result.Nested())
+}
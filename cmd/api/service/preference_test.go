@@ -0,0 +1,210 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+	"github.com/artistomin/friend4me/internal/preference"
+)
+
+func preferenceServer(pdb *mockdb.Preference, rbac *mock.RBAC) *httptest.Server {
+	r := server.New()
+	auth := &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, CompanyID: 1}
+		}}
+	service.NewPreference(preference.New(pdb, rbac, auth), r, r.Group("/v1/companies"), pass)
+	return httptest.NewServer(r)
+}
+
+func TestMyPreferences(t *testing.T) {
+	pdb := &mockdb.Preference{
+		LayersFn: func(db orm.DB, id int) (model.Preferences, model.Preferences, error) {
+			return model.Preferences{"timezone": "Europe/Berlin"}, model.Preferences{"privacy.show_email": true}, nil
+		}}
+	ts := preferenceServer(pdb, nil)
+	defer ts.Close()
+	res, err := http.Get(ts.URL + "/me/preferences")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	response := map[string]interface{}{}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Europe/Berlin", response["timezone"])
+	assert.Equal(t, "en", response["language"])
+	assert.Equal(t, true, response["privacy"].(map[string]interface{})["show_email"])
+}
+
+func TestUpdateMyPreferences(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantTheme  string
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"theme":"sepia"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success",
+			req:        `{"theme":"dark","privacy":{"searchable":null}}`,
+			wantStatus: http.StatusOK,
+			wantTheme:  "dark",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var stored model.Preferences
+			pdb := &mockdb.Preference{
+				UpdateUserFn: func(db orm.DB, userID, companyID int, set model.Preferences, reset []string) (model.Preferences, error) {
+					if len(reset) != 1 || reset[0] != "privacy.searchable" {
+						return nil, model.ErrGeneric
+					}
+					stored = set
+					return set, nil
+				},
+				LayersFn: func(orm.DB, int) (model.Preferences, model.Preferences, error) {
+					return nil, stored, nil
+				}}
+			ts := preferenceServer(pdb, nil)
+			defer ts.Close()
+			req, _ := http.NewRequest("PATCH", ts.URL+"/me/preferences", bytes.NewBufferString(tt.req))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantTheme != "" {
+				response := map[string]interface{}{}
+				if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantTheme, response["theme"])
+			}
+		})
+	}
+}
+
+func TestCompanyPreferences(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+		wantResp   map[string]interface{}
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid id",
+			id:         "a",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on RBAC",
+			id:         "2",
+			wantStatus: http.StatusForbidden,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return echo.ErrForbidden
+				}},
+		},
+		{
+			name:       "Success",
+			id:         "1",
+			wantStatus: http.StatusOK,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return nil
+				}},
+			wantResp: map[string]interface{}{"privacy": map[string]interface{}{"show_email": true}},
+		},
+	}
+	pdb := &mockdb.Preference{
+		CompanyFn: func(orm.DB, int) (model.Preferences, error) {
+			return model.Preferences{"privacy.show_email": true}, nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := preferenceServer(pdb, tt.rbac)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/companies/" + tt.id + "/preferences")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := map[string]interface{}{}
+				if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestUpdateCompanyPreferences(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"language":"English"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success",
+			req:        `{"language":"de"}`,
+			wantStatus: http.StatusOK,
+		},
+	}
+	rbac := &mock.RBAC{
+		EnforceCompanyFn: func(echo.Context, int) error {
+			return nil
+		}}
+	pdb := &mockdb.Preference{
+		UpdateCompanyFn: func(db orm.DB, companyID int, set model.Preferences, reset []string) (model.Preferences, error) {
+			if companyID != 1 {
+				return nil, model.ErrGeneric
+			}
+			return set, nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := preferenceServer(pdb, rbac)
+			defer ts.Close()
+			req, _ := http.NewRequest("PATCH", ts.URL+"/v1/companies/1/preferences", bytes.NewBufferString(tt.req))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
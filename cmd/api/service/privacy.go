@@ -0,0 +1,156 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/internal/privacy"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+// Privacy represents data subject request http service
+type Privacy struct {
+	svc *privacy.Service
+}
+
+// NewPrivacy creates new data subject request http service.
+// Requests of the current user are registered outside of v1 group, next to /me, while erasures of users are under er
+func NewPrivacy(svc *privacy.Service, e *echo.Echo, er *echo.Group, mw ...echo.MiddlewareFunc) {
+	p := Privacy{svc: svc}
+	// swagger:route GET /me/data-export privacy dataExport
+	// Downloads everything held about the current user, as a zip archive of JSON files:
+	// profile, session, notification preferences, settings, friendships, blocks, groups, rsvps,
+	// conversations, messages, posts, notifications, activities and role grants.
+	// produces:
+	//  - application/zip
+	// responses:
+	//  200: dataExportResp
+	//  401: err
+	//  404: err
+	//  500: err
+	e.GET("/me/data-export", p.export, mw...)
+	// swagger:route POST /me/erasure privacy erasureRequest
+	// Requests erasure of the current user's personal data, to be carried out by an admin.
+	// responses:
+	//  200: erasureResp
+	//  400: errMsg
+	//  401: err
+	//  409: errMsg
+	//  500: err
+	e.POST("/me/erasure", p.requestErasure, mw...)
+	// swagger:operation GET /v1/erasures privacy listErasures
+	// ---
+	// summary: Returns pending erasure requests.
+	// description: Returns paginated list of pending erasure requests, oldest first. Company admins see requests of their company only.
+	// parameters:
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/erasureListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	er.GET("", p.list)
+	// swagger:route POST /v1/erasures privacy erase
+	// Erases a user, anonymizing its personal fields and removing its relationships, while keeping
+	// its messages, posts and meetups referring to it. Completes pending erasure request of the user, if there is one.
+	// responses:
+	//  200: erasureResp
+	//  400: errMsg
+	//  401: err
+	//  403: err
+	//  404: err
+	//  500: err
+	er.POST("", p.erase)
+}
+
+func (p *Privacy) export(c echo.Context) error {
+	d, err := p.svc.Export(c)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range d.Files() {
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(f.Data, "", "  ")
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	c.Response().Header().Set("Content-Disposition", `attachment; filename="data-export.zip"`)
+	return c.Blob(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+func (p *Privacy) requestErasure(c echo.Context) error {
+	r, err := request.RequestErasure(c)
+	if err != nil {
+		return err
+	}
+	result, err := p.svc.RequestErasure(c, r.Reason)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+type erasureListResponse struct {
+	Erasures []model.Erasure `json:"erasures"`
+	Page     int             `json:"page"`
+}
+
+func (p *Privacy) list(c echo.Context) error {
+	pg, err := request.Paginate(c)
+	if err != nil {
+		return err
+	}
+	result, err := p.svc.Erasures(c, &model.Pagination{
+		Limit: pg.Limit, Offset: pg.Offset,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, erasureListResponse{result, pg.Page})
+}
+
+func (p *Privacy) erase(c echo.Context) error {
+	r, err := request.Erase(c)
+	if err != nil {
+		return err
+	}
+	result, err := p.svc.Erase(c, r.UserID, r.Reason)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,281 @@
+package service_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+	"github.com/artistomin/friend4me/internal/privacy"
+)
+
+func privacyServer(pdb *mockdb.Privacy, rbac *mock.RBAC) *httptest.Server {
+	r := server.New()
+	auth := &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, CompanyID: 1, Role: model.CompanyAdminRole}
+		}}
+	service.NewPrivacy(privacy.New(pdb, nil, rbac, auth), r, r.Group("/v1/erasures"), pass)
+	return httptest.NewServer(r)
+}
+
+func TestDataExport(t *testing.T) {
+	cases := []struct {
+		name       string
+		wantStatus int
+		wantFiles  int
+		pdb        *mockdb.Privacy
+	}{
+		{
+			name:       "Erased user",
+			wantStatus: http.StatusNotFound,
+			pdb: &mockdb.Privacy{
+				SubjectFn: func(orm.DB, int) (*model.User, error) {
+					return nil, echo.ErrNotFound
+				}},
+		},
+		{
+			name:       "Success",
+			wantStatus: http.StatusOK,
+			wantFiles:  15,
+			pdb: &mockdb.Privacy{
+				SubjectFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, Username: "johndoe"}, nil
+				},
+				ExportFn: func(db orm.DB, u *model.User) (*model.DataExport, error) {
+					return &model.DataExport{Profile: u, Blocks: []model.Block{{UserID: 1, TargetID: 2}}}, nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := privacyServer(tt.pdb, nil)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/me/data-export")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantFiles == 0 {
+				return
+			}
+			assert.Equal(t, "application/zip", res.Header.Get("Content-Type"))
+			assert.Equal(t, `attachment; filename="data-export.zip"`, res.Header.Get("Content-Disposition"))
+			body, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Len(t, zr.File, tt.wantFiles)
+			f, err := zr.File[0].Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			profile := new(model.User)
+			if err := json.NewDecoder(f).Decode(profile); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, "profile.json", zr.File[0].Name)
+			assert.Equal(t, "johndoe", profile.Username)
+		})
+	}
+}
+
+func TestRequestErasure(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *model.Erasure
+		pdb        *mockdb.Privacy
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"reason":1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Already requested",
+			wantStatus: http.StatusConflict,
+			pdb: &mockdb.Privacy{
+				RequestErasureFn: func(orm.DB, *model.Erasure) error {
+					return echo.NewHTTPError(http.StatusConflict, "Erasure was already requested.")
+				}},
+		},
+		{
+			name:       "Success",
+			req:        `{"reason":"Leaving"}`,
+			wantStatus: http.StatusOK,
+			pdb: &mockdb.Privacy{
+				RequestErasureFn: func(db orm.DB, e *model.Erasure) error {
+					e.ID = 1
+					return nil
+				}},
+			wantResp: &model.Erasure{Base: model.Base{ID: 1}, UserID: 1, CompanyID: 1, RequestedBy: 1, Reason: "Leaving"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := privacyServer(tt.pdb, nil)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/me/erasure", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(model.Erasure)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestListErasures(t *testing.T) {
+	type listResponse struct {
+		Erasures []model.Erasure `json:"erasures"`
+		Page     int             `json:"page"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *listResponse
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid pagination",
+			req:        `?page=a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on RBAC",
+			wantStatus: http.StatusForbidden,
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(echo.Context, model.AccessRole) error {
+					return echo.ErrForbidden
+				}},
+		},
+		{
+			name:       "Success",
+			req:        `?page=1`,
+			wantStatus: http.StatusOK,
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(echo.Context, model.AccessRole) error {
+					return nil
+				}},
+			wantResp: &listResponse{Erasures: []model.Erasure{{UserID: 2, CompanyID: 1}}, Page: 1},
+		},
+	}
+	pdb := &mockdb.Privacy{
+		ErasuresFn: func(db orm.DB, companyID int, p *model.Pagination) ([]model.Erasure, error) {
+			return []model.Erasure{{UserID: 2, CompanyID: companyID}}, nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := privacyServer(pdb, tt.rbac)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/erasures" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestErase(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *model.Erasure
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"reason":"Court order"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on RBAC",
+			req:        `{"user_id":2}`,
+			wantStatus: http.StatusForbidden,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return echo.ErrForbidden
+				}},
+		},
+		{
+			name:       "Success",
+			req:        `{"user_id":2,"reason":"Court order"}`,
+			wantStatus: http.StatusOK,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return nil
+				},
+				IsLowerRoleFn: func(echo.Context, model.AccessRole) error {
+					return nil
+				}},
+			wantResp: &model.Erasure{Base: model.Base{ID: 1}, UserID: 2, CompanyID: 1, RequestedBy: 1, ErasedBy: 1, Reason: "Court order"},
+		},
+	}
+	pdb := &mockdb.Privacy{
+		SubjectFn: func(db orm.DB, id int) (*model.User, error) {
+			return &model.User{Base: model.Base{ID: id}, CompanyID: 1, RoleID: 5}, nil
+		},
+		EraseFn: func(db orm.DB, u *model.User, e *model.Erasure) error {
+			e.ID = 1
+			return nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := privacyServer(pdb, tt.rbac)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/erasures", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(model.Erasure)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/labstack/echo"
+	"golang.org/x/net/websocket"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// writeWait is time allowed to write a single event to the connection
+const writeWait = 10 * time.Second
+
+// Realtime represents realtime WebSocket gateway
+type Realtime struct {
+	broker    model.Broker
+	auth      model.AuthService
+	heartbeat time.Duration
+}
+
+// NewRealtime creates new realtime WebSocket gateway.
+// Heartbeat event is sent to every connection each heartbeat interval
+func NewRealtime(broker model.Broker, auth model.AuthService, heartbeat time.Duration, e *echo.Echo, mw ...echo.MiddlewareFunc) {
+	r := Realtime{broker: broker, auth: auth, heartbeat: heartbeat}
+	// swagger:operation GET /ws realtime connect
+	// ---
+	// summary: Opens realtime WebSocket connection.
+	// description: Pushes new messages, friend requests and notifications of the current user as JSON events.
+	//   Browsers may pass the token as query parameter. Heartbeat events are sent periodically.
+	//   Connections that cannot keep up with events are closed, and should reconnect.
+	// parameters:
+	// - name: token
+	//   in: query
+	//   description: JWT, if Authorization header cannot be set
+	//   type: string
+	//   required: false
+	// responses:
+	//   "101":
+	//     description: Switching protocols
+	//   "401":
+	//     "$ref": "#/responses/err"
+	e.GET("/ws", r.connect, mw...)
+}
+
+func (r *Realtime) connect(c echo.Context) error {
+	sub := r.broker.Subscribe(r.auth.User(c).ID)
+	defer r.broker.Unsubscribe(sub)
+	// Tokens are not sent by browsers implicitly, so there is no need to check origin
+	ws := websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+		closed := make(chan struct{})
+		go discard(ws, closed)
+		ticker := time.NewTicker(r.heartbeat)
+		defer ticker.Stop()
+		for {
+			var e model.Event
+			select {
+			case ev, ok := <-sub.Events:
+				if !ok {
+					return
+				}
+				e = ev
+			case <-ticker.C:
+				e = model.Event{Type: model.EventHeartbeat, UserID: sub.UserID}
+			case <-closed:
+				return
+			}
+			ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := websocket.JSON.Send(ws, e); err != nil {
+				return
+			}
+		}
+	}}
+	ws.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// discard reads and drops client frames until the connection is closed
+func discard(ws *websocket.Conn, closed chan struct{}) {
+	defer close(closed)
+	// Clear read deadline set by the server for regular requests
+	ws.SetReadDeadline(time.Time{})
+	io.Copy(ioutil.Discard, ws)
+}
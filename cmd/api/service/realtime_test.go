@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/realtime"
+)
+
+func realtimeServer(hub *realtime.Hub, heartbeat time.Duration) *httptest.Server {
+	r := server.New()
+	auth := &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, CompanyID: 1}
+		}}
+	service.NewRealtime(hub, auth, heartbeat, r)
+	return httptest.NewServer(r)
+}
+
+func dial(t *testing.T, ts *httptest.Server) *websocket.Conn {
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", "", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return ws
+}
+
+func TestRealtimeFanOut(t *testing.T) {
+	hub := realtime.NewHub(8, 0)
+	ts := realtimeServer(hub, time.Hour)
+	defer ts.Close()
+	phone, laptop := dial(t, ts), dial(t, ts)
+	defer phone.Close()
+	defer laptop.Close()
+
+	e, err := model.NewEvent(model.EventMessage, 1, &model.Message{Body: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub.Publish(nil, model.Event{Type: model.EventMessage, UserID: 2})
+	hub.Publish(nil, e)
+	for _, ws := range []*websocket.Conn{phone, laptop} {
+		var got model.Event
+		if err := websocket.JSON.Receive(ws, &got); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, e.Type, got.Type)
+		assert.Equal(t, 1, got.UserID)
+		assert.JSONEq(t, string(e.Payload), string(got.Payload))
+	}
+}
+
+func TestRealtimeHeartbeat(t *testing.T) {
+	ts := realtimeServer(realtime.NewHub(8, 0), 10*time.Millisecond)
+	defer ts.Close()
+	ws := dial(t, ts)
+	defer ws.Close()
+	var got model.Event
+	if err := websocket.JSON.Receive(ws, &got); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, model.EventHeartbeat, got.Type)
+}
+
+func TestRealtimeSlowConsumer(t *testing.T) {
+	hub := realtime.NewHub(1, 0)
+	ts := realtimeServer(hub, time.Hour)
+	defer ts.Close()
+	ws := dial(t, ts)
+	defer ws.Close()
+
+	// Overflowing the buffer drops the subscription, closing the connection
+	for i := 0; i < 100; i++ {
+		hub.Publish(nil, model.Event{Type: model.EventNotification, UserID: 1})
+	}
+	var err error
+	for err == nil {
+		var got model.Event
+		err = websocket.JSON.Receive(ws, &got)
+	}
+	assert.NotContains(t, err.Error(), "timeout")
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/internal/search"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+// Search represents search http service
+type Search struct {
+	svc *search.Service
+}
+
+// NewSearch creates new search http service
+func NewSearch(svc *search.Service, sr *echo.Group) {
+	s := Search{svc: svc}
+	// swagger:operation GET /v1/search/users search searchUsers
+	// ---
+	// summary: Searches users.
+	// description: Returns users with words starting with every search term in their names, username, email or address,
+	//   or with names resembling the terms, best matches first. Names, username and email come HTML escaped, with matching words wrapped in mark tags.
+	//   Like the user list, it returns users within the scope admins administer, and an error for non-admin users.
+	// parameters:
+	// - name: q
+	//   in: query
+	//   description: search text, e.g. ann smi
+	//   type: string
+	//   required: true
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/searchResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	sr.GET("/users", s.users)
+}
+
+type searchResponse struct {
+	Hits []model.SearchHit `json:"hits"`
+	Page int               `json:"page"`
+}
+
+func (s *Search) users(c echo.Context) error {
+	text, err := request.SearchText(c)
+	if err != nil {
+		return err
+	}
+	p, err := request.Paginate(c)
+	if err != nil {
+		return err
+	}
+	result, err := s.svc.Users(c, text, &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, searchResponse{result, p.Page})
+}
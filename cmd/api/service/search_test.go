@@ -0,0 +1,88 @@
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/search"
+)
+
+func TestSearchUsers(t *testing.T) {
+	type searchResponse struct {
+		Hits []model.SearchHit `json:"hits"`
+		Page int               `json:"page"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		role       model.AccessRole
+		wantStatus int
+		wantResp   *searchResponse
+	}{
+		{
+			name:       "Invalid request",
+			req:        `?q=%20`,
+			role:       model.AdminRole,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Regular user",
+			req:        `?q=ann`,
+			role:       model.UserRole,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Success",
+			req:        `?q=ann+smi&limit=5&page=1`,
+			role:       model.AdminRole,
+			wantStatus: http.StatusOK,
+			wantResp: &searchResponse{Hits: []model.SearchHit{{User: model.User{Base: model.Base{ID: 3}, FirstName: "Anna", LastName: "Smith"},
+				Rank: 0.9, Highlight: "<mark>Anna</mark> <mark>Smith</mark>"}}, Page: 1},
+		},
+	}
+	searcher := &mock.Searcher{
+		SearchUsersFn: func(db orm.DB, terms []string, q *model.ListQuery, p *model.Pagination) ([]model.SearchHit, error) {
+			if len(terms) != 2 || p.Limit != 5 || p.Offset != 5 {
+				return nil, model.ErrGeneric
+			}
+			return []model.SearchHit{{User: model.User{Base: model.Base{ID: 3}, FirstName: "Anna", LastName: "Smith"},
+				Rank: 0.9, Highlight: "<mark>Anna</mark> <mark>Smith</mark>"}}, nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &mock.Auth{
+				UserFn: func(echo.Context) *model.AuthUser {
+					return &model.AuthUser{ID: 1, CompanyID: 1, Role: tt.role}
+				}}
+			r := server.New()
+			service.NewSearch(search.New(searcher, nil, auth), r.Group("/v1/search"))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/search/users" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(searchResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/internal/tag"
+
+	"github.com/artistomin/friend4me/cmd/api/request"
+)
+
+// Tag represents interest tag http service
+type Tag struct {
+	svc *tag.Service
+}
+
+// NewTag creates new interest tag http service
+func NewTag(svc *tag.Service, tr *echo.Group) {
+	t := Tag{svc: svc}
+	// swagger:route GET /v1/tags tags listTags
+	// Returns interest tags users of the current user's company can pick from.
+	// responses:
+	//  200: tagListResp
+	//  401: err
+	//  500: err
+	tr.GET("", t.list)
+	// swagger:route POST /v1/tags tags tagCreate
+	// Adds an interest tag to company's vocabulary. Tag names are lowercased. Only company admins can add tags.
+	// responses:
+	//  200: tagResp
+	//  400: errMsg
+	//  401: err
+	//  403: err
+	//  409: errMsg
+	//  500: err
+	tr.POST("", t.create)
+	// swagger:operation DELETE /v1/tags/{id} tags tagDelete
+	// ---
+	// summary: Deletes an interest tag.
+	// description: Removes an interest tag from company's vocabulary and from profiles of users having it. Only company admins can delete tags.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of tag
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	tr.DELETE("/:id", t.delete)
+}
+
+type tagListResponse struct {
+	Tags []model.Tag `json:"tags"`
+}
+
+func (t *Tag) list(c echo.Context) error {
+	result, err := t.svc.List(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, tagListResponse{result})
+}
+
+func (t *Tag) create(c echo.Context) error {
+	r, err := request.TagCreate(c)
+	if err != nil {
+		return err
+	}
+	result, err := t.svc.Create(c, r.CompanyID, r.Name)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (t *Tag) delete(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	if err := t.svc.Delete(c, id); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,159 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/cmd/api/server"
+	"github.com/artistomin/friend4me/cmd/api/service"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+	"github.com/artistomin/friend4me/internal/tag"
+)
+
+func tagServer(tdb *mockdb.Tag) *httptest.Server {
+	r := server.New()
+	auth := &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, CompanyID: 1}
+		}}
+	rbac := &mock.RBAC{
+		EnforceCompanyFn: func(c echo.Context, id int) error {
+			if id != 1 {
+				return echo.ErrForbidden
+			}
+			return nil
+		}}
+	service.NewTag(tag.New(tdb, rbac, auth), r.Group("/v1/tags"))
+	return httptest.NewServer(r)
+}
+
+func TestListTags(t *testing.T) {
+	type listResponse struct {
+		Tags []model.Tag `json:"tags"`
+	}
+	ts := tagServer(&mockdb.Tag{
+		ListFn: func(db orm.DB, companyID int) ([]model.Tag, error) {
+			if companyID != 1 {
+				return nil, model.ErrGeneric
+			}
+			return []model.Tag{{Base: model.Base{ID: 1}, CompanyID: 1, Name: "chess"}}, nil
+		}})
+	defer ts.Close()
+	res, err := http.Get(ts.URL + "/v1/tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	response := new(listResponse)
+	if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, &listResponse{Tags: []model.Tag{{Base: model.Base{ID: 1}, CompanyID: 1, Name: "chess"}}}, response)
+}
+
+func TestCreateTag(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *model.Tag
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"company_id":1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Another company",
+			req:        `{"name":"chess","company_id":2}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Success",
+			req:        `{"name":"  Board  Games "}`,
+			wantStatus: http.StatusOK,
+			wantResp:   &model.Tag{Base: model.Base{ID: 1}, CompanyID: 1, Name: "board games"},
+		},
+	}
+	tdb := &mockdb.Tag{
+		CreateFn: func(db orm.DB, tag model.Tag) (*model.Tag, error) {
+			tag.ID = 1
+			return &tag, nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := tagServer(tdb)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/tags", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(model.Tag)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestDeleteTag(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+	}{
+		{
+			name:       "Invalid id",
+			id:         "a",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Tag of another company",
+			id:         "2",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Success",
+			id:         "1",
+			wantStatus: http.StatusOK,
+		},
+	}
+	tdb := &mockdb.Tag{
+		ViewFn: func(db orm.DB, id int) (*model.Tag, error) {
+			return &model.Tag{Base: model.Base{ID: id}, CompanyID: id, Name: "chess"}, nil
+		},
+		DeleteFn: func(orm.DB, *model.Tag) error {
+			return nil
+		}}
+	client := &http.Client{}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := tagServer(tdb)
+			defer ts.Close()
+			req, _ := http.NewRequest("DELETE", ts.URL+"/v1/tags/"+tt.id, nil)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -1,12 +1,14 @@
 package service
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo"
 
 	"github.com/artistomin/friend4me/internal"
 
+	"github.com/artistomin/friend4me/internal/platform/export"
 	"github.com/artistomin/friend4me/internal/user"
 
 	"github.com/artistomin/friend4me/cmd/api/request"
@@ -24,6 +26,8 @@ func NewUser(svc *user.Service, ur *echo.Group) {
 	// ---
 	// summary: Returns list of users.
 	// description: Returns list of users. Depending on the user role requesting it, it may return all users for SuperAdmin/Admin users, all company/location users for Company/Location admins, and an error for non-admin users.
+	//   Filters narrow the list within that scope.
+	//   Cursors of next and previous pages are returned in the body and in Link header.
 	// parameters:
 	// - name: limit
 	//   in: query
@@ -35,6 +39,70 @@ func NewUser(svc *user.Service, ur *echo.Group) {
 	//   description: page number
 	//   type: int
 	//   required: false
+	// - name: cursor
+	//   in: query
+	//   description: next or prev cursor of a previously listed page, used in place of page number. Has to be used with the same sort
+	//   type: string
+	//   required: false
+	// - name: count
+	//   in: query
+	//   description: whether to count all users matching the filters
+	//   type: boolean
+	//   required: false
+	// - name: active
+	//   in: query
+	//   description: only active or inactive users
+	//   type: boolean
+	//   required: false
+	// - name: role
+	//   in: query
+	//   description: role id, 1 to 5
+	//   type: int
+	//   required: false
+	// - name: company_id
+	//   in: query
+	//   description: company id
+	//   type: int
+	//   required: false
+	// - name: location_id
+	//   in: query
+	//   description: location id
+	//   type: int
+	//   required: false
+	// - name: created_after
+	//   in: query
+	//   description: users created at or after, RFC3339 timestamp
+	//   type: string
+	//   format: date-time
+	//   required: false
+	// - name: created_before
+	//   in: query
+	//   description: users created before, RFC3339 timestamp
+	//   type: string
+	//   format: date-time
+	//   required: false
+	// - name: last_login_after
+	//   in: query
+	//   description: users last logged in at or after, RFC3339 timestamp
+	//   type: string
+	//   format: date-time
+	//   required: false
+	// - name: last_login_before
+	//   in: query
+	//   description: users last logged in before, RFC3339 timestamp
+	//   type: string
+	//   format: date-time
+	//   required: false
+	// - name: q
+	//   in: query
+	//   description: search term matched against first name, last name, username and email
+	//   type: string
+	//   required: false
+	// - name: sort
+	//   in: query
+	//   description: up to 3 comma separated fields out of id, first_name, last_name, username, email, created_at and last_login, prefixed with '-' for descending order
+	//   type: string
+	//   required: false
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/userListResp"
@@ -47,6 +115,170 @@ func NewUser(svc *user.Service, ur *echo.Group) {
 	//   "500":
 	//     "$ref": "#/responses/err"
 	ur.GET("", u.list)
+	// swagger:operation GET /v1/users/discover users discoverUsers
+	// ---
+	// summary: Returns users with shared interests.
+	// description: Returns users sharing interest tags with the current user, most shared tags first.
+	//   Regular users discover people within their company, admins within the scope they administer. Blocked users are left out.
+	// parameters:
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/matchListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("/discover", u.discover)
+	// swagger:operation GET /v1/users/export users exportUsers
+	// ---
+	// summary: Exports users to a file.
+	// description: Streams users the requesting user may list as CSV, JSON lines or Excel workbook, with the same filters and sort as the user list.
+	// produces:
+	// - text/csv
+	// - application/x-ndjson
+	// - application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+	// parameters:
+	// - name: format
+	//   in: query
+	//   description: csv (default), jsonl or xlsx
+	//   type: string
+	//   required: false
+	// - name: columns
+	//   in: query
+	//   description: comma separated columns out of id, first_name, last_name, username, email, mobile, phone, address, active,
+	//     role_id, company_id, location_id, created_at and last_login, all of them by default
+	//   type: string
+	//   required: false
+	// - name: active
+	//   in: query
+	//   description: only active or inactive users
+	//   type: boolean
+	//   required: false
+	// - name: role
+	//   in: query
+	//   description: role id, 1 to 5
+	//   type: int
+	//   required: false
+	// - name: company_id
+	//   in: query
+	//   description: company id
+	//   type: int
+	//   required: false
+	// - name: location_id
+	//   in: query
+	//   description: location id
+	//   type: int
+	//   required: false
+	// - name: created_after
+	//   in: query
+	//   description: users created at or after, RFC3339 timestamp
+	//   type: string
+	//   format: date-time
+	//   required: false
+	// - name: created_before
+	//   in: query
+	//   description: users created before, RFC3339 timestamp
+	//   type: string
+	//   format: date-time
+	//   required: false
+	// - name: last_login_after
+	//   in: query
+	//   description: users last logged in at or after, RFC3339 timestamp
+	//   type: string
+	//   format: date-time
+	//   required: false
+	// - name: last_login_before
+	//   in: query
+	//   description: users last logged in before, RFC3339 timestamp
+	//   type: string
+	//   format: date-time
+	//   required: false
+	// - name: q
+	//   in: query
+	//   description: search term matched against first name, last name, username and email
+	//   type: string
+	//   required: false
+	// - name: sort
+	//   in: query
+	//   description: up to 3 comma separated fields out of id, first_name, last_name, username, email, created_at and last_login, prefixed with '-' for descending order
+	//   type: string
+	//   required: false
+	// responses:
+	//   "200":
+	//     description: file of users
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("/export", u.export)
+	// swagger:operation POST /v1/users/bulk users userBulk
+	// ---
+	// summary: Changes many users at once.
+	// description: Activates, deactivates, moves to a location, changes role of or deletes users given by ids or by a filter of user list.
+	//   Users out of the scope the requesting user lists users in are reported as not found, and users whose role is not lower
+	//   than requesting user's are left unchanged. The rest are changed together, returning outcome for every user.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/Bulk"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/bulkResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/bulk", u.bulk)
+	// swagger:operation GET /v1/users/deleted users listDeletedUsers
+	// ---
+	// summary: Returns list of deleted users.
+	// description: Returns deleted users that can still be restored, most recently deleted first, within the scope the requesting admin lists users in.
+	// parameters:
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/userListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("/deleted", u.listDeleted)
 	// swagger:operation GET /v1/users/{id} users getUser
 	// ---
 	// summary: Returns a single user.
@@ -73,8 +305,9 @@ func NewUser(svc *user.Service, ur *echo.Group) {
 	ur.GET("/:id", u.view)
 	// swagger:operation PATCH /v1/users/{id} users userUpdate
 	// ---
-	// summary: Updates user's contact information
-	// description: Updates user's contact information -> first name, last name, mobile, phone, address.
+	// summary: Updates user's contact information and profile
+	// description: Updates user's contact information -> first name, last name, mobile, phone, address, whether presence is hidden from friends,
+	//   bio and interest tags. Tags have to be in the vocabulary of user's company, and replace the current ones.
 	// parameters:
 	// - name: id
 	//   in: path
@@ -121,25 +354,161 @@ func NewUser(svc *user.Service, ur *echo.Group) {
 	//   "500":
 	//     "$ref": "#/responses/err"
 	ur.DELETE("/:id", u.delete)
+	// swagger:operation POST /v1/users/{id}/restore users userRestore
+	// ---
+	// summary: Restores a deleted user
+	// description: Restores a deleted user with requested ID, unless it was purged or another user took its username or email.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of user
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/userResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/:id/restore", u.restore)
 }
 
 type listResponse struct {
 	Users []model.User `json:"users"`
 	Page  int          `json:"page"`
+	page
+}
+
+type matchListResponse struct {
+	Matches []model.Match `json:"matches"`
+	Page    int           `json:"page"`
 }
 
 func (u *User) list(c echo.Context) error {
+	p, err := request.PaginateKeyset(c)
+	if err != nil {
+		return err
+	}
+	f, err := request.UserList(c)
+	if err != nil {
+		return err
+	}
+	pg := &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset, Cursor: p.Keyset, Count: p.Count,
+	}
+	result, err := u.svc.List(c, f, pg)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, listResponse{result, p.Page, paginate(c, pg, len(result), func(i int, prev bool) *model.Cursor {
+		return f.Cursor(&result[i], prev)
+	})})
+}
+
+func (u *User) discover(c echo.Context) error {
 	p, err := request.Paginate(c)
 	if err != nil {
 		return err
 	}
-	result, err := u.svc.List(c, &model.Pagination{
+	result, err := u.svc.Discover(c, &model.Pagination{
 		Limit: p.Limit, Offset: p.Offset,
 	})
 	if err != nil {
 		return err
 	}
-	return c.JSON(http.StatusOK, listResponse{result, p.Page})
+	return c.JSON(http.StatusOK, matchListResponse{result, p.Page})
+}
+
+// export writes the file as batches of users are read, sending headers only once the first batch is read,
+// so errors found before that are still returned as error responses. Later errors abort the connection
+func (u *User) export(c echo.Context) error {
+	r, err := request.UserExport(c)
+	if err != nil {
+		return err
+	}
+	var w export.Writer
+	start := func() error {
+		res := c.Response()
+		res.Header().Set(echo.HeaderContentType, r.Format.ContentType())
+		res.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="users.%s"`, r.Format))
+		res.WriteHeader(http.StatusOK)
+		w, err = export.New(r.Format, res, r.Columns)
+		return err
+	}
+	row := make([]interface{}, len(r.Columns))
+	err = u.svc.Export(c, r.Filter, func(users []model.User) error {
+		if w == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		for i := range users {
+			for j, col := range r.Columns {
+				row[j] = users[i].ExportValue(col)
+			}
+			if err := w.Write(row); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		c.Response().Flush()
+		return nil
+	})
+	if err != nil {
+		if w != nil {
+			abort(c)
+		}
+		return err
+	}
+	if w == nil {
+		if err := start(); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// abort closes the connection of a response which is already being sent, so that clients
+// see that it was cut short, rather than taking a truncated file for a complete one
+func abort(c echo.Context) {
+	h, ok := c.Response().Writer.(http.Hijacker)
+	if !ok {
+		return
+	}
+	if conn, _, err := h.Hijack(); err == nil {
+		conn.Close()
+	}
+}
+
+type bulkResponse struct {
+	Results []model.BulkResult `json:"results"`
+}
+
+func (u *User) bulk(c echo.Context) error {
+	r, err := request.UserBulk(c)
+	if err != nil {
+		return err
+	}
+	result, err := u.svc.Bulk(c, r.IDs, r.Users, model.BulkChange{
+		Action:     model.BulkAction(r.Action),
+		LocationID: r.LocationID,
+		RoleID:     r.RoleID,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, bulkResponse{result})
 }
 
 func (u *User) view(c echo.Context) error {
@@ -166,6 +535,11 @@ func (u *User) update(c echo.Context) error {
 		Mobile:    req.Mobile,
 		Phone:     req.Phone,
 		Address:   req.Address,
+
+		HidePresence: req.HidePresence,
+
+		Bio:  req.Bio,
+		Tags: req.Tags,
 	})
 	if err != nil {
 		return err
@@ -183,3 +557,29 @@ func (u *User) delete(c echo.Context) error {
 	}
 	return c.NoContent(http.StatusOK)
 }
+
+func (u *User) listDeleted(c echo.Context) error {
+	p, err := request.Paginate(c)
+	if err != nil {
+		return err
+	}
+	result, err := u.svc.ListDeleted(c, &model.Pagination{
+		Limit: p.Limit, Offset: p.Offset,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, listResponse{Users: result, Page: p.Page})
+}
+
+func (u *User) restore(c echo.Context) error {
+	id, err := request.ID(c)
+	if err != nil {
+		return err
+	}
+	result, err := u.svc.Restore(c, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
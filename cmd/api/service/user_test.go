@@ -3,10 +3,13 @@ package service_test
 import (
 	"bytes"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-pg/pg/orm"
+
 	"github.com/labstack/echo"
 	"github.com/stretchr/testify/assert"
 
@@ -20,6 +23,12 @@ import (
 	"github.com/artistomin/friend4me/internal/mock/mockdb"
 )
 
+// untagged mocks tag database of users without interest tags
+var untagged = &mockdb.Tag{
+	UserTagsFn: func(orm.DB, int) ([]model.Tag, error) {
+		return nil, nil
+	}}
+
 func TestListUsers(t *testing.T) {
 	type listResponse struct {
 		Users []model.User `json:"users"`
@@ -39,6 +48,28 @@ func TestListUsers(t *testing.T) {
 			req:        `?limit=2222&page=-1`,
 			wantStatus: http.StatusBadRequest,
 		},
+		{
+			name:       "Invalid filter",
+			req:        `?sort=password`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Filtered",
+			req:  `?active=true&q=jo&sort=-created_at`,
+			auth: &mock.Auth{
+				UserFn: func(c echo.Context) *model.AuthUser {
+					return &model.AuthUser{ID: 1, Role: model.SuperAdminRole}
+				}},
+			udb: &mockdb.User{
+				ListFn: func(db orm.DB, q *model.ListQuery, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
+					if f.Active == nil || !*f.Active || f.Search != "jo" || len(f.Sort) != 1 || !f.Sort[0].Desc {
+						return nil, model.ErrGeneric
+					}
+					return []model.User{{Base: model.Base{ID: 4}, FirstName: "Joanna"}}, nil
+				}},
+			wantStatus: http.StatusOK,
+			wantResp:   &listResponse{Users: []model.User{{Base: model.Base{ID: 4}, FirstName: "Joanna"}}},
+		},
 		{
 			name: "Fail on query list",
 			req:  `?limit=100&page=1`,
@@ -68,7 +99,7 @@ func TestListUsers(t *testing.T) {
 					}
 				}},
 			udb: &mockdb.User{
-				ListFn: func(q *model.ListQuery, p *model.Pagination) ([]model.User, error) {
+				ListFn: func(db orm.DB, q *model.ListQuery, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
 					if p.Limit == 100 && p.Offset == 100 {
 						return []model.User{
 							{
@@ -155,7 +186,7 @@ func TestListUsers(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("/v1/users")
-			service.NewUser(user.New(tt.udb, tt.rbac, tt.auth), rg)
+			service.NewUser(user.New(tt.udb, nil, nil, tt.rbac, nil, activityLogger, tt.auth, nil), rg)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/v1/users" + tt.req
@@ -176,6 +207,321 @@ func TestListUsers(t *testing.T) {
 	}
 }
 
+func TestListUsersPaginated(t *testing.T) {
+	type listResponse struct {
+		Users []model.User `json:"users"`
+		Next  string       `json:"next"`
+		Prev  string       `json:"prev"`
+		Total *int         `json:"total"`
+	}
+	doe, tenth, total := "Doe", "10", 5
+	next := &model.Cursor{Order: "last_name,-id", Values: []*string{&doe, &tenth}}
+	prev := &model.Cursor{Order: "last_name,-id", Values: []*string{&doe, &tenth}, Prev: true}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *listResponse
+		wantLinks  []string
+	}{
+		{
+			name:       "Cursor of another order",
+			req:        `?sort=last_name&cursor=` + (&model.Cursor{Order: "-id", Values: []*string{&tenth}}).Encode(),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "First page",
+			req:        `?limit=2&sort=last_name&count=true`,
+			wantStatus: http.StatusOK,
+			wantResp:   &listResponse{Next: next.Encode(), Total: &total},
+			wantLinks:  []string{"cursor=" + next.Encode(), `rel="next"`},
+		},
+		{
+			name:       "Next page",
+			req:        `?limit=2&sort=last_name&cursor=` + next.Encode(),
+			wantStatus: http.StatusOK,
+			wantResp:   &listResponse{Next: next.Encode(), Prev: prev.Encode()},
+			wantLinks:  []string{`rel="next"`, `rel="prev"`},
+		},
+		{
+			name:       "First page read backward",
+			req:        `?limit=3&sort=last_name&cursor=` + prev.Encode(),
+			wantStatus: http.StatusOK,
+			wantResp:   &listResponse{Next: next.Encode()},
+			wantLinks:  []string{`rel="next"`},
+		},
+	}
+	udb := &mockdb.User{
+		ListFn: func(db orm.DB, q *model.ListQuery, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
+			if p.Offset != 0 {
+				return nil, model.ErrGeneric
+			}
+			p.Total = 5
+			return []model.User{{Base: model.Base{ID: 10}, LastName: "Doe"}, {Base: model.Base{ID: 10}, LastName: "Doe"}}, nil
+		}}
+	auth := &mock.Auth{
+		UserFn: func(c echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, Role: model.SuperAdminRole}
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			service.NewUser(user.New(udb, nil, nil, nil, nil, activityLogger, auth, nil), r.Group("/v1/users"))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/users" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				response.Users = nil
+				assert.Equal(t, tt.wantResp, response)
+			}
+			for _, l := range tt.wantLinks {
+				assert.Contains(t, res.Header.Get("Link"), l)
+			}
+		})
+	}
+}
+
+func TestExportUsers(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		role       model.AccessRole
+		wantStatus int
+		wantType   string
+		wantBody   string
+	}{
+		{
+			name:       "Invalid format",
+			req:        "?format=pdf",
+			role:       model.AdminRole,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on scope",
+			role:       model.UserRole,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "CSV",
+			req:        "?columns=id,username,active&active=true",
+			role:       model.AdminRole,
+			wantStatus: http.StatusOK,
+			wantType:   "text/csv; charset=utf-8",
+			wantBody:   "id,username,active\n2,janedoe,true\n1,johndoe,true\n",
+		},
+		{
+			name:       "JSON lines",
+			req:        "?format=jsonl&columns=username,last_login",
+			role:       model.AdminRole,
+			wantStatus: http.StatusOK,
+			wantType:   "application/x-ndjson",
+			wantBody:   `{"username":"janedoe","last_login":null}` + "\n" + `{"username":"johndoe","last_login":null}` + "\n",
+		},
+	}
+	udb := &mockdb.User{
+		ListFn: func(db orm.DB, q *model.ListQuery, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
+			if p.Cursor != nil || (f.Active != nil && !*f.Active) {
+				return nil, model.ErrGeneric
+			}
+			return []model.User{
+				{Base: model.Base{ID: 2}, Username: "janedoe", Active: true},
+				{Base: model.Base{ID: 1}, Username: "johndoe", Active: true},
+			}, nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &mock.Auth{
+				UserFn: func(c echo.Context) *model.AuthUser {
+					return &model.AuthUser{ID: 1, Role: tt.role}
+				}}
+			r := server.New()
+			service.NewUser(user.New(udb, nil, nil, nil, nil, activityLogger, auth, nil), r.Group("/v1/users"))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/users/export" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantBody != "" {
+				body, err := ioutil.ReadAll(res.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantType, res.Header.Get("Content-Type"))
+				assert.Contains(t, res.Header.Get("Content-Disposition"), "users.")
+				assert.Equal(t, tt.wantBody, string(body))
+			}
+		})
+	}
+}
+
+func TestExportUsersFailure(t *testing.T) {
+	udb := &mockdb.User{
+		ListFn: func(db orm.DB, q *model.ListQuery, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
+			if p.Cursor != nil {
+				return nil, model.ErrGeneric
+			}
+			users := make([]model.User, p.Limit)
+			for i := range users {
+				users[i] = model.User{Base: model.Base{ID: i + 1}, Username: "user"}
+			}
+			return users, nil
+		}}
+	auth := &mock.Auth{
+		UserFn: func(c echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, Role: model.AdminRole}
+		}}
+	r := server.New()
+	service.NewUser(user.New(udb, nil, nil, nil, nil, activityLogger, auth, nil), r.Group("/v1/users"))
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	res, err := http.Get(ts.URL + "/v1/users/export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	// Second batch fails after the first one was sent, so the file is cut short
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	_, err = ioutil.ReadAll(res.Body)
+	assert.NotNil(t, err)
+}
+
+func TestBulkUsers(t *testing.T) {
+	type bulkResponse struct {
+		Results []model.BulkResult `json:"results"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *bulkResponse
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"ids":[2],"action":"move_location"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success",
+			req:        `{"ids":[2,3],"action":"deactivate"}`,
+			wantStatus: http.StatusOK,
+			wantResp:   &bulkResponse{Results: []model.BulkResult{{ID: 2, OK: true}, {ID: 3, Error: "user not found"}}},
+		},
+	}
+	udb := &mockdb.User{
+		ListFn: func(db orm.DB, q *model.ListQuery, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
+			return []model.User{{Base: model.Base{ID: 2}, Role: &model.Role{AccessLevel: model.UserRole}}}, nil
+		},
+		BulkFn: func(db orm.DB, ids []int, ch model.BulkChange) error {
+			if len(ids) != 1 || ids[0] != 2 || ch.Action != model.BulkDeactivate {
+				return model.ErrGeneric
+			}
+			return nil
+		}}
+	rbac := &mock.RBAC{
+		IsLowerRoleFn: func(echo.Context, model.AccessRole) error {
+			return nil
+		}}
+	auth := &mock.Auth{
+		UserFn: func(c echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, Role: model.AdminRole}
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			service.NewUser(user.New(udb, nil, nil, rbac, nil, activityLogger, auth, nil), r.Group("/v1/users"))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/v1/users/bulk", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantResp != nil {
+				response := new(bulkResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+		})
+	}
+}
+
+func TestDeletedUsers(t *testing.T) {
+	type listResponse struct {
+		Users []model.User `json:"users"`
+		Page  int          `json:"page"`
+	}
+	udb := &mockdb.User{
+		ListDeletedFn: func(db orm.DB, q *model.ListQuery, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
+			if f != nil && f.IDs[0] != 2 {
+				return nil, nil
+			}
+			return []model.User{{Base: model.Base{ID: 2, DeletedAt: mock.TestTimePtr(2018)}, Username: "johndoe"}}, nil
+		},
+		RestoreFn: func(db orm.DB, u *model.User) error {
+			u.DeletedAt = nil
+			return nil
+		}}
+	rbac := &mock.RBAC{
+		IsLowerRoleFn: func(echo.Context, model.AccessRole) error {
+			return nil
+		}}
+	auth := &mock.Auth{
+		UserFn: func(c echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, Role: model.AdminRole}
+		}}
+	r := server.New()
+	service.NewUser(user.New(udb, nil, nil, rbac, nil, activityLogger, auth, nil), r.Group("/v1/users"))
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/v1/users/deleted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := new(listResponse)
+	if err := json.NewDecoder(res.Body).Decode(list); err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, &listResponse{Users: []model.User{{Base: model.Base{ID: 2, DeletedAt: mock.TestTimePtr(2018)}, Username: "johndoe"}}}, list)
+
+	res, err = http.Post(ts.URL+"/v1/users/3/restore", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res, err = http.Post(ts.URL+"/v1/users/2/restore", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	restored := new(model.User)
+	if err := json.NewDecoder(res.Body).Decode(restored); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, &model.User{Base: model.Base{ID: 2}, Username: "johndoe"}, restored)
+}
+
 func TestViewUser(t *testing.T) {
 	cases := []struct {
 		name       string
@@ -183,6 +529,7 @@ func TestViewUser(t *testing.T) {
 		wantStatus int
 		wantResp   *model.User
 		udb        *mockdb.User
+		bdb        *mockdb.Block
 		rbac       *mock.RBAC
 		auth       *mock.Auth
 	}{
@@ -201,6 +548,24 @@ func TestViewUser(t *testing.T) {
 			},
 			wantStatus: http.StatusForbidden,
 		},
+		{
+			name: "Blocked",
+			req:  `1`,
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(echo.Context, int) error {
+					return nil
+				},
+			},
+			auth: &mock.Auth{
+				UserFn: func(c echo.Context) *model.AuthUser {
+					return &model.AuthUser{ID: 2, Role: model.CompanyAdminRole}
+				}},
+			bdb: &mockdb.Block{
+				BlockedFn: func(orm.DB, int, int) (bool, error) {
+					return true, nil
+				}},
+			wantStatus: http.StatusNotFound,
+		},
 		{
 			name: "Success",
 			req:  `1`,
@@ -209,8 +574,12 @@ func TestViewUser(t *testing.T) {
 					return nil
 				},
 			},
+			auth: &mock.Auth{
+				UserFn: func(c echo.Context) *model.AuthUser {
+					return &model.AuthUser{ID: 1, Role: model.UserRole}
+				}},
 			udb: &mockdb.User{
-				ViewFn: func(id int) (*model.User, error) {
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
 					return &model.User{
 						Base: model.Base{
 							ID:        1,
@@ -241,7 +610,7 @@ func TestViewUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("/v1/users")
-			service.NewUser(user.New(tt.udb, tt.rbac, tt.auth), rg)
+			service.NewUser(user.New(tt.udb, tt.bdb, untagged, tt.rbac, nil, nil, tt.auth, nil), rg)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/v1/users/" + tt.req
@@ -299,7 +668,7 @@ func TestUpdateUser(t *testing.T) {
 				},
 			},
 			udb: &mockdb.User{
-				ViewFn: func(id int) (*model.User, error) {
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
 					return &model.User{
 						Base: model.Base{
 							ID:        1,
@@ -313,7 +682,7 @@ func TestUpdateUser(t *testing.T) {
 						Phone:     "332223",
 					}, nil
 				},
-				UpdateFn: func(usr *model.User) (*model.User, error) {
+				UpdateFn: func(db orm.DB, usr *model.User) (*model.User, error) {
 					usr.UpdatedAt = mock.TestTime(2010)
 					usr.Mobile = "991991"
 					return usr, nil
@@ -342,7 +711,7 @@ func TestUpdateUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("/v1/users")
-			service.NewUser(user.New(tt.udb, tt.rbac, tt.auth), rg)
+			service.NewUser(user.New(tt.udb, nil, untagged, tt.rbac, nil, activityLogger, tt.auth, nil), rg)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/v1/users/" + tt.id
@@ -383,7 +752,7 @@ func TestDeleteUser(t *testing.T) {
 			name: "Fail on RBAC",
 			id:   `1`,
 			udb: &mockdb.User{
-				ViewFn: func(id int) (*model.User, error) {
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
 					return &model.User{
 						Role: &model.Role{
 							AccessLevel: model.CompanyAdminRole,
@@ -402,14 +771,14 @@ func TestDeleteUser(t *testing.T) {
 			name: "Success",
 			id:   `1`,
 			udb: &mockdb.User{
-				ViewFn: func(id int) (*model.User, error) {
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
 					return &model.User{
 						Role: &model.Role{
 							AccessLevel: model.CompanyAdminRole,
 						},
 					}, nil
 				},
-				DeleteFn: func(*model.User) error {
+				DeleteFn: func(orm.DB, *model.User) error {
 					return nil
 				},
 			},
@@ -428,7 +797,7 @@ func TestDeleteUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("/v1/users")
-			service.NewUser(user.New(tt.udb, tt.rbac, tt.auth), rg)
+			service.NewUser(user.New(tt.udb, nil, nil, tt.rbac, nil, activityLogger, tt.auth, nil), rg)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/v1/users/" + tt.id
@@ -442,3 +811,42 @@ func TestDeleteUser(t *testing.T) {
 		})
 	}
 }
+
+func TestDiscoverUsers(t *testing.T) {
+	type matchListResponse struct {
+		Matches []model.Match `json:"matches"`
+		Page    int           `json:"page"`
+	}
+	r := server.New()
+	auth := &mock.Auth{
+		UserFn: func(c echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 1, CompanyID: 2, Role: model.UserRole}
+		}}
+	bdb := &mockdb.Block{
+		RelatedFn: func(orm.DB, int) ([]int, error) {
+			return []int{5}, nil
+		}}
+	tdb := &mockdb.Tag{
+		DiscoverFn: func(db orm.DB, id int, q *model.ListQuery, p *model.Pagination) ([]model.Match, error) {
+			if id != 1 || q.ID != 2 || len(q.Exclude) != 1 || p.Limit != 10 {
+				return nil, model.ErrGeneric
+			}
+			return []model.Match{{PublicUser: model.PublicUser{ID: 3, FirstName: "Chess"}, SharedTags: []string{"chess"}, Overlap: 1}}, nil
+		}}
+	service.NewUser(user.New(nil, bdb, tdb, nil, nil, nil, auth, nil), r.Group("/v1/users"))
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	res, err := http.Get(ts.URL + "/v1/users/discover?limit=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	response := new(matchListResponse)
+	if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, &matchListResponse{
+		Matches: []model.Match{{PublicUser: model.PublicUser{ID: 3, FirstName: "Chess"}, SharedTags: []string{"chess"}, Overlap: 1}},
+	}, response)
+}
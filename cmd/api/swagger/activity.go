@@ -0,0 +1,15 @@
+package swagger
+
+import (
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Activity feed model response
+// swagger:response feedResp
+type swaggFeedResp struct {
+	// in:body
+	Body struct {
+		Items      []model.FeedItem `json:"items"`
+		NextCursor int              `json:"next_cursor,omitempty"`
+	}
+}
@@ -0,0 +1,22 @@
+package swagger
+
+import (
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Authorization explain request
+// swagger:parameters authzExplain
+type swaggAuthzExplainReq struct {
+	// in:body
+	Body request.Explain
+}
+
+// Authorization decision trace response
+// swagger:response authzTraceResp
+type swaggAuthzTraceResp struct {
+	// in:body
+	Body struct {
+		*model.AuthzTrace
+	}
+}
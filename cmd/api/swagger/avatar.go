@@ -0,0 +1,14 @@
+package swagger
+
+import (
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Avatar model response
+// swagger:response avatarResp
+type swaggAvatarResp struct {
+	// in:body
+	Body struct {
+		*model.Avatar
+	}
+}
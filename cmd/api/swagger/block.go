@@ -0,0 +1,32 @@
+package swagger
+
+import (
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Block request
+// swagger:parameters blockCreate
+type swaggBlockCreateReq struct {
+	// in:body
+	Body request.Block
+}
+
+// Block model response
+// swagger:response blockResp
+type swaggBlockResp struct {
+	// in:body
+	Body struct {
+		*model.Block
+	}
+}
+
+// Blocked users model response
+// swagger:response blockListResp
+type swaggBlockListResp struct {
+	// in:body
+	Body struct {
+		Users []model.User `json:"users"`
+		Page  int          `json:"page"`
+	}
+}
@@ -0,0 +1,52 @@
+package swagger
+
+import (
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Friend request
+// swagger:parameters friendRequestCreate
+type swaggFriendRequestCreateReq struct {
+	// in:body
+	Body request.FriendRequest
+}
+
+// Friendship model response
+// swagger:response friendshipResp
+type swaggFriendshipResp struct {
+	// in:body
+	Body struct {
+		*model.Friendship
+	}
+}
+
+// Friends model response
+// swagger:response friendListResp
+type swaggFriendListResp struct {
+	// in:body
+	Body struct {
+		Friends []model.Friend `json:"friends"`
+		Page    int            `json:"page"`
+	}
+}
+
+// Friend requests model response
+// swagger:response friendRequestListResp
+type swaggFriendRequestListResp struct {
+	// in:body
+	Body struct {
+		Requests []model.Friendship `json:"requests"`
+		Page     int                `json:"page"`
+	}
+}
+
+// Friend suggestions model response
+// swagger:response friendSuggestionListResp
+type swaggFriendSuggestionListResp struct {
+	// in:body
+	Body struct {
+		Suggestions []model.FriendSuggestion `json:"suggestions"`
+		Page        int                      `json:"page"`
+	}
+}
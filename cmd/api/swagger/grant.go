@@ -0,0 +1,38 @@
+package swagger
+
+import (
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Role grant request
+// swagger:parameters grantCreate
+type swaggGrantCreateReq struct {
+	// in:body
+	Body request.Grant
+}
+
+// Role delegation request
+// swagger:parameters grantDelegate
+type swaggGrantDelegateReq struct {
+	// in:body
+	Body request.Delegation
+}
+
+// Role grant model response
+// swagger:response grantResp
+type swaggGrantResp struct {
+	// in:body
+	Body struct {
+		*model.RoleGrant
+	}
+}
+
+// Role grants model response
+// swagger:response grantListResp
+type swaggGrantListResp struct {
+	// in:body
+	Body struct {
+		Grants []model.RoleGrant `json:"grants"`
+	}
+}
@@ -0,0 +1,70 @@
+package swagger
+
+import (
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Group request
+// swagger:parameters groupCreate
+type swaggGroupCreateReq struct {
+	// in:body
+	Body request.Group
+}
+
+// Group model response
+// swagger:response groupResp
+type swaggGroupResp struct {
+	// in:body
+	Body struct {
+		*model.Group
+	}
+}
+
+// Groups model response
+// swagger:response groupListResp
+type swaggGroupListResp struct {
+	// in:body
+	Body struct {
+		Groups []model.Group `json:"groups"`
+		Page   int           `json:"page"`
+	}
+}
+
+// Group member model response
+// swagger:response groupMemberResp
+type swaggGroupMemberResp struct {
+	// in:body
+	Body struct {
+		*model.GroupMember
+	}
+}
+
+// Group members model response
+// swagger:response groupMemberListResp
+type swaggGroupMemberListResp struct {
+	// in:body
+	Body struct {
+		Members []model.GroupMember `json:"members"`
+		Page    int                 `json:"page"`
+	}
+}
+
+// Group post model response
+// swagger:response groupPostResp
+type swaggGroupPostResp struct {
+	// in:body
+	Body struct {
+		*model.GroupPost
+	}
+}
+
+// Group posts model response
+// swagger:response groupPostListResp
+type swaggGroupPostListResp struct {
+	// in:body
+	Body struct {
+		Posts []model.GroupPost `json:"posts"`
+		Page  int               `json:"page"`
+	}
+}
@@ -0,0 +1,64 @@
+package swagger
+
+import (
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Meetup request
+// swagger:parameters meetupCreate
+type swaggMeetupCreateReq struct {
+	// in:body
+	Body request.Meetup
+}
+
+// Meetup update request
+// swagger:parameters meetupUpdate
+type swaggMeetupUpdateReq struct {
+	// in:body
+	Body request.UpdateMeetup
+}
+
+// RSVP request
+// swagger:parameters meetupRSVP
+type swaggRSVPReq struct {
+	// in:body
+	Body request.RSVP
+}
+
+// Meetup model response
+// swagger:response meetupResp
+type swaggMeetupResp struct {
+	// in:body
+	Body struct {
+		*model.Meetup
+	}
+}
+
+// Meetups model response
+// swagger:response meetupListResp
+type swaggMeetupListResp struct {
+	// in:body
+	Body struct {
+		Meetups []model.Meetup `json:"meetups"`
+		Page    int            `json:"page"`
+	}
+}
+
+// RSVP model response
+// swagger:response rsvpResp
+type swaggRSVPResp struct {
+	// in:body
+	Body struct {
+		*model.RSVP
+	}
+}
+
+// RSVPs model response
+// swagger:response rsvpListResp
+type swaggRSVPListResp struct {
+	// in:body
+	Body struct {
+		RSVPs []model.RSVP `json:"rsvps"`
+	}
+}
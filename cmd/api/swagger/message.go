@@ -0,0 +1,60 @@
+package swagger
+
+import (
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Conversation request
+// swagger:parameters conversationCreate
+type swaggConversationCreateReq struct {
+	// in:body
+	Body request.Conversation
+}
+
+// Conversation model response
+// swagger:response conversationResp
+type swaggConversationResp struct {
+	// in:body
+	Body struct {
+		*model.Conversation
+	}
+}
+
+// Conversations model response
+// swagger:response conversationListResp
+type swaggConversationListResp struct {
+	// in:body
+	Body struct {
+		Conversations []model.Conversation `json:"conversations"`
+		Page          int                  `json:"page"`
+	}
+}
+
+// Message model response
+// swagger:response messageResp
+type swaggMessageResp struct {
+	// in:body
+	Body struct {
+		*model.Message
+	}
+}
+
+// Messages model response
+// swagger:response messageListResp
+type swaggMessageListResp struct {
+	// in:body
+	Body struct {
+		Messages   []model.Message `json:"messages"`
+		NextCursor int             `json:"next_cursor"`
+	}
+}
+
+// Participant model response
+// swagger:response participantResp
+type swaggParticipantResp struct {
+	// in:body
+	Body struct {
+		*model.Participant
+	}
+}
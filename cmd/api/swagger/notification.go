@@ -0,0 +1,51 @@
+package swagger
+
+import (
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Notification preferences request
+// swagger:parameters notificationPreferencesUpdate
+type swaggNotificationPreferencesUpdateReq struct {
+	// in:body
+	Body request.NotificationPreferences
+}
+
+// Notification model response
+// swagger:response notificationResp
+type swaggNotificationResp struct {
+	// in:body
+	Body struct {
+		*model.Notification
+	}
+}
+
+// Notifications model response
+// swagger:response notificationListResp
+type swaggNotificationListResp struct {
+	// in:body
+	Body struct {
+		Notifications []model.Notification `json:"notifications"`
+		Unread        int                  `json:"unread"`
+		Page          int                  `json:"page"`
+	}
+}
+
+// Marked notifications response
+// swagger:response notificationReadAllResp
+type swaggNotificationReadAllResp struct {
+	// in:body
+	Body struct {
+		Read int `json:"read"`
+	}
+}
+
+// Notification preferences model response
+// swagger:response notificationPreferencesResp
+type swaggNotificationPreferencesResp struct {
+	// in:body
+	Body struct {
+		Preferences []model.NotificationPreference `json:"preferences"`
+	}
+}
@@ -0,0 +1,16 @@
+package swagger
+
+// JSON merge patch of preferences, nesting preferences of a group under its name
+// swagger:parameters updateMyPreferences
+type swaggPreferencesReq struct {
+	// in:body
+	Body map[string]interface{}
+}
+
+// Preferences by their names, nesting preferences of a group under its name,
+// e.g. {"language": "en", "timezone": "UTC", "privacy": {"searchable": true}}
+// swagger:response preferencesResp
+type swaggPreferencesResp struct {
+	// in:body
+	Body map[string]interface{}
+}
@@ -0,0 +1,46 @@
+package swagger
+
+import (
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Erasure request of the current user
+// swagger:parameters erasureRequest
+type swaggErasureRequestReq struct {
+	// in:body
+	Body request.ErasureRequest
+}
+
+// Erasure of a user
+// swagger:parameters erase
+type swaggEraseReq struct {
+	// in:body
+	Body request.Erasure
+}
+
+// Zip archive of JSON files, one for each section of the data export
+// swagger:response dataExportResp
+type swaggDataExportResp struct {
+	// in:body
+	Body []byte
+}
+
+// Erasure model response
+// swagger:response erasureResp
+type swaggErasureResp struct {
+	// in:body
+	Body struct {
+		*model.Erasure
+	}
+}
+
+// Pending erasures model response
+// swagger:response erasureListResp
+type swaggErasureListResp struct {
+	// in:body
+	Body struct {
+		Erasures []model.Erasure `json:"erasures"`
+		Page     int             `json:"page"`
+	}
+}
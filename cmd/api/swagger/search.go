@@ -0,0 +1,15 @@
+package swagger
+
+import (
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Search hits response
+// swagger:response searchResp
+type swaggSearchResp struct {
+	// in:body
+	Body struct {
+		Hits []model.SearchHit `json:"hits"`
+		Page int               `json:"page"`
+	}
+}
@@ -0,0 +1,31 @@
+package swagger
+
+import (
+	"github.com/artistomin/friend4me/cmd/api/request"
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Tag request
+// swagger:parameters tagCreate
+type swaggTagCreateReq struct {
+	// in:body
+	Body request.Tag
+}
+
+// Tag model response
+// swagger:response tagResp
+type swaggTagResp struct {
+	// in:body
+	Body struct {
+		*model.Tag
+	}
+}
+
+// Tags model response
+// swagger:response tagListResp
+type swaggTagListResp struct {
+	// in:body
+	Body struct {
+		Tags []model.Tag `json:"tags"`
+	}
+}
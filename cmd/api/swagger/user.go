@@ -43,5 +43,43 @@ type swaggUserListResponse struct {
 	Body struct {
 		Users []model.User `json:"users"`
 		Page  int          `json:"page"`
+		Next  string       `json:"next,omitempty"`
+		Prev  string       `json:"prev,omitempty"`
+		Total int          `json:"total,omitempty"`
+	}
+}
+
+// Matching users model response
+// swagger:response matchListResp
+type swaggMatchListResponse struct {
+	// in:body
+	Body struct {
+		Matches []model.Match `json:"matches"`
+		Page    int           `json:"page"`
+	}
+}
+
+// User import report response
+// swagger:response importResp
+type swaggImportResponse struct {
+	// in:body
+	Body struct {
+		*model.ImportReport
+	}
+}
+
+// Bulk user operation request
+// swagger:parameters userBulk
+type swaggBulkReq struct {
+	// in:body
+	Body request.Bulk
+}
+
+// Bulk user operation response
+// swagger:response bulkResp
+type swaggBulkResponse struct {
+	// in:body
+	Body struct {
+		Results []model.BulkResult `json:"results"`
 	}
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/artistomin/friend4me/internal"
 
 	"github.com/artistomin/friend4me/internal/auth"
+	"github.com/artistomin/friend4me/internal/platform/postgres"
 	"github.com/go-pg/pg"
 )
 
@@ -29,7 +30,11 @@ func main() {
 	db := pg.Connect(u)
 	_, err = db.Exec("SELECT 1")
 	checkErr(err)
-	createSchema(db, &model.Company{}, &model.Location{}, &model.Role{}, &model.User{})
+	createSchema(db, &model.Company{}, &model.Location{}, &model.Role{}, &model.User{}, &model.RoleGrant{}, &model.Friendship{}, &model.Block{}, &model.MutualFriends{}, &model.Conversation{}, &model.Participant{}, &model.Message{}, &model.Notification{}, &model.NotificationPreference{}, &model.Activity{}, &model.Meetup{}, &model.RSVP{}, &model.Tag{}, &model.UserTag{}, &model.Group{}, &model.GroupMember{}, &model.GroupPost{}, &model.Erasure{}, &model.CompanyPreference{}, &model.UserPreference{})
+	checkErr(pgsql.CreateSearchIndex(db))
+	checkErr(pgsql.CreateEventLog(db))
+	checkErr(pgsql.CreateUniqueIndexes(db))
+	checkErr(pgsql.CreateSuggestionIndexes(db))
 
 	for _, v := range queries[0 : len(queries)-1] {
 		_, err := db.Exec(v)
@@ -38,6 +43,8 @@ func main() {
 	userInsert := `INSERT INTO public.users VALUES (1, now(),now(), NULL, 'Admin', 'Admin', 'admin', '%s', 'johndoe@mail.com', NULL, NULL, NULL, NULL, true, 1, 1, 1);`
 	_, err = db.Exec(fmt.Sprintf(userInsert, auth.HashPassword("admin")))
 	checkErr(err)
+	// Policies are installed after seeding, as the seed is written by a connection scoped to no company
+	checkErr(pgsql.EnableRLS(db))
 }
 
 func checkErr(err error) {
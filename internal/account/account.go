@@ -1,6 +1,7 @@
 package account
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo"
@@ -11,36 +12,57 @@ import (
 )
 
 // New creates new user application service
-func New(adb model.AccountDB, udb model.UserDB, rbac model.RBACService) *Service {
+func New(adb model.AccountDB, udb model.UserDB, rbac model.RBACService, notifier model.Notifier, activity model.ActivityLogger) *Service {
 	return &Service{
-		adb:  adb,
-		udb:  udb,
-		rbac: rbac,
+		adb:      adb,
+		udb:      udb,
+		rbac:     rbac,
+		notifier: notifier,
+		activity: activity,
 	}
 }
 
 // Service represents account application service
 type Service struct {
-	adb  model.AccountDB
-	udb  model.UserDB
-	rbac model.RBACService
+	adb      model.AccountDB
+	udb      model.UserDB
+	rbac     model.RBACService
+	notifier model.Notifier
+	activity model.ActivityLogger
 }
 
-// Create creates a new user account
+// Create creates a new user account, recording that the user joined its location
 func (s *Service) Create(c echo.Context, req model.User) (*model.User, error) {
 	if err := s.rbac.AccountCreate(c, req.RoleID, req.CompanyID, req.LocationID); err != nil {
 		return nil, err
 	}
 	req.Password = auth.HashPassword(req.Password)
-	return s.adb.Create(req)
+	u, err := s.adb.Create(model.Conn(c), req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.joined(c, u); err != nil {
+		return nil, err
+	}
+	return u, nil
 }
 
-// ChangePassword changes user's password
+// joined records that the user joined its location
+func (s *Service) joined(c echo.Context, u *model.User) error {
+	return s.activity.Log(c, model.Activity{
+		ActorID:   u.ID,
+		CompanyID: u.CompanyID,
+		Type:      model.ActivityJoinedLocation,
+		ObjectID:  u.LocationID,
+	})
+}
+
+// ChangePassword changes user's password, notifying the user
 func (s *Service) ChangePassword(c echo.Context, oldPass, newPass string, id int) error {
 	if err := s.rbac.EnforceUser(c, id); err != nil {
 		return err
 	}
-	u, err := s.udb.View(id)
+	u, err := s.udb.View(model.Conn(c), id)
 	if err != nil {
 		return err
 	}
@@ -48,5 +70,72 @@ func (s *Service) ChangePassword(c echo.Context, oldPass, newPass string, id int
 		return echo.NewHTTPError(http.StatusBadRequest, "old password is not correct")
 	}
 	u.Password = auth.HashPassword(newPass)
-	return s.adb.ChangePassword(u)
+	if err := s.adb.ChangePassword(model.Conn(c), u); err != nil {
+		return err
+	}
+	return s.notifier.Notify(c, model.Notification{
+		UserID:    u.ID,
+		CompanyID: u.CompanyID,
+		Type:      model.NotificationPasswordChanged,
+	})
+}
+
+// Import creates user accounts from rows of an import file, checking each row as if its account was created alone.
+// Rows failing validation or checks are reported, and so are users that could not be stored, e.g. because of taken usernames.
+// Dry runs keep no users, and atomic imports keep them only if all rows succeed.
+// Imports keeping no users only look for taken usernames and emails, without hashing passwords
+func (s *Service) Import(c echo.Context, rows []model.ImportRow, dryRun, atomic bool) (*model.ImportReport, error) {
+	report := &model.ImportReport{DryRun: dryRun, Atomic: atomic, Rows: len(rows)}
+	var valid []model.ImportRow
+	for _, row := range rows {
+		if row.Error != "" {
+			report.Fail(row, row.Error)
+			continue
+		}
+		if err := s.rbac.AccountCreate(c, row.User.RoleID, row.User.CompanyID, row.User.LocationID); err != nil {
+			report.Fail(row, "not allowed to create an account with this role, company and location")
+			continue
+		}
+		valid = append(valid, row)
+	}
+	if len(valid) == 0 {
+		return report, nil
+	}
+	mode := report.Mode()
+	users := make([]model.User, len(valid))
+	for i, row := range valid {
+		users[i] = row.User
+		users[i].Password = ""
+		if mode != model.ImportDryRun {
+			users[i].Password = auth.HashPassword(row.User.Password)
+		}
+	}
+	errs, err := s.adb.Import(model.Conn(c), users, mode)
+	if err != nil {
+		return nil, err
+	}
+	for i, err := range errs {
+		if err == nil {
+			report.Imported++
+			continue
+		}
+		msg := "account could not be created"
+		if he, ok := err.(*echo.HTTPError); ok {
+			msg = fmt.Sprint(he.Message)
+		}
+		report.Fail(valid[i], msg)
+	}
+	report.Committed = mode == model.ImportBestEffort || (mode == model.ImportAtomic && report.Imported == len(rows))
+	if !report.Committed {
+		return report, nil
+	}
+	for i := range users {
+		if errs[i] != nil {
+			continue
+		}
+		if err := s.joined(c, &users[i]); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
 }
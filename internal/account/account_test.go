@@ -3,6 +3,8 @@ package account_test
 import (
 	"testing"
 
+	"github.com/go-pg/pg/orm"
+
 	"github.com/labstack/echo"
 
 	"github.com/artistomin/friend4me/internal/mock"
@@ -51,7 +53,7 @@ func TestCreate(t *testing.T) {
 				Password:  "Thranduil8822",
 			}},
 			adb: &mockdb.Account{
-				CreateFn: func(u model.User) (*model.User, error) {
+				CreateFn: func(db orm.DB, u model.User) (*model.User, error) {
 					u.CreatedAt = mock.TestTime(2000)
 					u.UpdatedAt = mock.TestTime(2000)
 					u.Base.ID = 1
@@ -75,12 +77,19 @@ func TestCreate(t *testing.T) {
 			}}}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := account.New(tt.adb, tt.udb, tt.rbac)
+			var logged []model.Activity
+			activity := &mock.ActivityLogger{
+				LogFn: func(c echo.Context, a model.Activity) error {
+					logged = append(logged, a)
+					return nil
+				}}
+			s := account.New(tt.adb, tt.udb, tt.rbac, nil, activity)
 			usr, err := s.Create(tt.args.c, tt.args.req)
 			assert.Equal(t, tt.wantErr, err != nil)
 			if tt.wantData != nil {
 				tt.wantData.Password = usr.Password
 				assert.Equal(t, tt.wantData, usr)
+				assert.Equal(t, []model.Activity{{ActorID: 1, Type: model.ActivityJoinedLocation}}, logged)
 			}
 		})
 	}
@@ -119,7 +128,7 @@ func TestChangePassword(t *testing.T) {
 					return nil
 				}},
 			udb: &mockdb.User{
-				ViewFn: func(id int) (*model.User, error) {
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
 					if id != 1 {
 						return nil, nil
 					}
@@ -136,7 +145,7 @@ func TestChangePassword(t *testing.T) {
 				}},
 			wantErr: true,
 			udb: &mockdb.User{
-				ViewFn: func(id int) (*model.User, error) {
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
 					return &model.User{
 						Password: "IncorrectHashedPassword",
 					}, nil
@@ -151,15 +160,17 @@ func TestChangePassword(t *testing.T) {
 					return nil
 				}},
 			udb: &mockdb.User{
-				ViewFn: func(id int) (*model.User, error) {
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
 					return &model.User{
-						Password: "$2a$10$udRBroNGBeOYwSWCVzf6Lulg98uAoRCIi4t75VZg84xgw6EJbFNsG",
+						Base:      model.Base{ID: id},
+						CompanyID: 1,
+						Password:  "$2a$10$udRBroNGBeOYwSWCVzf6Lulg98uAoRCIi4t75VZg84xgw6EJbFNsG",
 					}, nil
 				},
 			},
 			adb: &mockdb.Account{
 				// Check whether password was hashed correctly
-				ChangePasswordFn: func(usr *model.User) error {
+				ChangePasswordFn: func(db orm.DB, usr *model.User) error {
 					return nil
 				},
 			},
@@ -167,9 +178,113 @@ func TestChangePassword(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := account.New(tt.adb, tt.udb, tt.rbac)
+			var sent []model.Notification
+			notifier := &mock.Notifier{
+				NotifyFn: func(c echo.Context, n model.Notification) error {
+					sent = append(sent, n)
+					return nil
+				}}
+			s := account.New(tt.adb, tt.udb, tt.rbac, notifier, nil)
 			err := s.ChangePassword(tt.args.c, tt.args.oldpass, tt.args.newpass, tt.args.id)
 			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantErr {
+				assert.Empty(t, sent)
+				return
+			}
+			assert.Equal(t, []model.Notification{{UserID: 1, CompanyID: 1, Type: model.NotificationPasswordChanged}}, sent)
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	rows := []model.ImportRow{
+		{Row: 1, User: model.User{Username: "anna", Password: "Thranduil8822", RoleID: 5, CompanyID: 1, LocationID: 1}},
+		{Row: 2, User: model.User{Username: "bob"}, Error: "Email is required"},
+		{Row: 3, User: model.User{Username: "carl", Password: "Thranduil8822", RoleID: 5, CompanyID: 2, LocationID: 1}},
+		{Row: 4, User: model.User{Username: "johndoe", Password: "Thranduil8822", RoleID: 5, CompanyID: 1, LocationID: 1}},
+	}
+	cases := []struct {
+		name       string
+		rows       []model.ImportRow
+		dryRun     bool
+		atomic     bool
+		wantMode   model.ImportMode
+		wantReport *model.ImportReport
+		wantLogged int
+	}{
+		{
+			name:     "Best effort",
+			rows:     rows,
+			wantMode: model.ImportBestEffort,
+			wantReport: &model.ImportReport{Committed: true, Rows: 4, Imported: 1, Errors: []model.ImportError{
+				{Row: 2, Username: "bob", Message: "Email is required"},
+				{Row: 3, Username: "carl", Message: "not allowed to create an account with this role, company and location"},
+				{Row: 4, Username: "johndoe", Message: "Username or email already exists."},
+			}},
+			wantLogged: 1,
+		},
+		{
+			name:     "Atomic with invalid rows",
+			rows:     rows[:2],
+			atomic:   true,
+			wantMode: model.ImportDryRun,
+			wantReport: &model.ImportReport{Atomic: true, Rows: 2, Imported: 1, Errors: []model.ImportError{
+				{Row: 2, Username: "bob", Message: "Email is required"},
+			}},
+		},
+		{
+			name:       "Atomic",
+			rows:       rows[:1],
+			atomic:     true,
+			wantMode:   model.ImportAtomic,
+			wantReport: &model.ImportReport{Atomic: true, Committed: true, Rows: 1, Imported: 1},
+			wantLogged: 1,
+		},
+		{
+			name:       "Dry run",
+			rows:       rows[:1],
+			dryRun:     true,
+			wantMode:   model.ImportDryRun,
+			wantReport: &model.ImportReport{DryRun: true, Rows: 1, Imported: 1},
+		},
+	}
+	rbac := &mock.RBAC{
+		AccountCreateFn: func(c echo.Context, roleID, companyID, locationID int) error {
+			if companyID != 1 {
+				return echo.ErrForbidden
+			}
+			return nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			adb := &mockdb.Account{
+				ImportFn: func(db orm.DB, users []model.User, mode model.ImportMode) ([]error, error) {
+					if mode != tt.wantMode {
+						return nil, model.ErrGeneric
+					}
+					errs := make([]error, len(users))
+					for i := range users {
+						if users[i].Password == "Thranduil8822" || (mode == model.ImportDryRun) != (users[i].Password == "") {
+							return nil, model.ErrGeneric
+						}
+						if users[i].Username == "johndoe" {
+							errs[i] = echo.NewHTTPError(500, "Username or email already exists.")
+							continue
+						}
+						users[i].ID = i + 10
+					}
+					return errs, nil
+				}}
+			var logged []model.Activity
+			activity := &mock.ActivityLogger{
+				LogFn: func(c echo.Context, a model.Activity) error {
+					logged = append(logged, a)
+					return nil
+				}}
+			report, err := account.New(adb, nil, rbac, nil, activity).Import(nil, tt.rows, tt.dryRun, tt.atomic)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantReport, report)
+			assert.Equal(t, tt.wantLogged, len(logged))
 		})
 	}
 }
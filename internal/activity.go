@@ -0,0 +1,95 @@
+package model
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+)
+
+// ActivityType represents kind of action shown on friends' feeds
+type ActivityType string
+
+const (
+	// ActivityJoinedLocation means user joined a location. Object is the location
+	ActivityJoinedLocation ActivityType = "joined_location"
+
+	// ActivityBecameFriends means user became friends with another user. Object is the other user
+	ActivityBecameFriends ActivityType = "became_friends"
+
+	// ActivityUpdatedProfile means user updated its profile
+	ActivityUpdatedProfile ActivityType = "updated_profile"
+)
+
+// FeedWindow is the period within which repeated activities of the same user and type are aggregated
+const FeedWindow = 24 * time.Hour
+
+// Activity represents action performed by a user, shown on its friends' feeds
+type Activity struct {
+	Base
+	ActorID   int                    `json:"actor_id"`
+	CompanyID int                    `json:"-"`
+	Type      ActivityType           `json:"type"`
+	ObjectID  int                    `json:"object_id,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// FeedItem represents one or more activities of a user of the same type, newest first
+type FeedItem struct {
+	ID        int                    `json:"id"`
+	ActorID   int                    `json:"actor_id"`
+	Type      ActivityType           `json:"type"`
+	ObjectIDs []int                  `json:"object_ids,omitempty"`
+	Count     int                    `json:"count"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// AggregateFeed groups activities, ordered newest first, into feed items.
+// Activities of the same user and type are aggregated into the item of the newest one,
+// if they happened within the window before it
+func AggregateFeed(activities []Activity, window time.Duration) []FeedItem {
+	type key struct {
+		actor int
+		typ   ActivityType
+	}
+	var items []FeedItem
+	open := make(map[key]int)
+	for _, a := range activities {
+		k := key{a.ActorID, a.Type}
+		if i, ok := open[k]; ok && items[i].CreatedAt.Sub(a.CreatedAt) <= window {
+			items[i].Count++
+			if a.ObjectID != 0 {
+				items[i].ObjectIDs = append(items[i].ObjectIDs, a.ObjectID)
+			}
+			continue
+		}
+		item := FeedItem{ID: a.ID, ActorID: a.ActorID, Type: a.Type, Count: 1, Data: a.Data, CreatedAt: a.CreatedAt}
+		if a.ObjectID != 0 {
+			item.ObjectIDs = []int{a.ObjectID}
+		}
+		open[k] = len(items)
+		items = append(items, item)
+	}
+	return items
+}
+
+// FeedCursor holds cursor pagination data for feeds.
+// Activities older than Before are returned, or the newest ones if Before is zero
+type FeedCursor struct {
+	Before int
+	Limit  int
+}
+
+// ActivityDB represents activity database interface (repository).
+// Feed returns activities visible to the user, newest first
+type ActivityDB interface {
+	Create(orm.DB, Activity) (*Activity, error)
+	Feed(orm.DB, int, *FeedCursor) ([]Activity, error)
+}
+
+// ActivityLogger records activities of users.
+// Services call it when users do something their friends should see
+type ActivityLogger interface {
+	Log(echo.Context, Activity) error
+}
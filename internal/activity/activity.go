@@ -0,0 +1,39 @@
+// Package activity contains activity feed application services
+package activity
+
+import (
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// New creates new activity feed application service
+func New(adb model.ActivityDB, auth model.AuthService) *Service {
+	return &Service{adb: adb, auth: auth}
+}
+
+// Service represents activity feed application service
+type Service struct {
+	adb  model.ActivityDB
+	auth model.AuthService
+}
+
+// Log records an activity to be shown on friends' feeds of its actor
+func (s *Service) Log(c echo.Context, a model.Activity) error {
+	_, err := s.adb.Create(model.Conn(c), a)
+	return err
+}
+
+// Feed returns activities of requesting user's friends, newest first, with repeated activities aggregated.
+// Cursor for the next page is returned if there may be older activities
+func (s *Service) Feed(c echo.Context, cur *model.FeedCursor) ([]model.FeedItem, int, error) {
+	acts, err := s.adb.Feed(model.Conn(c), s.auth.User(c).ID, cur)
+	if err != nil {
+		return nil, 0, err
+	}
+	var next int
+	if len(acts) == cur.Limit {
+		next = acts[len(acts)-1].ID
+	}
+	return model.AggregateFeed(acts, model.FeedWindow), next, nil
+}
@@ -0,0 +1,90 @@
+package activity_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/activity"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+func authUser(id int) *mock.Auth {
+	return &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: id, CompanyID: 1}
+		}}
+}
+
+func TestLog(t *testing.T) {
+	var created []model.Activity
+	adb := &mockdb.Activity{
+		CreateFn: func(db orm.DB, a model.Activity) (*model.Activity, error) {
+			created = append(created, a)
+			return &a, nil
+		}}
+	s := activity.New(adb, authUser(1))
+	assert.Nil(t, s.Log(nil, model.Activity{ActorID: 1, CompanyID: 1, Type: model.ActivityUpdatedProfile}))
+	assert.Equal(t, []model.Activity{{ActorID: 1, CompanyID: 1, Type: model.ActivityUpdatedProfile}}, created)
+}
+
+func TestFeed(t *testing.T) {
+	now := mock.TestTime(2018)
+	acts := []model.Activity{
+		{Base: model.Base{ID: 3, CreatedAt: now}, ActorID: 2, Type: model.ActivityUpdatedProfile},
+		{Base: model.Base{ID: 2, CreatedAt: now}, ActorID: 2, Type: model.ActivityUpdatedProfile},
+	}
+	cases := []struct {
+		name     string
+		limit    int
+		wantErr  bool
+		wantData []model.FeedItem
+		wantNext int
+		adb      *mockdb.Activity
+	}{
+		{
+			name:    "Fail on feed",
+			limit:   2,
+			wantErr: true,
+			adb: &mockdb.Activity{
+				FeedFn: func(orm.DB, int, *model.FeedCursor) ([]model.Activity, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name:     "Full page",
+			limit:    2,
+			wantData: []model.FeedItem{{ID: 3, ActorID: 2, Type: model.ActivityUpdatedProfile, Count: 2, CreatedAt: now}},
+			wantNext: 2,
+		},
+		{
+			name:     "Last page",
+			limit:    10,
+			wantData: []model.FeedItem{{ID: 3, ActorID: 2, Type: model.ActivityUpdatedProfile, Count: 2, CreatedAt: now}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.adb == nil {
+				tt.adb = &mockdb.Activity{
+					FeedFn: func(db orm.DB, id int, cur *model.FeedCursor) ([]model.Activity, error) {
+						if id != 1 {
+							return nil, model.ErrGeneric
+						}
+						return acts, nil
+					}}
+			}
+			s := activity.New(tt.adb, authUser(1))
+			items, next, err := s.Feed(nil, &model.FeedCursor{Limit: tt.limit})
+			assert.Equal(t, tt.wantData, items)
+			assert.Equal(t, tt.wantNext, next)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestAggregateFeed(t *testing.T) {
+	now := mock.TestTime(2018)
+	at := func(d time.Duration) model.Base {
+		return model.Base{CreatedAt: now.Add(-d)}
+	}
+	activity := func(id, actor int, typ model.ActivityType, object int, ago time.Duration) model.Activity {
+		a := model.Activity{Base: at(ago), ActorID: actor, Type: typ, ObjectID: object}
+		a.ID = id
+		return a
+	}
+	activities := []model.Activity{
+		activity(9, 1, model.ActivityBecameFriends, 5, 0),
+		activity(8, 2, model.ActivityUpdatedProfile, 0, time.Hour),
+		activity(7, 1, model.ActivityBecameFriends, 6, 2*time.Hour),
+		activity(6, 2, model.ActivityUpdatedProfile, 0, 3*time.Hour),
+		activity(5, 1, model.ActivityJoinedLocation, 3, 4*time.Hour),
+		activity(4, 1, model.ActivityBecameFriends, 7, 48*time.Hour),
+	}
+	assert.Equal(t, []model.FeedItem{
+		{ID: 9, ActorID: 1, Type: model.ActivityBecameFriends, ObjectIDs: []int{5, 6}, Count: 2, CreatedAt: now},
+		{ID: 8, ActorID: 2, Type: model.ActivityUpdatedProfile, Count: 2, CreatedAt: now.Add(-time.Hour)},
+		{ID: 5, ActorID: 1, Type: model.ActivityJoinedLocation, ObjectIDs: []int{3}, Count: 1, CreatedAt: now.Add(-4 * time.Hour)},
+		{ID: 4, ActorID: 1, Type: model.ActivityBecameFriends, ObjectIDs: []int{7}, Count: 1, CreatedAt: now.Add(-48 * time.Hour)},
+	}, model.AggregateFeed(activities, model.FeedWindow))
+	assert.Nil(t, model.AggregateFeed(nil, model.FeedWindow))
+}
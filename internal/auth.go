@@ -30,4 +30,6 @@ type RBACService interface {
 	EnforceLocation(echo.Context, int) error
 	AccountCreate(echo.Context, int, int, int) error
 	IsLowerRole(echo.Context, AccessRole) error
+	EnforceParticipant(echo.Context, []int) error
+	EnforceGroup(echo.Context, int, GroupRole, GroupRole) error
 }
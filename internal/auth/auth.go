@@ -33,7 +33,7 @@ type JWT interface {
 
 // Authenticate tries to authenticate the user provided by username and password
 func (s *Service) Authenticate(c echo.Context, user, pass string) (*model.AuthToken, error) {
-	u, err := s.udb.FindByUsername(user)
+	u, err := s.udb.FindByUsername(model.Conn(c), user)
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +51,7 @@ func (s *Service) Authenticate(c echo.Context, user, pass string) (*model.AuthTo
 
 	u.UpdateLastLogin()
 	u.Token = xid.New().String()
-	_, err = s.udb.Update(u)
+	_, err = s.udb.Update(model.Conn(c), u)
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +61,7 @@ func (s *Service) Authenticate(c echo.Context, user, pass string) (*model.AuthTo
 
 // Refresh refreshes jwt token and puts new claims inside
 func (s *Service) Refresh(c echo.Context, token string) (*model.RefreshToken, error) {
-	user, err := s.udb.FindByToken(token)
+	user, err := s.udb.FindByToken(model.Conn(c), token)
 	if err != nil {
 		return nil, err
 	}
@@ -75,7 +75,7 @@ func (s *Service) Refresh(c echo.Context, token string) (*model.RefreshToken, er
 // Me returns info about currently logged user
 func (s *Service) Me(c echo.Context) (*model.User, error) {
 	au := s.User(c)
-	return s.udb.View(au.ID)
+	return s.udb.View(model.Conn(c), au.ID)
 }
 
 // User returns user data stored in jwt token
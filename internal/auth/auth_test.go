@@ -4,6 +4,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-pg/pg/orm"
+
 	"github.com/artistomin/friend4me/internal"
 	"github.com/artistomin/friend4me/internal/auth"
 	"github.com/artistomin/friend4me/internal/mock"
@@ -31,7 +33,7 @@ func TestAuthenticate(t *testing.T) {
 			args:    args{user: "juzernejm"},
 			wantErr: true,
 			udb: &mockdb.User{
-				FindByUsernameFn: func(user string) (*model.User, error) {
+				FindByUsernameFn: func(db orm.DB, user string) (*model.User, error) {
 					return nil, model.ErrGeneric
 				},
 			},
@@ -41,7 +43,7 @@ func TestAuthenticate(t *testing.T) {
 			args:    args{user: "juzernejm", pass: "notHashedPassword"},
 			wantErr: true,
 			udb: &mockdb.User{
-				FindByUsernameFn: func(user string) (*model.User, error) {
+				FindByUsernameFn: func(db orm.DB, user string) (*model.User, error) {
 					return &model.User{
 						Username: user,
 						Password: "HashedPassword",
@@ -54,7 +56,7 @@ func TestAuthenticate(t *testing.T) {
 			args:    args{user: "juzernejm", pass: "pass"},
 			wantErr: true,
 			udb: &mockdb.User{
-				FindByUsernameFn: func(user string) (*model.User, error) {
+				FindByUsernameFn: func(db orm.DB, user string) (*model.User, error) {
 					return &model.User{
 						Username: user,
 						Password: auth.HashPassword("pass"),
@@ -68,7 +70,7 @@ func TestAuthenticate(t *testing.T) {
 			args:    args{user: "juzernejm", pass: "pass"},
 			wantErr: true,
 			udb: &mockdb.User{
-				FindByUsernameFn: func(user string) (*model.User, error) {
+				FindByUsernameFn: func(db orm.DB, user string) (*model.User, error) {
 					return &model.User{
 						Username: user,
 						Password: auth.HashPassword("pass"),
@@ -87,14 +89,14 @@ func TestAuthenticate(t *testing.T) {
 			args:    args{user: "juzernejm", pass: "pass"},
 			wantErr: true,
 			udb: &mockdb.User{
-				FindByUsernameFn: func(user string) (*model.User, error) {
+				FindByUsernameFn: func(db orm.DB, user string) (*model.User, error) {
 					return &model.User{
 						Username: user,
 						Password: auth.HashPassword("pass"),
 						Active:   true,
 					}, nil
 				},
-				UpdateFn: func(u *model.User) (*model.User, error) {
+				UpdateFn: func(db orm.DB, u *model.User) (*model.User, error) {
 					return nil, model.ErrGeneric
 				},
 			},
@@ -108,14 +110,14 @@ func TestAuthenticate(t *testing.T) {
 			name: "Success",
 			args: args{user: "juzernejm", pass: "pass"},
 			udb: &mockdb.User{
-				FindByUsernameFn: func(user string) (*model.User, error) {
+				FindByUsernameFn: func(db orm.DB, user string) (*model.User, error) {
 					return &model.User{
 						Username: user,
 						Password: auth.HashPassword("pass"),
 						Active:   true,
 					}, nil
 				},
-				UpdateFn: func(u *model.User) (*model.User, error) {
+				UpdateFn: func(db orm.DB, u *model.User) (*model.User, error) {
 					return u, nil
 				},
 			},
@@ -160,7 +162,7 @@ func TestRefresh(t *testing.T) {
 			args:    args{token: "refreshtoken"},
 			wantErr: true,
 			udb: &mockdb.User{
-				FindByTokenFn: func(token string) (*model.User, error) {
+				FindByTokenFn: func(db orm.DB, token string) (*model.User, error) {
 					return nil, model.ErrGeneric
 				},
 			},
@@ -170,7 +172,7 @@ func TestRefresh(t *testing.T) {
 			args:    args{token: "refreshtoken"},
 			wantErr: true,
 			udb: &mockdb.User{
-				FindByTokenFn: func(token string) (*model.User, error) {
+				FindByTokenFn: func(db orm.DB, token string) (*model.User, error) {
 					return &model.User{
 						Username: "username",
 						Password: "password",
@@ -189,7 +191,7 @@ func TestRefresh(t *testing.T) {
 			name: "Success",
 			args: args{token: "refreshtoken"},
 			udb: &mockdb.User{
-				FindByTokenFn: func(token string) (*model.User, error) {
+				FindByTokenFn: func(db orm.DB, token string) (*model.User, error) {
 					return &model.User{
 						Username: "username",
 						Password: "password",
@@ -263,7 +265,7 @@ func TestMe(t *testing.T) {
 				"id", "company_id", "location_id", "username", "email", "role"},
 				9, 15, 52, "ribice", "ribice@gmail.com", int8(1)),
 			udb: &mockdb.User{
-				ViewFn: func(id int) (*model.User, error) {
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
 					return &model.User{
 						Base: model.Base{
 							ID:        id,
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AuthzDecision represents a single check made by RBAC while evaluating an action
+type AuthzDecision struct {
+	Check    string     `json:"check"`
+	Role     AccessRole `json:"role"`
+	Required AccessRole `json:"required_level,omitempty"`
+	Scope    string     `json:"scope,omitempty"`
+	Have     int        `json:"have,omitempty"`
+	Want     int        `json:"want,omitempty"`
+	Allowed  bool       `json:"allowed"`
+}
+
+// String returns human readable reason of the decision
+func (d AuthzDecision) String() string {
+	outcome := "denied"
+	if d.Allowed {
+		outcome = "allowed"
+	}
+	if d.Scope != "" {
+		return fmt.Sprintf("%s %s(%d vs %d) %s", d.Check, d.Scope, d.Have, d.Want, outcome)
+	}
+	return fmt.Sprintf("%s(role %d, required %d) %s", d.Check, d.Role, d.Required, outcome)
+}
+
+// AuthzTrace holds all decisions made while evaluating a single action
+type AuthzTrace struct {
+	Action    string          `json:"action"`
+	Subject   int             `json:"subject_id"`
+	GrantID   int             `json:"grant_id,omitempty"`
+	Allowed   bool            `json:"allowed"`
+	Decisions []AuthzDecision `json:"decisions"`
+}
+
+// String returns human readable summary of the trace
+func (t *AuthzTrace) String() string {
+	steps := make([]string, len(t.Decisions))
+	for i, d := range t.Decisions {
+		steps[i] = d.String()
+	}
+	return fmt.Sprintf("%s by user %d allowed=%t [%s]", t.Action, t.Subject, t.Allowed, strings.Join(steps, "; "))
+}
+
+// AuthzResource represents the resource an action is evaluated against
+type AuthzResource struct {
+	ID           int        `json:"id"`
+	Role         AccessRole `json:"role"`
+	CompanyID    int        `json:"company_id"`
+	LocationID   int        `json:"location_id"`
+	Participants []int      `json:"participants"`
+
+	GroupRole         GroupRole `json:"group_role,omitempty"`
+	RequiredGroupRole GroupRole `json:"required_group_role,omitempty"`
+}
@@ -0,0 +1,22 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+func TestAuthzTraceString(t *testing.T) {
+	trace := &model.AuthzTrace{
+		Action:  "enforce_company",
+		Subject: 5,
+		Decisions: []model.AuthzDecision{
+			{Check: "role", Role: model.CompanyAdminRole, Required: model.CompanyAdminRole, Allowed: true},
+			{Check: "scope", Role: model.CompanyAdminRole, Scope: "company", Have: 2, Want: 3},
+		},
+	}
+	want := "enforce_company by user 5 allowed=false [role(role 3, required 3) allowed; scope company(2 vs 3) denied]"
+	if got := trace.String(); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
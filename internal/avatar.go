@@ -0,0 +1,45 @@
+package model
+
+import "fmt"
+
+// AvatarSize represents a size avatars are stored in, as width and height of a square thumbnail in pixels
+type AvatarSize struct {
+	Name   string
+	Pixels int
+}
+
+// AvatarSizes lists sizes avatars are stored in, largest first
+var AvatarSizes = []AvatarSize{
+	{Name: "large", Pixels: 256},
+	{Name: "medium", Pixels: 128},
+	{Name: "small", Pixels: 64},
+}
+
+// Avatar represents profile picture of a user, with URLs of its thumbnails by names of AvatarSizes.
+// ID names the upload, so that a new avatar never shows up under URLs of the previous one cached by clients
+type Avatar struct {
+	ID   string            `json:"id"`
+	URLs map[string]string `json:"urls"`
+}
+
+// AvatarKey returns storage key of user's avatar thumbnail in the size
+func AvatarKey(userID int, id, size string) string {
+	return fmt.Sprintf("avatars/%d/%s/%s.jpg", userID, id, size)
+}
+
+// Keys returns storage keys of all thumbnails of user's avatar
+func (a *Avatar) Keys(userID int) []string {
+	keys := make([]string, len(AvatarSizes))
+	for i, s := range AvatarSizes {
+		keys[i] = AvatarKey(userID, a.ID, s.Name)
+	}
+	return keys
+}
+
+// Storage represents file storage, such as a local directory or an S3 bucket.
+// Keys are slash separated paths, and URL returns URL the file stored under the key is served at
+type Storage interface {
+	Put(key, contentType string, data []byte) error
+	Delete(key string) error
+	URL(key string) string
+}
@@ -0,0 +1,90 @@
+// Package avatar contains profile picture application services
+package avatar
+
+import (
+	"github.com/labstack/echo"
+	"github.com/rs/xid"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// New creates new avatar application service
+func New(udb model.UserDB, st model.Storage, rbac model.RBACService) *Service {
+	return &Service{udb: udb, st: st, rbac: rbac}
+}
+
+// Service represents avatar application service
+type Service struct {
+	udb  model.UserDB
+	st   model.Storage
+	rbac model.RBACService
+}
+
+// Upload replaces user's avatar with thumbnails of the image.
+// Thumbnails of the previous avatar are deleted once the user referring to the new one commits
+func (s *Service) Upload(c echo.Context, userID int, data []byte) (*model.Avatar, error) {
+	if err := s.rbac.EnforceUser(c, userID); err != nil {
+		return nil, err
+	}
+	u, err := s.udb.View(model.Conn(c), userID)
+	if err != nil {
+		return nil, err
+	}
+	thumbs, err := Thumbnails(data)
+	if err != nil {
+		return nil, err
+	}
+	a := &model.Avatar{ID: xid.New().String(), URLs: make(map[string]string, len(thumbs))}
+	for _, size := range model.AvatarSizes {
+		key := model.AvatarKey(u.ID, a.ID, size.Name)
+		if err := s.st.Put(key, "image/jpeg", thumbs[size.Name]); err != nil {
+			Remove(s.st, u.ID, a)
+			return nil, err
+		}
+		a.URLs[size.Name] = s.st.URL(key)
+	}
+	prev := u.Avatar
+	u.Avatar = a
+	if _, err := s.udb.Update(model.Conn(c), u); err != nil {
+		Remove(s.st, u.ID, a)
+		return nil, err
+	}
+	// Thumbnails left behind by a failed deletion are not referred to anymore, so the upload still succeeds
+	model.AfterCommit(c, func() error { return Remove(s.st, u.ID, prev) })
+	return a, nil
+}
+
+// Delete removes user's avatar, deleting its thumbnails once the user without avatar commits
+func (s *Service) Delete(c echo.Context, userID int) error {
+	if err := s.rbac.EnforceUser(c, userID); err != nil {
+		return err
+	}
+	u, err := s.udb.View(model.Conn(c), userID)
+	if err != nil {
+		return err
+	}
+	if u.Avatar == nil {
+		return nil
+	}
+	prev := u.Avatar
+	u.Avatar = nil
+	if _, err := s.udb.Update(model.Conn(c), u); err != nil {
+		return err
+	}
+	return model.AfterCommit(c, func() error { return Remove(s.st, u.ID, prev) })
+}
+
+// Remove deletes all thumbnails of user's avatar from the storage, returning the first failure.
+// Users without avatar have nothing to remove
+func Remove(st model.Storage, userID int, a *model.Avatar) error {
+	if a == nil {
+		return nil
+	}
+	var first error
+	for _, key := range a.Keys(userID) {
+		if err := st.Delete(key); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,205 @@
+package avatar_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/avatar"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+// memStorage returns storage mock keeping files in the map
+func memStorage(files map[string][]byte) *mock.Storage {
+	return &mock.Storage{
+		PutFn: func(key, contentType string, data []byte) error {
+			if contentType != "image/jpeg" {
+				return model.ErrGeneric
+			}
+			files[key] = data
+			return nil
+		},
+		DeleteFn: func(key string) error {
+			delete(files, key)
+			return nil
+		},
+		URLFn: func(key string) string {
+			return "/uploads/" + key
+		}}
+}
+
+func allowUser() *mock.RBAC {
+	return &mock.RBAC{
+		EnforceUserFn: func(c echo.Context, id int) error {
+			if id != 1 {
+				return echo.ErrForbidden
+			}
+			return nil
+		}}
+}
+
+func TestUpload(t *testing.T) {
+	cases := []struct {
+		name    string
+		user    int
+		data    func(*testing.T) []byte
+		wantErr bool
+		udb     *mockdb.User
+	}{
+		{
+			name:    "Fail on RBAC",
+			user:    2,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on view",
+			user:    1,
+			wantErr: true,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*model.User, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name:    "Invalid image",
+			user:    1,
+			data:    func(*testing.T) []byte { return []byte("GIF89a") },
+			wantErr: true,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}}, nil
+				}},
+		},
+		{
+			name:    "Fail on update",
+			user:    1,
+			data:    func(t *testing.T) []byte { return encodeJPEG(t, halves(10, 10)) },
+			wantErr: true,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}}, nil
+				},
+				UpdateFn: func(orm.DB, *model.User) (*model.User, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name: "Success",
+			user: 1,
+			data: func(t *testing.T) []byte { return encodeJPEG(t, halves(10, 10)) },
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, Avatar: &model.Avatar{ID: "old"}}, nil
+				},
+				UpdateFn: func(db orm.DB, u *model.User) (*model.User, error) {
+					return u, nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string][]byte{}
+			for _, key := range (&model.Avatar{ID: "old"}).Keys(1) {
+				files[key] = []byte("old")
+			}
+			var data []byte
+			if tt.data != nil {
+				data = tt.data(t)
+			}
+			s := avatar.New(tt.udb, memStorage(files), allowUser())
+			a, err := s.Upload(nil, tt.user, data)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantErr {
+				assert.Len(t, files, 3, "only the previous avatar is stored")
+				assert.Contains(t, files, "avatars/1/old/small.jpg")
+				return
+			}
+			assert.Len(t, files, 3, "previous avatar is deleted")
+			for _, size := range model.AvatarSizes {
+				key := model.AvatarKey(1, a.ID, size.Name)
+				assert.Contains(t, files, key)
+				assert.Equal(t, "/uploads/"+key, a.URLs[size.Name])
+			}
+			assert.True(t, strings.HasPrefix(a.URLs["small"], "/uploads/avatars/1/"))
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	cases := []struct {
+		name      string
+		user      int
+		wantErr   bool
+		wantFiles int
+		udb       *mockdb.User
+	}{
+		{
+			name:      "Fail on RBAC",
+			user:      2,
+			wantErr:   true,
+			wantFiles: 3,
+		},
+		{
+			name:      "No avatar",
+			user:      1,
+			wantFiles: 3,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}}, nil
+				}},
+		},
+		{
+			name: "Success",
+			user: 1,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, Avatar: &model.Avatar{ID: "old"}}, nil
+				},
+				UpdateFn: func(db orm.DB, u *model.User) (*model.User, error) {
+					if u.Avatar != nil {
+						return nil, model.ErrGeneric
+					}
+					return u, nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string][]byte{}
+			for _, key := range (&model.Avatar{ID: "old"}).Keys(1) {
+				files[key] = []byte("old")
+			}
+			s := avatar.New(tt.udb, memStorage(files), allowUser())
+			err := s.Delete(nil, tt.user)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Len(t, files, tt.wantFiles)
+		})
+	}
+}
+
+func TestDeleteAfterCommit(t *testing.T) {
+	files := map[string][]byte{}
+	for _, key := range (&model.Avatar{ID: "old"}).Keys(1) {
+		files[key] = []byte("old")
+	}
+	udb := &mockdb.User{
+		ViewFn: func(db orm.DB, id int) (*model.User, error) {
+			return &model.User{Base: model.Base{ID: id}, Avatar: &model.Avatar{ID: "old"}}, nil
+		},
+		UpdateFn: func(db orm.DB, u *model.User) (*model.User, error) {
+			return u, nil
+		}}
+	c := echo.New().NewContext(nil, nil)
+	committed := model.HoldCommitted(c)
+	s := avatar.New(udb, memStorage(files), allowUser())
+	assert.Nil(t, s.Delete(c, 1))
+	assert.Len(t, files, 3, "thumbnails are kept until the transaction commits")
+	assert.Len(t, committed(), 0)
+	assert.Len(t, files, 0)
+}
@@ -0,0 +1,201 @@
+package avatar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"net/http"
+
+	// Decoders of accepted image types
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// maxPixels limits dimensions of uploaded images, so that decoding a small but huge image can't exhaust memory
+const maxPixels = 25 * 1000 * 1000
+
+// imageTypes lists accepted image types, as sniffed from the content rather than taken from the client
+var imageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// Thumbnails decodes the image and returns JPEG thumbnails of it by names of model.AvatarSizes.
+// Thumbnails are centered square crops, turned upright as EXIF orientation says, and carry no metadata
+// of the upload, since they are encoded anew. Transparent areas are filled with white
+func Thumbnails(data []byte) (map[string][]byte, error) {
+	if !imageTypes[http.DetectContentType(data)] {
+		return nil, echo.NewHTTPError(http.StatusUnsupportedMediaType, "avatar must be a JPEG, PNG or GIF image")
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "avatar is not a valid image")
+	}
+	if cfg.Width < 1 || cfg.Height < 1 || cfg.Width*cfg.Height > maxPixels {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "avatar dimensions are too large")
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "avatar is not a valid image")
+	}
+	thumbs := make(map[string][]byte, len(model.AvatarSizes))
+	var prev image.Image
+	for i, size := range model.AvatarSizes {
+		var thumb *image.RGBA
+		// Sizes are listed largest first, so smaller thumbnails are scaled down from the previous one
+		if i == 0 {
+			thumb = orient(scale(src, size.Pixels), orientation(data))
+		} else {
+			thumb = scale(prev, size.Pixels)
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, err
+		}
+		thumbs[size.Name] = buf.Bytes()
+		prev = thumb
+	}
+	return thumbs, nil
+}
+
+// scale crops centered square of the image and scales it to size, averaging the pixels covered by each pixel
+// of the thumbnail over white background
+func scale(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	left, top := b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := span(top, side, size, y)
+		for x := 0; x < size; x++ {
+			x0, x1 := span(left, side, size, x)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			// Colors are premultiplied by alpha, so white shows through by what alpha leaves uncovered
+			white := 0xffff*n - a
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8((r + white) / n >> 8)
+			dst.Pix[i+1] = uint8((g + white) / n >> 8)
+			dst.Pix[i+2] = uint8((bl + white) / n >> 8)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+	return dst
+}
+
+// span returns range of source pixels covered by i-th of size pixels scaled from side pixels starting at start,
+// always covering at least one pixel
+func span(start, side, size, i int) (int, int) {
+	from, to := start+i*side/size, start+(i+1)*side/size
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
+
+// orient turns square image upright as EXIF orientation says
+func orient(src *image.RGBA, o int) *image.RGBA {
+	if o == 1 {
+		return src
+	}
+	n := src.Bounds().Dx() - 1
+	dst := image.NewRGBA(src.Bounds())
+	for y := 0; y <= n; y++ {
+		for x := 0; x <= n; x++ {
+			var sx, sy int
+			switch o {
+			case 2:
+				sx, sy = n-x, y
+			case 3:
+				sx, sy = n-x, n-y
+			case 4:
+				sx, sy = x, n-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, n-x
+			case 7:
+				sx, sy = n-y, n-x
+			case 8:
+				sx, sy = n-y, x
+			default:
+				sx, sy = x, y
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
+
+// orientation returns EXIF orientation of a JPEG image, read from the first IFD of its APP1 segment.
+// 1, meaning the image is stored upright, is returned for images without it
+func orientation(data []byte) int {
+	if len(data) < 2 || data[0] != 0xff || data[1] != 0xd8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data) && data[i] == 0xff; {
+		marker := data[i+1]
+		// Segments with metadata all come before the scan
+		if marker == 0xda || marker == 0xd9 {
+			return 1
+		}
+		l := int(binary.BigEndian.Uint16(data[i+2:]))
+		if l < 2 || i+2+l > len(data) {
+			return 1
+		}
+		seg := data[i+4 : i+2+l]
+		if marker == 0xe1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return exifOrientation(seg[6:])
+		}
+		i += 2 + l
+	}
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		e := ifd + 2 + i*12
+		if e+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[e:]) == 0x0112 {
+			if o := int(order.Uint16(tiff[e+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 1
+		}
+	}
+	return 1
+}
@@ -0,0 +1,145 @@
+package avatar_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal/avatar"
+)
+
+// halves returns image with red left half and blue right half
+func halves(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withOrientation inserts EXIF segment with the orientation and a camera model after start of JPEG image
+func withOrientation(data []byte, o uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x2a, 0, 8, 2})
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3, 0, 1, o, 0})
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0110, 2, 0, 4})
+	tiff.WriteString("Cam\x00")
+	binary.Write(&tiff, binary.BigEndian, []uint32{0})
+	seg := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var out bytes.Buffer
+	out.Write(data[:2])
+	out.Write([]byte{0xff, 0xe1})
+	binary.Write(&out, binary.BigEndian, uint16(len(seg)+2))
+	out.Write(seg)
+	out.Write(data[2:])
+	return out.Bytes()
+}
+
+func decodeThumb(t *testing.T, data []byte) image.Image {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xc000 && g < 0x4000 && b < 0x4000
+}
+
+func isBlue(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return b > 0xc000 && r < 0x4000 && g < 0x4000
+}
+
+func TestThumbnails(t *testing.T) {
+	cases := []struct {
+		name       string
+		data       []byte
+		wantStatus int
+	}{
+		{
+			name:       "Not an image",
+			data:       []byte("%PDF-1.4 not an image"),
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:       "Corrupt image",
+			data:       append([]byte("\x89PNG\x0d\x0a\x1a\x0a"), make([]byte, 32)...),
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := avatar.Thumbnails(tt.data)
+			if he, ok := err.(*echo.HTTPError); assert.True(t, ok) {
+				assert.Equal(t, tt.wantStatus, he.Code)
+			}
+		})
+	}
+
+	t.Run("Sizes", func(t *testing.T) {
+		// Wider than high, so that the crop keeps the middle, where halves meet
+		thumbs, err := avatar.Thumbnails(encodeJPEG(t, halves(300, 100)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, px := range map[string]int{"large": 256, "medium": 128, "small": 64} {
+			img := decodeThumb(t, thumbs[name])
+			assert.Equal(t, image.Rect(0, 0, px, px), img.Bounds(), name)
+			assert.True(t, isRed(img.At(px/8, px/2)), name)
+			assert.True(t, isBlue(img.At(px-px/8, px/2)), name)
+		}
+	})
+
+	t.Run("Orientation", func(t *testing.T) {
+		data := withOrientation(encodeJPEG(t, halves(100, 100)), 6)
+		thumbs, err := avatar.Thumbnails(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.False(t, bytes.Contains(thumbs["large"], []byte("Exif")))
+		assert.False(t, bytes.Contains(thumbs["large"], []byte("Cam\x00")))
+		// Turned clockwise, the left half is on top
+		img := decodeThumb(t, thumbs["large"])
+		assert.True(t, isRed(img.At(128, 32)))
+		assert.True(t, isBlue(img.At(128, 224)))
+	})
+
+	t.Run("Transparency", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 10, 10))); err != nil {
+			t.Fatal(err)
+		}
+		thumbs, err := avatar.Thumbnails(buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, g, b, _ := decodeThumb(t, thumbs["small"]).At(32, 32).RGBA()
+		assert.True(t, r > 0xf000 && g > 0xf000 && b > 0xf000)
+	})
+}
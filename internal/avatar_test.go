@@ -0,0 +1,18 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+func TestAvatarKeys(t *testing.T) {
+	a := &model.Avatar{ID: "b9ke5v"}
+	assert.Equal(t, []string{
+		"avatars/3/b9ke5v/large.jpg",
+		"avatars/3/b9ke5v/medium.jpg",
+		"avatars/3/b9ke5v/small.jpg",
+	}, a.Keys(3))
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"github.com/go-pg/pg/orm"
+)
+
+// BlockKind represents kind of restriction one user puts on another
+type BlockKind string
+
+const (
+	// BlockFull prevents users from interacting and seeing each other's profiles
+	BlockFull BlockKind = "block"
+
+	// BlockMute silences user's activity without the user knowing
+	BlockMute BlockKind = "mute"
+)
+
+// Block represents a block or mute put by a user on another user
+type Block struct {
+	Base
+	UserID    int       `json:"user_id"`
+	TargetID  int       `json:"target_id"`
+	CompanyID int       `json:"company_id"`
+	Kind      BlockKind `json:"kind"`
+}
+
+// BlockDB represents block database interface (repository)
+type BlockDB interface {
+	Create(orm.DB, Block) (*Block, error)
+	Find(orm.DB, int, int, BlockKind) (*Block, error)
+	Delete(orm.DB, *Block) error
+	List(orm.DB, int, BlockKind, *Pagination) ([]User, error)
+	Blocked(orm.DB, int, int) (bool, error)
+	Related(orm.DB, int) ([]int, error)
+}
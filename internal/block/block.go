@@ -0,0 +1,69 @@
+// Package block contains blocking and muting application services
+package block
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// New creates new block application service
+func New(bdb model.BlockDB, fdb model.FriendDB, udb model.UserDB, sdb model.SuggestionDB, auth model.AuthService) *Service {
+	return &Service{bdb: bdb, fdb: fdb, udb: udb, sdb: sdb, auth: auth}
+}
+
+// Service represents block application service
+type Service struct {
+	bdb  model.BlockDB
+	fdb  model.FriendDB
+	udb  model.UserDB
+	sdb  model.SuggestionDB
+	auth model.AuthService
+}
+
+// Create blocks or mutes a user on behalf of requesting user.
+// Blocking ends friendship and withdraws pending friend requests between the users
+func (s *Service) Create(c echo.Context, userID int, kind model.BlockKind) (*model.Block, error) {
+	au := s.auth.User(c)
+	if au.ID == userID {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "cannot block yourself")
+	}
+	u, err := s.udb.View(model.Conn(c), userID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.bdb.Create(model.Conn(c), model.Block{
+		UserID:    au.ID,
+		TargetID:  u.ID,
+		CompanyID: u.CompanyID,
+		Kind:      kind,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if kind == model.BlockFull {
+		if err := s.fdb.DeleteBetween(model.Conn(c), au.ID, u.ID); err != nil {
+			return nil, err
+		}
+		if err := s.sdb.Refresh(model.Conn(c), au.ID, u.ID); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// Delete lifts a block or mute put by requesting user
+func (s *Service) Delete(c echo.Context, userID int, kind model.BlockKind) error {
+	b, err := s.bdb.Find(model.Conn(c), s.auth.User(c).ID, userID, kind)
+	if err != nil {
+		return err
+	}
+	return s.bdb.Delete(model.Conn(c), b)
+}
+
+// List returns users blocked or muted by requesting user
+func (s *Service) List(c echo.Context, kind model.BlockKind, p *model.Pagination) ([]model.User, error) {
+	return s.bdb.List(model.Conn(c), s.auth.User(c).ID, kind, p)
+}
@@ -0,0 +1,162 @@
+package block_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/block"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+func authUser(id int) *mock.Auth {
+	return &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: id, CompanyID: 1}
+		}}
+}
+
+func TestCreate(t *testing.T) {
+	users := &mockdb.User{
+		ViewFn: func(db orm.DB, id int) (*model.User, error) {
+			return &model.User{Base: model.Base{ID: id}, CompanyID: 1}, nil
+		}}
+	created := &mockdb.Block{
+		CreateFn: func(db orm.DB, b model.Block) (*model.Block, error) {
+			b.ID = 1
+			return &b, nil
+		}}
+	cases := []struct {
+		name         string
+		user         int
+		kind         model.BlockKind
+		wantErr      bool
+		wantData     *model.Block
+		wantUnfriend bool
+		bdb          *mockdb.Block
+		udb          *mockdb.User
+	}{
+		{
+			name:    "Block yourself",
+			user:    1,
+			kind:    model.BlockFull,
+			wantErr: true,
+		},
+		{
+			name:    "Fail on user view",
+			user:    2,
+			kind:    model.BlockFull,
+			wantErr: true,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*model.User, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name:    "Already blocked",
+			user:    2,
+			kind:    model.BlockFull,
+			wantErr: true,
+			udb:     users,
+			bdb: &mockdb.Block{
+				CreateFn: func(orm.DB, model.Block) (*model.Block, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name:         "Block",
+			user:         2,
+			kind:         model.BlockFull,
+			udb:          users,
+			bdb:          created,
+			wantData:     &model.Block{Base: model.Base{ID: 1}, UserID: 1, TargetID: 2, CompanyID: 1, Kind: model.BlockFull},
+			wantUnfriend: true,
+		},
+		{
+			name:     "Mute",
+			user:     2,
+			kind:     model.BlockMute,
+			udb:      users,
+			bdb:      created,
+			wantData: &model.Block{Base: model.Base{ID: 1}, UserID: 1, TargetID: 2, CompanyID: 1, Kind: model.BlockMute},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var unfriended, refreshed bool
+			fdb := &mockdb.Friend{
+				DeleteBetweenFn: func(db orm.DB, userID, otherID int) error {
+					unfriended = userID == 1 && otherID == 2
+					return nil
+				}}
+			sdb := &mockdb.Suggestion{
+				RefreshFn: func(db orm.DB, ids ...int) error {
+					refreshed = len(ids) == 2 && ids[0] == 1 && ids[1] == 2
+					return nil
+				}}
+			s := block.New(tt.bdb, fdb, tt.udb, sdb, authUser(1))
+			b, err := s.Create(nil, tt.user, tt.kind)
+			assert.Equal(t, tt.wantData, b)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantUnfriend, unfriended)
+			assert.Equal(t, tt.wantUnfriend, refreshed)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	cases := []struct {
+		name    string
+		wantErr bool
+		bdb     *mockdb.Block
+	}{
+		{
+			name:    "Not blocked",
+			wantErr: true,
+			bdb: &mockdb.Block{
+				FindFn: func(orm.DB, int, int, model.BlockKind) (*model.Block, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name: "Success",
+			bdb: &mockdb.Block{
+				FindFn: func(db orm.DB, userID, targetID int, kind model.BlockKind) (*model.Block, error) {
+					return &model.Block{UserID: userID, TargetID: targetID, Kind: kind}, nil
+				},
+				DeleteFn: func(db orm.DB, b *model.Block) error {
+					if b.UserID != 1 || b.TargetID != 2 || b.Kind != model.BlockMute {
+						return model.ErrGeneric
+					}
+					return nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := block.New(tt.bdb, nil, nil, nil, authUser(1))
+			err := s.Delete(nil, 2, model.BlockMute)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	bdb := &mockdb.Block{
+		ListFn: func(db orm.DB, id int, kind model.BlockKind, p *model.Pagination) ([]model.User, error) {
+			if id != 1 || kind != model.BlockFull {
+				return nil, model.ErrGeneric
+			}
+			return []model.User{{Base: model.Base{ID: 2}}}, nil
+		}}
+	s := block.New(bdb, nil, nil, nil, authUser(1))
+	users, err := s.List(nil, model.BlockFull, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, []model.User{{Base: model.Base{ID: 2}}}, users)
+}
@@ -0,0 +1,39 @@
+package model
+
+// MaxBulkUsers is the maximum number of users changed by a bulk operation
+const MaxBulkUsers = 1000
+
+// BulkAction represents a change applied to many users at once
+type BulkAction string
+
+const (
+	// BulkActivate activates users
+	BulkActivate BulkAction = "activate"
+
+	// BulkDeactivate deactivates users
+	BulkDeactivate BulkAction = "deactivate"
+
+	// BulkMoveLocation moves users to a location
+	BulkMoveLocation BulkAction = "move_location"
+
+	// BulkChangeRole changes role of users
+	BulkChangeRole BulkAction = "change_role"
+
+	// BulkDelete deletes users
+	BulkDelete BulkAction = "delete"
+)
+
+// BulkChange represents a bulk action with its arguments,
+// the location users are moved to and the role they are given
+type BulkChange struct {
+	Action     BulkAction
+	LocationID int
+	RoleID     int
+}
+
+// BulkResult represents outcome of a bulk operation for a single user
+type BulkResult struct {
+	ID    int    `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
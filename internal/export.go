@@ -0,0 +1,48 @@
+package model
+
+// ExportBatch is the number of users read at a time while exporting
+const ExportBatch = 500
+
+// UserExportColumns lists columns users can be exported with, in the order exported by default
+var UserExportColumns = []string{
+	"id", "first_name", "last_name", "username", "email", "mobile", "phone", "address",
+	"active", "role_id", "company_id", "location_id", "created_at", "last_login",
+}
+
+// ExportValue returns value of user's column, nil when the user never logged in or the column is unknown.
+// Values are ints, strings, bools or times
+func (u *User) ExportValue(column string) interface{} {
+	switch column {
+	case "id":
+		return u.ID
+	case "first_name":
+		return u.FirstName
+	case "last_name":
+		return u.LastName
+	case "username":
+		return u.Username
+	case "email":
+		return u.Email
+	case "mobile":
+		return u.Mobile
+	case "phone":
+		return u.Phone
+	case "address":
+		return u.Address
+	case "active":
+		return u.Active
+	case "role_id":
+		return u.RoleID
+	case "company_id":
+		return u.CompanyID
+	case "location_id":
+		return u.LocationID
+	case "created_at":
+		return u.CreatedAt
+	case "last_login":
+		if u.LastLogin != nil {
+			return *u.LastLogin
+		}
+	}
+	return nil
+}
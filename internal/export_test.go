@@ -0,0 +1,42 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestExportValue(t *testing.T) {
+	u := &model.User{
+		Base:      model.Base{ID: 3, CreatedAt: mock.TestTime(2018)},
+		FirstName: "John",
+		Active:    true,
+		RoleID:    4,
+	}
+	cases := []struct {
+		column string
+		want   interface{}
+	}{
+		{column: "id", want: 3},
+		{column: "first_name", want: "John"},
+		{column: "phone", want: ""},
+		{column: "active", want: true},
+		{column: "role_id", want: 4},
+		{column: "created_at", want: mock.TestTime(2018)},
+		{column: "last_login", want: nil},
+		{column: "password", want: nil},
+	}
+	for _, tt := range cases {
+		t.Run(tt.column, func(t *testing.T) {
+			assert.Equal(t, tt.want, u.ExportValue(tt.column))
+		})
+	}
+	for _, col := range model.UserExportColumns {
+		if col != "last_login" {
+			assert.NotNil(t, u.ExportValue(col), col)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// FriendshipStatus represents state of a friendship
+type FriendshipStatus string
+
+const (
+	// FriendshipPending is a friend request waiting for addressee's response
+	FriendshipPending FriendshipStatus = "pending"
+
+	// FriendshipAccepted means users are friends
+	FriendshipAccepted FriendshipStatus = "accepted"
+
+	// FriendshipDeclined is a friend request declined by addressee
+	FriendshipDeclined FriendshipStatus = "declined"
+
+	// FriendshipCancelled is a friend request withdrawn by requester
+	FriendshipCancelled FriendshipStatus = "cancelled"
+)
+
+// Friendship represents a friend request sent from one user to another, and the friendship once accepted
+type Friendship struct {
+	Base
+	RequesterID int              `json:"requester_id"`
+	AddresseeID int              `json:"addressee_id"`
+	CompanyID   int              `json:"company_id"`
+	Status      FriendshipStatus `json:"status"`
+	RespondedAt *time.Time       `json:"responded_at,omitempty"`
+}
+
+// Respond moves pending friend request to the given state.
+// False is returned if the request is not pending anymore
+func (f *Friendship) Respond(to FriendshipStatus, t time.Time) bool {
+	if f.Status != FriendshipPending || to == FriendshipPending {
+		return false
+	}
+	f.Status = to
+	f.RespondedAt = &t
+	return true
+}
+
+// Other returns ID of the other user in the friendship
+func (f *Friendship) Other(userID int) int {
+	if f.RequesterID == userID {
+		return f.AddresseeID
+	}
+	return f.RequesterID
+}
+
+// Friend represents public profile of a friend along with its presence, nil if the friend hides it.
+// Last seen time and whether presence is hidden are read only to resolve the presence
+type Friend struct {
+	PublicUser
+	LastSeenAt   *time.Time `json:"-"`
+	HidePresence bool       `json:"-"`
+	Presence     *Presence  `json:"presence,omitempty" sql:"-"`
+}
+
+// FriendDB represents friendship database interface (repository)
+type FriendDB interface {
+	Create(orm.DB, Friendship) (*Friendship, error)
+	View(orm.DB, int) (*Friendship, error)
+	FindBetween(orm.DB, int, int) (*Friendship, error)
+	Update(orm.DB, *Friendship) error
+	Delete(orm.DB, *Friendship) error
+	DeleteBetween(orm.DB, int, int) error
+	ListFriends(orm.DB, int, *Pagination) ([]Friend, error)
+	ListRequests(orm.DB, int, bool, *Pagination) ([]Friendship, error)
+}
@@ -0,0 +1,212 @@
+// Package friend contains friendship application services
+package friend
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// New creates new friendship application service
+func New(fdb model.FriendDB, udb model.UserDB, bdb model.BlockDB, sdb model.SuggestionDB, broker model.Broker, notifier model.Notifier, presence model.PresenceTracker, activity model.ActivityLogger, prefs model.PreferenceService, auth model.AuthService) *Service {
+	return &Service{fdb: fdb, udb: udb, bdb: bdb, sdb: sdb, broker: broker, notifier: notifier, presence: presence, activity: activity, prefs: prefs, auth: auth}
+}
+
+// Service represents friendship application service
+type Service struct {
+	fdb      model.FriendDB
+	udb      model.UserDB
+	bdb      model.BlockDB
+	sdb      model.SuggestionDB
+	broker   model.Broker
+	notifier model.Notifier
+	presence model.PresenceTracker
+	activity model.ActivityLogger
+	prefs    model.PreferenceService
+	auth     model.AuthService
+}
+
+// Request sends a friend request from requesting user to another user of the same company,
+// pushing it to the addressee and notifying it. Requests cannot be sent if either of the users blocked the other,
+// or if the addressee does not accept requests from the requesting user
+func (s *Service) Request(c echo.Context, userID int) (*model.Friendship, error) {
+	au := s.auth.User(c)
+	if au.ID == userID {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "cannot send friend request to yourself")
+	}
+	blocked, err := s.bdb.Blocked(model.Conn(c), au.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, echo.ErrForbidden
+	}
+	u, err := s.udb.View(model.Conn(c), userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.CompanyID != au.CompanyID {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "user does not belong to the company")
+	}
+	if err := s.acceptsRequests(c, au.ID, u.ID); err != nil {
+		return nil, err
+	}
+	f, err := s.fdb.Create(model.Conn(c), model.Friendship{
+		RequesterID: au.ID,
+		AddresseeID: u.ID,
+		CompanyID:   u.CompanyID,
+		Status:      model.FriendshipPending,
+	})
+	if err != nil {
+		return nil, err
+	}
+	e, err := model.NewEvent(model.EventFriendRequest, u.ID, f)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.broker.Publish(model.Conn(c), e); err != nil {
+		return nil, err
+	}
+	if err := s.notifier.Notify(c, model.Notification{
+		UserID:    u.ID,
+		CompanyID: u.CompanyID,
+		Type:      model.NotificationFriendRequest,
+		Data:      map[string]interface{}{"friendship_id": f.ID, "requester_id": au.ID},
+	}); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// acceptsRequests checks whether the addressee accepts friend requests from the requester,
+// as its privacy.friend_requests preference says
+func (s *Service) acceptsRequests(c echo.Context, requesterID, addresseeID int) error {
+	p, err := s.prefs.Preferences(c, addresseeID)
+	if err != nil {
+		return err
+	}
+	switch p.String("privacy.friend_requests") {
+	case "nobody":
+		return echo.NewHTTPError(http.StatusForbidden, "user does not accept friend requests")
+	case "friends_of_friends":
+		mutual, err := s.sdb.Mutual(model.Conn(c), requesterID, addresseeID)
+		if err != nil {
+			return err
+		}
+		if mutual == 0 {
+			return echo.NewHTTPError(http.StatusForbidden, "user only accepts friend requests from friends of friends")
+		}
+	}
+	return nil
+}
+
+// Accept accepts a friend request sent to requesting user, notifying the requester.
+// New friendship is shown on friends' feeds of both users
+func (s *Service) Accept(c echo.Context, id int) (*model.Friendship, error) {
+	return s.respond(c, id, model.FriendshipAccepted)
+}
+
+// Decline declines a friend request sent to requesting user
+func (s *Service) Decline(c echo.Context, id int) (*model.Friendship, error) {
+	return s.respond(c, id, model.FriendshipDeclined)
+}
+
+// Cancel withdraws a friend request sent by requesting user
+func (s *Service) Cancel(c echo.Context, id int) (*model.Friendship, error) {
+	return s.respond(c, id, model.FriendshipCancelled)
+}
+
+// respond moves friend request to the given state.
+// Only requester can cancel the request, and only addressee can accept or decline it
+func (s *Service) respond(c echo.Context, id int, to model.FriendshipStatus) (*model.Friendship, error) {
+	f, err := s.fdb.View(model.Conn(c), id)
+	if err != nil {
+		return nil, err
+	}
+	au := s.auth.User(c)
+	party := f.AddresseeID
+	if to == model.FriendshipCancelled {
+		party = f.RequesterID
+	}
+	if au.ID != party {
+		return nil, echo.ErrForbidden
+	}
+	if !f.Respond(to, time.Now()) {
+		return nil, echo.NewHTTPError(http.StatusConflict, "friend request is not pending")
+	}
+	if err := s.fdb.Update(model.Conn(c), f); err != nil {
+		return nil, err
+	}
+	if to == model.FriendshipAccepted {
+		if err := s.sdb.Refresh(model.Conn(c), f.RequesterID, f.AddresseeID); err != nil {
+			return nil, err
+		}
+		if err := s.notifier.Notify(c, model.Notification{
+			UserID:    f.RequesterID,
+			CompanyID: f.CompanyID,
+			Type:      model.NotificationInvitationAccepted,
+			Data:      map[string]interface{}{"friendship_id": f.ID, "addressee_id": f.AddresseeID},
+		}); err != nil {
+			return nil, err
+		}
+		for _, a := range [][2]int{{f.AddresseeID, f.RequesterID}, {f.RequesterID, f.AddresseeID}} {
+			if err := s.activity.Log(c, model.Activity{
+				ActorID:   a[0],
+				CompanyID: f.CompanyID,
+				Type:      model.ActivityBecameFriends,
+				ObjectID:  a[1],
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return f, nil
+}
+
+// Unfriend ends friendship between requesting user and another user
+func (s *Service) Unfriend(c echo.Context, userID int) error {
+	au := s.auth.User(c)
+	f, err := s.fdb.FindBetween(model.Conn(c), au.ID, userID)
+	if err != nil {
+		return err
+	}
+	if f.Status != model.FriendshipAccepted {
+		return echo.NewHTTPError(http.StatusBadRequest, "users are not friends")
+	}
+	if err := s.fdb.Delete(model.Conn(c), f); err != nil {
+		return err
+	}
+	return s.sdb.Refresh(model.Conn(c), f.RequesterID, f.AddresseeID)
+}
+
+// Friends returns friends of requesting user along with their presence, unless they hide it
+func (s *Service) Friends(c echo.Context, p *model.Pagination) ([]model.Friend, error) {
+	friends, err := s.fdb.ListFriends(model.Conn(c), s.auth.User(c).ID, p)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i, f := range friends {
+		u := &model.User{Base: model.Base{ID: f.ID}, LastSeenAt: f.LastSeenAt, HidePresence: f.HidePresence}
+		friends[i].Presence = s.presence.Presence(u, now)
+	}
+	return friends, nil
+}
+
+// Requests returns pending friend requests sent to requesting user, or sent by it if incoming is false
+func (s *Service) Requests(c echo.Context, incoming bool, p *model.Pagination) ([]model.Friendship, error) {
+	return s.fdb.ListRequests(model.Conn(c), s.auth.User(c).ID, incoming, p)
+}
+
+// Suggestions returns users suggested as friends to requesting user,
+// ranked by mutual friends, shared location and shared company
+func (s *Service) Suggestions(c echo.Context, p *model.Pagination) ([]model.FriendSuggestion, error) {
+	u, err := s.udb.View(model.Conn(c), s.auth.User(c).ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.sdb.List(model.Conn(c), u, p)
+}
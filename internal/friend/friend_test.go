@@ -0,0 +1,466 @@
+package friend_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/friend"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+func authUser(id int) *mock.Auth {
+	return &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: id, CompanyID: 1}
+		}}
+}
+
+var notBlocked = &mockdb.Block{
+	BlockedFn: func(orm.DB, int, int) (bool, error) {
+		return false, nil
+	}}
+
+// refresher returns suggestion database mock recording users whose suggestions were refreshed
+func refresher(refreshed *[]int) *mockdb.Suggestion {
+	return &mockdb.Suggestion{
+		RefreshFn: func(db orm.DB, ids ...int) error {
+			*refreshed = append(*refreshed, ids...)
+			return nil
+		}}
+}
+
+// accepting returns preferences mock of users accepting friend requests from whom the value says
+func accepting(from string) *mock.Preferences {
+	return &mock.Preferences{
+		PreferencesFn: func(c echo.Context, id int) (model.Preferences, error) {
+			return model.Preferences{"privacy.friend_requests": from}, nil
+		}}
+}
+
+// mutual returns suggestion database mock of users having the given number of friends in common
+func mutual(n int) *mockdb.Suggestion {
+	return &mockdb.Suggestion{
+		MutualFn: func(db orm.DB, userID, candidateID int) (int, error) {
+			return n, nil
+		}}
+}
+
+// notifier returns notifier mock recording emitted notifications
+func notifier(sent *[]model.Notification) *mock.Notifier {
+	return &mock.Notifier{
+		NotifyFn: func(c echo.Context, n model.Notification) error {
+			*sent = append(*sent, n)
+			return nil
+		}}
+}
+
+func TestRequest(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      int
+		wantErr  bool
+		wantData *model.Friendship
+		fdb      *mockdb.Friend
+		udb      *mockdb.User
+		bdb      *mockdb.Block
+		sdb      *mockdb.Suggestion
+		prefs    *mock.Preferences
+	}{
+		{
+			name:    "Request to yourself",
+			req:     1,
+			wantErr: true,
+		},
+		{
+			name:    "Blocked",
+			req:     2,
+			wantErr: true,
+			bdb: &mockdb.Block{
+				BlockedFn: func(orm.DB, int, int) (bool, error) {
+					return true, nil
+				}},
+		},
+		{
+			name:    "Fail on user view",
+			req:     2,
+			wantErr: true,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name:    "User from another company",
+			req:     2,
+			wantErr: true,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, CompanyID: 2}, nil
+				}},
+		},
+		{
+			name:    "Fail on preferences",
+			req:     2,
+			wantErr: true,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, CompanyID: 1}, nil
+				}},
+			prefs: &mock.Preferences{
+				PreferencesFn: func(c echo.Context, id int) (model.Preferences, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name:    "Addressee accepts no requests",
+			req:     2,
+			wantErr: true,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, CompanyID: 1}, nil
+				}},
+			prefs: accepting("nobody"),
+		},
+		{
+			name:    "No friends in common",
+			req:     2,
+			wantErr: true,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, CompanyID: 1}, nil
+				}},
+			sdb:   mutual(0),
+			prefs: accepting("friends_of_friends"),
+		},
+		{
+			name: "Friend of a friend",
+			req:  2,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, CompanyID: 1}, nil
+				}},
+			fdb: &mockdb.Friend{
+				CreateFn: func(db orm.DB, f model.Friendship) (*model.Friendship, error) {
+					f.ID = 1
+					return &f, nil
+				}},
+			sdb:      mutual(1),
+			prefs:    accepting("friends_of_friends"),
+			wantData: &model.Friendship{Base: model.Base{ID: 1}, RequesterID: 1, AddresseeID: 2, CompanyID: 1, Status: model.FriendshipPending},
+		},
+		{
+			name: "Success",
+			req:  2,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, CompanyID: 1}, nil
+				}},
+			fdb: &mockdb.Friend{
+				CreateFn: func(db orm.DB, f model.Friendship) (*model.Friendship, error) {
+					f.ID = 1
+					return &f, nil
+				}},
+			wantData: &model.Friendship{Base: model.Base{ID: 1}, RequesterID: 1, AddresseeID: 2, CompanyID: 1, Status: model.FriendshipPending},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.bdb == nil {
+				tt.bdb = notBlocked
+			}
+			if tt.prefs == nil {
+				tt.prefs = accepting("everyone")
+			}
+			var pushed []model.Event
+			broker := &mock.Broker{
+				PublishFn: func(db orm.DB, e model.Event) error {
+					pushed = append(pushed, e)
+					return nil
+				}}
+			var sent []model.Notification
+			s := friend.New(tt.fdb, tt.udb, tt.bdb, tt.sdb, broker, notifier(&sent), nil, nil, tt.prefs, authUser(1))
+			f, err := s.Request(nil, tt.req)
+			assert.Equal(t, tt.wantData, f)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Len(t, pushed, 1)
+				assert.Equal(t, model.EventFriendRequest, pushed[0].Type)
+				assert.Equal(t, 2, pushed[0].UserID)
+				assert.Len(t, sent, 1)
+				assert.Equal(t, model.NotificationFriendRequest, sent[0].Type)
+				assert.Equal(t, 2, sent[0].UserID)
+			}
+		})
+	}
+}
+
+func TestRespond(t *testing.T) {
+	pending := func(db orm.DB, id int) (*model.Friendship, error) {
+		return &model.Friendship{Base: model.Base{ID: id}, RequesterID: 1, AddresseeID: 2, Status: model.FriendshipPending}, nil
+	}
+	cases := []struct {
+		name       string
+		user       int
+		to         model.FriendshipStatus
+		wantErr    bool
+		wantStatus model.FriendshipStatus
+		wantUsers  []int
+		fdb        *mockdb.Friend
+	}{
+		{
+			name:    "Fail on view",
+			user:    2,
+			to:      model.FriendshipAccepted,
+			wantErr: true,
+			fdb: &mockdb.Friend{
+				ViewFn: func(orm.DB, int) (*model.Friendship, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name:    "Requester accepting own request",
+			user:    1,
+			to:      model.FriendshipAccepted,
+			wantErr: true,
+			fdb:     &mockdb.Friend{ViewFn: pending},
+		},
+		{
+			name:    "Addressee cancelling request",
+			user:    2,
+			to:      model.FriendshipCancelled,
+			wantErr: true,
+			fdb:     &mockdb.Friend{ViewFn: pending},
+		},
+		{
+			name:    "Request is not pending",
+			user:    2,
+			to:      model.FriendshipDeclined,
+			wantErr: true,
+			fdb: &mockdb.Friend{
+				ViewFn: func(db orm.DB, id int) (*model.Friendship, error) {
+					return &model.Friendship{Base: model.Base{ID: id}, RequesterID: 1, AddresseeID: 2, Status: model.FriendshipAccepted}, nil
+				}},
+		},
+		{
+			name:    "Fail on update",
+			user:    2,
+			to:      model.FriendshipAccepted,
+			wantErr: true,
+			fdb: &mockdb.Friend{
+				ViewFn: pending,
+				UpdateFn: func(orm.DB, *model.Friendship) error {
+					return model.ErrGeneric
+				}},
+		},
+		{
+			name:       "Accept",
+			user:       2,
+			to:         model.FriendshipAccepted,
+			wantStatus: model.FriendshipAccepted,
+			wantUsers:  []int{1, 2},
+			fdb: &mockdb.Friend{
+				ViewFn: pending,
+				UpdateFn: func(orm.DB, *model.Friendship) error {
+					return nil
+				}},
+		},
+		{
+			name:       "Decline",
+			user:       2,
+			to:         model.FriendshipDeclined,
+			wantStatus: model.FriendshipDeclined,
+			fdb: &mockdb.Friend{
+				ViewFn: pending,
+				UpdateFn: func(orm.DB, *model.Friendship) error {
+					return nil
+				}},
+		},
+		{
+			name:       "Cancel",
+			user:       1,
+			to:         model.FriendshipCancelled,
+			wantStatus: model.FriendshipCancelled,
+			fdb: &mockdb.Friend{
+				ViewFn: pending,
+				UpdateFn: func(orm.DB, *model.Friendship) error {
+					return nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var refreshed []int
+			var sent []model.Notification
+			var logged []model.Activity
+			activity := &mock.ActivityLogger{
+				LogFn: func(c echo.Context, a model.Activity) error {
+					logged = append(logged, a)
+					return nil
+				}}
+			s := friend.New(tt.fdb, nil, nil, refresher(&refreshed), nil, notifier(&sent), nil, activity, nil, authUser(tt.user))
+			var f *model.Friendship
+			var err error
+			switch tt.to {
+			case model.FriendshipAccepted:
+				f, err = s.Accept(nil, 1)
+			case model.FriendshipDeclined:
+				f, err = s.Decline(nil, 1)
+			case model.FriendshipCancelled:
+				f, err = s.Cancel(nil, 1)
+			}
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantUsers, refreshed)
+			if tt.wantErr {
+				assert.Nil(t, f)
+				return
+			}
+			if tt.to == model.FriendshipAccepted {
+				assert.Len(t, sent, 1)
+				assert.Equal(t, model.NotificationInvitationAccepted, sent[0].Type)
+				assert.Equal(t, 1, sent[0].UserID)
+				assert.Equal(t, []model.Activity{
+					{ActorID: 2, Type: model.ActivityBecameFriends, ObjectID: 1},
+					{ActorID: 1, Type: model.ActivityBecameFriends, ObjectID: 2},
+				}, logged)
+			} else {
+				assert.Empty(t, sent)
+				assert.Empty(t, logged)
+			}
+			assert.Equal(t, tt.wantStatus, f.Status)
+			assert.NotNil(t, f.RespondedAt)
+		})
+	}
+}
+
+func TestUnfriend(t *testing.T) {
+	cases := []struct {
+		name      string
+		wantErr   bool
+		wantUsers []int
+		fdb       *mockdb.Friend
+	}{
+		{
+			name:    "Fail on find",
+			wantErr: true,
+			fdb: &mockdb.Friend{
+				FindBetweenFn: func(orm.DB, int, int) (*model.Friendship, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name:    "Not friends yet",
+			wantErr: true,
+			fdb: &mockdb.Friend{
+				FindBetweenFn: func(orm.DB, int, int) (*model.Friendship, error) {
+					return &model.Friendship{RequesterID: 1, AddresseeID: 2, Status: model.FriendshipPending}, nil
+				}},
+		},
+		{
+			name:      "Success",
+			wantUsers: []int{2, 1},
+			fdb: &mockdb.Friend{
+				FindBetweenFn: func(db orm.DB, userID, otherID int) (*model.Friendship, error) {
+					return &model.Friendship{RequesterID: otherID, AddresseeID: userID, Status: model.FriendshipAccepted}, nil
+				},
+				DeleteFn: func(orm.DB, *model.Friendship) error {
+					return nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var refreshed []int
+			s := friend.New(tt.fdb, nil, nil, refresher(&refreshed), nil, nil, nil, nil, nil, authUser(1))
+			err := s.Unfriend(nil, 2)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantUsers, refreshed)
+		})
+	}
+}
+
+func TestFriends(t *testing.T) {
+	fdb := &mockdb.Friend{
+		ListFriendsFn: func(db orm.DB, id int, p *model.Pagination) ([]model.Friend, error) {
+			if id != 1 || p.Limit != 10 {
+				return nil, model.ErrGeneric
+			}
+			return []model.Friend{{PublicUser: model.PublicUser{ID: 2}}, {PublicUser: model.PublicUser{ID: 3}, HidePresence: true}}, nil
+		}}
+	presence := &mock.Presence{
+		PresenceFn: func(u *model.User, t time.Time) *model.Presence {
+			if u.HidePresence {
+				return nil
+			}
+			return &model.Presence{UserID: u.ID, Status: model.PresenceOnline}
+		}}
+	s := friend.New(fdb, nil, nil, nil, nil, nil, presence, nil, nil, authUser(1))
+	friends, err := s.Friends(nil, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, []model.Friend{
+		{PublicUser: model.PublicUser{ID: 2}, Presence: &model.Presence{UserID: 2, Status: model.PresenceOnline}},
+		{PublicUser: model.PublicUser{ID: 3}, HidePresence: true},
+	}, friends)
+}
+
+func TestRequests(t *testing.T) {
+	fdb := &mockdb.Friend{
+		ListRequestsFn: func(db orm.DB, id int, incoming bool, p *model.Pagination) ([]model.Friendship, error) {
+			if id != 1 || incoming {
+				return nil, model.ErrGeneric
+			}
+			return []model.Friendship{{RequesterID: 1, AddresseeID: 2}}, nil
+		}}
+	s := friend.New(fdb, nil, nil, nil, nil, nil, nil, nil, nil, authUser(1))
+	fs, err := s.Requests(nil, false, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, []model.Friendship{{RequesterID: 1, AddresseeID: 2}}, fs)
+}
+
+func TestSuggestions(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		wantData []model.FriendSuggestion
+		udb      *mockdb.User
+	}{
+		{
+			name:    "Fail on user view",
+			wantErr: true,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*model.User, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name: "Success",
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, LocationID: 3}, nil
+				}},
+			wantData: []model.FriendSuggestion{{PublicUser: model.PublicUser{ID: 2}, Mutual: 2, Score: 6}},
+		},
+	}
+	sdb := &mockdb.Suggestion{
+		ListFn: func(db orm.DB, u *model.User, p *model.Pagination) ([]model.FriendSuggestion, error) {
+			if u.ID != 1 || u.LocationID != 3 || p.Limit != 10 {
+				return nil, model.ErrGeneric
+			}
+			return []model.FriendSuggestion{{PublicUser: model.PublicUser{ID: 2}, Mutual: 2, Score: 6}}, nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := friend.New(nil, tt.udb, nil, sdb, nil, nil, nil, nil, nil, authUser(1))
+			sgs, err := s.Suggestions(nil, &model.Pagination{Limit: 10})
+			assert.Equal(t, tt.wantData, sgs)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestFriendshipRespond(t *testing.T) {
+	cases := []struct {
+		name       string
+		status     model.FriendshipStatus
+		to         model.FriendshipStatus
+		wantOK     bool
+		wantStatus model.FriendshipStatus
+	}{
+		{
+			name:       "Accept pending",
+			status:     model.FriendshipPending,
+			to:         model.FriendshipAccepted,
+			wantOK:     true,
+			wantStatus: model.FriendshipAccepted,
+		},
+		{
+			name:       "Cancel pending",
+			status:     model.FriendshipPending,
+			to:         model.FriendshipCancelled,
+			wantOK:     true,
+			wantStatus: model.FriendshipCancelled,
+		},
+		{
+			name:       "Decline accepted",
+			status:     model.FriendshipAccepted,
+			to:         model.FriendshipDeclined,
+			wantStatus: model.FriendshipAccepted,
+		},
+		{
+			name:       "Accept declined",
+			status:     model.FriendshipDeclined,
+			to:         model.FriendshipAccepted,
+			wantStatus: model.FriendshipDeclined,
+		},
+		{
+			name:       "Back to pending",
+			status:     model.FriendshipPending,
+			to:         model.FriendshipPending,
+			wantStatus: model.FriendshipPending,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &model.Friendship{Status: tt.status}
+			ok := f.Respond(tt.to, mock.TestTime(2018))
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantStatus, f.Status)
+			if ok {
+				assert.Equal(t, mock.TestTime(2018), *f.RespondedAt)
+			} else {
+				assert.Nil(t, f.RespondedAt)
+			}
+		})
+	}
+}
+
+func TestFriendshipOther(t *testing.T) {
+	f := &model.Friendship{RequesterID: 1, AddresseeID: 2}
+	assert.Equal(t, 2, f.Other(1))
+	assert.Equal(t, 1, f.Other(2))
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// RoleGrant represents temporary role given to a user for a limited period of time.
+// Grants delegated by another user carry the delegator's ID and are limited to delegator's scope.
+type RoleGrant struct {
+	Base
+	UserID      int        `json:"user_id"`
+	AccessLevel AccessRole `json:"access_level"`
+	CompanyID   int        `json:"company_id"`
+	LocationID  int        `json:"location_id"`
+	DelegatorID int        `json:"delegator_id,omitempty"`
+	StartsAt    time.Time  `json:"starts_at"`
+	EndsAt      time.Time  `json:"ends_at"`
+}
+
+// Active checks whether the grant is in effect at the given time
+func (g *RoleGrant) Active(t time.Time) bool {
+	return g.DeletedAt == nil && !t.Before(g.StartsAt) && t.Before(g.EndsAt)
+}
+
+// ApplyGrants elevates user's role and scope to the highest active grant,
+// if it is higher than the role user already has
+func (u *AuthUser) ApplyGrants(grants []RoleGrant, t time.Time) {
+	for _, g := range grants {
+		if !g.Active(t) || g.AccessLevel >= u.Role {
+			continue
+		}
+		u.Role = g.AccessLevel
+		u.CompanyID = g.CompanyID
+		u.LocationID = g.LocationID
+		u.GrantID = g.ID
+	}
+}
+
+// GrantDB represents role grant database interface (repository)
+type GrantDB interface {
+	Create(orm.DB, RoleGrant) (*RoleGrant, error)
+	View(orm.DB, int) (*RoleGrant, error)
+	List(orm.DB, int) ([]RoleGrant, error)
+	ListActive(orm.DB, int, time.Time) ([]RoleGrant, error)
+	Delete(orm.DB, *RoleGrant) error
+}
@@ -0,0 +1,129 @@
+// Package grant contains temporary role grant application services
+package grant
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// New creates new role grant application service
+func New(gdb model.GrantDB, udb model.UserDB, rbac model.RBACService, notifier model.Notifier, auth model.AuthService) *Service {
+	return &Service{gdb: gdb, udb: udb, rbac: rbac, notifier: notifier, auth: auth}
+}
+
+// Service represents role grant application service
+type Service struct {
+	gdb      model.GrantDB
+	udb      model.UserDB
+	rbac     model.RBACService
+	notifier model.Notifier
+	auth     model.AuthService
+}
+
+// Create grants a temporary role to a user.
+// Requesting user must be able to create accounts with granted role in granted location, and administer granted company
+func (s *Service) Create(c echo.Context, g model.RoleGrant) (*model.RoleGrant, error) {
+	if err := s.rbac.AccountCreate(c, int(g.AccessLevel), g.CompanyID, g.LocationID); err != nil {
+		return nil, err
+	}
+	if err := s.rbac.EnforceCompany(c, g.CompanyID); err != nil {
+		return nil, err
+	}
+	l, err := s.udb.Location(model.Conn(c), g.LocationID)
+	if err != nil {
+		return nil, err
+	}
+	if l.CompanyID != g.CompanyID {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "location does not belong to the company")
+	}
+	u, err := s.udb.View(model.Conn(c), g.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u.CompanyID != g.CompanyID {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "user does not belong to the company")
+	}
+	if !g.EndsAt.After(time.Now()) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "grant has already expired")
+	}
+	g.DelegatorID = 0
+	return s.create(c, g)
+}
+
+// Delegate hands requesting user's own role, limited to its own scope, to a colleague for a period of time.
+// Only roles held directly can be delegated, not ones obtained through other grants
+func (s *Service) Delegate(c echo.Context, g model.RoleGrant) (*model.RoleGrant, error) {
+	au := s.auth.User(c)
+	if au.ID == g.UserID {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "cannot delegate role to yourself")
+	}
+	d, err := s.udb.View(model.Conn(c), au.ID)
+	if err != nil {
+		return nil, err
+	}
+	if g.AccessLevel == 0 {
+		g.AccessLevel = d.Role.AccessLevel
+	}
+	if d.Role.AccessLevel >= model.UserRole || g.AccessLevel < d.Role.AccessLevel {
+		return nil, echo.ErrForbidden
+	}
+	u, err := s.udb.View(model.Conn(c), g.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u.CompanyID != d.CompanyID {
+		return nil, echo.ErrForbidden
+	}
+	if !g.EndsAt.After(time.Now()) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "grant has already expired")
+	}
+	g.DelegatorID = d.ID
+	g.CompanyID = d.CompanyID
+	g.LocationID = d.LocationID
+	return s.create(c, g)
+}
+
+// create stores the grant and notifies its holder of the role change
+func (s *Service) create(c echo.Context, g model.RoleGrant) (*model.RoleGrant, error) {
+	rg, err := s.gdb.Create(model.Conn(c), g)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.notifier.Notify(c, model.Notification{
+		UserID:    rg.UserID,
+		CompanyID: rg.CompanyID,
+		Type:      model.NotificationRoleChanged,
+		Data:      map[string]interface{}{"grant_id": rg.ID, "access_level": rg.AccessLevel, "ends_at": rg.EndsAt},
+	}); err != nil {
+		return nil, err
+	}
+	return rg, nil
+}
+
+// List returns all grants given to a user
+func (s *Service) List(c echo.Context, userID int) ([]model.RoleGrant, error) {
+	if err := s.rbac.EnforceUser(c, userID); err != nil {
+		return nil, err
+	}
+	return s.gdb.List(model.Conn(c), userID)
+}
+
+// Revoke revokes a grant before it expires.
+// Grant can be revoked by its delegator, its holder, or anyone who could have granted it
+func (s *Service) Revoke(c echo.Context, id int) error {
+	g, err := s.gdb.View(model.Conn(c), id)
+	if err != nil {
+		return err
+	}
+	au := s.auth.User(c)
+	if au.ID != g.DelegatorID && au.ID != g.UserID {
+		if err := s.rbac.AccountCreate(c, int(g.AccessLevel), g.CompanyID, g.LocationID); err != nil {
+			return err
+		}
+	}
+	return s.gdb.Delete(model.Conn(c), g)
+}
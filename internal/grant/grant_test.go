@@ -0,0 +1,349 @@
+package grant_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/grant"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+func authUser(id int) *mock.Auth {
+	return &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: id}
+		}}
+}
+
+// notifier returns notifier mock recording emitted notifications
+func notifier(sent *[]model.Notification) *mock.Notifier {
+	return &mock.Notifier{
+		NotifyFn: func(c echo.Context, n model.Notification) error {
+			*sent = append(*sent, n)
+			return nil
+		}}
+}
+
+func TestCreate(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	// Locations 1 and 2 belong to company 1, location 3 to company 2
+	location := func(db orm.DB, id int) (*model.Location, error) {
+		return &model.Location{Base: model.Base{ID: id}, CompanyID: (id + 1) / 2}, nil
+	}
+	cases := []struct {
+		name     string
+		req      model.RoleGrant
+		wantErr  bool
+		wantData *model.RoleGrant
+		gdb      *mockdb.Grant
+		udb      *mockdb.User
+		rbac     *mock.RBAC
+	}{
+		{
+			name:    "Fail on RBAC",
+			req:     model.RoleGrant{UserID: 2, AccessLevel: model.CompanyAdminRole, CompanyID: 1, LocationID: 1},
+			wantErr: true,
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, int, int, int) error {
+					return echo.ErrForbidden
+				}},
+		},
+		{
+			name:    "Fail on company",
+			req:     model.RoleGrant{UserID: 2, AccessLevel: model.LocationAdminRole, CompanyID: 2, LocationID: 3, EndsAt: future},
+			wantErr: true,
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, int, int, int) error {
+					return nil
+				},
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return echo.ErrForbidden
+				}},
+		},
+		{
+			name:    "Location from another company",
+			req:     model.RoleGrant{UserID: 2, AccessLevel: model.LocationAdminRole, CompanyID: 1, LocationID: 3, EndsAt: future},
+			wantErr: true,
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, int, int, int) error {
+					return nil
+				},
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return nil
+				}},
+			udb: &mockdb.User{
+				LocationFn: location,
+			},
+		},
+		{
+			name:    "User from another company",
+			req:     model.RoleGrant{UserID: 2, AccessLevel: model.LocationAdminRole, CompanyID: 1, LocationID: 1, EndsAt: future},
+			wantErr: true,
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, int, int, int) error {
+					return nil
+				},
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return nil
+				}},
+			udb: &mockdb.User{
+				LocationFn: location,
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, CompanyID: 2}, nil
+				}},
+		},
+		{
+			name:    "Already expired",
+			req:     model.RoleGrant{UserID: 2, AccessLevel: model.LocationAdminRole, CompanyID: 1, LocationID: 1, EndsAt: mock.TestTime(2000)},
+			wantErr: true,
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, int, int, int) error {
+					return nil
+				},
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return nil
+				}},
+			udb: &mockdb.User{
+				LocationFn: location,
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, CompanyID: 1}, nil
+				}},
+		},
+		{
+			name: "Success",
+			req:  model.RoleGrant{UserID: 2, AccessLevel: model.LocationAdminRole, CompanyID: 1, LocationID: 1, DelegatorID: 9, EndsAt: future},
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, int, int, int) error {
+					return nil
+				},
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return nil
+				}},
+			udb: &mockdb.User{
+				LocationFn: location,
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}, CompanyID: 1}, nil
+				}},
+			gdb: &mockdb.Grant{
+				CreateFn: func(db orm.DB, g model.RoleGrant) (*model.RoleGrant, error) {
+					g.ID = 1
+					return &g, nil
+				}},
+			wantData: &model.RoleGrant{Base: model.Base{ID: 1}, UserID: 2, AccessLevel: model.LocationAdminRole, CompanyID: 1, LocationID: 1, EndsAt: future},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []model.Notification
+			s := grant.New(tt.gdb, tt.udb, tt.rbac, notifier(&sent), nil)
+			g, err := s.Create(nil, tt.req)
+			assert.Equal(t, tt.wantData, g)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Len(t, sent, 1)
+				assert.Equal(t, model.NotificationRoleChanged, sent[0].Type)
+				assert.Equal(t, 2, sent[0].UserID)
+			}
+		})
+	}
+}
+
+func TestDelegate(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	users := &mockdb.User{
+		ViewFn: func(db orm.DB, id int) (*model.User, error) {
+			switch id {
+			case 1:
+				return &model.User{Base: model.Base{ID: 1}, CompanyID: 1, LocationID: 4, Role: &model.Role{AccessLevel: model.LocationAdminRole}}, nil
+			case 2:
+				return &model.User{Base: model.Base{ID: 2}, CompanyID: 1, LocationID: 5, Role: &model.Role{AccessLevel: model.UserRole}}, nil
+			case 3:
+				return &model.User{Base: model.Base{ID: 3}, CompanyID: 2, LocationID: 6, Role: &model.Role{AccessLevel: model.UserRole}}, nil
+			}
+			return nil, model.ErrGeneric
+		}}
+	cases := []struct {
+		name     string
+		auth     *mock.Auth
+		req      model.RoleGrant
+		wantErr  bool
+		wantData *model.RoleGrant
+		gdb      *mockdb.Grant
+	}{
+		{
+			name:    "Delegating to yourself",
+			auth:    authUser(1),
+			req:     model.RoleGrant{UserID: 1, EndsAt: future},
+			wantErr: true,
+		},
+		{
+			name:    "Delegator has nothing to delegate",
+			auth:    authUser(2),
+			req:     model.RoleGrant{UserID: 1, EndsAt: future},
+			wantErr: true,
+		},
+		{
+			name:    "Delegating higher role than own",
+			auth:    authUser(1),
+			req:     model.RoleGrant{UserID: 2, AccessLevel: model.CompanyAdminRole, EndsAt: future},
+			wantErr: true,
+		},
+		{
+			name:    "Delegating to another company",
+			auth:    authUser(1),
+			req:     model.RoleGrant{UserID: 3, EndsAt: future},
+			wantErr: true,
+		},
+		{
+			name:    "Already expired",
+			auth:    authUser(1),
+			req:     model.RoleGrant{UserID: 2, EndsAt: mock.TestTime(2000)},
+			wantErr: true,
+		},
+		{
+			name: "Success",
+			auth: authUser(1),
+			req:  model.RoleGrant{UserID: 2, CompanyID: 9, LocationID: 9, StartsAt: mock.TestTime(2000), EndsAt: future},
+			gdb: &mockdb.Grant{
+				CreateFn: func(db orm.DB, g model.RoleGrant) (*model.RoleGrant, error) {
+					g.ID = 1
+					return &g, nil
+				}},
+			wantData: &model.RoleGrant{
+				Base:        model.Base{ID: 1},
+				UserID:      2,
+				AccessLevel: model.LocationAdminRole,
+				CompanyID:   1,
+				LocationID:  4,
+				DelegatorID: 1,
+				StartsAt:    mock.TestTime(2000),
+				EndsAt:      future,
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []model.Notification
+			s := grant.New(tt.gdb, users, nil, notifier(&sent), tt.auth)
+			g, err := s.Delegate(nil, tt.req)
+			assert.Equal(t, tt.wantData, g)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Len(t, sent, 1)
+				assert.Equal(t, model.NotificationRoleChanged, sent[0].Type)
+				assert.Equal(t, 2, sent[0].UserID)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		wantData []model.RoleGrant
+		gdb      *mockdb.Grant
+		rbac     *mock.RBAC
+	}{
+		{
+			name:    "Fail on RBAC",
+			wantErr: true,
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(echo.Context, int) error {
+					return echo.ErrForbidden
+				}},
+		},
+		{
+			name: "Success",
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(echo.Context, int) error {
+					return nil
+				}},
+			gdb: &mockdb.Grant{
+				ListFn: func(db orm.DB, id int) ([]model.RoleGrant, error) {
+					return []model.RoleGrant{{Base: model.Base{ID: 1}, UserID: id}}, nil
+				}},
+			wantData: []model.RoleGrant{{Base: model.Base{ID: 1}, UserID: 5}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := grant.New(tt.gdb, nil, tt.rbac, nil, nil)
+			grants, err := s.List(nil, 5)
+			assert.Equal(t, tt.wantData, grants)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	gdb := func() *mockdb.Grant {
+		return &mockdb.Grant{
+			ViewFn: func(db orm.DB, id int) (*model.RoleGrant, error) {
+				if id == 1 {
+					return &model.RoleGrant{Base: model.Base{ID: 1}, UserID: 2, DelegatorID: 3, AccessLevel: model.LocationAdminRole}, nil
+				}
+				return nil, model.ErrGeneric
+			},
+			DeleteFn: func(orm.DB, *model.RoleGrant) error {
+				return nil
+			}}
+	}
+	cases := []struct {
+		name    string
+		id      int
+		auth    *mock.Auth
+		rbac    *mock.RBAC
+		wantErr bool
+	}{
+		{
+			name:    "Fail on view",
+			id:      2,
+			wantErr: true,
+		},
+		{
+			name:    "Not related, fail on RBAC",
+			id:      1,
+			auth:    authUser(7),
+			wantErr: true,
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, int, int, int) error {
+					return echo.ErrForbidden
+				}},
+		},
+		{
+			name: "Revoked by delegator",
+			id:   1,
+			auth: authUser(3),
+		},
+		{
+			name: "Revoked by holder",
+			id:   1,
+			auth: authUser(2),
+		},
+		{
+			name: "Revoked by admin",
+			id:   1,
+			auth: authUser(7),
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, int, int, int) error {
+					return nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := grant.New(gdb(), nil, tt.rbac, nil, tt.auth)
+			err := s.Revoke(nil, tt.id)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestGrantActive(t *testing.T) {
+	g := &model.RoleGrant{StartsAt: mock.TestTime(2001), EndsAt: mock.TestTime(2003)}
+	if g.Active(mock.TestTime(2000)) {
+		t.Error("Grant should not be active before it starts")
+	}
+	if !g.Active(mock.TestTime(2002)) {
+		t.Error("Grant should be active")
+	}
+	if g.Active(mock.TestTime(2003)) {
+		t.Error("Grant should expire at its end time")
+	}
+	g.Delete()
+	if g.Active(mock.TestTime(2002)) {
+		t.Error("Revoked grant should not be active")
+	}
+}
+
+func TestApplyGrants(t *testing.T) {
+	grants := []model.RoleGrant{
+		{Base: model.Base{ID: 1}, AccessLevel: model.LocationAdminRole, CompanyID: 1, LocationID: 3, StartsAt: mock.TestTime(2001), EndsAt: mock.TestTime(2003)},
+		{Base: model.Base{ID: 2}, AccessLevel: model.CompanyAdminRole, CompanyID: 1, LocationID: 4, StartsAt: mock.TestTime(2001), EndsAt: mock.TestTime(2002)},
+		{Base: model.Base{ID: 3}, AccessLevel: model.SuperAdminRole, CompanyID: 1, LocationID: 4, StartsAt: mock.TestTime(2010), EndsAt: mock.TestTime(2011)},
+	}
+	cases := []struct {
+		name     string
+		user     model.AuthUser
+		wantData model.AuthUser
+	}{
+		{
+			name:     "Highest active grant wins",
+			user:     model.AuthUser{ID: 5, Role: model.UserRole, CompanyID: 1, LocationID: 2},
+			wantData: model.AuthUser{ID: 5, Role: model.CompanyAdminRole, CompanyID: 1, LocationID: 4, GrantID: 2},
+		},
+		{
+			name:     "Own role is higher",
+			user:     model.AuthUser{ID: 5, Role: model.AdminRole, CompanyID: 1, LocationID: 2},
+			wantData: model.AuthUser{ID: 5, Role: model.AdminRole, CompanyID: 1, LocationID: 2},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.user.ApplyGrants(grants, mock.TestTime(2001))
+			assert.Equal(t, tt.wantData, tt.user)
+		})
+	}
+}
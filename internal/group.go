@@ -0,0 +1,145 @@
+package model
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// GroupVisibility represents who can find, read and join a group
+type GroupVisibility string
+
+const (
+	// GroupPublic groups can be read and joined by anyone in the company
+	GroupPublic GroupVisibility = "public"
+
+	// GroupPrivate groups are listed in the company, but joining needs approval of a moderator
+	// and only members can read them
+	GroupPrivate GroupVisibility = "private"
+
+	// GroupInviteOnly groups are hidden from non-members and joined by invitation only
+	GroupInviteOnly GroupVisibility = "invite_only"
+)
+
+// GroupRole represents role of a member within a group
+type GroupRole string
+
+const (
+	// GroupRoleOwner manages the group, its moderators and can delete it
+	GroupRoleOwner GroupRole = "owner"
+
+	// GroupRoleModerator manages members and posts of the group
+	GroupRoleModerator GroupRole = "moderator"
+
+	// GroupRoleMember reads and posts to the group
+	GroupRoleMember GroupRole = "member"
+)
+
+// Level returns rank of the role within a group, higher being more privileged.
+// Zero means the user has no role in the group
+func (r GroupRole) Level() int {
+	switch r {
+	case GroupRoleOwner:
+		return 3
+	case GroupRoleModerator:
+		return 2
+	case GroupRoleMember:
+		return 1
+	}
+	return 0
+}
+
+// MembershipStatus represents state of user's membership in a group
+type MembershipStatus string
+
+const (
+	// MembershipActive means user is a member of the group
+	MembershipActive MembershipStatus = "active"
+
+	// MembershipRequested means user asked to join the group and waits for approval
+	MembershipRequested MembershipStatus = "requested"
+
+	// MembershipInvited means user was invited to the group and has not joined yet
+	MembershipInvited MembershipStatus = "invited"
+)
+
+// Group represents interest group of users within a company
+type Group struct {
+	Base
+	CompanyID   int             `json:"company_id"`
+	OwnerID     int             `json:"owner_id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Visibility  GroupVisibility `json:"visibility"`
+
+	Members int `json:"members" sql:"-"`
+}
+
+// Listed checks whether the group is shown to users who are not its members
+func (g *Group) Listed() bool {
+	return g.Visibility != GroupInviteOnly
+}
+
+// Open checks whether the group can be read and joined without approval
+func (g *Group) Open() bool {
+	return g.Visibility == GroupPublic
+}
+
+// GroupMember represents user's membership in a group, including pending requests and invitations
+type GroupMember struct {
+	GroupID   int              `json:"group_id" sql:",pk"`
+	UserID    int              `json:"user_id" sql:",pk"`
+	CompanyID int              `json:"-"`
+	Role      GroupRole        `json:"role"`
+	Status    MembershipStatus `json:"status"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// Active checks whether user has joined the group
+func (m *GroupMember) Active() bool {
+	return m != nil && m.Status == MembershipActive
+}
+
+// Effective returns role of the member within the group.
+// Users with pending requests or invitations have no role
+func (m *GroupMember) Effective() GroupRole {
+	if !m.Active() {
+		return ""
+	}
+	return m.Role
+}
+
+// GroupPost represents a post in a group
+type GroupPost struct {
+	Base
+	GroupID   int    `json:"group_id"`
+	CompanyID int    `json:"-"`
+	AuthorID  int    `json:"author_id"`
+	Body      string `json:"body"`
+}
+
+// GroupFilter holds group list filters.
+// Unlisted groups are returned only to their members, unless All is set
+type GroupFilter struct {
+	CompanyID int
+	UserID    int
+	All       bool
+}
+
+// GroupDB represents group database interface (repository)
+type GroupDB interface {
+	Create(orm.DB, Group) (*Group, error)
+	View(orm.DB, int) (*Group, error)
+	List(orm.DB, *GroupFilter, *Pagination) ([]Group, error)
+	Update(orm.DB, *Group) error
+	Delete(orm.DB, *Group) error
+	Member(orm.DB, int, int) (*GroupMember, error)
+	Members(orm.DB, int, MembershipStatus, *Pagination) ([]GroupMember, error)
+	SetMember(orm.DB, *GroupMember) error
+	RemoveMember(orm.DB, *GroupMember) error
+	CreatePost(orm.DB, GroupPost) (*GroupPost, error)
+	ViewPost(orm.DB, int) (*GroupPost, error)
+	Posts(orm.DB, int, *Pagination) ([]GroupPost, error)
+	DeletePost(orm.DB, *GroupPost) error
+}
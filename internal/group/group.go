@@ -0,0 +1,395 @@
+// Package group contains interest group application services
+package group
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/internal/platform/structs"
+)
+
+// New creates new group application service
+func New(gdb model.GroupDB, udb model.UserDB, rbac model.RBACService, auth model.AuthService) *Service {
+	return &Service{gdb: gdb, udb: udb, rbac: rbac, auth: auth}
+}
+
+// Service represents group application service
+type Service struct {
+	gdb  model.GroupDB
+	udb  model.UserDB
+	rbac model.RBACService
+	auth model.AuthService
+}
+
+// Create creates a group in requesting user's company, owned by the user
+func (s *Service) Create(c echo.Context, gr model.Group) (*model.Group, error) {
+	au := s.auth.User(c)
+	gr.CompanyID = au.CompanyID
+	gr.OwnerID = au.ID
+	created, err := s.gdb.Create(model.Conn(c), gr)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := s.gdb.SetMember(model.Conn(c), &model.GroupMember{
+		GroupID:   created.ID,
+		UserID:    au.ID,
+		CompanyID: created.CompanyID,
+		Role:      model.GroupRoleOwner,
+		Status:    model.MembershipActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}); err != nil {
+		return nil, err
+	}
+	created.Members = 1
+	return created, nil
+}
+
+// List returns groups of requesting user's company.
+// Invite-only groups are listed only to users invited to or belonging to them, and to company admins
+func (s *Service) List(c echo.Context, p *model.Pagination) ([]model.Group, error) {
+	au := s.auth.User(c)
+	return s.gdb.List(model.Conn(c), &model.GroupFilter{
+		CompanyID: au.CompanyID,
+		UserID:    au.ID,
+		All:       s.rbac.EnforceCompany(c, au.CompanyID) == nil,
+	}, p)
+}
+
+// View returns single group
+func (s *Service) View(c echo.Context, id int) (*model.Group, error) {
+	gr, _, err := s.view(c, id)
+	return gr, err
+}
+
+// Update contains group details used for updating
+type Update struct {
+	ID          int
+	Name        *string
+	Description *string
+	Visibility  *model.GroupVisibility
+}
+
+// Update updates group details. Only group owner and company admins can update a group
+func (s *Service) Update(c echo.Context, u *Update) (*model.Group, error) {
+	gr, m, err := s.view(c, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enforce(c, gr, m, model.GroupRoleOwner); err != nil {
+		return nil, err
+	}
+	structs.Merge(gr, u)
+	if err := s.gdb.Update(model.Conn(c), gr); err != nil {
+		return nil, err
+	}
+	return gr, nil
+}
+
+// Delete deletes a group. Only group owner and company admins can delete a group
+func (s *Service) Delete(c echo.Context, id int) error {
+	gr, m, err := s.view(c, id)
+	if err != nil {
+		return err
+	}
+	if err := s.enforce(c, gr, m, model.GroupRoleOwner); err != nil {
+		return err
+	}
+	return s.gdb.Delete(model.Conn(c), gr)
+}
+
+// Join adds requesting user to a group. Public groups are joined right away, invite-only groups by accepting an invitation,
+// while joining a private group requests approval of its moderators
+func (s *Service) Join(c echo.Context, id int) (*model.GroupMember, error) {
+	gr, m, err := s.view(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if m.Active() {
+		return m, nil
+	}
+	now := time.Now()
+	if m == nil {
+		au := s.auth.User(c)
+		m = &model.GroupMember{GroupID: gr.ID, UserID: au.ID, CompanyID: gr.CompanyID, Role: model.GroupRoleMember, CreatedAt: now}
+	}
+	switch {
+	case gr.Open() || m.Status == model.MembershipInvited:
+		m.Status = model.MembershipActive
+	case gr.Visibility == model.GroupPrivate:
+		if m.Status == model.MembershipRequested {
+			return m, nil
+		}
+		m.Status = model.MembershipRequested
+	default:
+		return nil, echo.NewHTTPError(http.StatusForbidden, "group can be joined by invitation only")
+	}
+	m.UpdatedAt = now
+	if err := s.gdb.SetMember(model.Conn(c), m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Leave removes requesting user from a group, withdrawing its join request or declining its invitation.
+// Owner has to transfer the ownership before leaving
+func (s *Service) Leave(c echo.Context, id int) error {
+	_, m, err := s.view(c, id)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return echo.ErrNotFound
+	}
+	if m.Effective() == model.GroupRoleOwner {
+		return echo.NewHTTPError(http.StatusBadRequest, "owner cannot leave the group")
+	}
+	return s.gdb.RemoveMember(model.Conn(c), m)
+}
+
+// Members returns memberships of a group in the given status.
+// Members of public groups are listed to everyone, of other groups only to their members.
+// Join requests and invitations are listed only to moderators
+func (s *Service) Members(c echo.Context, id int, status model.MembershipStatus, p *model.Pagination) ([]model.GroupMember, error) {
+	gr, m, err := s.view(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if status == model.MembershipActive {
+		if err := s.readable(c, gr, m); err != nil {
+			return nil, err
+		}
+	} else if err := s.enforce(c, gr, m, model.GroupRoleModerator); err != nil {
+		return nil, err
+	}
+	return s.gdb.Members(model.Conn(c), gr.ID, status, p)
+}
+
+// Invite invites a user of the group's company to a group. Inviting a user who requested to join approves the request.
+// Only moderators can invite users
+func (s *Service) Invite(c echo.Context, id, userID int) (*model.GroupMember, error) {
+	gr, m, err := s.view(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enforce(c, gr, m, model.GroupRoleModerator); err != nil {
+		return nil, err
+	}
+	u, err := s.udb.View(model.Conn(c), userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.CompanyID != gr.CompanyID {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "user does not belong to the group's company")
+	}
+	target, err := s.gdb.Member(model.Conn(c), gr.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	switch {
+	case target == nil:
+		target = &model.GroupMember{GroupID: gr.ID, UserID: userID, CompanyID: gr.CompanyID, Role: model.GroupRoleMember,
+			Status: model.MembershipInvited, CreatedAt: now}
+	case target.Status == model.MembershipRequested:
+		target.Status = model.MembershipActive
+	case target.Status == model.MembershipInvited:
+		return target, nil
+	default:
+		return nil, echo.NewHTTPError(http.StatusConflict, "User is already a member.")
+	}
+	target.UpdatedAt = now
+	if err := s.gdb.SetMember(model.Conn(c), target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// Approve approves user's request to join a group. Only moderators can approve requests
+func (s *Service) Approve(c echo.Context, id, userID int) (*model.GroupMember, error) {
+	gr, m, err := s.view(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enforce(c, gr, m, model.GroupRoleModerator); err != nil {
+		return nil, err
+	}
+	target, err := s.gdb.Member(model.Conn(c), gr.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil || target.Status != model.MembershipRequested {
+		return nil, echo.ErrNotFound
+	}
+	target.Status = model.MembershipActive
+	target.UpdatedAt = time.Now()
+	if err := s.gdb.SetMember(model.Conn(c), target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// RemoveMember removes a member from a group, rejecting its join request or revoking its invitation.
+// Moderators remove members, while only owner removes moderators. Owner cannot be removed
+func (s *Service) RemoveMember(c echo.Context, id, userID int) error {
+	gr, m, err := s.view(c, id)
+	if err != nil {
+		return err
+	}
+	target, err := s.gdb.Member(model.Conn(c), gr.ID, userID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return echo.ErrNotFound
+	}
+	required := model.GroupRoleModerator
+	switch target.Effective() {
+	case model.GroupRoleOwner:
+		return echo.NewHTTPError(http.StatusBadRequest, "owner cannot be removed from the group")
+	case model.GroupRoleModerator:
+		required = model.GroupRoleOwner
+	}
+	if err := s.enforce(c, gr, m, required); err != nil {
+		return err
+	}
+	return s.gdb.RemoveMember(model.Conn(c), target)
+}
+
+// SetRole changes role of a member within a group. Making a member the owner transfers the ownership,
+// leaving the previous owner a moderator. Only owner and company admins can change roles
+func (s *Service) SetRole(c echo.Context, id, userID int, role model.GroupRole) (*model.GroupMember, error) {
+	gr, m, err := s.view(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enforce(c, gr, m, model.GroupRoleOwner); err != nil {
+		return nil, err
+	}
+	target, err := s.gdb.Member(model.Conn(c), gr.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !target.Active() {
+		return nil, echo.ErrNotFound
+	}
+	if target.Role == role {
+		return target, nil
+	}
+	if target.Role == model.GroupRoleOwner {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "ownership has to be transferred to another member")
+	}
+	now := time.Now()
+	if role == model.GroupRoleOwner {
+		owner, err := s.gdb.Member(model.Conn(c), gr.ID, gr.OwnerID)
+		if err != nil {
+			return nil, err
+		}
+		if owner != nil {
+			owner.Role = model.GroupRoleModerator
+			owner.UpdatedAt = now
+			if err := s.gdb.SetMember(model.Conn(c), owner); err != nil {
+				return nil, err
+			}
+		}
+		gr.OwnerID = target.UserID
+		if err := s.gdb.Update(model.Conn(c), gr); err != nil {
+			return nil, err
+		}
+	}
+	target.Role = role
+	target.UpdatedAt = now
+	if err := s.gdb.SetMember(model.Conn(c), target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// Posts returns posts of a group, newest first. Posts of public groups are visible to everyone in the company
+func (s *Service) Posts(c echo.Context, id int, p *model.Pagination) ([]model.GroupPost, error) {
+	gr, m, err := s.view(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.readable(c, gr, m); err != nil {
+		return nil, err
+	}
+	return s.gdb.Posts(model.Conn(c), gr.ID, p)
+}
+
+// CreatePost creates a post in a group. Only members can post
+func (s *Service) CreatePost(c echo.Context, id int, body string) (*model.GroupPost, error) {
+	gr, m, err := s.view(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enforce(c, gr, m, model.GroupRoleMember); err != nil {
+		return nil, err
+	}
+	return s.gdb.CreatePost(model.Conn(c), model.GroupPost{
+		GroupID:   gr.ID,
+		CompanyID: gr.CompanyID,
+		AuthorID:  s.auth.User(c).ID,
+		Body:      body,
+	})
+}
+
+// DeletePost deletes a post in a group. Posts are deleted by their authors and moderators
+func (s *Service) DeletePost(c echo.Context, id, postID int) error {
+	gr, m, err := s.view(c, id)
+	if err != nil {
+		return err
+	}
+	p, err := s.gdb.ViewPost(model.Conn(c), postID)
+	if err != nil {
+		return err
+	}
+	if p.GroupID != gr.ID {
+		return echo.ErrNotFound
+	}
+	if p.AuthorID != s.auth.User(c).ID {
+		if err := s.enforce(c, gr, m, model.GroupRoleModerator); err != nil {
+			return err
+		}
+	}
+	return s.gdb.DeletePost(model.Conn(c), p)
+}
+
+// view returns a group visible to requesting user with the user's membership in it.
+// Groups of other companies and unlisted groups the user is not invited to or belonging to are visible only to company admins
+func (s *Service) view(c echo.Context, id int) (*model.Group, *model.GroupMember, error) {
+	gr, err := s.gdb.View(model.Conn(c), id)
+	if err != nil {
+		return nil, nil, err
+	}
+	au := s.auth.User(c)
+	m, err := s.gdb.Member(model.Conn(c), gr.ID, au.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m == nil && (gr.CompanyID != au.CompanyID || !gr.Listed()) {
+		if err := s.enforce(c, gr, m, model.GroupRoleMember); err != nil {
+			return nil, nil, echo.ErrNotFound
+		}
+	}
+	return gr, m, nil
+}
+
+// readable checks whether requesting user can read members and posts of a group.
+// Public groups are readable by everyone who can see them
+func (s *Service) readable(c echo.Context, gr *model.Group, m *model.GroupMember) error {
+	if gr.Open() {
+		return nil
+	}
+	return s.enforce(c, gr, m, model.GroupRoleMember)
+}
+
+// enforce checks whether requesting user has at least the required role within a group, or moderates it as company admin
+func (s *Service) enforce(c echo.Context, gr *model.Group, m *model.GroupMember, required model.GroupRole) error {
+	return s.rbac.EnforceGroup(c, gr.CompanyID, m.Effective(), required)
+}
@@ -0,0 +1,480 @@
+package group_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/group"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+func authUser(id int) *mock.Auth {
+	return &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: id, CompanyID: 1}
+		}}
+}
+
+// groupRBAC mocks RBAC evaluating group roles, letting company admins moderate every group
+func groupRBAC(companyAdmin bool) *mock.RBAC {
+	return &mock.RBAC{
+		EnforceGroupFn: func(c echo.Context, companyID int, role, required model.GroupRole) error {
+			if role.Level() >= required.Level() || companyAdmin {
+				return nil
+			}
+			return echo.ErrForbidden
+		},
+		EnforceCompanyFn: func(echo.Context, int) error {
+			if companyAdmin {
+				return nil
+			}
+			return echo.ErrForbidden
+		}}
+}
+
+// groups mocks database holding a single group with memberships, recording the changed memberships
+func groups(gr model.Group, members []model.GroupMember, set *[]model.GroupMember) *mockdb.Group {
+	return &mockdb.Group{
+		ViewFn: func(db orm.DB, id int) (*model.Group, error) {
+			if id != gr.ID {
+				return nil, model.ErrGeneric
+			}
+			g := gr
+			return &g, nil
+		},
+		MemberFn: func(db orm.DB, groupID, userID int) (*model.GroupMember, error) {
+			for _, m := range members {
+				if m.UserID == userID {
+					return &m, nil
+				}
+			}
+			return nil, nil
+		},
+		SetMemberFn: func(db orm.DB, m *model.GroupMember) error {
+			*set = append(*set, *m)
+			return nil
+		},
+		RemoveMemberFn: func(db orm.DB, m *model.GroupMember) error {
+			*set = append(*set, model.GroupMember{UserID: m.UserID})
+			return nil
+		},
+		UpdateFn: func(orm.DB, *model.Group) error {
+			return nil
+		}}
+}
+
+// members of the mocked groups: 1 owns, 2 moderates, 3 is a member, 4 requested to join and 5 is invited
+var members = []model.GroupMember{
+	{GroupID: 1, UserID: 1, Role: model.GroupRoleOwner, Status: model.MembershipActive},
+	{GroupID: 1, UserID: 2, Role: model.GroupRoleModerator, Status: model.MembershipActive},
+	{GroupID: 1, UserID: 3, Role: model.GroupRoleMember, Status: model.MembershipActive},
+	{GroupID: 1, UserID: 4, Role: model.GroupRoleMember, Status: model.MembershipRequested},
+	{GroupID: 1, UserID: 5, Role: model.GroupRoleMember, Status: model.MembershipInvited},
+}
+
+func testGroup(v model.GroupVisibility) model.Group {
+	return model.Group{Base: model.Base{ID: 1}, CompanyID: 1, OwnerID: 1, Name: "runners", Visibility: v}
+}
+
+func TestCreate(t *testing.T) {
+	var set []model.GroupMember
+	gdb := &mockdb.Group{
+		CreateFn: func(db orm.DB, gr model.Group) (*model.Group, error) {
+			gr.ID = 1
+			return &gr, nil
+		},
+		SetMemberFn: func(db orm.DB, m *model.GroupMember) error {
+			set = append(set, *m)
+			return nil
+		}}
+	s := group.New(gdb, nil, nil, authUser(7))
+	gr, err := s.Create(nil, model.Group{Name: "runners", Visibility: model.GroupPublic})
+	assert.Nil(t, err)
+	assert.Equal(t, 7, gr.OwnerID)
+	assert.Equal(t, 1, gr.CompanyID)
+	assert.Equal(t, 1, gr.Members)
+	assert.Equal(t, 1, len(set))
+	assert.Equal(t, model.GroupRoleOwner, set[0].Role)
+	assert.Equal(t, model.MembershipActive, set[0].Status)
+}
+
+func TestView(t *testing.T) {
+	cases := []struct {
+		name         string
+		visibility   model.GroupVisibility
+		user         int
+		companyAdmin bool
+		wantErr      error
+	}{
+		{
+			name:       "Listed group",
+			visibility: model.GroupPrivate,
+			user:       9,
+		},
+		{
+			name:       "Unlisted group",
+			visibility: model.GroupInviteOnly,
+			user:       9,
+			wantErr:    echo.ErrNotFound,
+		},
+		{
+			name:       "Invited to unlisted group",
+			visibility: model.GroupInviteOnly,
+			user:       5,
+		},
+		{
+			name:         "Company admin",
+			visibility:   model.GroupInviteOnly,
+			user:         9,
+			companyAdmin: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var set []model.GroupMember
+			s := group.New(groups(testGroup(tt.visibility), members, &set), nil, groupRBAC(tt.companyAdmin), authUser(tt.user))
+			gr, err := s.View(nil, 1)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantErr == nil, gr != nil)
+		})
+	}
+}
+
+func TestJoin(t *testing.T) {
+	cases := []struct {
+		name       string
+		visibility model.GroupVisibility
+		user       int
+		wantErr    bool
+		wantStatus model.MembershipStatus
+		wantSet    bool
+	}{
+		{
+			name:       "Public group",
+			visibility: model.GroupPublic,
+			user:       9,
+			wantStatus: model.MembershipActive,
+			wantSet:    true,
+		},
+		{
+			name:       "Private group",
+			visibility: model.GroupPrivate,
+			user:       9,
+			wantStatus: model.MembershipRequested,
+			wantSet:    true,
+		},
+		{
+			name:       "Already requested",
+			visibility: model.GroupPrivate,
+			user:       4,
+			wantStatus: model.MembershipRequested,
+		},
+		{
+			name:       "Invited",
+			visibility: model.GroupPrivate,
+			user:       5,
+			wantStatus: model.MembershipActive,
+			wantSet:    true,
+		},
+		{
+			name:       "Already a member",
+			visibility: model.GroupInviteOnly,
+			user:       3,
+			wantStatus: model.MembershipActive,
+		},
+		{
+			name:       "Invite-only group",
+			visibility: model.GroupInviteOnly,
+			user:       4,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var set []model.GroupMember
+			s := group.New(groups(testGroup(tt.visibility), members, &set), nil, groupRBAC(false), authUser(tt.user))
+			m, err := s.Join(nil, 1)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if err == nil {
+				assert.Equal(t, tt.wantStatus, m.Status)
+				assert.Equal(t, tt.user, m.UserID)
+			}
+			assert.Equal(t, tt.wantSet, len(set) == 1)
+		})
+	}
+}
+
+func TestLeave(t *testing.T) {
+	var set []model.GroupMember
+	s := group.New(groups(testGroup(model.GroupPublic), members, &set), nil, groupRBAC(false), authUser(1))
+	assert.NotNil(t, s.Leave(nil, 1))
+	s = group.New(groups(testGroup(model.GroupPublic), members, &set), nil, groupRBAC(false), authUser(2))
+	assert.Nil(t, s.Leave(nil, 1))
+	assert.Equal(t, []model.GroupMember{{UserID: 2}}, set)
+}
+
+func TestMembers(t *testing.T) {
+	cases := []struct {
+		name       string
+		visibility model.GroupVisibility
+		user       int
+		status     model.MembershipStatus
+		wantErr    bool
+	}{
+		{
+			name:       "Members of public group",
+			visibility: model.GroupPublic,
+			user:       9,
+			status:     model.MembershipActive,
+		},
+		{
+			name:       "Members of private group",
+			visibility: model.GroupPrivate,
+			user:       9,
+			status:     model.MembershipActive,
+			wantErr:    true,
+		},
+		{
+			name:       "Join requests by member",
+			visibility: model.GroupPublic,
+			user:       3,
+			status:     model.MembershipRequested,
+			wantErr:    true,
+		},
+		{
+			name:       "Join requests by moderator",
+			visibility: model.GroupPrivate,
+			user:       2,
+			status:     model.MembershipRequested,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var set []model.GroupMember
+			gdb := groups(testGroup(tt.visibility), members, &set)
+			gdb.MembersFn = func(db orm.DB, id int, status model.MembershipStatus, p *model.Pagination) ([]model.GroupMember, error) {
+				return []model.GroupMember{{GroupID: id, Status: status}}, nil
+			}
+			s := group.New(gdb, nil, groupRBAC(false), authUser(tt.user))
+			ms, err := s.Members(nil, 1, tt.status, &model.Pagination{Limit: 10})
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantErr, ms == nil)
+		})
+	}
+}
+
+func TestInvite(t *testing.T) {
+	udb := &mockdb.User{
+		ViewFn: func(db orm.DB, id int) (*model.User, error) {
+			companyID := 1
+			if id == 8 {
+				companyID = 2
+			}
+			return &model.User{Base: model.Base{ID: id}, CompanyID: companyID}, nil
+		}}
+	cases := []struct {
+		name       string
+		user       int
+		target     int
+		wantErr    bool
+		wantStatus model.MembershipStatus
+	}{
+		{
+			name:    "Invited by member",
+			user:    3,
+			target:  9,
+			wantErr: true,
+		},
+		{
+			name:    "User of another company",
+			user:    2,
+			target:  8,
+			wantErr: true,
+		},
+		{
+			name:    "Already a member",
+			user:    2,
+			target:  3,
+			wantErr: true,
+		},
+		{
+			name:       "Requested to join",
+			user:       2,
+			target:     4,
+			wantStatus: model.MembershipActive,
+		},
+		{
+			name:       "Success",
+			user:       2,
+			target:     9,
+			wantStatus: model.MembershipInvited,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var set []model.GroupMember
+			s := group.New(groups(testGroup(model.GroupInviteOnly), members, &set), udb, groupRBAC(false), authUser(tt.user))
+			m, err := s.Invite(nil, 1, tt.target)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if err == nil {
+				assert.Equal(t, tt.wantStatus, m.Status)
+				assert.Equal(t, tt.target, set[0].UserID)
+			}
+		})
+	}
+}
+
+func TestApprove(t *testing.T) {
+	var set []model.GroupMember
+	s := group.New(groups(testGroup(model.GroupPrivate), members, &set), nil, groupRBAC(false), authUser(2))
+	_, err := s.Approve(nil, 1, 5)
+	assert.Equal(t, echo.ErrNotFound, err)
+	m, err := s.Approve(nil, 1, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, model.MembershipActive, m.Status)
+}
+
+func TestRemoveMember(t *testing.T) {
+	cases := []struct {
+		name         string
+		user         int
+		target       int
+		companyAdmin bool
+		wantErr      bool
+	}{
+		{
+			name:    "Not a member",
+			user:    2,
+			target:  9,
+			wantErr: true,
+		},
+		{
+			name:    "Owner",
+			user:    2,
+			target:  1,
+			wantErr: true,
+		},
+		{
+			name:    "Moderator removing moderator",
+			user:    2,
+			target:  2,
+			wantErr: true,
+		},
+		{
+			name:   "Owner removing moderator",
+			user:   1,
+			target: 2,
+		},
+		{
+			name:   "Moderator rejecting join request",
+			user:   2,
+			target: 4,
+		},
+		{
+			name:         "Company admin removing member",
+			user:         9,
+			target:       3,
+			companyAdmin: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var set []model.GroupMember
+			s := group.New(groups(testGroup(model.GroupPublic), members, &set), nil, groupRBAC(tt.companyAdmin), authUser(tt.user))
+			err := s.RemoveMember(nil, 1, tt.target)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantErr, len(set) == 0)
+		})
+	}
+}
+
+func TestSetRole(t *testing.T) {
+	var set []model.GroupMember
+	s := group.New(groups(testGroup(model.GroupPublic), members, &set), nil, groupRBAC(false), authUser(2))
+	_, err := s.SetRole(nil, 1, 3, model.GroupRoleModerator)
+	assert.Equal(t, echo.ErrForbidden, err)
+
+	s = group.New(groups(testGroup(model.GroupPublic), members, &set), nil, groupRBAC(false), authUser(1))
+	_, err = s.SetRole(nil, 1, 4, model.GroupRoleModerator)
+	assert.Equal(t, echo.ErrNotFound, err)
+	_, err = s.SetRole(nil, 1, 1, model.GroupRoleMember)
+	assert.NotNil(t, err)
+
+	m, err := s.SetRole(nil, 1, 3, model.GroupRoleOwner)
+	assert.Nil(t, err)
+	assert.Equal(t, model.GroupRoleOwner, m.Role)
+	assert.Equal(t, 2, len(set))
+	assert.Equal(t, 1, set[0].UserID)
+	assert.Equal(t, model.GroupRoleModerator, set[0].Role)
+	assert.Equal(t, 3, set[1].UserID)
+}
+
+func TestCreatePost(t *testing.T) {
+	var set []model.GroupMember
+	gdb := groups(testGroup(model.GroupPublic), members, &set)
+	gdb.CreatePostFn = func(db orm.DB, p model.GroupPost) (*model.GroupPost, error) {
+		p.ID = 1
+		return &p, nil
+	}
+	s := group.New(gdb, nil, groupRBAC(false), authUser(4))
+	_, err := s.CreatePost(nil, 1, "hello")
+	assert.Equal(t, echo.ErrForbidden, err)
+
+	s = group.New(gdb, nil, groupRBAC(false), authUser(3))
+	p, err := s.CreatePost(nil, 1, "hello")
+	assert.Nil(t, err)
+	assert.Equal(t, &model.GroupPost{Base: model.Base{ID: 1}, GroupID: 1, CompanyID: 1, AuthorID: 3, Body: "hello"}, p)
+}
+
+func TestDeletePost(t *testing.T) {
+	cases := []struct {
+		name    string
+		user    int
+		post    int
+		wantErr error
+	}{
+		{
+			name:    "Post of another group",
+			user:    3,
+			post:    2,
+			wantErr: echo.ErrNotFound,
+		},
+		{
+			name:    "Post of another member",
+			user:    3,
+			post:    1,
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Own post",
+			user: 5,
+			post: 1,
+		},
+		{
+			name: "Moderator",
+			user: 2,
+			post: 1,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var set []model.GroupMember
+			gdb := groups(testGroup(model.GroupPublic), members, &set)
+			gdb.ViewPostFn = func(db orm.DB, id int) (*model.GroupPost, error) {
+				return &model.GroupPost{Base: model.Base{ID: id}, GroupID: id, AuthorID: 5}, nil
+			}
+			gdb.DeletePostFn = func(orm.DB, *model.GroupPost) error {
+				return nil
+			}
+			s := group.New(gdb, nil, groupRBAC(false), authUser(tt.user))
+			assert.Equal(t, tt.wantErr, s.DeletePost(nil, 1, tt.post))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+func TestGroupRoleLevel(t *testing.T) {
+	assert.True(t, model.GroupRoleOwner.Level() > model.GroupRoleModerator.Level())
+	assert.True(t, model.GroupRoleModerator.Level() > model.GroupRoleMember.Level())
+	assert.True(t, model.GroupRoleMember.Level() > model.GroupRole("").Level())
+	assert.Equal(t, 0, model.GroupRole("admin").Level())
+}
+
+func TestGroupVisibility(t *testing.T) {
+	cases := []struct {
+		visibility model.GroupVisibility
+		wantListed bool
+		wantOpen   bool
+	}{
+		{visibility: model.GroupPublic, wantListed: true, wantOpen: true},
+		{visibility: model.GroupPrivate, wantListed: true},
+		{visibility: model.GroupInviteOnly},
+	}
+	for _, tt := range cases {
+		t.Run(string(tt.visibility), func(t *testing.T) {
+			g := &model.Group{Visibility: tt.visibility}
+			assert.Equal(t, tt.wantListed, g.Listed())
+			assert.Equal(t, tt.wantOpen, g.Open())
+		})
+	}
+}
+
+func TestGroupMemberEffective(t *testing.T) {
+	cases := []struct {
+		name       string
+		member     *model.GroupMember
+		wantActive bool
+		wantRole   model.GroupRole
+	}{
+		{
+			name: "Not a member",
+		},
+		{
+			name:   "Requested",
+			member: &model.GroupMember{Role: model.GroupRoleMember, Status: model.MembershipRequested},
+		},
+		{
+			name:   "Invited",
+			member: &model.GroupMember{Role: model.GroupRoleMember, Status: model.MembershipInvited},
+		},
+		{
+			name:       "Moderator",
+			member:     &model.GroupMember{Role: model.GroupRoleModerator, Status: model.MembershipActive},
+			wantActive: true,
+			wantRole:   model.GroupRoleModerator,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantActive, tt.member.Active())
+			assert.Equal(t, tt.wantRole, tt.member.Effective())
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package model
+
+// MaxImportRows is the maximum number of users imported at once
+const MaxImportRows = 1000
+
+// ImportMode tells which users created by an import are kept
+type ImportMode int
+
+const (
+	// ImportDryRun keeps no users, only checking whether they can be created
+	ImportDryRun ImportMode = iota
+
+	// ImportAtomic keeps users only if all of them were created
+	ImportAtomic
+
+	// ImportBestEffort keeps users that were created, skipping the others
+	ImportBestEffort
+)
+
+// ImportRow represents a user to be created from a row of an import file.
+// Row is the number of the row in the file, not counting the header. Rows failing validation carry the reason in Error
+type ImportRow struct {
+	Row   int
+	User  User
+	Error string
+}
+
+// ImportError represents a row of an import file that was not imported
+type ImportError struct {
+	Row      int    `json:"row"`
+	Username string `json:"username,omitempty"`
+	Message  string `json:"message"`
+}
+
+// ImportReport represents outcome of a user import.
+// Imported counts rows that passed all checks, whose users are kept only if the import is committed
+type ImportReport struct {
+	DryRun    bool          `json:"dry_run"`
+	Atomic    bool          `json:"atomic"`
+	Committed bool          `json:"committed"`
+	Rows      int           `json:"rows"`
+	Imported  int           `json:"imported"`
+	Errors    []ImportError `json:"errors"`
+}
+
+// Fail records that the row was not imported
+func (r *ImportReport) Fail(row ImportRow, msg string) {
+	r.Errors = append(r.Errors, ImportError{Row: row.Row, Username: row.User.Username, Message: msg})
+}
+
+// Mode returns which users the import keeps, given the rows failed so far.
+// Atomic imports with failed rows are checked to the end, as dry runs
+func (r *ImportReport) Mode() ImportMode {
+	switch {
+	case r.DryRun || (r.Atomic && len(r.Errors) > 0):
+		return ImportDryRun
+	case r.Atomic:
+		return ImportAtomic
+	}
+	return ImportBestEffort
+}
@@ -0,0 +1,32 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+func TestImportReportMode(t *testing.T) {
+	cases := []struct {
+		name     string
+		report   model.ImportReport
+		fail     bool
+		wantMode model.ImportMode
+	}{
+		{name: "Best effort", fail: true, wantMode: model.ImportBestEffort},
+		{name: "Atomic", report: model.ImportReport{Atomic: true}, wantMode: model.ImportAtomic},
+		{name: "Atomic with errors", report: model.ImportReport{Atomic: true}, fail: true, wantMode: model.ImportDryRun},
+		{name: "Dry run", report: model.ImportReport{DryRun: true}, wantMode: model.ImportDryRun},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.fail {
+				tt.report.Fail(model.ImportRow{Row: 3, User: model.User{Username: "johndoe"}}, "email is required")
+				assert.Equal(t, []model.ImportError{{Row: 3, Username: "johndoe", Message: "email is required"}}, tt.report.Errors)
+			}
+			assert.Equal(t, tt.wantMode, tt.report.Mode())
+		})
+	}
+}
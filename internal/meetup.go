@@ -0,0 +1,194 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// RSVPStatus represents user's response to a meetup invitation
+type RSVPStatus string
+
+const (
+	// RSVPGoing means user attends the meetup
+	RSVPGoing RSVPStatus = "going"
+
+	// RSVPMaybe means user might attend the meetup
+	RSVPMaybe RSVPStatus = "maybe"
+
+	// RSVPDeclined means user does not attend the meetup
+	RSVPDeclined RSVPStatus = "declined"
+
+	// RSVPWaitlisted means user wants to attend the meetup, but it is full
+	RSVPWaitlisted RSVPStatus = "waitlisted"
+)
+
+// Meetup represents meetup hosted at a company location.
+// Zero capacity means the number of attendees is not limited
+type Meetup struct {
+	Base
+	CompanyID   int        `json:"company_id"`
+	LocationID  int        `json:"location_id"`
+	OrganizerID int        `json:"organizer_id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	StartsAt    time.Time  `json:"starts_at"`
+	EndsAt      time.Time  `json:"ends_at"`
+	Capacity    int        `json:"capacity,omitempty"`
+	CanceledAt  *time.Time `json:"canceled_at,omitempty"`
+
+	Going      int `json:"going" sql:"-"`
+	Waitlisted int `json:"waitlisted" sql:"-"`
+}
+
+// Cancel marks meetup as canceled. Returns false if it was already canceled
+func (e *Meetup) Cancel(t time.Time) bool {
+	if e.CanceledAt != nil {
+		return false
+	}
+	e.CanceledAt = &t
+	return true
+}
+
+// Attendance counts attendees and waitlisted users from meetup responses
+func (e *Meetup) Attendance(rsvps []RSVP) {
+	e.Going, e.Waitlisted = 0, 0
+	for _, r := range rsvps {
+		switch r.Status {
+		case RSVPGoing:
+			e.Going++
+		case RSVPWaitlisted:
+			e.Waitlisted++
+		}
+	}
+}
+
+// Free returns the number of places left, or -1 if capacity is not limited
+func (e *Meetup) Free() int {
+	if e.Capacity == 0 {
+		return -1
+	}
+	if e.Going >= e.Capacity {
+		return 0
+	}
+	return e.Capacity - e.Going
+}
+
+// RSVP represents user's response to a meetup.
+// UpdatedAt is the time of the last status change, ordering the waitlist
+type RSVP struct {
+	MeetupID  int        `json:"meetup_id" sql:",pk"`
+	UserID    int        `json:"user_id" sql:",pk"`
+	CompanyID int        `json:"-"`
+	Status    RSVPStatus `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Attending checks whether user is going or wants to go to the meetup
+func (r *RSVP) Attending() bool {
+	return r.Status == RSVPGoing || r.Status == RSVPMaybe || r.Status == RSVPWaitlisted
+}
+
+// Waitlist returns waitlisted responses, longest waiting first
+func Waitlist(rsvps []RSVP) []RSVP {
+	var wl []RSVP
+	for _, r := range rsvps {
+		if r.Status == RSVPWaitlisted {
+			wl = append(wl, r)
+		}
+	}
+	sort.SliceStable(wl, func(i, j int) bool {
+		return wl[i].UpdatedAt.Before(wl[j].UpdatedAt)
+	})
+	return wl
+}
+
+// MeetupFilter holds meetup list filters.
+// Meetups ending after After are returned, optionally only those at a location
+type MeetupFilter struct {
+	CompanyID  int
+	LocationID int
+	After      time.Time
+}
+
+// MeetupDB represents meetup database interface (repository)
+type MeetupDB interface {
+	Create(orm.DB, Meetup) (*Meetup, error)
+	View(orm.DB, int) (*Meetup, error)
+	Lock(orm.DB, int) (*Meetup, error)
+	List(orm.DB, *MeetupFilter, *Pagination) ([]Meetup, error)
+	Update(orm.DB, *Meetup) error
+	RSVPs(orm.DB, int) ([]RSVP, error)
+	SetRSVP(orm.DB, *RSVP) error
+	Location(orm.DB, int) (*Location, error)
+}
+
+// icsTime is the UTC date-time format of iCalendar
+const icsTime = "20060102T150405Z"
+
+// ICS returns the meetup held at the location as iCalendar (RFC 5545) object
+func (e *Meetup) ICS(l *Location) string {
+	status := "CONFIRMED"
+	if e.CanceledAt != nil {
+		status = "CANCELLED"
+	}
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//friend4me//meetups//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"BEGIN:VEVENT",
+		fmt.Sprintf("UID:meetup-%d@friend4me", e.ID),
+		"DTSTAMP:" + e.UpdatedAt.UTC().Format(icsTime),
+		"DTSTART:" + e.StartsAt.UTC().Format(icsTime),
+		"DTEND:" + e.EndsAt.UTC().Format(icsTime),
+		"SUMMARY:" + icsText(e.Title),
+	}
+	if e.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+icsText(e.Description))
+	}
+	if l != nil {
+		where := l.Name
+		if l.Address != "" {
+			where += ", " + l.Address
+		}
+		lines = append(lines, "LOCATION:"+icsText(where))
+	}
+	lines = append(lines, "STATUS:"+status, "END:VEVENT", "END:VCALENDAR")
+
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.WriteString(icsFold(line))
+		buf.WriteString("\r\n")
+	}
+	return buf.String()
+}
+
+// icsText escapes text value of an iCalendar property
+func icsText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icsFold folds content line longer than 75 octets, without splitting UTF-8 characters
+func icsFold(line string) string {
+	const max = 75
+	var buf bytes.Buffer
+	n := 0
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+		if n+size > max {
+			buf.WriteString("\r\n ")
+			n = 1
+		}
+		buf.WriteRune(r)
+		n += size
+	}
+	return buf.String()
+}
@@ -0,0 +1,280 @@
+// Package meetup contains meetup application services
+package meetup
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/internal/platform/structs"
+)
+
+// New creates new meetup application service
+func New(edb model.MeetupDB, notifier model.Notifier, rbac model.RBACService, auth model.AuthService) *Service {
+	return &Service{edb: edb, notifier: notifier, rbac: rbac, auth: auth}
+}
+
+// Service represents meetup application service
+type Service struct {
+	edb      model.MeetupDB
+	notifier model.Notifier
+	rbac     model.RBACService
+	auth     model.AuthService
+}
+
+// Create creates a meetup organized by requesting user at a location of its company
+func (s *Service) Create(c echo.Context, ev model.Meetup) (*model.Meetup, error) {
+	au := s.auth.User(c)
+	l, err := s.edb.Location(model.Conn(c), ev.LocationID)
+	if err != nil {
+		return nil, err
+	}
+	if l.CompanyID != au.CompanyID {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "location does not belong to the company")
+	}
+	if !ev.StartsAt.After(time.Now()) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "meetup must start in the future")
+	}
+	ev.CompanyID = au.CompanyID
+	ev.OrganizerID = au.ID
+	return s.edb.Create(model.Conn(c), ev)
+}
+
+// View returns single meetup with attendance counts. Meetups are visible within their company
+func (s *Service) View(c echo.Context, id int) (*model.Meetup, error) {
+	ev, _, err := s.view(c, id)
+	return ev, err
+}
+
+// List returns upcoming meetups of requesting user's company, optionally only those at a location
+func (s *Service) List(c echo.Context, locationID int, p *model.Pagination) ([]model.Meetup, error) {
+	return s.edb.List(model.Conn(c), &model.MeetupFilter{
+		CompanyID:  s.auth.User(c).CompanyID,
+		LocationID: locationID,
+		After:      time.Now(),
+	}, p)
+}
+
+// RSVPs returns responses to a meetup
+func (s *Service) RSVPs(c echo.Context, id int) ([]model.RSVP, error) {
+	_, rsvps, err := s.view(c, id)
+	return rsvps, err
+}
+
+// Update contains meetup details used for updating
+type Update struct {
+	ID          int
+	Title       *string
+	Description *string
+	StartsAt    *time.Time
+	EndsAt      *time.Time
+	Capacity    *int
+}
+
+// Update updates meetup details, notifying users attending it.
+// Raising the capacity moves waitlisted users to attendees
+func (s *Service) Update(c echo.Context, u *Update) (*model.Meetup, error) {
+	ev, rsvps, err := s.manage(c, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if ev.CanceledAt != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "meetup is canceled")
+	}
+	structs.Merge(ev, u)
+	if !ev.EndsAt.After(ev.StartsAt) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "meetup must end after it starts")
+	}
+	if err := s.edb.Update(model.Conn(c), ev); err != nil {
+		return nil, err
+	}
+	if err := s.promote(c, ev, rsvps); err != nil {
+		return nil, err
+	}
+	if err := s.notify(c, ev, rsvps, model.NotificationMeetupUpdated); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// Cancel cancels a meetup, notifying users attending it
+func (s *Service) Cancel(c echo.Context, id int) (*model.Meetup, error) {
+	ev, rsvps, err := s.manage(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ev.Cancel(time.Now()) {
+		return ev, nil
+	}
+	if err := s.edb.Update(model.Conn(c), ev); err != nil {
+		return nil, err
+	}
+	if err := s.notify(c, ev, rsvps, model.NotificationMeetupCanceled); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// RSVP sets requesting user's response to a meetup.
+// Users going to a full meetup are waitlisted, and the longest waiting user takes the place of an attendee who is no longer going
+func (s *Service) RSVP(c echo.Context, id int, status model.RSVPStatus) (*model.RSVP, error) {
+	ev, err := s.edb.Lock(model.Conn(c), id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.visible(c, ev); err != nil {
+		return nil, err
+	}
+	if ev.CanceledAt != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "meetup is canceled")
+	}
+	now := time.Now()
+	if !ev.EndsAt.After(now) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "meetup has ended")
+	}
+	rsvps, err := s.edb.RSVPs(model.Conn(c), ev.ID)
+	if err != nil {
+		return nil, err
+	}
+	ev.Attendance(rsvps)
+
+	au := s.auth.User(c)
+	r := &model.RSVP{MeetupID: ev.ID, UserID: au.ID, CompanyID: ev.CompanyID, CreatedAt: now}
+	idx := -1
+	for i := range rsvps {
+		if rsvps[i].UserID == au.ID {
+			idx = i
+			*r = rsvps[i]
+		}
+	}
+	was := r.Status
+	if status == model.RSVPGoing && (was == model.RSVPGoing || was == model.RSVPWaitlisted) {
+		return r, nil
+	}
+	if status == model.RSVPGoing && ev.Free() == 0 {
+		status = model.RSVPWaitlisted
+	}
+	if status == was {
+		return r, nil
+	}
+	r.Status = status
+	r.UpdatedAt = now
+	if err := s.edb.SetRSVP(model.Conn(c), r); err != nil {
+		return nil, err
+	}
+	if was != model.RSVPGoing {
+		return r, nil
+	}
+	if idx >= 0 {
+		rsvps[idx] = *r
+	}
+	if err := s.promote(c, ev, rsvps); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ICS returns a meetup as iCalendar object
+func (s *Service) ICS(c echo.Context, id int) (string, error) {
+	ev, err := s.View(c, id)
+	if err != nil {
+		return "", err
+	}
+	l, err := s.edb.Location(model.Conn(c), ev.LocationID)
+	if err != nil {
+		return "", err
+	}
+	return ev.ICS(l), nil
+}
+
+// view returns single meetup with responses to it
+func (s *Service) view(c echo.Context, id int) (*model.Meetup, []model.RSVP, error) {
+	ev, err := s.edb.View(model.Conn(c), id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.visible(c, ev); err != nil {
+		return nil, nil, err
+	}
+	rsvps, err := s.edb.RSVPs(model.Conn(c), ev.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ev.Attendance(rsvps)
+	return ev, rsvps, nil
+}
+
+// visible checks whether requesting user belongs to the company of the meetup, or administers it
+func (s *Service) visible(c echo.Context, ev *model.Meetup) error {
+	if ev.CompanyID == s.auth.User(c).CompanyID {
+		return nil
+	}
+	return s.rbac.EnforceCompany(c, ev.CompanyID)
+}
+
+// manage locks a meetup which can be changed by requesting user, returning it with responses to it.
+// Meetups are managed by their organizers and admins of their location
+func (s *Service) manage(c echo.Context, id int) (*model.Meetup, []model.RSVP, error) {
+	ev, err := s.edb.Lock(model.Conn(c), id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ev.OrganizerID != s.auth.User(c).ID {
+		if err := s.rbac.EnforceLocation(c, ev.LocationID); err != nil {
+			return nil, nil, err
+		}
+	}
+	rsvps, err := s.edb.RSVPs(model.Conn(c), ev.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ev.Attendance(rsvps)
+	return ev, rsvps, nil
+}
+
+// promote moves waitlisted users to attendees while the meetup has free places, notifying them
+func (s *Service) promote(c echo.Context, ev *model.Meetup, rsvps []model.RSVP) error {
+	ev.Attendance(rsvps)
+	for _, r := range model.Waitlist(rsvps) {
+		if ev.Free() == 0 {
+			return nil
+		}
+		r.Status = model.RSVPGoing
+		r.UpdatedAt = time.Now()
+		if err := s.edb.SetRSVP(model.Conn(c), &r); err != nil {
+			return err
+		}
+		ev.Going++
+		ev.Waitlisted--
+		if err := s.notifier.Notify(c, meetupNotification(ev, r.UserID, model.NotificationMeetupConfirmed)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notify notifies users attending a meetup of its change, except the user who changed it
+func (s *Service) notify(c echo.Context, ev *model.Meetup, rsvps []model.RSVP, t model.NotificationType) error {
+	au := s.auth.User(c)
+	for _, r := range rsvps {
+		if r.UserID == au.ID || !r.Attending() {
+			continue
+		}
+		if err := s.notifier.Notify(c, meetupNotification(ev, r.UserID, t)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func meetupNotification(ev *model.Meetup, userID int, t model.NotificationType) model.Notification {
+	return model.Notification{
+		UserID:    userID,
+		CompanyID: ev.CompanyID,
+		Type:      t,
+		Data:      map[string]interface{}{"meetup_id": ev.ID, "title": ev.Title},
+	}
+}
@@ -0,0 +1,410 @@
+package meetup_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/meetup"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+func authUser(id int) *mock.Auth {
+	return &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: id, CompanyID: 1}
+		}}
+}
+
+var notAdmin = &mock.RBAC{
+	EnforceCompanyFn: func(echo.Context, int) error {
+		return echo.ErrForbidden
+	},
+	EnforceLocationFn: func(echo.Context, int) error {
+		return echo.ErrForbidden
+	}}
+
+// sent records notifications sent by the returned notifier
+func sent(nts *[]model.Notification) *mock.Notifier {
+	return &mock.Notifier{
+		NotifyFn: func(c echo.Context, n model.Notification) error {
+			*nts = append(*nts, n)
+			return nil
+		}}
+}
+
+// meetups mocks database holding a single meetup with responses, recording the changed responses
+func meetups(ev model.Meetup, rsvps []model.RSVP, set *[]model.RSVP) *mockdb.Meetup {
+	view := func(db orm.DB, id int) (*model.Meetup, error) {
+		if id != ev.ID {
+			return nil, model.ErrGeneric
+		}
+		e := ev
+		return &e, nil
+	}
+	return &mockdb.Meetup{
+		ViewFn: view,
+		LockFn: view,
+		RSVPsFn: func(orm.DB, int) ([]model.RSVP, error) {
+			return append([]model.RSVP(nil), rsvps...), nil
+		},
+		SetRSVPFn: func(db orm.DB, r *model.RSVP) error {
+			*set = append(*set, *r)
+			return nil
+		},
+		UpdateFn: func(orm.DB, *model.Meetup) error {
+			return nil
+		},
+		LocationFn: func(db orm.DB, id int) (*model.Location, error) {
+			return &model.Location{Base: model.Base{ID: id}, Name: "HQ", CompanyID: 1}, nil
+		}}
+}
+
+func upcoming(capacity int) model.Meetup {
+	return model.Meetup{
+		Base:        model.Base{ID: 1},
+		CompanyID:   1,
+		LocationID:  1,
+		OrganizerID: 1,
+		Title:       "Meetup",
+		StartsAt:    time.Now().Add(time.Hour),
+		EndsAt:      time.Now().Add(2 * time.Hour),
+		Capacity:    capacity,
+	}
+}
+
+func statuses(rsvps []model.RSVP) map[int]model.RSVPStatus {
+	m := make(map[int]model.RSVPStatus)
+	for _, r := range rsvps {
+		m[r.UserID] = r.Status
+	}
+	return m
+}
+
+func TestCreate(t *testing.T) {
+	edb := &mockdb.Meetup{
+		LocationFn: func(db orm.DB, id int) (*model.Location, error) {
+			return &model.Location{Base: model.Base{ID: id}, CompanyID: id}, nil
+		},
+		CreateFn: func(db orm.DB, ev model.Meetup) (*model.Meetup, error) {
+			ev.ID = 1
+			return &ev, nil
+		}}
+	starts := time.Now().Add(time.Hour)
+	cases := []struct {
+		name     string
+		ev       model.Meetup
+		wantErr  bool
+		wantData *model.Meetup
+	}{
+		{
+			name:    "Location of another company",
+			ev:      model.Meetup{LocationID: 2, StartsAt: starts},
+			wantErr: true,
+		},
+		{
+			name:    "Starts in the past",
+			ev:      model.Meetup{LocationID: 1, StartsAt: time.Now().Add(-time.Hour)},
+			wantErr: true,
+		},
+		{
+			name:     "Success",
+			ev:       model.Meetup{LocationID: 1, Title: "Meetup", StartsAt: starts, CompanyID: 5, OrganizerID: 5},
+			wantData: &model.Meetup{Base: model.Base{ID: 1}, LocationID: 1, Title: "Meetup", StartsAt: starts, CompanyID: 1, OrganizerID: 3},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := meetup.New(edb, nil, nil, authUser(3))
+			ev, err := s.Create(nil, tt.ev)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantData, ev)
+		})
+	}
+}
+
+func TestView(t *testing.T) {
+	rsvps := []model.RSVP{
+		{UserID: 2, Status: model.RSVPGoing},
+		{UserID: 3, Status: model.RSVPWaitlisted},
+		{UserID: 4, Status: model.RSVPMaybe},
+	}
+	var set []model.RSVP
+	ev := upcoming(1)
+	s := meetup.New(meetups(ev, rsvps, &set), nil, notAdmin, authUser(2))
+	got, err := s.View(nil, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, got.Going)
+	assert.Equal(t, 1, got.Waitlisted)
+
+	list, err := s.RSVPs(nil, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, rsvps, list)
+
+	ev.CompanyID = 2
+	s = meetup.New(meetups(ev, rsvps, &set), nil, notAdmin, authUser(2))
+	_, err = s.View(nil, 1)
+	assert.Equal(t, echo.ErrForbidden, err)
+}
+
+func TestList(t *testing.T) {
+	edb := &mockdb.Meetup{
+		ListFn: func(db orm.DB, f *model.MeetupFilter, p *model.Pagination) ([]model.Meetup, error) {
+			if f.CompanyID != 1 || f.LocationID != 4 || time.Since(f.After) > time.Minute || p.Limit != 10 {
+				return nil, model.ErrGeneric
+			}
+			return []model.Meetup{upcoming(0)}, nil
+		}}
+	s := meetup.New(edb, nil, nil, authUser(2))
+	list, err := s.List(nil, 4, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestUpdate(t *testing.T) {
+	rsvps := []model.RSVP{
+		{UserID: 2, Status: model.RSVPGoing},
+		{UserID: 3, Status: model.RSVPWaitlisted, UpdatedAt: mock.TestTime(2002)},
+		{UserID: 4, Status: model.RSVPWaitlisted, UpdatedAt: mock.TestTime(2001)},
+		{UserID: 5, Status: model.RSVPDeclined},
+	}
+	capacity, title := 2, "Bigger meetup"
+	past := time.Now().Add(-time.Hour)
+	canceled := upcoming(1)
+	canceled.Cancel(time.Now())
+	cases := []struct {
+		name       string
+		user       int
+		ev         model.Meetup
+		update     *meetup.Update
+		wantErr    bool
+		wantSet    []int
+		wantNotify []model.Notification
+	}{
+		{
+			name:    "Not organizer",
+			user:    2,
+			ev:      upcoming(1),
+			update:  &meetup.Update{ID: 1},
+			wantErr: true,
+		},
+		{
+			name:    "Canceled meetup",
+			user:    1,
+			ev:      canceled,
+			update:  &meetup.Update{ID: 1},
+			wantErr: true,
+		},
+		{
+			name:    "Ends before start",
+			user:    1,
+			ev:      upcoming(1),
+			update:  &meetup.Update{ID: 1, EndsAt: &past},
+			wantErr: true,
+		},
+		{
+			name:    "Success",
+			user:    1,
+			ev:      upcoming(1),
+			update:  &meetup.Update{ID: 1, Title: &title, Capacity: &capacity},
+			wantSet: []int{4},
+			wantNotify: []model.Notification{
+				{UserID: 4, CompanyID: 1, Type: model.NotificationMeetupConfirmed, Data: map[string]interface{}{"meetup_id": 1, "title": title}},
+				{UserID: 2, CompanyID: 1, Type: model.NotificationMeetupUpdated, Data: map[string]interface{}{"meetup_id": 1, "title": title}},
+				{UserID: 3, CompanyID: 1, Type: model.NotificationMeetupUpdated, Data: map[string]interface{}{"meetup_id": 1, "title": title}},
+				{UserID: 4, CompanyID: 1, Type: model.NotificationMeetupUpdated, Data: map[string]interface{}{"meetup_id": 1, "title": title}},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var set []model.RSVP
+			var nts []model.Notification
+			s := meetup.New(meetups(tt.ev, rsvps, &set), sent(&nts), notAdmin, authUser(tt.user))
+			ev, err := s.Update(nil, tt.update)
+			assert.Equal(t, tt.wantErr, err != nil)
+			var ids []int
+			for _, r := range set {
+				assert.Equal(t, model.RSVPGoing, r.Status)
+				ids = append(ids, r.UserID)
+			}
+			assert.Equal(t, tt.wantSet, ids)
+			assert.Equal(t, tt.wantNotify, nts)
+			if !tt.wantErr {
+				assert.Equal(t, title, ev.Title)
+				assert.Equal(t, 2, ev.Going)
+				assert.Equal(t, 1, ev.Waitlisted)
+			}
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	rsvps := []model.RSVP{
+		{UserID: 1, Status: model.RSVPGoing},
+		{UserID: 2, Status: model.RSVPMaybe},
+		{UserID: 3, Status: model.RSVPDeclined},
+	}
+	var set []model.RSVP
+	var nts []model.Notification
+	locationAdmin := &mock.RBAC{
+		EnforceLocationFn: func(echo.Context, int) error {
+			return nil
+		}}
+	s := meetup.New(meetups(upcoming(0), rsvps, &set), sent(&nts), locationAdmin, authUser(5))
+	ev, err := s.Cancel(nil, 1)
+	assert.Nil(t, err)
+	assert.NotNil(t, ev.CanceledAt)
+	assert.Equal(t, []model.Notification{
+		{UserID: 1, CompanyID: 1, Type: model.NotificationMeetupCanceled, Data: map[string]interface{}{"meetup_id": 1, "title": "Meetup"}},
+		{UserID: 2, CompanyID: 1, Type: model.NotificationMeetupCanceled, Data: map[string]interface{}{"meetup_id": 1, "title": "Meetup"}},
+	}, nts)
+
+	nts = nil
+	canceled := upcoming(0)
+	canceled.Cancel(mock.TestTime(2018))
+	s = meetup.New(meetups(canceled, rsvps, &set), sent(&nts), locationAdmin, authUser(5))
+	ev, err = s.Cancel(nil, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, mock.TestTime(2018), *ev.CanceledAt)
+	assert.Empty(t, nts)
+
+	_, err = meetup.New(meetups(upcoming(0), rsvps, &set), sent(&nts), notAdmin, authUser(5)).Cancel(nil, 1)
+	assert.Equal(t, echo.ErrForbidden, err)
+}
+
+func TestRSVP(t *testing.T) {
+	full := []model.RSVP{
+		{UserID: 2, Status: model.RSVPGoing},
+		{UserID: 3, Status: model.RSVPWaitlisted, UpdatedAt: mock.TestTime(2001)},
+		{UserID: 4, Status: model.RSVPMaybe},
+	}
+	canceled := upcoming(1)
+	canceled.Cancel(time.Now())
+	ended := upcoming(1)
+	ended.StartsAt, ended.EndsAt = time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour)
+	cases := []struct {
+		name       string
+		user       int
+		ev         model.Meetup
+		rsvps      []model.RSVP
+		status     model.RSVPStatus
+		wantErr    bool
+		wantStatus model.RSVPStatus
+		wantSet    map[int]model.RSVPStatus
+		wantNotify []int
+	}{
+		{
+			name:    "Canceled meetup",
+			user:    5,
+			ev:      canceled,
+			status:  model.RSVPGoing,
+			wantErr: true,
+		},
+		{
+			name:    "Ended meetup",
+			user:    5,
+			ev:      ended,
+			status:  model.RSVPGoing,
+			wantErr: true,
+		},
+		{
+			name:       "Going with free places",
+			user:       5,
+			ev:         upcoming(2),
+			rsvps:      full,
+			status:     model.RSVPGoing,
+			wantStatus: model.RSVPGoing,
+			wantSet:    map[int]model.RSVPStatus{5: model.RSVPGoing},
+		},
+		{
+			name:       "Going to full meetup",
+			user:       5,
+			ev:         upcoming(1),
+			rsvps:      full,
+			status:     model.RSVPGoing,
+			wantStatus: model.RSVPWaitlisted,
+			wantSet:    map[int]model.RSVPStatus{5: model.RSVPWaitlisted},
+		},
+		{
+			name:       "Maybe going to full meetup",
+			user:       4,
+			ev:         upcoming(1),
+			rsvps:      full,
+			status:     model.RSVPGoing,
+			wantStatus: model.RSVPWaitlisted,
+			wantSet:    map[int]model.RSVPStatus{4: model.RSVPWaitlisted},
+		},
+		{
+			name:       "Already waitlisted",
+			user:       3,
+			ev:         upcoming(1),
+			rsvps:      full,
+			status:     model.RSVPGoing,
+			wantStatus: model.RSVPWaitlisted,
+		},
+		{
+			name:       "Leaving waitlist",
+			user:       3,
+			ev:         upcoming(1),
+			rsvps:      full,
+			status:     model.RSVPDeclined,
+			wantStatus: model.RSVPDeclined,
+			wantSet:    map[int]model.RSVPStatus{3: model.RSVPDeclined},
+		},
+		{
+			name:       "Attendee declines",
+			user:       2,
+			ev:         upcoming(1),
+			rsvps:      full,
+			status:     model.RSVPDeclined,
+			wantStatus: model.RSVPDeclined,
+			wantSet:    map[int]model.RSVPStatus{2: model.RSVPDeclined, 3: model.RSVPGoing},
+			wantNotify: []int{3},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var set []model.RSVP
+			var nts []model.Notification
+			s := meetup.New(meetups(tt.ev, tt.rsvps, &set), sent(&nts), notAdmin, authUser(tt.user))
+			r, err := s.RSVP(nil, 1, tt.status)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, tt.wantStatus, r.Status)
+			assert.Equal(t, tt.user, r.UserID)
+			if tt.wantSet == nil {
+				assert.Empty(t, set)
+			} else {
+				assert.Equal(t, tt.wantSet, statuses(set))
+			}
+			var notified []int
+			for _, n := range nts {
+				assert.Equal(t, model.NotificationMeetupConfirmed, n.Type)
+				notified = append(notified, n.UserID)
+			}
+			assert.Equal(t, tt.wantNotify, notified)
+		})
+	}
+}
+
+func TestICS(t *testing.T) {
+	var set []model.RSVP
+	s := meetup.New(meetups(upcoming(0), nil, &set), nil, notAdmin, authUser(2))
+	ics, err := s.ICS(nil, 1)
+	assert.Nil(t, err)
+	assert.Contains(t, ics, "SUMMARY:Meetup\r\n")
+	assert.Contains(t, ics, "LOCATION:HQ\r\n")
+
+	_, err = s.ICS(nil, 2)
+	assert.NotNil(t, err)
+}
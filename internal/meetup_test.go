@@ -0,0 +1,127 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestMeetupCancel(t *testing.T) {
+	e := new(model.Meetup)
+	assert.True(t, e.Cancel(mock.TestTime(2018)))
+	assert.Equal(t, mock.TestTime(2018), *e.CanceledAt)
+	assert.False(t, e.Cancel(mock.TestTime(2019)))
+	assert.Equal(t, mock.TestTime(2018), *e.CanceledAt)
+}
+
+func TestMeetupAttendance(t *testing.T) {
+	rsvps := []model.RSVP{
+		{UserID: 1, Status: model.RSVPGoing},
+		{UserID: 2, Status: model.RSVPMaybe},
+		{UserID: 3, Status: model.RSVPGoing},
+		{UserID: 4, Status: model.RSVPWaitlisted},
+		{UserID: 5, Status: model.RSVPDeclined},
+	}
+	cases := []struct {
+		name     string
+		capacity int
+		wantFree int
+	}{
+		{
+			name:     "Unlimited",
+			wantFree: -1,
+		},
+		{
+			name:     "Places left",
+			capacity: 5,
+			wantFree: 3,
+		},
+		{
+			name:     "Full",
+			capacity: 2,
+			wantFree: 0,
+		},
+		{
+			name:     "Over capacity",
+			capacity: 1,
+			wantFree: 0,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &model.Meetup{Capacity: tt.capacity, Going: 7}
+			e.Attendance(rsvps)
+			assert.Equal(t, 2, e.Going)
+			assert.Equal(t, 1, e.Waitlisted)
+			assert.Equal(t, tt.wantFree, e.Free())
+		})
+	}
+}
+
+func TestRSVPAttending(t *testing.T) {
+	assert.True(t, (&model.RSVP{Status: model.RSVPGoing}).Attending())
+	assert.True(t, (&model.RSVP{Status: model.RSVPMaybe}).Attending())
+	assert.True(t, (&model.RSVP{Status: model.RSVPWaitlisted}).Attending())
+	assert.False(t, (&model.RSVP{Status: model.RSVPDeclined}).Attending())
+}
+
+func TestWaitlist(t *testing.T) {
+	rsvps := []model.RSVP{
+		{UserID: 1, Status: model.RSVPWaitlisted, UpdatedAt: mock.TestTime(2003)},
+		{UserID: 2, Status: model.RSVPGoing, UpdatedAt: mock.TestTime(2000)},
+		{UserID: 3, Status: model.RSVPWaitlisted, UpdatedAt: mock.TestTime(2001)},
+		{UserID: 4, Status: model.RSVPWaitlisted, UpdatedAt: mock.TestTime(2002)},
+	}
+	var got []int
+	for _, r := range model.Waitlist(rsvps) {
+		got = append(got, r.UserID)
+	}
+	assert.Equal(t, []int{3, 4, 1}, got)
+	assert.Empty(t, model.Waitlist(rsvps[1:2]))
+}
+
+func TestMeetupICS(t *testing.T) {
+	e := &model.Meetup{
+		Base:        model.Base{ID: 7, CreatedAt: mock.TestTime(2018), UpdatedAt: mock.TestTime(2018)},
+		Title:       "Board games; snacks, drinks",
+		Description: "Bring your own\ngames",
+		StartsAt:    time.Date(2018, 6, 1, 18, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+		EndsAt:      time.Date(2018, 6, 1, 22, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+	}
+	l := &model.Location{Name: "HQ", Address: "Main street 1"}
+
+	want := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//friend4me//meetups//EN\r\n" +
+		"CALSCALE:GREGORIAN\r\n" +
+		"METHOD:PUBLISH\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:meetup-7@friend4me\r\n" +
+		"DTSTAMP:20180519T010203Z\r\n" +
+		"DTSTART:20180601T160000Z\r\n" +
+		"DTEND:20180601T200000Z\r\n" +
+		`SUMMARY:Board games\; snacks\, drinks` + "\r\n" +
+		`DESCRIPTION:Bring your own\ngames` + "\r\n" +
+		`LOCATION:HQ\, Main street 1` + "\r\n" +
+		"STATUS:CONFIRMED\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	assert.Equal(t, want, e.ICS(l))
+
+	e.Cancel(mock.TestTime(2018))
+	e.Description = ""
+	e.Title = strings.Repeat("ä", 40)
+	ics := e.ICS(nil)
+	assert.Contains(t, ics, "STATUS:CANCELLED\r\n")
+	assert.NotContains(t, ics, "DESCRIPTION")
+	assert.NotContains(t, ics, "LOCATION")
+	assert.Contains(t, ics, "SUMMARY:"+strings.Repeat("ä", 33)+"\r\n "+strings.Repeat("ä", 7)+"\r\n")
+	for _, line := range strings.Split(ics, "\r\n") {
+		assert.True(t, len(line) <= 75)
+	}
+}
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// MaxParticipants is the maximum number of users in a group conversation
+const MaxParticipants = 10
+
+// Conversation represents one-to-one or group conversation between users of a company
+type Conversation struct {
+	Base
+	CompanyID int    `json:"company_id"`
+	CreatorID int    `json:"creator_id"`
+	Title     string `json:"title,omitempty"`
+	IsGroup   bool   `json:"group"`
+	// DirectKey identifies one-to-one conversation by its users, so that only one is started between them
+	DirectKey string `json:"-"`
+
+	Participants []Participant `json:"participants,omitempty" sql:"-"`
+	Unread       int           `json:"unread" sql:"-"`
+}
+
+// DirectConversationKey returns key of one-to-one conversation between two users, whichever of them started it
+func DirectConversationKey(userID, otherID int) string {
+	if userID > otherID {
+		userID, otherID = otherID, userID
+	}
+	return fmt.Sprintf("%d:%d", userID, otherID)
+}
+
+// UserIDs returns IDs of conversation participants
+func (c *Conversation) UserIDs() []int {
+	ids := make([]int, len(c.Participants))
+	for i, p := range c.Participants {
+		ids[i] = p.UserID
+	}
+	return ids
+}
+
+// Other returns ID of the other participant of one-to-one conversation, or zero for group conversations
+func (c *Conversation) Other(userID int) int {
+	if c.IsGroup {
+		return 0
+	}
+	for _, p := range c.Participants {
+		if p.UserID != userID {
+			return p.UserID
+		}
+	}
+	return 0
+}
+
+// Participant represents user taking part in a conversation.
+// LastReadID holds the ID of the last message read by the user, serving as read receipt
+type Participant struct {
+	ConversationID int        `json:"-" sql:",pk"`
+	UserID         int        `json:"user_id" sql:",pk"`
+	CompanyID      int        `json:"-"`
+	LastReadID     int        `json:"last_read_id"`
+	ReadAt         *time.Time `json:"read_at,omitempty"`
+}
+
+// Read moves read receipt forward to the message. Returns false if the message was already read
+func (p *Participant) Read(messageID int, t time.Time) bool {
+	if messageID <= p.LastReadID {
+		return false
+	}
+	p.LastReadID = messageID
+	p.ReadAt = &t
+	return true
+}
+
+// Attachment represents file attached to a message
+type Attachment struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int    `json:"size,omitempty"`
+}
+
+// Message represents message sent to a conversation
+type Message struct {
+	Base
+	ConversationID int          `json:"conversation_id"`
+	SenderID       int          `json:"sender_id"`
+	CompanyID      int          `json:"-"`
+	Body           string       `json:"body"`
+	Attachments    []Attachment `json:"attachments,omitempty"`
+}
+
+// MessageCursor holds cursor pagination data for message history.
+// Messages older than Before are returned, or the newest ones if Before is zero
+type MessageCursor struct {
+	Before int
+	Limit  int
+}
+
+// MessageDB represents conversation and message database interface (repository)
+type MessageDB interface {
+	CreateConversation(orm.DB, Conversation, []int) (*Conversation, error)
+	ViewConversation(orm.DB, int) (*Conversation, error)
+	FindDirect(orm.DB, int, int) (*Conversation, error)
+	ListConversations(orm.DB, int, *Pagination) ([]Conversation, error)
+	CreateMessage(orm.DB, Message) (*Message, error)
+	ViewMessage(orm.DB, int) (*Message, error)
+	History(orm.DB, int, *MessageCursor) ([]Message, error)
+	UpdateParticipant(orm.DB, *Participant) error
+}
@@ -0,0 +1,184 @@
+// Package message contains direct messaging application services
+package message
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// New creates new messaging application service
+func New(mdb model.MessageDB, udb model.UserDB, bdb model.BlockDB, broker model.Broker, rbac model.RBACService, auth model.AuthService) *Service {
+	return &Service{mdb: mdb, udb: udb, bdb: bdb, broker: broker, rbac: rbac, auth: auth}
+}
+
+// Service represents messaging application service
+type Service struct {
+	mdb    model.MessageDB
+	udb    model.UserDB
+	bdb    model.BlockDB
+	broker model.Broker
+	rbac   model.RBACService
+	auth   model.AuthService
+}
+
+// Start starts a conversation between requesting user and other users of the same company.
+// Conversation with a single user is one-to-one, and is reused if the users already have one.
+// Users blocked by or blocking requesting user cannot be added
+func (s *Service) Start(c echo.Context, userIDs []int, title string) (*model.Conversation, error) {
+	au := s.auth.User(c)
+	ids := []int{au.ID}
+	seen := map[int]bool{au.ID: true}
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < 2 {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "conversation needs at least one other participant")
+	}
+	if len(ids) > model.MaxParticipants {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "too many participants")
+	}
+	for _, id := range ids[1:] {
+		blocked, err := s.bdb.Blocked(model.Conn(c), au.ID, id)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, echo.ErrForbidden
+		}
+		u, err := s.udb.View(model.Conn(c), id)
+		if err != nil {
+			return nil, err
+		}
+		if u.CompanyID != au.CompanyID {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "user does not belong to the company")
+		}
+	}
+	cv := model.Conversation{
+		CompanyID: au.CompanyID,
+		CreatorID: au.ID,
+		Title:     title,
+		IsGroup:   len(ids) > 2,
+	}
+	if !cv.IsGroup {
+		existing, err := s.mdb.FindDirect(model.Conn(c), ids[0], ids[1])
+		if err != nil || existing != nil {
+			return existing, err
+		}
+		cv.DirectKey = model.DirectConversationKey(ids[0], ids[1])
+	}
+	return s.mdb.CreateConversation(model.Conn(c), cv, ids)
+}
+
+// View returns single conversation. Only participants can view the conversation
+func (s *Service) View(c echo.Context, id int) (*model.Conversation, error) {
+	cv, err := s.mdb.ViewConversation(model.Conn(c), id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.rbac.EnforceParticipant(c, cv.UserIDs()); err != nil {
+		return nil, err
+	}
+	return cv, nil
+}
+
+// List returns conversations of requesting user, with unread message counts
+func (s *Service) List(c echo.Context, p *model.Pagination) ([]model.Conversation, error) {
+	return s.mdb.ListConversations(model.Conn(c), s.auth.User(c).ID, p)
+}
+
+// Send sends a message to a conversation on behalf of requesting user, pushing it to other participants.
+// Messages cannot be sent to one-to-one conversation if either of the users blocked the other
+func (s *Service) Send(c echo.Context, conversationID int, body string, attachments []model.Attachment) (*model.Message, error) {
+	cv, err := s.View(c, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	au := s.auth.User(c)
+	if other := cv.Other(au.ID); other != 0 {
+		blocked, err := s.bdb.Blocked(model.Conn(c), au.ID, other)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, echo.ErrForbidden
+		}
+	}
+	msg, err := s.mdb.CreateMessage(model.Conn(c), model.Message{
+		ConversationID: cv.ID,
+		SenderID:       au.ID,
+		CompanyID:      cv.CompanyID,
+		Body:           body,
+		Attachments:    attachments,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Sender has read its own message
+	if err := s.read(c, cv, au.ID, msg.ID); err != nil {
+		return nil, err
+	}
+	for _, id := range cv.UserIDs() {
+		if id == au.ID {
+			continue
+		}
+		e, err := model.NewEvent(model.EventMessage, id, msg)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.broker.Publish(model.Conn(c), e); err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// History returns messages of a conversation, newest first
+func (s *Service) History(c echo.Context, conversationID int, cur *model.MessageCursor) ([]model.Message, error) {
+	if _, err := s.View(c, conversationID); err != nil {
+		return nil, err
+	}
+	return s.mdb.History(model.Conn(c), conversationID, cur)
+}
+
+// Read marks messages of a conversation up to the given one as read by requesting user
+func (s *Service) Read(c echo.Context, conversationID, messageID int) (*model.Participant, error) {
+	cv, err := s.View(c, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := s.mdb.ViewMessage(model.Conn(c), messageID)
+	if err != nil {
+		return nil, err
+	}
+	if msg.ConversationID != cv.ID {
+		return nil, echo.ErrNotFound
+	}
+	au := s.auth.User(c)
+	if err := s.read(c, cv, au.ID, msg.ID); err != nil {
+		return nil, err
+	}
+	for _, p := range cv.Participants {
+		if p.UserID == au.ID {
+			return &p, nil
+		}
+	}
+	return nil, echo.ErrNotFound
+}
+
+// read moves participant's read receipt forward to the message
+func (s *Service) read(c echo.Context, cv *model.Conversation, userID, messageID int) error {
+	for i := range cv.Participants {
+		p := &cv.Participants[i]
+		if p.UserID == userID && p.Read(messageID, time.Now()) {
+			return s.mdb.UpdateParticipant(model.Conn(c), p)
+		}
+	}
+	return nil
+}
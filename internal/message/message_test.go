@@ -0,0 +1,345 @@
+package message_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/message"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+)
+
+func authUser(id int) *mock.Auth {
+	return &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: id, CompanyID: 1}
+		}}
+}
+
+var participant = &mock.RBAC{
+	EnforceParticipantFn: func(c echo.Context, ids []int) error {
+		for _, id := range ids {
+			if id == 1 {
+				return nil
+			}
+		}
+		return echo.ErrForbidden
+	}}
+
+func blocked(b bool) *mockdb.Block {
+	return &mockdb.Block{
+		BlockedFn: func(orm.DB, int, int) (bool, error) {
+			return b, nil
+		}}
+}
+
+var colleagues = &mockdb.User{
+	ViewFn: func(db orm.DB, id int) (*model.User, error) {
+		if id == 9 {
+			return &model.User{Base: model.Base{ID: id}, CompanyID: 2}, nil
+		}
+		return &model.User{Base: model.Base{ID: id}, CompanyID: 1}, nil
+	}}
+
+func conversation(group bool, ids ...int) *model.Conversation {
+	cv := &model.Conversation{Base: model.Base{ID: 1}, CompanyID: 1, IsGroup: group}
+	for _, id := range ids {
+		cv.Participants = append(cv.Participants, model.Participant{ConversationID: 1, UserID: id})
+	}
+	return cv
+}
+
+func TestStart(t *testing.T) {
+	created := &mockdb.Message{
+		FindDirectFn: func(orm.DB, int, int) (*model.Conversation, error) {
+			return nil, nil
+		},
+		CreateConversationFn: func(db orm.DB, cv model.Conversation, ids []int) (*model.Conversation, error) {
+			cv.ID = 1
+			for _, id := range ids {
+				cv.Participants = append(cv.Participants, model.Participant{ConversationID: 1, UserID: id})
+			}
+			return &cv, nil
+		}}
+	cases := []struct {
+		name     string
+		users    []int
+		wantErr  bool
+		wantData *model.Conversation
+		mdb      *mockdb.Message
+		bdb      *mockdb.Block
+	}{
+		{
+			name:    "Only yourself",
+			users:   []int{1},
+			wantErr: true,
+		},
+		{
+			name:    "Too many participants",
+			users:   []int{2, 3, 4, 5, 6, 7, 8, 10, 11, 12},
+			wantErr: true,
+		},
+		{
+			name:    "Blocked user",
+			users:   []int{2},
+			wantErr: true,
+			bdb:     blocked(true),
+		},
+		{
+			name:    "User from another company",
+			users:   []int{9},
+			wantErr: true,
+			bdb:     blocked(false),
+		},
+		{
+			name:  "Existing one-to-one conversation",
+			users: []int{2, 2},
+			bdb:   blocked(false),
+			mdb: &mockdb.Message{
+				FindDirectFn: func(orm.DB, int, int) (*model.Conversation, error) {
+					return conversation(false, 1, 2), nil
+				}},
+			wantData: conversation(false, 1, 2),
+		},
+		{
+			name:     "New one-to-one conversation",
+			users:    []int{2},
+			bdb:      blocked(false),
+			mdb:      created,
+			wantData: &model.Conversation{Base: model.Base{ID: 1}, CompanyID: 1, CreatorID: 1, Title: "chat", DirectKey: "1:2", Participants: conversation(false, 1, 2).Participants},
+		},
+		{
+			name:     "Group conversation",
+			users:    []int{2, 3},
+			bdb:      blocked(false),
+			mdb:      created,
+			wantData: &model.Conversation{Base: model.Base{ID: 1}, CompanyID: 1, CreatorID: 1, Title: "chat", IsGroup: true, Participants: conversation(true, 1, 2, 3).Participants},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := message.New(tt.mdb, colleagues, tt.bdb, nil, participant, authUser(1))
+			cv, err := s.Start(nil, tt.users, "chat")
+			assert.Equal(t, tt.wantData, cv)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestView(t *testing.T) {
+	cases := []struct {
+		name    string
+		user    int
+		wantErr bool
+		mdb     *mockdb.Message
+	}{
+		{
+			name:    "Fail on view",
+			user:    1,
+			wantErr: true,
+			mdb: &mockdb.Message{
+				ViewConversationFn: func(orm.DB, int) (*model.Conversation, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name:    "Not a participant",
+			user:    1,
+			wantErr: true,
+			mdb: &mockdb.Message{
+				ViewConversationFn: func(orm.DB, int) (*model.Conversation, error) {
+					return conversation(false, 2, 3), nil
+				}},
+		},
+		{
+			name: "Participant",
+			user: 1,
+			mdb: &mockdb.Message{
+				ViewConversationFn: func(orm.DB, int) (*model.Conversation, error) {
+					return conversation(false, 1, 2), nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := message.New(tt.mdb, nil, nil, nil, participant, authUser(tt.user))
+			cv, err := s.View(nil, 1)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantErr, cv == nil)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	mdb := &mockdb.Message{
+		ListConversationsFn: func(db orm.DB, id int, p *model.Pagination) ([]model.Conversation, error) {
+			if id != 1 || p.Limit != 10 {
+				return nil, model.ErrGeneric
+			}
+			return []model.Conversation{{Base: model.Base{ID: 1}, Unread: 3}}, nil
+		}}
+	s := message.New(mdb, nil, nil, nil, nil, authUser(1))
+	cvs, err := s.List(nil, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, []model.Conversation{{Base: model.Base{ID: 1}, Unread: 3}}, cvs)
+}
+
+func TestSend(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		wantRead int
+		wantPush []int
+		cv       *model.Conversation
+		bdb      *mockdb.Block
+		createFn func(orm.DB, model.Message) (*model.Message, error)
+	}{
+		{
+			name:    "Not a participant",
+			wantErr: true,
+			cv:      conversation(true, 2, 3),
+		},
+		{
+			name:    "Blocked in one-to-one conversation",
+			wantErr: true,
+			cv:      conversation(false, 1, 2),
+			bdb:     blocked(true),
+		},
+		{
+			name:    "Fail on create",
+			wantErr: true,
+			cv:      conversation(false, 1, 2),
+			bdb:     blocked(false),
+			createFn: func(orm.DB, model.Message) (*model.Message, error) {
+				return nil, model.ErrGeneric
+			},
+		},
+		{
+			name:     "Group conversation",
+			cv:       conversation(true, 1, 2, 3),
+			wantRead: 5,
+			wantPush: []int{2, 3},
+			createFn: func(db orm.DB, m model.Message) (*model.Message, error) {
+				m.ID = 5
+				return &m, nil
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var read int
+			mdb := &mockdb.Message{
+				ViewConversationFn: func(orm.DB, int) (*model.Conversation, error) {
+					return tt.cv, nil
+				},
+				CreateMessageFn: tt.createFn,
+				UpdateParticipantFn: func(db orm.DB, p *model.Participant) error {
+					if p.UserID == 1 {
+						read = p.LastReadID
+					}
+					return nil
+				}}
+			var pushed []int
+			broker := &mock.Broker{
+				PublishFn: func(db orm.DB, e model.Event) error {
+					if e.Type == model.EventMessage {
+						pushed = append(pushed, e.UserID)
+					}
+					return nil
+				}}
+			s := message.New(mdb, nil, tt.bdb, broker, participant, authUser(1))
+			attachments := []model.Attachment{{Name: "cat.png", URL: "https://example.com/cat.png"}}
+			msg, err := s.Send(nil, 1, "hello", attachments)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantRead, read)
+			assert.Equal(t, tt.wantPush, pushed)
+			if !tt.wantErr {
+				assert.Equal(t, &model.Message{Base: model.Base{ID: 5}, ConversationID: 1, SenderID: 1, CompanyID: 1, Body: "hello", Attachments: attachments}, msg)
+			}
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	mdb := &mockdb.Message{
+		ViewConversationFn: func(orm.DB, int) (*model.Conversation, error) {
+			return conversation(false, 1, 2), nil
+		},
+		HistoryFn: func(db orm.DB, id int, cur *model.MessageCursor) ([]model.Message, error) {
+			if id != 1 || cur.Before != 10 {
+				return nil, model.ErrGeneric
+			}
+			return []model.Message{{Base: model.Base{ID: 9}}}, nil
+		}}
+	s := message.New(mdb, nil, nil, nil, participant, authUser(1))
+	msgs, err := s.History(nil, 1, &model.MessageCursor{Before: 10, Limit: 20})
+	assert.Nil(t, err)
+	assert.Equal(t, []model.Message{{Base: model.Base{ID: 9}}}, msgs)
+
+	mdb.ViewConversationFn = func(orm.DB, int) (*model.Conversation, error) {
+		return conversation(false, 2, 3), nil
+	}
+	_, err = s.History(nil, 1, &model.MessageCursor{Before: 10, Limit: 20})
+	assert.Equal(t, echo.ErrForbidden, err)
+}
+
+func TestRead(t *testing.T) {
+	cases := []struct {
+		name     string
+		message  *model.Message
+		last     int
+		wantErr  bool
+		wantLast int
+		updated  bool
+	}{
+		{
+			name:    "Message from another conversation",
+			message: &model.Message{Base: model.Base{ID: 7}, ConversationID: 2},
+			wantErr: true,
+		},
+		{
+			name:     "Already read",
+			message:  &model.Message{Base: model.Base{ID: 7}, ConversationID: 1},
+			last:     8,
+			wantLast: 8,
+		},
+		{
+			name:     "Success",
+			message:  &model.Message{Base: model.Base{ID: 7}, ConversationID: 1},
+			last:     3,
+			wantLast: 7,
+			updated:  true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated bool
+			mdb := &mockdb.Message{
+				ViewConversationFn: func(orm.DB, int) (*model.Conversation, error) {
+					cv := conversation(false, 1, 2)
+					cv.Participants[0].LastReadID = tt.last
+					return cv, nil
+				},
+				ViewMessageFn: func(orm.DB, int) (*model.Message, error) {
+					return tt.message, nil
+				},
+				UpdateParticipantFn: func(orm.DB, *model.Participant) error {
+					updated = true
+					return nil
+				}}
+			s := message.New(mdb, nil, nil, nil, participant, authUser(1))
+			p, err := s.Read(nil, 1, 7)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.updated, updated)
+			if !tt.wantErr {
+				assert.Equal(t, tt.wantLast, p.LastReadID)
+			}
+		})
+	}
+}
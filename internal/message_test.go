@@ -0,0 +1,57 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestConversationParticipants(t *testing.T) {
+	c := &model.Conversation{Participants: []model.Participant{{UserID: 1}, {UserID: 2}}}
+	assert.Equal(t, []int{1, 2}, c.UserIDs())
+	assert.Equal(t, 2, c.Other(1))
+	assert.Equal(t, 1, c.Other(2))
+	c.IsGroup = true
+	assert.Equal(t, 0, c.Other(1))
+}
+
+func TestDirectConversationKey(t *testing.T) {
+	assert.Equal(t, "3:7", model.DirectConversationKey(3, 7))
+	assert.Equal(t, "3:7", model.DirectConversationKey(7, 3))
+}
+
+func TestParticipantRead(t *testing.T) {
+	cases := []struct {
+		name     string
+		last     int
+		message  int
+		wantOK   bool
+		wantLast int
+	}{
+		{
+			name:     "Newer message",
+			last:     3,
+			message:  5,
+			wantOK:   true,
+			wantLast: 5,
+		},
+		{
+			name:     "Already read",
+			last:     5,
+			message:  3,
+			wantLast: 5,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &model.Participant{LastReadID: tt.last}
+			ok := p.Read(tt.message, mock.TestTime(2018))
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantLast, p.LastReadID)
+			assert.Equal(t, tt.wantOK, p.ReadAt != nil)
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package mock
+
+import (
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// ActivityLogger mock
+type ActivityLogger struct {
+	LogFn func(echo.Context, model.Activity) error
+}
+
+// Log mock
+func (a *ActivityLogger) Log(c echo.Context, act model.Activity) error {
+	return a.LogFn(c, act)
+}
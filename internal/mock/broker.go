@@ -0,0 +1,35 @@
+package mock
+
+import (
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Broker mock
+type Broker struct {
+	PublishFn     func(orm.DB, model.Event) error
+	SubscribeFn   func(int) *model.Subscription
+	UnsubscribeFn func(*model.Subscription)
+	SinceFn       func(int, int64) []model.Event
+}
+
+// Publish mock
+func (b *Broker) Publish(db orm.DB, e model.Event) error {
+	return b.PublishFn(db, e)
+}
+
+// Subscribe mock
+func (b *Broker) Subscribe(userID int) *model.Subscription {
+	return b.SubscribeFn(userID)
+}
+
+// Unsubscribe mock
+func (b *Broker) Unsubscribe(s *model.Subscription) {
+	b.UnsubscribeFn(s)
+}
+
+// Since mock
+func (b *Broker) Since(userID int, after int64) []model.Event {
+	return b.SinceFn(userID, after)
+}
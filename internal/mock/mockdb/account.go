@@ -1,21 +1,29 @@
 package mockdb
 
 import (
+	"github.com/go-pg/pg/orm"
+
 	"github.com/artistomin/friend4me/internal"
 )
 
 // Account database mock
 type Account struct {
-	CreateFn         func(model.User) (*model.User, error)
-	ChangePasswordFn func(*model.User) error
+	CreateFn         func(orm.DB, model.User) (*model.User, error)
+	ChangePasswordFn func(orm.DB, *model.User) error
+	ImportFn         func(orm.DB, []model.User, model.ImportMode) ([]error, error)
 }
 
 // Create mock
-func (a *Account) Create(usr model.User) (*model.User, error) {
-	return a.CreateFn(usr)
+func (a *Account) Create(db orm.DB, usr model.User) (*model.User, error) {
+	return a.CreateFn(db, usr)
 }
 
 // ChangePassword mock
-func (a *Account) ChangePassword(usr *model.User) error {
-	return a.ChangePasswordFn(usr)
+func (a *Account) ChangePassword(db orm.DB, usr *model.User) error {
+	return a.ChangePasswordFn(db, usr)
+}
+
+// Import mock
+func (a *Account) Import(db orm.DB, users []model.User, mode model.ImportMode) ([]error, error) {
+	return a.ImportFn(db, users, mode)
 }
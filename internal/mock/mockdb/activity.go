@@ -0,0 +1,23 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Activity database mock
+type Activity struct {
+	CreateFn func(orm.DB, model.Activity) (*model.Activity, error)
+	FeedFn   func(orm.DB, int, *model.FeedCursor) ([]model.Activity, error)
+}
+
+// Create mock
+func (a *Activity) Create(db orm.DB, act model.Activity) (*model.Activity, error) {
+	return a.CreateFn(db, act)
+}
+
+// Feed mock
+func (a *Activity) Feed(db orm.DB, userID int, cur *model.FeedCursor) ([]model.Activity, error) {
+	return a.FeedFn(db, userID, cur)
+}
@@ -0,0 +1,47 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Block database mock
+type Block struct {
+	CreateFn  func(orm.DB, model.Block) (*model.Block, error)
+	FindFn    func(orm.DB, int, int, model.BlockKind) (*model.Block, error)
+	DeleteFn  func(orm.DB, *model.Block) error
+	ListFn    func(orm.DB, int, model.BlockKind, *model.Pagination) ([]model.User, error)
+	BlockedFn func(orm.DB, int, int) (bool, error)
+	RelatedFn func(orm.DB, int) ([]int, error)
+}
+
+// Create mock
+func (b *Block) Create(db orm.DB, bl model.Block) (*model.Block, error) {
+	return b.CreateFn(db, bl)
+}
+
+// Find mock
+func (b *Block) Find(db orm.DB, userID, targetID int, kind model.BlockKind) (*model.Block, error) {
+	return b.FindFn(db, userID, targetID, kind)
+}
+
+// Delete mock
+func (b *Block) Delete(db orm.DB, bl *model.Block) error {
+	return b.DeleteFn(db, bl)
+}
+
+// List mock
+func (b *Block) List(db orm.DB, userID int, kind model.BlockKind, p *model.Pagination) ([]model.User, error) {
+	return b.ListFn(db, userID, kind, p)
+}
+
+// Blocked mock
+func (b *Block) Blocked(db orm.DB, userID, otherID int) (bool, error) {
+	return b.BlockedFn(db, userID, otherID)
+}
+
+// Related mock
+func (b *Block) Related(db orm.DB, userID int) ([]int, error) {
+	return b.RelatedFn(db, userID)
+}
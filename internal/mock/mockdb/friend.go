@@ -0,0 +1,59 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Friend database mock
+type Friend struct {
+	CreateFn        func(orm.DB, model.Friendship) (*model.Friendship, error)
+	ViewFn          func(orm.DB, int) (*model.Friendship, error)
+	FindBetweenFn   func(orm.DB, int, int) (*model.Friendship, error)
+	UpdateFn        func(orm.DB, *model.Friendship) error
+	DeleteFn        func(orm.DB, *model.Friendship) error
+	DeleteBetweenFn func(orm.DB, int, int) error
+	ListFriendsFn   func(orm.DB, int, *model.Pagination) ([]model.Friend, error)
+	ListRequestsFn  func(orm.DB, int, bool, *model.Pagination) ([]model.Friendship, error)
+}
+
+// Create mock
+func (f *Friend) Create(db orm.DB, fr model.Friendship) (*model.Friendship, error) {
+	return f.CreateFn(db, fr)
+}
+
+// View mock
+func (f *Friend) View(db orm.DB, id int) (*model.Friendship, error) {
+	return f.ViewFn(db, id)
+}
+
+// FindBetween mock
+func (f *Friend) FindBetween(db orm.DB, userID, otherID int) (*model.Friendship, error) {
+	return f.FindBetweenFn(db, userID, otherID)
+}
+
+// Update mock
+func (f *Friend) Update(db orm.DB, fr *model.Friendship) error {
+	return f.UpdateFn(db, fr)
+}
+
+// Delete mock
+func (f *Friend) Delete(db orm.DB, fr *model.Friendship) error {
+	return f.DeleteFn(db, fr)
+}
+
+// DeleteBetween mock
+func (f *Friend) DeleteBetween(db orm.DB, userID, otherID int) error {
+	return f.DeleteBetweenFn(db, userID, otherID)
+}
+
+// ListFriends mock
+func (f *Friend) ListFriends(db orm.DB, userID int, p *model.Pagination) ([]model.Friend, error) {
+	return f.ListFriendsFn(db, userID, p)
+}
+
+// ListRequests mock
+func (f *Friend) ListRequests(db orm.DB, userID int, incoming bool, p *model.Pagination) ([]model.Friendship, error) {
+	return f.ListRequestsFn(db, userID, incoming, p)
+}
@@ -0,0 +1,43 @@
+package mockdb
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Grant database mock
+type Grant struct {
+	CreateFn     func(orm.DB, model.RoleGrant) (*model.RoleGrant, error)
+	ViewFn       func(orm.DB, int) (*model.RoleGrant, error)
+	ListFn       func(orm.DB, int) ([]model.RoleGrant, error)
+	ListActiveFn func(orm.DB, int, time.Time) ([]model.RoleGrant, error)
+	DeleteFn     func(orm.DB, *model.RoleGrant) error
+}
+
+// Create mock
+func (g *Grant) Create(db orm.DB, gr model.RoleGrant) (*model.RoleGrant, error) {
+	return g.CreateFn(db, gr)
+}
+
+// View mock
+func (g *Grant) View(db orm.DB, id int) (*model.RoleGrant, error) {
+	return g.ViewFn(db, id)
+}
+
+// List mock
+func (g *Grant) List(db orm.DB, userID int) ([]model.RoleGrant, error) {
+	return g.ListFn(db, userID)
+}
+
+// ListActive mock
+func (g *Grant) ListActive(db orm.DB, userID int, t time.Time) ([]model.RoleGrant, error) {
+	return g.ListActiveFn(db, userID, t)
+}
+
+// Delete mock
+func (g *Grant) Delete(db orm.DB, gr *model.RoleGrant) error {
+	return g.DeleteFn(db, gr)
+}
@@ -0,0 +1,89 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Group database mock
+type Group struct {
+	CreateFn       func(orm.DB, model.Group) (*model.Group, error)
+	ViewFn         func(orm.DB, int) (*model.Group, error)
+	ListFn         func(orm.DB, *model.GroupFilter, *model.Pagination) ([]model.Group, error)
+	UpdateFn       func(orm.DB, *model.Group) error
+	DeleteFn       func(orm.DB, *model.Group) error
+	MemberFn       func(orm.DB, int, int) (*model.GroupMember, error)
+	MembersFn      func(orm.DB, int, model.MembershipStatus, *model.Pagination) ([]model.GroupMember, error)
+	SetMemberFn    func(orm.DB, *model.GroupMember) error
+	RemoveMemberFn func(orm.DB, *model.GroupMember) error
+	CreatePostFn   func(orm.DB, model.GroupPost) (*model.GroupPost, error)
+	ViewPostFn     func(orm.DB, int) (*model.GroupPost, error)
+	PostsFn        func(orm.DB, int, *model.Pagination) ([]model.GroupPost, error)
+	DeletePostFn   func(orm.DB, *model.GroupPost) error
+}
+
+// Create mock
+func (g *Group) Create(db orm.DB, gr model.Group) (*model.Group, error) {
+	return g.CreateFn(db, gr)
+}
+
+// View mock
+func (g *Group) View(db orm.DB, id int) (*model.Group, error) {
+	return g.ViewFn(db, id)
+}
+
+// List mock
+func (g *Group) List(db orm.DB, f *model.GroupFilter, p *model.Pagination) ([]model.Group, error) {
+	return g.ListFn(db, f, p)
+}
+
+// Update mock
+func (g *Group) Update(db orm.DB, gr *model.Group) error {
+	return g.UpdateFn(db, gr)
+}
+
+// Delete mock
+func (g *Group) Delete(db orm.DB, gr *model.Group) error {
+	return g.DeleteFn(db, gr)
+}
+
+// Member mock
+func (g *Group) Member(db orm.DB, groupID, userID int) (*model.GroupMember, error) {
+	return g.MemberFn(db, groupID, userID)
+}
+
+// Members mock
+func (g *Group) Members(db orm.DB, groupID int, status model.MembershipStatus, p *model.Pagination) ([]model.GroupMember, error) {
+	return g.MembersFn(db, groupID, status, p)
+}
+
+// SetMember mock
+func (g *Group) SetMember(db orm.DB, m *model.GroupMember) error {
+	return g.SetMemberFn(db, m)
+}
+
+// RemoveMember mock
+func (g *Group) RemoveMember(db orm.DB, m *model.GroupMember) error {
+	return g.RemoveMemberFn(db, m)
+}
+
+// CreatePost mock
+func (g *Group) CreatePost(db orm.DB, p model.GroupPost) (*model.GroupPost, error) {
+	return g.CreatePostFn(db, p)
+}
+
+// ViewPost mock
+func (g *Group) ViewPost(db orm.DB, id int) (*model.GroupPost, error) {
+	return g.ViewPostFn(db, id)
+}
+
+// Posts mock
+func (g *Group) Posts(db orm.DB, groupID int, p *model.Pagination) ([]model.GroupPost, error) {
+	return g.PostsFn(db, groupID, p)
+}
+
+// DeletePost mock
+func (g *Group) DeletePost(db orm.DB, p *model.GroupPost) error {
+	return g.DeletePostFn(db, p)
+}
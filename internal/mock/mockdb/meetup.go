@@ -0,0 +1,59 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Meetup database mock
+type Meetup struct {
+	CreateFn   func(orm.DB, model.Meetup) (*model.Meetup, error)
+	ViewFn     func(orm.DB, int) (*model.Meetup, error)
+	LockFn     func(orm.DB, int) (*model.Meetup, error)
+	ListFn     func(orm.DB, *model.MeetupFilter, *model.Pagination) ([]model.Meetup, error)
+	UpdateFn   func(orm.DB, *model.Meetup) error
+	RSVPsFn    func(orm.DB, int) ([]model.RSVP, error)
+	SetRSVPFn  func(orm.DB, *model.RSVP) error
+	LocationFn func(orm.DB, int) (*model.Location, error)
+}
+
+// Create mock
+func (e *Meetup) Create(db orm.DB, ev model.Meetup) (*model.Meetup, error) {
+	return e.CreateFn(db, ev)
+}
+
+// View mock
+func (e *Meetup) View(db orm.DB, id int) (*model.Meetup, error) {
+	return e.ViewFn(db, id)
+}
+
+// Lock mock
+func (e *Meetup) Lock(db orm.DB, id int) (*model.Meetup, error) {
+	return e.LockFn(db, id)
+}
+
+// List mock
+func (e *Meetup) List(db orm.DB, f *model.MeetupFilter, p *model.Pagination) ([]model.Meetup, error) {
+	return e.ListFn(db, f, p)
+}
+
+// Update mock
+func (e *Meetup) Update(db orm.DB, ev *model.Meetup) error {
+	return e.UpdateFn(db, ev)
+}
+
+// RSVPs mock
+func (e *Meetup) RSVPs(db orm.DB, meetupID int) ([]model.RSVP, error) {
+	return e.RSVPsFn(db, meetupID)
+}
+
+// SetRSVP mock
+func (e *Meetup) SetRSVP(db orm.DB, r *model.RSVP) error {
+	return e.SetRSVPFn(db, r)
+}
+
+// Location mock
+func (e *Meetup) Location(db orm.DB, id int) (*model.Location, error) {
+	return e.LocationFn(db, id)
+}
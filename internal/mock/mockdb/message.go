@@ -0,0 +1,59 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Message database mock
+type Message struct {
+	CreateConversationFn func(orm.DB, model.Conversation, []int) (*model.Conversation, error)
+	ViewConversationFn   func(orm.DB, int) (*model.Conversation, error)
+	FindDirectFn         func(orm.DB, int, int) (*model.Conversation, error)
+	ListConversationsFn  func(orm.DB, int, *model.Pagination) ([]model.Conversation, error)
+	CreateMessageFn      func(orm.DB, model.Message) (*model.Message, error)
+	ViewMessageFn        func(orm.DB, int) (*model.Message, error)
+	HistoryFn            func(orm.DB, int, *model.MessageCursor) ([]model.Message, error)
+	UpdateParticipantFn  func(orm.DB, *model.Participant) error
+}
+
+// CreateConversation mock
+func (m *Message) CreateConversation(db orm.DB, cv model.Conversation, userIDs []int) (*model.Conversation, error) {
+	return m.CreateConversationFn(db, cv, userIDs)
+}
+
+// ViewConversation mock
+func (m *Message) ViewConversation(db orm.DB, id int) (*model.Conversation, error) {
+	return m.ViewConversationFn(db, id)
+}
+
+// FindDirect mock
+func (m *Message) FindDirect(db orm.DB, userID, otherID int) (*model.Conversation, error) {
+	return m.FindDirectFn(db, userID, otherID)
+}
+
+// ListConversations mock
+func (m *Message) ListConversations(db orm.DB, userID int, p *model.Pagination) ([]model.Conversation, error) {
+	return m.ListConversationsFn(db, userID, p)
+}
+
+// CreateMessage mock
+func (m *Message) CreateMessage(db orm.DB, msg model.Message) (*model.Message, error) {
+	return m.CreateMessageFn(db, msg)
+}
+
+// ViewMessage mock
+func (m *Message) ViewMessage(db orm.DB, id int) (*model.Message, error) {
+	return m.ViewMessageFn(db, id)
+}
+
+// History mock
+func (m *Message) History(db orm.DB, conversationID int, cur *model.MessageCursor) ([]model.Message, error) {
+	return m.HistoryFn(db, conversationID, cur)
+}
+
+// UpdateParticipant mock
+func (m *Message) UpdateParticipant(db orm.DB, p *model.Participant) error {
+	return m.UpdateParticipantFn(db, p)
+}
@@ -0,0 +1,61 @@
+package mockdb
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Notification database mock
+type Notification struct {
+	CreateFn        func(orm.DB, model.Notification) (*model.Notification, error)
+	ViewFn          func(orm.DB, int) (*model.Notification, error)
+	ListFn          func(orm.DB, int, *model.NotificationFilter, *model.Pagination) ([]model.Notification, error)
+	CountUnreadFn   func(orm.DB, int) (int, error)
+	UpdateFn        func(orm.DB, *model.Notification) error
+	ReadAllFn       func(orm.DB, int, time.Time) (int, error)
+	PreferencesFn   func(orm.DB, int) ([]model.NotificationPreference, error)
+	SetPreferenceFn func(orm.DB, model.NotificationPreference) error
+}
+
+// Create mock
+func (n *Notification) Create(db orm.DB, nt model.Notification) (*model.Notification, error) {
+	return n.CreateFn(db, nt)
+}
+
+// View mock
+func (n *Notification) View(db orm.DB, id int) (*model.Notification, error) {
+	return n.ViewFn(db, id)
+}
+
+// List mock
+func (n *Notification) List(db orm.DB, userID int, f *model.NotificationFilter, p *model.Pagination) ([]model.Notification, error) {
+	return n.ListFn(db, userID, f, p)
+}
+
+// CountUnread mock
+func (n *Notification) CountUnread(db orm.DB, userID int) (int, error) {
+	return n.CountUnreadFn(db, userID)
+}
+
+// Update mock
+func (n *Notification) Update(db orm.DB, nt *model.Notification) error {
+	return n.UpdateFn(db, nt)
+}
+
+// ReadAll mock
+func (n *Notification) ReadAll(db orm.DB, userID int, t time.Time) (int, error) {
+	return n.ReadAllFn(db, userID, t)
+}
+
+// Preferences mock
+func (n *Notification) Preferences(db orm.DB, userID int) ([]model.NotificationPreference, error) {
+	return n.PreferencesFn(db, userID)
+}
+
+// SetPreference mock
+func (n *Notification) SetPreference(db orm.DB, p model.NotificationPreference) error {
+	return n.SetPreferenceFn(db, p)
+}
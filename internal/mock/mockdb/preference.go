@@ -0,0 +1,35 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Preference database mock
+type Preference struct {
+	LayersFn        func(orm.DB, int) (model.Preferences, model.Preferences, error)
+	CompanyFn       func(orm.DB, int) (model.Preferences, error)
+	UpdateUserFn    func(orm.DB, int, int, model.Preferences, []string) (model.Preferences, error)
+	UpdateCompanyFn func(orm.DB, int, model.Preferences, []string) (model.Preferences, error)
+}
+
+// Layers mock
+func (p *Preference) Layers(db orm.DB, userID int) (model.Preferences, model.Preferences, error) {
+	return p.LayersFn(db, userID)
+}
+
+// Company mock
+func (p *Preference) Company(db orm.DB, companyID int) (model.Preferences, error) {
+	return p.CompanyFn(db, companyID)
+}
+
+// UpdateUser mock
+func (p *Preference) UpdateUser(db orm.DB, userID, companyID int, set model.Preferences, reset []string) (model.Preferences, error) {
+	return p.UpdateUserFn(db, userID, companyID, set, reset)
+}
+
+// UpdateCompany mock
+func (p *Preference) UpdateCompany(db orm.DB, companyID int, set model.Preferences, reset []string) (model.Preferences, error) {
+	return p.UpdateCompanyFn(db, companyID, set, reset)
+}
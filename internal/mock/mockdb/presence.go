@@ -0,0 +1,23 @@
+package mockdb
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// Presence database mock
+type Presence struct {
+	TouchFn    func(orm.DB, map[int]time.Time) error
+	WatchersFn func(orm.DB, int) ([]int, error)
+}
+
+// Touch mock
+func (p *Presence) Touch(db orm.DB, seen map[int]time.Time) error {
+	return p.TouchFn(db, seen)
+}
+
+// Watchers mock
+func (p *Presence) Watchers(db orm.DB, userID int) ([]int, error) {
+	return p.WatchersFn(db, userID)
+}
@@ -0,0 +1,41 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Privacy database mock
+type Privacy struct {
+	SubjectFn        func(orm.DB, int) (*model.User, error)
+	ExportFn         func(orm.DB, *model.User) (*model.DataExport, error)
+	RequestErasureFn func(orm.DB, *model.Erasure) error
+	ErasuresFn       func(orm.DB, int, *model.Pagination) ([]model.Erasure, error)
+	EraseFn          func(orm.DB, *model.User, *model.Erasure) error
+}
+
+// Subject mock
+func (p *Privacy) Subject(db orm.DB, id int) (*model.User, error) {
+	return p.SubjectFn(db, id)
+}
+
+// Export mock
+func (p *Privacy) Export(db orm.DB, u *model.User) (*model.DataExport, error) {
+	return p.ExportFn(db, u)
+}
+
+// RequestErasure mock
+func (p *Privacy) RequestErasure(db orm.DB, e *model.Erasure) error {
+	return p.RequestErasureFn(db, e)
+}
+
+// Erasures mock
+func (p *Privacy) Erasures(db orm.DB, companyID int, pg *model.Pagination) ([]model.Erasure, error) {
+	return p.ErasuresFn(db, companyID, pg)
+}
+
+// Erase mock
+func (p *Privacy) Erase(db orm.DB, u *model.User, e *model.Erasure) error {
+	return p.EraseFn(db, u, e)
+}
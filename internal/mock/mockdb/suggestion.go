@@ -0,0 +1,29 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Suggestion database mock
+type Suggestion struct {
+	ListFn    func(orm.DB, *model.User, *model.Pagination) ([]model.FriendSuggestion, error)
+	MutualFn  func(orm.DB, int, int) (int, error)
+	RefreshFn func(orm.DB, ...int) error
+}
+
+// List mock
+func (s *Suggestion) List(db orm.DB, u *model.User, p *model.Pagination) ([]model.FriendSuggestion, error) {
+	return s.ListFn(db, u, p)
+}
+
+// Mutual mock
+func (s *Suggestion) Mutual(db orm.DB, userID, candidateID int) (int, error) {
+	return s.MutualFn(db, userID, candidateID)
+}
+
+// Refresh mock
+func (s *Suggestion) Refresh(db orm.DB, ids ...int) error {
+	return s.RefreshFn(db, ids...)
+}
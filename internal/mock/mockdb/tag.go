@@ -0,0 +1,59 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Tag database mock
+type Tag struct {
+	CreateFn      func(orm.DB, model.Tag) (*model.Tag, error)
+	ViewFn        func(orm.DB, int) (*model.Tag, error)
+	ListFn        func(orm.DB, int) ([]model.Tag, error)
+	DeleteFn      func(orm.DB, *model.Tag) error
+	FindByNamesFn func(orm.DB, int, []string) ([]model.Tag, error)
+	UserTagsFn    func(orm.DB, int) ([]model.Tag, error)
+	SetUserTagsFn func(orm.DB, *model.User, []model.Tag) error
+	DiscoverFn    func(orm.DB, int, *model.ListQuery, *model.Pagination) ([]model.Match, error)
+}
+
+// Create mock
+func (t *Tag) Create(db orm.DB, tag model.Tag) (*model.Tag, error) {
+	return t.CreateFn(db, tag)
+}
+
+// View mock
+func (t *Tag) View(db orm.DB, id int) (*model.Tag, error) {
+	return t.ViewFn(db, id)
+}
+
+// List mock
+func (t *Tag) List(db orm.DB, companyID int) ([]model.Tag, error) {
+	return t.ListFn(db, companyID)
+}
+
+// Delete mock
+func (t *Tag) Delete(db orm.DB, tag *model.Tag) error {
+	return t.DeleteFn(db, tag)
+}
+
+// FindByNames mock
+func (t *Tag) FindByNames(db orm.DB, companyID int, names []string) ([]model.Tag, error) {
+	return t.FindByNamesFn(db, companyID, names)
+}
+
+// UserTags mock
+func (t *Tag) UserTags(db orm.DB, userID int) ([]model.Tag, error) {
+	return t.UserTagsFn(db, userID)
+}
+
+// SetUserTags mock
+func (t *Tag) SetUserTags(db orm.DB, u *model.User, tags []model.Tag) error {
+	return t.SetUserTagsFn(db, u, tags)
+}
+
+// Discover mock
+func (t *Tag) Discover(db orm.DB, userID int, qp *model.ListQuery, p *model.Pagination) ([]model.Match, error) {
+	return t.DiscoverFn(db, userID, qp, p)
+}
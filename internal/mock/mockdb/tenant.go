@@ -0,0 +1,34 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Tenant database mock
+type Tenant struct {
+	BeginFn func(int) (model.TenantTx, error)
+}
+
+// Begin mock
+func (t *Tenant) Begin(companyID int) (model.TenantTx, error) {
+	return t.BeginFn(companyID)
+}
+
+// Tx transaction mock
+type Tx struct {
+	orm.DB
+	CommitFn   func() error
+	RollbackFn func() error
+}
+
+// Commit mock
+func (t *Tx) Commit() error {
+	return t.CommitFn()
+}
+
+// Rollback mock
+func (t *Tx) Rollback() error {
+	return t.RollbackFn()
+}
@@ -1,45 +1,79 @@
 package mockdb
 
 import (
+	"time"
+
+	"github.com/go-pg/pg/orm"
+
 	"github.com/artistomin/friend4me/internal"
 )
 
 // User database mock
 type User struct {
-	ViewFn           func(int) (*model.User, error)
-	FindByUsernameFn func(string) (*model.User, error)
-	FindByTokenFn    func(string) (*model.User, error)
-	ListFn           func(*model.ListQuery, *model.Pagination) ([]model.User, error)
-	DeleteFn         func(*model.User) error
-	UpdateFn         func(*model.User) (*model.User, error)
+	ViewFn           func(orm.DB, int) (*model.User, error)
+	FindByUsernameFn func(orm.DB, string) (*model.User, error)
+	FindByTokenFn    func(orm.DB, string) (*model.User, error)
+	ListFn           func(orm.DB, *model.ListQuery, *model.UserFilter, *model.Pagination) ([]model.User, error)
+	DeleteFn         func(orm.DB, *model.User) error
+	UpdateFn         func(orm.DB, *model.User) (*model.User, error)
+	BulkFn           func(orm.DB, []int, model.BulkChange) error
+	ListDeletedFn    func(orm.DB, *model.ListQuery, *model.UserFilter, *model.Pagination) ([]model.User, error)
+	RestoreFn        func(orm.DB, *model.User) error
+	PurgeFn          func(orm.DB, time.Time) ([]model.User, error)
+	LocationFn       func(orm.DB, int) (*model.Location, error)
 }
 
 // View mock
-func (u *User) View(id int) (*model.User, error) {
-	return u.ViewFn(id)
+func (u *User) View(db orm.DB, id int) (*model.User, error) {
+	return u.ViewFn(db, id)
 }
 
 // FindByUsername mock
-func (u *User) FindByUsername(username string) (*model.User, error) {
-	return u.FindByUsernameFn(username)
+func (u *User) FindByUsername(db orm.DB, username string) (*model.User, error) {
+	return u.FindByUsernameFn(db, username)
 }
 
 // FindByToken mock
-func (u *User) FindByToken(token string) (*model.User, error) {
-	return u.FindByTokenFn(token)
+func (u *User) FindByToken(db orm.DB, token string) (*model.User, error) {
+	return u.FindByTokenFn(db, token)
 }
 
 // List mock
-func (u *User) List(lq *model.ListQuery, p *model.Pagination) ([]model.User, error) {
-	return u.ListFn(lq, p)
+func (u *User) List(db orm.DB, lq *model.ListQuery, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
+	return u.ListFn(db, lq, f, p)
 }
 
 // Delete mock
-func (u *User) Delete(usr *model.User) error {
-	return u.DeleteFn(usr)
+func (u *User) Delete(db orm.DB, usr *model.User) error {
+	return u.DeleteFn(db, usr)
 }
 
 // Update mock
-func (u *User) Update(usr *model.User) (*model.User, error) {
-	return u.UpdateFn(usr)
+func (u *User) Update(db orm.DB, usr *model.User) (*model.User, error) {
+	return u.UpdateFn(db, usr)
+}
+
+// Bulk mock
+func (u *User) Bulk(db orm.DB, ids []int, ch model.BulkChange) error {
+	return u.BulkFn(db, ids, ch)
+}
+
+// ListDeleted mock
+func (u *User) ListDeleted(db orm.DB, lq *model.ListQuery, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
+	return u.ListDeletedFn(db, lq, f, p)
+}
+
+// Restore mock
+func (u *User) Restore(db orm.DB, usr *model.User) error {
+	return u.RestoreFn(db, usr)
+}
+
+// Purge mock
+func (u *User) Purge(db orm.DB, before time.Time) ([]model.User, error) {
+	return u.PurgeFn(db, before)
+}
+
+// Location mock
+func (u *User) Location(db orm.DB, id int) (*model.Location, error) {
+	return u.LocationFn(db, id)
 }
@@ -13,3 +13,13 @@ type JWT struct {
 func (j *JWT) GenerateToken(u *model.User) (string, string, error) {
 	return j.GenerateTokenFn(u)
 }
+
+// Resolver mock
+type Resolver struct {
+	EffectiveFn func(*model.AuthUser) (*model.AuthUser, error)
+}
+
+// Effective mock
+func (r *Resolver) Effective(u *model.AuthUser) (*model.AuthUser, error) {
+	return r.EffectiveFn(u)
+}
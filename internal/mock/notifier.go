@@ -0,0 +1,17 @@
+package mock
+
+import (
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Notifier mock
+type Notifier struct {
+	NotifyFn func(echo.Context, model.Notification) error
+}
+
+// Notify mock
+func (n *Notifier) Notify(c echo.Context, nt model.Notification) error {
+	return n.NotifyFn(c, nt)
+}
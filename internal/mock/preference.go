@@ -0,0 +1,17 @@
+package mock
+
+import (
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Preferences mock
+type Preferences struct {
+	PreferencesFn func(echo.Context, int) (model.Preferences, error)
+}
+
+// Preferences mock
+func (p *Preferences) Preferences(c echo.Context, userID int) (model.Preferences, error) {
+	return p.PreferencesFn(c, userID)
+}
@@ -0,0 +1,23 @@
+package mock
+
+import (
+	"time"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Presence tracker mock
+type Presence struct {
+	SeenFn     func(int, time.Time)
+	PresenceFn func(*model.User, time.Time) *model.Presence
+}
+
+// Seen mock
+func (p *Presence) Seen(userID int, t time.Time) {
+	p.SeenFn(userID, t)
+}
+
+// Presence mock
+func (p *Presence) Presence(u *model.User, t time.Time) *model.Presence {
+	return p.PresenceFn(u, t)
+}
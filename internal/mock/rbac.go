@@ -8,12 +8,14 @@ import (
 
 // RBAC Mock
 type RBAC struct {
-	EnforceRoleFn     func(echo.Context, model.AccessRole) error
-	EnforceUserFn     func(echo.Context, int) error
-	EnforceCompanyFn  func(echo.Context, int) error
-	EnforceLocationFn func(echo.Context, int) error
-	AccountCreateFn   func(echo.Context, int, int, int) error
-	IsLowerRoleFn     func(echo.Context, model.AccessRole) error
+	EnforceRoleFn        func(echo.Context, model.AccessRole) error
+	EnforceUserFn        func(echo.Context, int) error
+	EnforceCompanyFn     func(echo.Context, int) error
+	EnforceLocationFn    func(echo.Context, int) error
+	AccountCreateFn      func(echo.Context, int, int, int) error
+	IsLowerRoleFn        func(echo.Context, model.AccessRole) error
+	EnforceParticipantFn func(echo.Context, []int) error
+	EnforceGroupFn       func(echo.Context, int, model.GroupRole, model.GroupRole) error
 }
 
 // EnforceRole mock
@@ -45,3 +47,13 @@ func (a *RBAC) AccountCreate(c echo.Context, roleID, companyID, locationID int)
 func (a *RBAC) IsLowerRole(c echo.Context, role model.AccessRole) error {
 	return a.IsLowerRoleFn(c, role)
 }
+
+// EnforceParticipant mock
+func (a *RBAC) EnforceParticipant(c echo.Context, participants []int) error {
+	return a.EnforceParticipantFn(c, participants)
+}
+
+// EnforceGroup mock
+func (a *RBAC) EnforceGroup(c echo.Context, companyID int, role, required model.GroupRole) error {
+	return a.EnforceGroupFn(c, companyID, role, required)
+}
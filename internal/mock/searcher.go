@@ -0,0 +1,17 @@
+package mock
+
+import (
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// Searcher mock
+type Searcher struct {
+	SearchUsersFn func(orm.DB, []string, *model.ListQuery, *model.Pagination) ([]model.SearchHit, error)
+}
+
+// SearchUsers mock
+func (s *Searcher) SearchUsers(db orm.DB, terms []string, q *model.ListQuery, p *model.Pagination) ([]model.SearchHit, error) {
+	return s.SearchUsersFn(db, terms, q, p)
+}
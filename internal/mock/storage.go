@@ -0,0 +1,23 @@
+package mock
+
+// Storage mock
+type Storage struct {
+	PutFn    func(string, string, []byte) error
+	DeleteFn func(string) error
+	URLFn    func(string) string
+}
+
+// Put mock
+func (s *Storage) Put(key, contentType string, data []byte) error {
+	return s.PutFn(key, contentType, data)
+}
+
+// Delete mock
+func (s *Storage) Delete(key string) error {
+	return s.DeleteFn(key)
+}
+
+// URL mock
+func (s *Storage) URL(key string) string {
+	return s.URLFn(key)
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"time"
 
@@ -10,6 +12,9 @@ import (
 // ErrGeneric is used for testing purposes and for errors handled later in the callstack
 var ErrGeneric = errors.New("generic error")
 
+// ErrInvalidCursor is returned when pagination cursor can not be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
 // Base contains common fields for all tables
 type Base struct {
 	ID        int        `json:"id"`
@@ -18,16 +23,51 @@ type Base struct {
 	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
-// Pagination holds paginations data
+// Pagination holds paginations data.
+// Rows are paged by keyset when Cursor is set, and by Offset otherwise. Lists supporting it fill in Total when Count is set
 type Pagination struct {
 	Limit  int
 	Offset int
+	Cursor *Cursor
+	Count  bool
+	Total  int
+}
+
+// Cursor points at the row a keyset paginated list continues from, by values of the columns the list is ordered by.
+// Rows following it are listed, or rows preceding it when Prev is set.
+// Order names the ordering the values belong to, so cursors are not reused with another one
+type Cursor struct {
+	Order  string    `json:"o"`
+	Values []*string `json:"v"`
+	Prev   bool      `json:"p,omitempty"`
+}
+
+// Encode returns opaque representation of the cursor, safe to use in URLs
+func (c *Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseCursor decodes cursor returned by Encode
+func ParseCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	c := new(Cursor)
+	if err := json.Unmarshal(b, c); err != nil || c.Order == "" || len(c.Values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
 }
 
-// ListQuery holds company/location data used for list db queries
+// ListQuery holds company/location data used for list db queries.
+// Searchable leaves out users who turned privacy.searchable preference off
 type ListQuery struct {
-	Query string
-	ID    int
+	Query      string
+	ID         int
+	Exclude    []int
+	Searchable bool
 }
 
 // BeforeInsert hooks into insert operations, setting createdAt and updatedAt to current time
@@ -3,6 +3,8 @@ package model_test
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/artistomin/friend4me/internal"
 
 	"github.com/artistomin/friend4me/internal/mock"
@@ -45,3 +47,16 @@ func TestDelete(t *testing.T) {
 	}
 
 }
+
+func TestCursor(t *testing.T) {
+	name := "Doe"
+	cur := &model.Cursor{Order: "last_name,-id", Values: []*string{&name, nil}, Prev: true}
+	parsed, err := model.ParseCursor(cur.Encode())
+	assert.Nil(t, err)
+	assert.Equal(t, cur, parsed)
+
+	for _, s := range []string{"", "not base64!", "bnVsbA", "e30"} {
+		_, err := model.ParseCursor(s)
+		assert.Equal(t, model.ErrInvalidCursor, err)
+	}
+}
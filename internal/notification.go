@@ -0,0 +1,117 @@
+package model
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+)
+
+// NotificationType represents kind of notification sent to a user
+type NotificationType string
+
+const (
+	// NotificationFriendRequest notifies user of a friend request sent to it
+	NotificationFriendRequest NotificationType = "friend_request"
+
+	// NotificationPasswordChanged notifies user that its password was changed
+	NotificationPasswordChanged NotificationType = "password_changed"
+
+	// NotificationRoleChanged notifies user of a role granted to it
+	NotificationRoleChanged NotificationType = "role_changed"
+
+	// NotificationInvitationAccepted notifies user that its friend request was accepted
+	NotificationInvitationAccepted NotificationType = "invitation_accepted"
+
+	// NotificationMeetupUpdated notifies user that a meetup it attends was changed by the organizer
+	NotificationMeetupUpdated NotificationType = "meetup_updated"
+
+	// NotificationMeetupCanceled notifies user that a meetup it attends was canceled
+	NotificationMeetupCanceled NotificationType = "meetup_canceled"
+
+	// NotificationMeetupConfirmed notifies user that it moved from the waitlist to meetup attendees
+	NotificationMeetupConfirmed NotificationType = "meetup_confirmed"
+)
+
+// NotificationTypes lists all notification types
+var NotificationTypes = []NotificationType{
+	NotificationFriendRequest,
+	NotificationPasswordChanged,
+	NotificationRoleChanged,
+	NotificationInvitationAccepted,
+	NotificationMeetupUpdated,
+	NotificationMeetupCanceled,
+	NotificationMeetupConfirmed,
+}
+
+// Valid checks whether notification type is known
+func (t NotificationType) Valid() bool {
+	for _, nt := range NotificationTypes {
+		if t == nt {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification represents notification stored for a user
+type Notification struct {
+	Base
+	UserID    int                    `json:"user_id"`
+	CompanyID int                    `json:"-"`
+	Type      NotificationType       `json:"type"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	ReadAt    *time.Time             `json:"read_at,omitempty"`
+}
+
+// Read marks notification as read. Returns false if it was already read
+func (n *Notification) Read(t time.Time) bool {
+	if n.ReadAt != nil {
+		return false
+	}
+	n.ReadAt = &t
+	return true
+}
+
+// NotificationPreference represents user's choice to receive notifications of a type.
+// Users receive notifications of types without a preference
+type NotificationPreference struct {
+	UserID    int              `json:"-" sql:",pk"`
+	Type      NotificationType `json:"type" sql:",pk"`
+	CompanyID int              `json:"-"`
+	Enabled   bool             `json:"enabled" sql:",notnull"`
+}
+
+// NotificationEnabled checks whether notifications of a type are enabled by the preferences
+func NotificationEnabled(prefs []NotificationPreference, t NotificationType) bool {
+	for _, p := range prefs {
+		if p.Type == t {
+			return p.Enabled
+		}
+	}
+	return true
+}
+
+// NotificationFilter holds notification list filters. Empty Type matches all types
+type NotificationFilter struct {
+	Unread bool
+	Type   NotificationType
+}
+
+// NotificationDB represents notification database interface (repository)
+type NotificationDB interface {
+	Create(orm.DB, Notification) (*Notification, error)
+	View(orm.DB, int) (*Notification, error)
+	List(orm.DB, int, *NotificationFilter, *Pagination) ([]Notification, error)
+	CountUnread(orm.DB, int) (int, error)
+	Update(orm.DB, *Notification) error
+	ReadAll(orm.DB, int, time.Time) (int, error)
+	Preferences(orm.DB, int) ([]NotificationPreference, error)
+	SetPreference(orm.DB, NotificationPreference) error
+}
+
+// Notifier emits notifications to users.
+// Services call it to let users know about things that happened to them
+type Notifier interface {
+	Notify(echo.Context, Notification) error
+}
@@ -0,0 +1,114 @@
+// Package notification contains in-app notification application services
+package notification
+
+import (
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// New creates new notification application service
+func New(ndb model.NotificationDB, broker model.Broker, auth model.AuthService) *Service {
+	return &Service{ndb: ndb, broker: broker, auth: auth}
+}
+
+// Service represents notification application service
+type Service struct {
+	ndb    model.NotificationDB
+	broker model.Broker
+	auth   model.AuthService
+}
+
+// Notify stores a notification for its user and pushes it to the user.
+// Notifications of types disabled by the user are silently dropped
+func (s *Service) Notify(c echo.Context, n model.Notification) error {
+	prefs, err := s.ndb.Preferences(model.Conn(c), n.UserID)
+	if err != nil {
+		return err
+	}
+	if !model.NotificationEnabled(prefs, n.Type) {
+		return nil
+	}
+	nt, err := s.ndb.Create(model.Conn(c), n)
+	if err != nil {
+		return err
+	}
+	e, err := model.NewEvent(model.EventNotification, nt.UserID, nt)
+	if err != nil {
+		return err
+	}
+	return s.broker.Publish(model.Conn(c), e)
+}
+
+// List returns notifications of requesting user matching the filter, along with the number of unread ones
+func (s *Service) List(c echo.Context, f *model.NotificationFilter, p *model.Pagination) ([]model.Notification, int, error) {
+	au := s.auth.User(c)
+	nts, err := s.ndb.List(model.Conn(c), au.ID, f, p)
+	if err != nil {
+		return nil, 0, err
+	}
+	unread, err := s.ndb.CountUnread(model.Conn(c), au.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return nts, unread, nil
+}
+
+// Read marks a notification of requesting user as read
+func (s *Service) Read(c echo.Context, id int) (*model.Notification, error) {
+	nt, err := s.ndb.View(model.Conn(c), id)
+	if err != nil {
+		return nil, err
+	}
+	if nt.UserID != s.auth.User(c).ID {
+		return nil, echo.ErrForbidden
+	}
+	if !nt.Read(time.Now()) {
+		return nt, nil
+	}
+	if err := s.ndb.Update(model.Conn(c), nt); err != nil {
+		return nil, err
+	}
+	return nt, nil
+}
+
+// ReadAll marks all notifications of requesting user as read, returning how many were marked
+func (s *Service) ReadAll(c echo.Context) (int, error) {
+	return s.ndb.ReadAll(model.Conn(c), s.auth.User(c).ID, time.Now())
+}
+
+// Preferences returns requesting user's preferences for every notification type
+func (s *Service) Preferences(c echo.Context) ([]model.NotificationPreference, error) {
+	au := s.auth.User(c)
+	prefs, err := s.ndb.Preferences(model.Conn(c), au.ID)
+	if err != nil {
+		return nil, err
+	}
+	all := make([]model.NotificationPreference, len(model.NotificationTypes))
+	for i, t := range model.NotificationTypes {
+		all[i] = model.NotificationPreference{UserID: au.ID, Type: t, CompanyID: au.CompanyID, Enabled: model.NotificationEnabled(prefs, t)}
+	}
+	return all, nil
+}
+
+// SetPreferences enables or disables notification types for requesting user
+func (s *Service) SetPreferences(c echo.Context, prefs map[model.NotificationType]bool) ([]model.NotificationPreference, error) {
+	au := s.auth.User(c)
+	for _, t := range model.NotificationTypes {
+		enabled, ok := prefs[t]
+		if !ok {
+			continue
+		}
+		if err := s.ndb.SetPreference(model.Conn(c), model.NotificationPreference{
+			UserID:    au.ID,
+			Type:      t,
+			CompanyID: au.CompanyID,
+			Enabled:   enabled,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return s.Preferences(c)
+}
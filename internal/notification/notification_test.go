@@ -0,0 +1,263 @@
+package notification_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+	"github.com/artistomin/friend4me/internal/notification"
+)
+
+func authUser(id int) *mock.Auth {
+	return &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: id, CompanyID: 1}
+		}}
+}
+
+func TestNotify(t *testing.T) {
+	cases := []struct {
+		name       string
+		wantErr    bool
+		wantStored bool
+		ndb        *mockdb.Notification
+	}{
+		{
+			name:    "Fail on preferences",
+			wantErr: true,
+			ndb: &mockdb.Notification{
+				PreferencesFn: func(orm.DB, int) ([]model.NotificationPreference, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name: "Disabled type",
+			ndb: &mockdb.Notification{
+				PreferencesFn: func(db orm.DB, id int) ([]model.NotificationPreference, error) {
+					return []model.NotificationPreference{{UserID: id, Type: model.NotificationFriendRequest}}, nil
+				}},
+		},
+		{
+			name:    "Fail on create",
+			wantErr: true,
+			ndb: &mockdb.Notification{
+				PreferencesFn: func(orm.DB, int) ([]model.NotificationPreference, error) {
+					return nil, nil
+				},
+				CreateFn: func(orm.DB, model.Notification) (*model.Notification, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name:       "Success",
+			wantStored: true,
+			ndb: &mockdb.Notification{
+				PreferencesFn: func(db orm.DB, id int) ([]model.NotificationPreference, error) {
+					return []model.NotificationPreference{{UserID: id, Type: model.NotificationRoleChanged}}, nil
+				},
+				CreateFn: func(db orm.DB, n model.Notification) (*model.Notification, error) {
+					n.ID = 1
+					return &n, nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var pushed []model.Event
+			broker := &mock.Broker{
+				PublishFn: func(db orm.DB, e model.Event) error {
+					pushed = append(pushed, e)
+					return nil
+				}}
+			s := notification.New(tt.ndb, broker, authUser(1))
+			err := s.Notify(nil, model.Notification{UserID: 2, CompanyID: 1, Type: model.NotificationFriendRequest})
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantStored {
+				assert.Empty(t, pushed)
+				return
+			}
+			if assert.Len(t, pushed, 1) {
+				assert.Equal(t, model.EventNotification, pushed[0].Type)
+				assert.Equal(t, 2, pushed[0].UserID)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	cases := []struct {
+		name       string
+		wantErr    bool
+		wantData   []model.Notification
+		wantUnread int
+		ndb        *mockdb.Notification
+	}{
+		{
+			name:    "Fail on list",
+			wantErr: true,
+			ndb: &mockdb.Notification{
+				ListFn: func(orm.DB, int, *model.NotificationFilter, *model.Pagination) ([]model.Notification, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name:    "Fail on count",
+			wantErr: true,
+			ndb: &mockdb.Notification{
+				ListFn: func(orm.DB, int, *model.NotificationFilter, *model.Pagination) ([]model.Notification, error) {
+					return nil, nil
+				},
+				CountUnreadFn: func(orm.DB, int) (int, error) {
+					return 0, model.ErrGeneric
+				}},
+		},
+		{
+			name: "Success",
+			ndb: &mockdb.Notification{
+				ListFn: func(db orm.DB, id int, f *model.NotificationFilter, p *model.Pagination) ([]model.Notification, error) {
+					if id != 1 || !f.Unread || p.Limit != 10 {
+						return nil, model.ErrGeneric
+					}
+					return []model.Notification{{Base: model.Base{ID: 3}, UserID: 1}}, nil
+				},
+				CountUnreadFn: func(orm.DB, int) (int, error) {
+					return 4, nil
+				}},
+			wantData:   []model.Notification{{Base: model.Base{ID: 3}, UserID: 1}},
+			wantUnread: 4,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := notification.New(tt.ndb, nil, authUser(1))
+			nts, unread, err := s.List(nil, &model.NotificationFilter{Unread: true}, &model.Pagination{Limit: 10})
+			assert.Equal(t, tt.wantData, nts)
+			assert.Equal(t, tt.wantUnread, unread)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	readAt := mock.TestTime(2018)
+	cases := []struct {
+		name        string
+		wantErr     bool
+		wantUpdated bool
+		ndb         *mockdb.Notification
+	}{
+		{
+			name:    "Fail on view",
+			wantErr: true,
+			ndb: &mockdb.Notification{
+				ViewFn: func(orm.DB, int) (*model.Notification, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name:    "Notification of another user",
+			wantErr: true,
+			ndb: &mockdb.Notification{
+				ViewFn: func(db orm.DB, id int) (*model.Notification, error) {
+					return &model.Notification{Base: model.Base{ID: id}, UserID: 2}, nil
+				}},
+		},
+		{
+			name: "Already read",
+			ndb: &mockdb.Notification{
+				ViewFn: func(db orm.DB, id int) (*model.Notification, error) {
+					return &model.Notification{Base: model.Base{ID: id}, UserID: 1, ReadAt: &readAt}, nil
+				}},
+		},
+		{
+			name:    "Fail on update",
+			wantErr: true,
+			ndb: &mockdb.Notification{
+				ViewFn: func(db orm.DB, id int) (*model.Notification, error) {
+					return &model.Notification{Base: model.Base{ID: id}, UserID: 1}, nil
+				},
+				UpdateFn: func(orm.DB, *model.Notification) error {
+					return model.ErrGeneric
+				}},
+		},
+		{
+			name:        "Success",
+			wantUpdated: true,
+			ndb: &mockdb.Notification{
+				ViewFn: func(db orm.DB, id int) (*model.Notification, error) {
+					return &model.Notification{Base: model.Base{ID: id}, UserID: 1}, nil
+				},
+				UpdateFn: func(orm.DB, *model.Notification) error {
+					return nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := notification.New(tt.ndb, nil, authUser(1))
+			nt, err := s.Read(nil, 5)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantErr {
+				assert.Nil(t, nt)
+				return
+			}
+			assert.Equal(t, 5, nt.ID)
+			assert.NotNil(t, nt.ReadAt)
+			assert.Equal(t, tt.wantUpdated, !nt.ReadAt.Equal(readAt))
+		})
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	ndb := &mockdb.Notification{
+		ReadAllFn: func(db orm.DB, id int, at time.Time) (int, error) {
+			if id != 1 || at.IsZero() {
+				return 0, model.ErrGeneric
+			}
+			return 3, nil
+		}}
+	s := notification.New(ndb, nil, authUser(1))
+	n, err := s.ReadAll(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+}
+
+func TestPreferences(t *testing.T) {
+	var set []model.NotificationPreference
+	ndb := &mockdb.Notification{
+		PreferencesFn: func(db orm.DB, id int) ([]model.NotificationPreference, error) {
+			return set, nil
+		},
+		SetPreferenceFn: func(db orm.DB, p model.NotificationPreference) error {
+			if p.Type == model.NotificationRoleChanged {
+				return model.ErrGeneric
+			}
+			set = append(set, p)
+			return nil
+		}}
+	s := notification.New(ndb, nil, authUser(1))
+
+	prefs, err := s.SetPreferences(nil, map[model.NotificationType]bool{model.NotificationFriendRequest: false, "newsletter": false})
+	assert.Nil(t, err)
+	assert.Equal(t, []model.NotificationPreference{{UserID: 1, Type: model.NotificationFriendRequest, CompanyID: 1}}, set)
+	assert.Equal(t, []model.NotificationPreference{
+		{UserID: 1, Type: model.NotificationFriendRequest, CompanyID: 1, Enabled: false},
+		{UserID: 1, Type: model.NotificationPasswordChanged, CompanyID: 1, Enabled: true},
+		{UserID: 1, Type: model.NotificationRoleChanged, CompanyID: 1, Enabled: true},
+		{UserID: 1, Type: model.NotificationInvitationAccepted, CompanyID: 1, Enabled: true},
+		{UserID: 1, Type: model.NotificationMeetupUpdated, CompanyID: 1, Enabled: true},
+		{UserID: 1, Type: model.NotificationMeetupCanceled, CompanyID: 1, Enabled: true},
+		{UserID: 1, Type: model.NotificationMeetupConfirmed, CompanyID: 1, Enabled: true},
+	}, prefs)
+
+	_, err = s.SetPreferences(nil, map[model.NotificationType]bool{model.NotificationRoleChanged: true})
+	assert.NotNil(t, err)
+}
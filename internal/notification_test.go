@@ -0,0 +1,36 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestNotificationTypeValid(t *testing.T) {
+	assert.True(t, model.NotificationFriendRequest.Valid())
+	assert.True(t, model.NotificationType("role_changed").Valid())
+	assert.False(t, model.NotificationType("newsletter").Valid())
+	assert.False(t, model.NotificationType("").Valid())
+}
+
+func TestNotificationRead(t *testing.T) {
+	n := new(model.Notification)
+	assert.True(t, n.Read(mock.TestTime(2018)))
+	assert.Equal(t, mock.TestTime(2018), *n.ReadAt)
+	assert.False(t, n.Read(mock.TestTime(2019)))
+	assert.Equal(t, mock.TestTime(2018), *n.ReadAt)
+}
+
+func TestNotificationEnabled(t *testing.T) {
+	prefs := []model.NotificationPreference{
+		{Type: model.NotificationFriendRequest, Enabled: false},
+		{Type: model.NotificationRoleChanged, Enabled: true},
+	}
+	assert.False(t, model.NotificationEnabled(prefs, model.NotificationFriendRequest))
+	assert.True(t, model.NotificationEnabled(prefs, model.NotificationRoleChanged))
+	assert.True(t, model.NotificationEnabled(prefs, model.NotificationPasswordChanged))
+	assert.True(t, model.NotificationEnabled(nil, model.NotificationFriendRequest))
+}
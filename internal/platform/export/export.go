@@ -0,0 +1,152 @@
+// Package export writes tabular data in file formats meant for spreadsheets and data tools, row by row,
+// so exports of any size are streamed rather than held in memory
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format represents a file format of an export
+type Format string
+
+const (
+	// CSV writes comma separated values, with a header row
+	CSV Format = "csv"
+
+	// JSONL writes JSON lines, each row being an object keyed by column names
+	JSONL Format = "jsonl"
+
+	// XLSX writes an Excel workbook with a single sheet, with a header row
+	XLSX Format = "xlsx"
+)
+
+// Formats lists supported formats by their names
+var Formats = map[string]Format{
+	"csv":   CSV,
+	"jsonl": JSONL,
+	"xlsx":  XLSX,
+}
+
+// ContentType returns MIME type of files in the format
+func (f Format) ContentType() string {
+	switch f {
+	case JSONL:
+		return "application/x-ndjson"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Writer writes rows of an export. Values are ints, strings, bools, times or nil, in order of the columns.
+// Flush writes buffered rows to the underlying writer, and Close completes the file without closing the underlying writer
+type Writer interface {
+	Write(row []interface{}) error
+	Flush() error
+	Close() error
+}
+
+// New creates writer of the format, writing the header if the format has one
+func New(f Format, w io.Writer, columns []string) (Writer, error) {
+	switch f {
+	case JSONL:
+		return &jsonlWriter{w: bufio.NewWriter(w), columns: columns}, nil
+	case XLSX:
+		return newXLSX(w, columns)
+	}
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	if err := cw.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// text formats the value as written in text formats
+func text(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return ""
+}
+
+// formulaPrefixes start values spreadsheets would evaluate as formulas when opening CSV files
+const formulaPrefixes = "=+-@\t\r"
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+// Write writes the row, prefixing strings starting like formulas with a quote,
+// so that user supplied values are shown as they are rather than evaluated
+func (c *csvWriter) Write(row []interface{}) error {
+	record := make([]string, len(row))
+	for i, v := range row {
+		record[i] = text(v)
+		if s, ok := v.(string); ok && s != "" && strings.IndexByte(formulaPrefixes, s[0]) >= 0 {
+			record[i] = "'" + s
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+type jsonlWriter struct {
+	w       *bufio.Writer
+	columns []string
+}
+
+// Write writes the row as an object keeping order of the columns, which encoding maps would not
+func (j *jsonlWriter) Write(row []interface{}) error {
+	j.w.WriteByte('{')
+	for i, v := range row {
+		if i > 0 {
+			j.w.WriteByte(',')
+		}
+		key, err := json.Marshal(j.columns[i])
+		if err != nil {
+			return err
+		}
+		if t, ok := v.(time.Time); ok {
+			v = t.Format(time.RFC3339)
+		}
+		val, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		j.w.Write(key)
+		j.w.WriteByte(':')
+		j.w.Write(val)
+	}
+	j.w.WriteByte('}')
+	_, err := j.w.WriteString("\n")
+	return err
+}
+
+func (j *jsonlWriter) Flush() error {
+	return j.w.Flush()
+}
+
+func (j *jsonlWriter) Close() error {
+	return j.w.Flush()
+}
@@ -0,0 +1,70 @@
+package export_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal/platform/export"
+)
+
+var (
+	columns = []string{"id", "username", "active", "created_at", "last_login"}
+	rows    = [][]interface{}{
+		{1, "johndoe", true, time.Date(2018, 3, 1, 10, 30, 0, 0, time.UTC), nil},
+		{2, `"quoted", <tagged>`, false, time.Date(2018, 4, 2, 0, 0, 0, 0, time.UTC), time.Date(2018, 5, 3, 0, 0, 0, 0, time.UTC)},
+	}
+)
+
+func write(t *testing.T, f export.Format) []byte {
+	var buf bytes.Buffer
+	w, err := export.New(f, &buf, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rows {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCSV(t *testing.T) {
+	assert.Equal(t, "id,username,active,created_at,last_login\n"+
+		"1,johndoe,true,2018-03-01T10:30:00Z,\n"+
+		`2,"""quoted"", <tagged>",false,2018-04-02T00:00:00Z,2018-05-03T00:00:00Z`+"\n", string(write(t, export.CSV)))
+}
+
+func TestCSVFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := export.New(export.CSV, &buf, []string{"id", "first_name", "last_name", "email", "bio"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write([]interface{}{-1, "=HYPERLINK(\"http://evil\")", "+1", "@sum", "-x"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "id,first_name,last_name,email,bio\n"+
+		`-1,"'=HYPERLINK(""http://evil"")",'+1,'@sum,'-x`+"\n", buf.String())
+}
+
+func TestJSONL(t *testing.T) {
+	assert.Equal(t, `{"id":1,"username":"johndoe","active":true,"created_at":"2018-03-01T10:30:00Z","last_login":null}`+"\n"+
+		`{"id":2,"username":"\"quoted\", \u003ctagged\u003e","active":false,"created_at":"2018-04-02T00:00:00Z","last_login":"2018-05-03T00:00:00Z"}`+"\n",
+		string(write(t, export.JSONL)))
+}
+
+func TestContentType(t *testing.T) {
+	assert.Equal(t, "text/csv; charset=utf-8", export.CSV.ContentType())
+	assert.Equal(t, "application/x-ndjson", export.JSONL.ContentType())
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", export.XLSX.ContentType())
+}
@@ -0,0 +1,67 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal/platform/export"
+)
+
+type sheet struct {
+	Rows []struct {
+		Cells []struct {
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func TestXLSX(t *testing.T) {
+	b := write(t, export.XLSX)
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	var sh sheet
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v interface{} = &struct{}{}
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			v = &sh
+		}
+		if err := xml.Unmarshal(body, v); err != nil {
+			t.Fatalf("%s is not valid XML: %v", f.Name, err)
+		}
+	}
+	assert.Equal(t, []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"}, names)
+
+	var cells [][]string
+	for _, r := range sh.Rows {
+		var row []string
+		for _, c := range r.Cells {
+			row = append(row, c.Type+":"+c.Value+c.Inline)
+		}
+		cells = append(cells, row)
+	}
+	assert.Equal(t, [][]string{
+		{"inlineStr:id", "inlineStr:username", "inlineStr:active", "inlineStr:created_at", "inlineStr:last_login"},
+		{":1", "inlineStr:johndoe", "b:1", "inlineStr:2018-03-01T10:30:00Z", ":"},
+		{":2", `inlineStr:"quoted", <tagged>`, "b:0", "inlineStr:2018-04-02T00:00:00Z", "inlineStr:2018-05-03T00:00:00Z"},
+	}, cells)
+}
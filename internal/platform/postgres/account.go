@@ -2,11 +2,13 @@ package pgsql
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/artistomin/friend4me/internal"
 	"github.com/labstack/echo"
 
 	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
 )
 
 // NewAccountDB returns a new AccountDB instance
@@ -20,18 +22,13 @@ type AccountDB struct {
 	log echo.Logger
 }
 
-// Create creates a new user on database
-func (a *AccountDB) Create(usr model.User) (*model.User, error) {
-	var user = new(model.User)
-	res, err := a.cl.Query(user, "select id from users where username = ? or email = ? and deleted_at is null", usr.Username, usr.Email)
-	if err != nil {
-		a.log.Error("AccountDB Error: %v", err)
-		return nil, err
-	}
-	if res.RowsReturned() != 0 {
-		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Username or email already exists.")
-	}
-	if err := a.cl.Insert(&usr); err != nil {
+// Create creates a new user on database. Taken usernames and emails, regardless of case, are reported as conflicts.
+// Deleted users keep their username and email until purged, so that they can be restored
+func (a *AccountDB) Create(db orm.DB, usr model.User) (*model.User, error) {
+	if err := conn(a.cl, db).Insert(&usr); err != nil {
+		if isUniqueViolation(err, "users_username_idx", "users_email_idx") {
+			return nil, echo.NewHTTPError(http.StatusConflict, "Username or email already exists.")
+		}
 		a.log.Error("AccountDB Error: %v", err)
 		return nil, err
 	}
@@ -39,10 +36,100 @@ func (a *AccountDB) Create(usr model.User) (*model.User, error) {
 }
 
 // ChangePassword changes user's password
-func (a *AccountDB) ChangePassword(usr *model.User) error {
-	_, err := a.cl.Model(usr).Column("password", "updated_at").WherePK().Update()
+func (a *AccountDB) ChangePassword(db orm.DB, usr *model.User) error {
+	_, err := conn(a.cl, db).Model(usr).Column("password", "updated_at").WherePK().Update()
 	if err != nil {
 		a.log.Warnf("AccountDB Error: %v", err)
 	}
 	return err
 }
+
+// Import creates users within a savepoint, each in a savepoint of its own, so that users failing to be created
+// do not abort the others. Created users get their IDs, and are rolled back in the end unless the mode keeps them.
+// Dry runs create no users, looking up taken usernames and emails instead.
+// Without a request scoped transaction, users are imported in a transaction of their own
+func (a *AccountDB) Import(db orm.DB, users []model.User, mode model.ImportMode) ([]error, error) {
+	if mode == model.ImportDryRun {
+		return a.conflicts(db, users)
+	}
+	if db == nil {
+		var errs []error
+		err := a.cl.RunInTransaction(func(tx *pg.Tx) error {
+			var err error
+			errs, err = a.Import(tx, users, mode)
+			return err
+		})
+		return errs, err
+	}
+	if err := a.savepoint(db, "SAVEPOINT user_import"); err != nil {
+		return nil, err
+	}
+	errs := make([]error, len(users))
+	failed := false
+	for i := range users {
+		if err := a.savepoint(db, "SAVEPOINT user_import_row"); err != nil {
+			return nil, err
+		}
+		created, err := a.Create(db, users[i])
+		if err != nil {
+			errs[i], failed = err, true
+			if err := a.savepoint(db, "ROLLBACK TO SAVEPOINT user_import_row", "RELEASE SAVEPOINT user_import_row"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		users[i] = *created
+		if err := a.savepoint(db, "RELEASE SAVEPOINT user_import_row"); err != nil {
+			return nil, err
+		}
+	}
+	if mode == model.ImportDryRun || (mode == model.ImportAtomic && failed) {
+		if err := a.savepoint(db, "ROLLBACK TO SAVEPOINT user_import"); err != nil {
+			return nil, err
+		}
+	}
+	return errs, a.savepoint(db, "RELEASE SAVEPOINT user_import")
+}
+
+// conflicts reports users whose username or email is taken, by stored users or by preceding users of the list
+func (a *AccountDB) conflicts(db orm.DB, users []model.User) ([]error, error) {
+	usernames := make([]string, len(users))
+	emails := make([]string, len(users))
+	for i, u := range users {
+		usernames[i], emails[i] = strings.ToLower(u.Username), strings.ToLower(u.Email)
+	}
+	var stored []model.User
+	err := conn(a.cl, db).Model(&stored).Column("username", "email").Where("erased_at IS NULL").
+		WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+			return q.Where("lower(username) IN (?)", pg.In(usernames)).WhereOr("lower(email) IN (?)", pg.In(emails)), nil
+		}).Select()
+	if err != nil {
+		a.log.Warnf("AccountDB Error: %v", err)
+		return nil, err
+	}
+	taken := make(map[string]bool)
+	for _, u := range stored {
+		taken["username:"+strings.ToLower(u.Username)], taken["email:"+strings.ToLower(u.Email)] = true, true
+	}
+	errs := make([]error, len(users))
+	for i := range users {
+		username, email := "username:"+usernames[i], "email:"+emails[i]
+		if taken[username] || taken[email] {
+			errs[i] = echo.NewHTTPError(http.StatusConflict, "Username or email already exists.")
+			continue
+		}
+		taken[username], taken[email] = true, true
+	}
+	return errs, nil
+}
+
+// savepoint runs savepoint commands in order
+func (a *AccountDB) savepoint(db orm.DB, cmds ...string) error {
+	for _, cmd := range cmds {
+		if _, err := db.Exec(cmd); err != nil {
+			a.log.Warnf("AccountDB Error: %v", err)
+			return err
+		}
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/artistomin/friend4me/internal"
 
 	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
 )
 
 func testAccountDB(t *testing.T, c *pg.DB, l echo.Logger) {
@@ -27,6 +28,10 @@ func testAccountDB(t *testing.T, c *pg.DB, l echo.Logger) {
 			name: "changePassword",
 			fn:   testChangePassword,
 		},
+		{
+			name: "accountImport",
+			fn:   testAccountImport,
+		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -51,6 +56,17 @@ func testAccountCreate(t *testing.T, db *pgsql.AccountDB, c *pg.DB) {
 				Username: "johndoe",
 			},
 		},
+		{
+			name:    "Username taken in another case",
+			wantErr: true,
+			usr: model.User{
+				Email:      "johnny@mail.com",
+				Username:   "JohnDoe",
+				RoleID:     1,
+				CompanyID:  1,
+				LocationID: 1,
+			},
+		},
 		{
 			name:    "Fail on insert duplicate ID",
 			wantErr: true,
@@ -100,7 +116,7 @@ func testAccountCreate(t *testing.T, db *pgsql.AccountDB, c *pg.DB) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			usr, err := db.Create(tt.usr)
+			usr, err := db.Create(nil, tt.usr)
 			assert.Equal(t, tt.wantErr, err != nil)
 			if tt.wantData != nil {
 				tt.wantData.CreatedAt = usr.CreatedAt
@@ -150,7 +166,7 @@ func testChangePassword(t *testing.T, db *pgsql.AccountDB, c *pg.DB) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			err := db.ChangePassword(tt.usr)
+			err := db.ChangePassword(nil, tt.usr)
 			assert.Equal(t, tt.wantErr, err != nil)
 			if tt.wantData != nil {
 				userDB := queryUser(t, c, tt.usr.Base.ID)
@@ -162,3 +178,39 @@ func testChangePassword(t *testing.T, db *pgsql.AccountDB, c *pg.DB) {
 		})
 	}
 }
+
+func testAccountImport(t *testing.T, db *pgsql.AccountDB, c *pg.DB) {
+	tx, err := c.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	for _, conn := range []orm.DB{nil, tx} {
+		users := []model.User{
+			{Email: "imported@mail.com", Username: "imported", RoleID: 5, CompanyID: 1, LocationID: 1, Password: "pass"},
+			{Email: "johndoe@mail.com", Username: "johndoe", RoleID: 5, CompanyID: 1, LocationID: 1, Password: "pass"},
+			{Email: "imported@mail.com", Username: "imported", RoleID: 5, CompanyID: 1, LocationID: 1, Password: "pass"},
+		}
+		errs, err := db.Import(conn, users, model.ImportAtomic)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(errs))
+		assert.Nil(t, errs[0])
+		assert.NotNil(t, errs[1])
+		assert.NotNil(t, errs[2])
+		assert.True(t, users[0].ID > 0)
+		n, err := c.Model((*model.User)(nil)).Where("username = ?", "imported").Count()
+		assert.Nil(t, err)
+		assert.Equal(t, 0, n)
+
+		// Dry runs report the same conflicts without creating users
+		dry := []model.User{users[0], users[1], users[2]}
+		dry[0].ID = 0
+		errs, err = db.Import(conn, dry, model.ImportDryRun)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(errs))
+		assert.Nil(t, errs[0])
+		assert.NotNil(t, errs[1])
+		assert.NotNil(t, errs[2])
+		assert.Zero(t, dry[0].ID)
+	}
+}
@@ -0,0 +1,52 @@
+package pgsql
+
+import (
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// NewActivityDB returns a new ActivityDB instance
+func NewActivityDB(c *pg.DB, l echo.Logger) *ActivityDB {
+	return &ActivityDB{c, l}
+}
+
+// ActivityDB represents the client for activities table
+type ActivityDB struct {
+	cl  *pg.DB
+	log echo.Logger
+}
+
+// Create records a new activity
+func (a *ActivityDB) Create(db orm.DB, act model.Activity) (*model.Activity, error) {
+	if err := conn(a.cl, db).Insert(&act); err != nil {
+		a.log.Warnf("ActivityDB Error: %v", err)
+		return nil, err
+	}
+	return &act, nil
+}
+
+// Feed returns activities of user's friends, newest first.
+// Activities of users blocking the user, blocked or muted by it are left out,
+// as are friendships with users blocking or blocked by it
+func (a *ActivityDB) Feed(db orm.DB, userID int, cur *model.FeedCursor) ([]model.Activity, error) {
+	var acts []model.Activity
+	q := conn(a.cl, db).Model(&acts).Where(`"activity".deleted_at IS NULL`).
+		Where(`"activity".actor_id IN (SELECT CASE WHEN requester_id = ?0 THEN addressee_id ELSE requester_id END
+		FROM friendships WHERE status = ?1 AND deleted_at IS NULL AND (requester_id = ?0 OR addressee_id = ?0))`, userID, model.FriendshipAccepted).
+		Where(`NOT EXISTS (SELECT 1 FROM blocks AS b WHERE b.deleted_at IS NULL AND
+		((b.kind = ?1 AND b.target_id = ?0 AND b.user_id = "activity".actor_id) OR (b.user_id = ?0 AND b.target_id = "activity".actor_id)))`, userID, model.BlockFull).
+		Where(`("activity".type <> ?2 OR NOT EXISTS (SELECT 1 FROM blocks AS b WHERE b.deleted_at IS NULL AND b.kind = ?1 AND
+		((b.user_id = ?0 AND b.target_id = "activity".object_id) OR (b.target_id = ?0 AND b.user_id = "activity".object_id))))`,
+			userID, model.BlockFull, model.ActivityBecameFriends)
+	if cur.Before > 0 {
+		q.Where(`"activity".id < ?`, cur.Before)
+	}
+	if err := q.Order("id DESC").Limit(cur.Limit).Select(); err != nil {
+		a.log.Warnf("ActivityDB Error: %v", err)
+		return nil, err
+	}
+	return acts, nil
+}
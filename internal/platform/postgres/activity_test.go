@@ -0,0 +1,94 @@
+package pgsql_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/go-pg/pg"
+)
+
+func testActivityDB(t *testing.T, c *pg.DB, l echo.Logger) {
+	for _, id := range []int{70, 71, 72, 73, 74} {
+		u := &model.User{Base: model.Base{ID: id}, Username: fmt.Sprintf("active%d", id), Active: true, RoleID: 5, CompanyID: 1, LocationID: 1}
+		if err := c.Insert(u); err != nil {
+			t.Fatalf("Fail on seeding users: %v", err)
+		}
+	}
+	for _, f := range [][2]int{{70, 71}, {72, 70}, {70, 73}, {72, 74}} {
+		if err := c.Insert(&model.Friendship{RequesterID: f[0], AddresseeID: f[1], CompanyID: 1, Status: model.FriendshipAccepted}); err != nil {
+			t.Fatalf("Fail on seeding friendships: %v", err)
+		}
+	}
+	for _, b := range []model.Block{
+		{UserID: 70, TargetID: 73, CompanyID: 1, Kind: model.BlockMute},
+		{UserID: 74, TargetID: 70, CompanyID: 1, Kind: model.BlockFull},
+	} {
+		if err := c.Insert(&b); err != nil {
+			t.Fatalf("Fail on seeding blocks: %v", err)
+		}
+	}
+	adb := pgsql.NewActivityDB(c, l)
+
+	var ids []int
+	for _, a := range []model.Activity{
+		{ActorID: 71, CompanyID: 1, Type: model.ActivityUpdatedProfile},
+		{ActorID: 72, CompanyID: 1, Type: model.ActivityBecameFriends, ObjectID: 74},
+		{ActorID: 72, CompanyID: 1, Type: model.ActivityJoinedLocation, ObjectID: 1, Data: map[string]interface{}{"name": "home"}},
+		{ActorID: 73, CompanyID: 1, Type: model.ActivityUpdatedProfile},
+		{ActorID: 74, CompanyID: 1, Type: model.ActivityUpdatedProfile},
+	} {
+		act, err := adb.Create(nil, a)
+		if err != nil {
+			t.Fatalf("Fail on creating activity: %v", err)
+		}
+		ids = append(ids, act.ID)
+	}
+
+	cases := []struct {
+		name    string
+		user    int
+		cur     model.FeedCursor
+		wantIDs []int
+	}{
+		{
+			name:    "Friends' activities",
+			user:    70,
+			cur:     model.FeedCursor{Limit: 10},
+			wantIDs: []int{ids[2], ids[0]},
+		},
+		{
+			name:    "Before cursor",
+			user:    70,
+			cur:     model.FeedCursor{Before: ids[2], Limit: 10},
+			wantIDs: []int{ids[0]},
+		},
+		{
+			name:    "Limit",
+			user:    70,
+			cur:     model.FeedCursor{Limit: 1},
+			wantIDs: []int{ids[2]},
+		},
+		{
+			name:    "Friend of blocked user",
+			user:    74,
+			cur:     model.FeedCursor{Limit: 10},
+			wantIDs: []int{ids[2], ids[1]},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			acts, err := adb.Feed(nil, tt.user, &tt.cur)
+			assert.Nil(t, err)
+			var got []int
+			for _, a := range acts {
+				got = append(got, a.ID)
+			}
+			assert.Equal(t, tt.wantIDs, got)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package pgsql
+
+import (
+	"net/http"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// blockStates describes users under each kind of block
+var blockStates = map[model.BlockKind]string{
+	model.BlockFull: "blocked",
+	model.BlockMute: "muted",
+}
+
+// NewBlockDB returns a new BlockDB instance
+func NewBlockDB(c *pg.DB, l echo.Logger) *BlockDB {
+	return &BlockDB{c, l}
+}
+
+// BlockDB represents the client for blocks table
+type BlockDB struct {
+	cl  *pg.DB
+	log echo.Logger
+}
+
+// Create blocks or mutes a user. Blocks are unique by a unique index, so that concurrent requests cannot both succeed
+func (b *BlockDB) Create(db orm.DB, bl model.Block) (*model.Block, error) {
+	if err := conn(b.cl, db).Insert(&bl); err != nil {
+		if isUniqueViolation(err) {
+			return nil, echo.NewHTTPError(http.StatusConflict, "User is already "+blockStates[bl.Kind]+".")
+		}
+		b.log.Warnf("BlockDB Error: %v", err)
+		return nil, err
+	}
+	return &bl, nil
+}
+
+// Find returns block or mute put by a user on another user
+func (b *BlockDB) Find(db orm.DB, userID, targetID int, kind model.BlockKind) (*model.Block, error) {
+	var bl = new(model.Block)
+	err := conn(b.cl, db).Model(bl).Where("user_id = ? and target_id = ? and kind = ?", userID, targetID, kind).
+		Where(notDeleted).Select()
+	if err != nil {
+		b.log.Warnf("BlockDB Error: %v", err)
+	}
+	return bl, err
+}
+
+// Delete lifts a block or mute
+func (b *BlockDB) Delete(db orm.DB, bl *model.Block) error {
+	bl.Delete()
+	_, err := conn(b.cl, db).Model(bl).Column("deleted_at").WherePK().Update()
+	if err != nil {
+		b.log.Warnf("BlockDB Error: %v", err)
+	}
+	return err
+}
+
+// List returns users blocked or muted by a user
+func (b *BlockDB) List(db orm.DB, userID int, kind model.BlockKind, p *model.Pagination) ([]model.User, error) {
+	var users []model.User
+	err := conn(b.cl, db).Model(&users).Column("user.*", "Role").
+		Join(`JOIN blocks AS b ON b.target_id = "user".id AND b.user_id = ? AND b.kind = ? AND b.deleted_at IS NULL`, userID, kind).
+		Where(`"user".deleted_at IS NULL`).OrderExpr("b.created_at DESC").
+		Limit(p.Limit).Offset(p.Offset).Select()
+	if err != nil {
+		b.log.Warnf("BlockDB Error: %v", err)
+		return nil, err
+	}
+	return users, nil
+}
+
+// Blocked checks whether either of two users blocked the other
+func (b *BlockDB) Blocked(db orm.DB, userID, otherID int) (bool, error) {
+	n, err := conn(b.cl, db).Model((*model.Block)(nil)).Where("kind = ?", model.BlockFull).Where(notDeleted).
+		Where("((user_id = ? and target_id = ?) or (user_id = ? and target_id = ?))", userID, otherID, otherID, userID).Count()
+	if err != nil {
+		b.log.Warnf("BlockDB Error: %v", err)
+		return false, err
+	}
+	return n != 0, nil
+}
+
+// Related returns IDs of users who blocked the user or were blocked by it
+func (b *BlockDB) Related(db orm.DB, userID int) ([]int, error) {
+	var ids []int
+	_, err := conn(b.cl, db).Query(&ids, `SELECT CASE WHEN user_id = ?0 THEN target_id ELSE user_id END FROM blocks
+	WHERE kind = ?1 AND deleted_at IS NULL AND (user_id = ?0 OR target_id = ?0)`, userID, model.BlockFull)
+	if err != nil {
+		b.log.Warnf("BlockDB Error: %v", err)
+		return nil, err
+	}
+	return ids, nil
+}
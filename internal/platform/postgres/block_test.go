@@ -0,0 +1,160 @@
+package pgsql_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/go-pg/pg"
+)
+
+func testBlockDB(t *testing.T, c *pg.DB, l echo.Logger) {
+	for _, id := range []int{13, 14, 15} {
+		u := &model.User{Base: model.Base{ID: id}, Username: fmt.Sprintf("blocker%d", id), Active: true, RoleID: 5, CompanyID: 1, LocationID: 1}
+		if err := c.Insert(u); err != nil {
+			t.Fatalf("Fail on seeding users: %v", err)
+		}
+	}
+	blockDB := pgsql.NewBlockDB(c, l)
+	cases := []struct {
+		name string
+		fn   func(*testing.T, *pgsql.BlockDB, *pg.DB)
+	}{
+		{
+			name: "create",
+			fn:   testBlockCreate,
+		},
+		{
+			name: "blocked",
+			fn:   testBlockBlocked,
+		},
+		{
+			name: "list",
+			fn:   testBlockList,
+		},
+		{
+			name: "delete",
+			fn:   testBlockDelete,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, blockDB, c)
+		})
+	}
+}
+
+func testBlockCreate(t *testing.T, db *pgsql.BlockDB, c *pg.DB) {
+	cases := []struct {
+		name    string
+		wantErr bool
+		req     model.Block
+	}{
+		{
+			name: "Block",
+			req:  model.Block{Base: model.Base{ID: 1}, UserID: 13, TargetID: 14, CompanyID: 1, Kind: model.BlockFull},
+		},
+		{
+			name:    "Already blocked",
+			wantErr: true,
+			req:     model.Block{Base: model.Base{ID: 2}, UserID: 13, TargetID: 14, CompanyID: 1, Kind: model.BlockFull},
+		},
+		{
+			name: "Mute blocked user",
+			req:  model.Block{Base: model.Base{ID: 3}, UserID: 13, TargetID: 14, CompanyID: 1, Kind: model.BlockMute},
+		},
+		{
+			name: "Mute",
+			req:  model.Block{Base: model.Base{ID: 4}, UserID: 15, TargetID: 13, CompanyID: 1, Kind: model.BlockMute},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := db.Create(nil, tt.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantErr {
+				assert.Equal(t, http.StatusConflict, err.(*echo.HTTPError).Code)
+			} else {
+				assert.Equal(t, tt.req.ID, b.ID)
+			}
+		})
+	}
+}
+
+func testBlockBlocked(t *testing.T, db *pgsql.BlockDB, c *pg.DB) {
+	cases := []struct {
+		name        string
+		user, other int
+		want        bool
+	}{
+		{
+			name:  "Blocker",
+			user:  13,
+			other: 14,
+			want:  true,
+		},
+		{
+			name:  "Blocked",
+			user:  14,
+			other: 13,
+			want:  true,
+		},
+		{
+			name:  "Muted only",
+			user:  15,
+			other: 13,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			blocked, err := db.Blocked(nil, tt.user, tt.other)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, blocked)
+		})
+	}
+
+	ids, err := db.Related(nil, 13)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{14}, ids)
+
+	ids, err = db.Related(nil, 14)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{13}, ids)
+}
+
+func testBlockList(t *testing.T, db *pgsql.BlockDB, c *pg.DB) {
+	blocked, err := db.List(nil, 13, model.BlockFull, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(blocked)) {
+		assert.Equal(t, 14, blocked[0].ID)
+	}
+
+	muted, err := db.List(nil, 15, model.BlockMute, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(muted)) {
+		assert.Equal(t, 13, muted[0].ID)
+	}
+
+	none, err := db.List(nil, 15, model.BlockFull, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(none))
+}
+
+func testBlockDelete(t *testing.T, db *pgsql.BlockDB, c *pg.DB) {
+	b, err := db.Find(nil, 13, 14, model.BlockFull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, b.ID)
+	assert.Nil(t, db.Delete(nil, b))
+	_, err = db.Find(nil, 13, 14, model.BlockFull)
+	assert.NotNil(t, err)
+	blocked, err := db.Blocked(nil, 13, 14)
+	assert.Nil(t, err)
+	assert.False(t, blocked)
+}
@@ -0,0 +1,179 @@
+package pgsql
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// eventChannel is the notification channel realtime events are published to
+const eventChannel = "realtime_events"
+
+// eventLog holds statements creating the table realtime events are kept in, so that listeners load them by ID,
+// and the sequence their IDs are drawn from, shared by all instances.
+// Events carry company of their user, as payloads hold messages and names isolated to the company
+var eventLog = []string{
+	`CREATE SEQUENCE IF NOT EXISTS realtime_event_seq`,
+	`CREATE TABLE IF NOT EXISTS realtime_events (
+	id bigint PRIMARY KEY DEFAULT nextval('realtime_event_seq'),
+	user_id integer NOT NULL,
+	company_id integer NOT NULL,
+	type text NOT NULL,
+	payload jsonb,
+	created_at timestamptz NOT NULL DEFAULT now())`,
+	`CREATE INDEX IF NOT EXISTS realtime_events_user_idx ON realtime_events (user_id, id)`,
+}
+
+// CreateEventLog creates the table and sequence realtime events are published through
+func CreateEventLog(db orm.DB) error {
+	for _, q := range eventLog {
+		if _, err := db.Exec(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewBroker returns a new Broker instance, delivering events to subscribers of local broker.
+// Up to history stored events are replayed to resuming subscribers
+func NewBroker(c *pg.DB, local model.Broker, history int, l echo.Logger) *Broker {
+	return &Broker{c, local, history, l}
+}
+
+// Broker publishes realtime events with Postgres NOTIFY, so that they reach subscribers
+// connected to any instance of the application. Events published in a transaction
+// are delivered once it commits. Events are stored in the database and only their IDs are notified,
+// as notification payloads are limited to 8000 bytes. Stored events outlive restarts,
+// so that subscribers resume from them on any instance
+type Broker struct {
+	cl      *pg.DB
+	local   model.Broker
+	history int
+	log     echo.Logger
+}
+
+// Publish stores the event under company of its user and notifies all listening instances of its ID,
+// drawn from the shared sequence. Events published without a transaction, e.g. by presence tracker, are stored unscoped
+func (b *Broker) Publish(db orm.DB, e model.Event) error {
+	err := unscoped(b.cl, db, func(db orm.DB) error {
+		_, err := db.Exec(`WITH e AS (
+		INSERT INTO realtime_events (user_id, company_id, type, payload)
+		VALUES (?0, (SELECT company_id FROM users WHERE id = ?0), ?1, nullif(?2, '')::jsonb) RETURNING id)
+		SELECT pg_notify(?3, e.id::text) FROM e`, e.UserID, e.Type, string(e.Payload), eventChannel)
+		return err
+	})
+	if err != nil {
+		b.log.Warnf("Broker Error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Subscribe subscribes to events of the user on local broker
+func (b *Broker) Subscribe(userID int) *model.Subscription {
+	return b.local.Subscribe(userID)
+}
+
+// Unsubscribe ends the subscription on local broker
+func (b *Broker) Unsubscribe(s *model.Subscription) {
+	b.local.Unsubscribe(s)
+}
+
+// Since returns up to history most recent stored events of the user published after the event with the given ID
+func (b *Broker) Since(userID int, after int64) []model.Event {
+	var rows []eventRow
+	err := unscoped(b.cl, nil, func(db orm.DB) error {
+		_, err := db.Query(&rows, `SELECT * FROM (
+		SELECT id, user_id, type, payload::text AS payload FROM realtime_events
+		WHERE user_id = ? AND id > ? ORDER BY id DESC LIMIT ?) AS e ORDER BY id`, userID, after, b.history)
+		return err
+	})
+	if err != nil {
+		b.log.Warnf("Broker Error: %v", err)
+		return nil
+	}
+	var events []model.Event
+	for _, r := range rows {
+		events = append(events, r.event())
+	}
+	return events
+}
+
+// Prune deletes events stored before the given time, returning the number of deleted events
+func (b *Broker) Prune(before time.Time) (int, error) {
+	var n int
+	err := unscoped(b.cl, nil, func(db orm.DB) error {
+		res, err := db.Exec(`DELETE FROM realtime_events WHERE created_at < ?`, before)
+		if err == nil {
+			n = res.RowsAffected()
+		}
+		return err
+	})
+	if err != nil {
+		b.log.Warnf("Broker Error: %v", err)
+		return 0, err
+	}
+	return n, nil
+}
+
+// RunPrune deletes events older than retention every interval, logging failures. It never returns
+func (b *Broker) RunPrune(retention, interval time.Duration) {
+	for now := range time.Tick(interval) {
+		if _, err := b.Prune(now.Add(-retention)); err != nil {
+			b.log.Warnf("Prune Error: %v", err)
+		}
+	}
+}
+
+// Listen relays notified events to local broker until the returned listener is closed
+func (b *Broker) Listen() *pg.Listener {
+	ln := b.cl.Listen(eventChannel)
+	go func() {
+		for n := range ln.Channel() {
+			id, err := strconv.ParseInt(n.Payload, 10, 64)
+			if err != nil {
+				b.log.Warnf("Broker Error: %v", err)
+				continue
+			}
+			e, err := b.event(id)
+			if err != nil {
+				b.log.Warnf("Broker Error: %v", err)
+				continue
+			}
+			b.local.Publish(nil, e)
+		}
+	}()
+	return ln
+}
+
+// event loads the stored event with the given ID
+func (b *Broker) event(id int64) (model.Event, error) {
+	var row eventRow
+	err := unscoped(b.cl, nil, func(db orm.DB) error {
+		_, err := db.QueryOne(&row, `SELECT id, user_id, type, payload::text AS payload FROM realtime_events WHERE id = ?`, id)
+		return err
+	})
+	return row.event(), err
+}
+
+// eventRow represents stored event, with payload read as text
+type eventRow struct {
+	ID      int64
+	UserID  int
+	Type    string
+	Payload string
+}
+
+func (r eventRow) event() model.Event {
+	e := model.Event{ID: r.ID, Type: r.Type, UserID: r.UserID}
+	if r.Payload != "" {
+		e.Payload = json.RawMessage(r.Payload)
+	}
+	return e
+}
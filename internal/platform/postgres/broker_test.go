@@ -0,0 +1,81 @@
+package pgsql_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/artistomin/friend4me/internal/realtime"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/go-pg/pg"
+)
+
+func testBroker(t *testing.T, c *pg.DB, l echo.Logger) {
+	// Two instances of the application, sharing the database
+	first := pgsql.NewBroker(c, realtime.NewHub(4, 0), 4, l)
+	second := pgsql.NewBroker(c, realtime.NewHub(4, 0), 4, l)
+	ln := second.Listen()
+	defer ln.Close()
+	sub := second.Subscribe(1)
+
+	e, err := model.NewEvent(model.EventMessage, 1, &model.Message{Body: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Event published in a transaction is delivered on commit
+	tx, err := c.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, first.Publish(tx, e))
+	select {
+	case <-sub.Events:
+		t.Fatal("Event delivered before commit")
+	case <-time.After(200 * time.Millisecond):
+	}
+	assert.Nil(t, tx.Commit())
+
+	select {
+	case got := <-sub.Events:
+		assert.Equal(t, e.Type, got.Type)
+		assert.NotZero(t, got.ID)
+		assert.JSONEq(t, string(e.Payload), string(got.Payload))
+		assert.Equal(t, []model.Event{got}, second.Since(1, got.ID-1))
+		// Stored events are replayed after a restart
+		restarted := pgsql.NewBroker(c, realtime.NewHub(4, 0), 4, l)
+		assert.Equal(t, []model.Event{got}, restarted.Since(1, got.ID-1))
+		assert.Nil(t, restarted.Since(1, got.ID))
+	case <-time.After(5 * time.Second):
+		t.Fatal("Event was not delivered")
+	}
+
+	// Events larger than notification payload limit are delivered
+	big, err := model.NewEvent(model.EventMessage, 1, &model.Message{Body: strings.Repeat("x", 10000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, first.Publish(nil, big))
+	select {
+	case got := <-sub.Events:
+		assert.JSONEq(t, string(big.Payload), string(got.Payload))
+	case <-time.After(5 * time.Second):
+		t.Fatal("Event was not delivered")
+	}
+
+	// Events are stored under company of their user, so users have to exist
+	unknown, err := model.NewEvent(model.EventMessage, 1000, &model.Message{Body: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, first.Publish(nil, unknown))
+
+	n, err := first.Prune(time.Now().Add(time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Nil(t, first.Since(1, 0))
+}
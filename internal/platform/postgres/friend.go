@@ -0,0 +1,123 @@
+package pgsql
+
+import (
+	"net/http"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// NewFriendDB returns a new FriendDB instance
+func NewFriendDB(c *pg.DB, l echo.Logger) *FriendDB {
+	return &FriendDB{c, l}
+}
+
+// FriendDB represents the client for friendships table
+type FriendDB struct {
+	cl  *pg.DB
+	log echo.Logger
+}
+
+// between matches pending requests and friendships between two users in either direction
+const between = "deleted_at is null and status in (?, ?) and " +
+	"((requester_id = ? and addressee_id = ?) or (requester_id = ? and addressee_id = ?))"
+
+// Create creates a new friend request
+// Request cannot be created if users are already friends or a request between them is pending,
+// which is enforced by a unique index, so that concurrent requests cannot both succeed
+func (f *FriendDB) Create(db orm.DB, fr model.Friendship) (*model.Friendship, error) {
+	if err := conn(f.cl, db).Insert(&fr); err != nil {
+		if isUniqueViolation(err) {
+			return nil, echo.NewHTTPError(http.StatusConflict, "Friend request already exists.")
+		}
+		f.log.Warnf("FriendDB Error: %v", err)
+		return nil, err
+	}
+	return &fr, nil
+}
+
+// View returns single friendship by ID
+func (f *FriendDB) View(db orm.DB, id int) (*model.Friendship, error) {
+	var fr = &model.Friendship{Base: model.Base{ID: id}}
+	err := conn(f.cl, db).Model(fr).WherePK().Where(notDeleted).Select()
+	if err != nil {
+		f.log.Warnf("FriendDB Error: %v", err)
+	}
+	return fr, err
+}
+
+// FindBetween returns pending request or friendship between two users
+func (f *FriendDB) FindBetween(db orm.DB, userID, otherID int) (*model.Friendship, error) {
+	var fr = new(model.Friendship)
+	err := conn(f.cl, db).Model(fr).
+		Where(between, model.FriendshipPending, model.FriendshipAccepted, userID, otherID, otherID, userID).Select()
+	if err != nil {
+		f.log.Warnf("FriendDB Error: %v", err)
+	}
+	return fr, err
+}
+
+// Update updates friendship's status
+func (f *FriendDB) Update(db orm.DB, fr *model.Friendship) error {
+	_, err := conn(f.cl, db).Model(fr).Column("status", "responded_at", "updated_at").WherePK().Update()
+	if err != nil {
+		f.log.Warnf("FriendDB Error: %v", err)
+	}
+	return err
+}
+
+// Delete ends friendship
+func (f *FriendDB) Delete(db orm.DB, fr *model.Friendship) error {
+	fr.Delete()
+	_, err := conn(f.cl, db).Model(fr).Column("deleted_at").WherePK().Update()
+	if err != nil {
+		f.log.Warnf("FriendDB Error: %v", err)
+	}
+	return err
+}
+
+// DeleteBetween ends friendship and withdraws pending requests between two users, if there are any
+func (f *FriendDB) DeleteBetween(db orm.DB, userID, otherID int) error {
+	_, err := conn(f.cl, db).Model((*model.Friendship)(nil)).Set("deleted_at = now()").
+		Where(between, model.FriendshipPending, model.FriendshipAccepted, userID, otherID, otherID, userID).Update()
+	if err != nil {
+		f.log.Warnf("FriendDB Error: %v", err)
+	}
+	return err
+}
+
+// ListFriends returns public profiles of friends of a user, most recent friendships first
+func (f *FriendDB) ListFriends(db orm.DB, userID int, p *model.Pagination) ([]model.Friend, error) {
+	var friends []model.Friend
+	err := conn(f.cl, db).Model((*model.User)(nil)).
+		ColumnExpr(publicColumns(`"user"`)+`, "user".last_seen_at, "user".hide_presence`).
+		Join(`JOIN friendships AS f ON f.status = ? AND f.deleted_at IS NULL AND
+		((f.requester_id = ? AND f.addressee_id = "user".id) OR (f.addressee_id = ? AND f.requester_id = "user".id))`,
+			model.FriendshipAccepted, userID, userID).
+		Where(`"user".deleted_at IS NULL`).OrderExpr("f.responded_at DESC").
+		Limit(p.Limit).Offset(p.Offset).Select(&friends)
+	if err != nil {
+		f.log.Warnf("FriendDB Error: %v", err)
+		return nil, err
+	}
+	return friends, nil
+}
+
+// ListRequests returns pending friend requests sent to a user, or sent by the user if incoming is false
+func (f *FriendDB) ListRequests(db orm.DB, userID int, incoming bool, p *model.Pagination) ([]model.Friendship, error) {
+	var frs []model.Friendship
+	party := "requester_id = ?"
+	if incoming {
+		party = "addressee_id = ?"
+	}
+	err := conn(f.cl, db).Model(&frs).Where(party, userID).Where("status = ?", model.FriendshipPending).
+		Where(notDeleted).Order("id desc").Limit(p.Limit).Offset(p.Offset).Select()
+	if err != nil {
+		f.log.Warnf("FriendDB Error: %v", err)
+		return nil, err
+	}
+	return frs, nil
+}
@@ -0,0 +1,155 @@
+package pgsql_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/go-pg/pg"
+)
+
+func testFriendDB(t *testing.T, c *pg.DB, l echo.Logger) {
+	for _, id := range []int{10, 11, 12} {
+		u := &model.User{Base: model.Base{ID: id}, Username: fmt.Sprintf("friend%d", id), Email: fmt.Sprintf("friend%d@mail.com", id),
+			Active: true, RoleID: 5, CompanyID: 1, LocationID: 1}
+		if err := c.Insert(u); err != nil {
+			t.Fatalf("Fail on seeding users: %v", err)
+		}
+	}
+	if err := c.Insert(&model.UserPreference{UserID: 10, CompanyID: 1, Settings: model.Preferences{"privacy.show_email": true}}); err != nil {
+		t.Fatalf("Fail on seeding preferences: %v", err)
+	}
+	friendDB := pgsql.NewFriendDB(c, l)
+	cases := []struct {
+		name string
+		fn   func(*testing.T, *pgsql.FriendDB, *pg.DB)
+	}{
+		{
+			name: "create",
+			fn:   testFriendCreate,
+		},
+		{
+			name: "requests",
+			fn:   testFriendRequests,
+		},
+		{
+			name: "friends",
+			fn:   testFriendList,
+		},
+		{
+			name: "unfriend",
+			fn:   testFriendDelete,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, friendDB, c)
+		})
+	}
+}
+
+func testFriendCreate(t *testing.T, db *pgsql.FriendDB, c *pg.DB) {
+	cases := []struct {
+		name    string
+		wantErr bool
+		req     model.Friendship
+	}{
+		{
+			name: "Success",
+			req:  model.Friendship{Base: model.Base{ID: 1}, RequesterID: 10, AddresseeID: 11, CompanyID: 1, Status: model.FriendshipPending},
+		},
+		{
+			name:    "Request already pending",
+			wantErr: true,
+			req:     model.Friendship{Base: model.Base{ID: 2}, RequesterID: 11, AddresseeID: 10, CompanyID: 1, Status: model.FriendshipPending},
+		},
+		{
+			name: "Another user",
+			req:  model.Friendship{Base: model.Base{ID: 3}, RequesterID: 10, AddresseeID: 12, CompanyID: 1, Status: model.FriendshipPending},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := db.Create(nil, tt.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantErr {
+				assert.Equal(t, http.StatusConflict, err.(*echo.HTTPError).Code)
+			} else {
+				assert.Equal(t, tt.req.ID, f.ID)
+			}
+		})
+	}
+}
+
+func testFriendRequests(t *testing.T, db *pgsql.FriendDB, c *pg.DB) {
+	incoming, err := db.ListRequests(nil, 11, true, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(incoming))
+
+	outgoing, err := db.ListRequests(nil, 10, false, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(outgoing))
+
+	none, err := db.ListRequests(nil, 10, true, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(none))
+}
+
+func testFriendList(t *testing.T, db *pgsql.FriendDB, c *pg.DB) {
+	f, err := db.FindBetween(nil, 11, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, f.ID)
+	assert.True(t, f.Respond(model.FriendshipAccepted, time.Now()))
+	assert.Nil(t, db.Update(nil, f))
+
+	f, err = db.View(nil, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, model.FriendshipAccepted, f.Status)
+
+	_, err = db.Create(nil, model.Friendship{RequesterID: 11, AddresseeID: 10, CompanyID: 1, Status: model.FriendshipPending})
+	assert.NotNil(t, err)
+
+	friends, err := db.ListFriends(nil, 10, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(friends)) {
+		assert.Equal(t, 11, friends[0].ID)
+		assert.Equal(t, "", friends[0].Email)
+	}
+
+	friends, err = db.ListFriends(nil, 11, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(friends)) {
+		assert.Equal(t, 10, friends[0].ID)
+		assert.Equal(t, "friend10@mail.com", friends[0].Email)
+	}
+
+	friends, err = db.ListFriends(nil, 12, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(friends))
+}
+
+func testFriendDelete(t *testing.T, db *pgsql.FriendDB, c *pg.DB) {
+	f, err := db.FindBetween(nil, 10, 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, db.Delete(nil, f))
+	_, err = db.FindBetween(nil, 10, 11)
+	assert.NotNil(t, err)
+	friends, err := db.ListFriends(nil, 10, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(friends))
+
+	assert.Nil(t, db.DeleteBetween(nil, 12, 10))
+	_, err = db.FindBetween(nil, 10, 12)
+	assert.NotNil(t, err)
+	assert.Nil(t, db.DeleteBetween(nil, 12, 10))
+}
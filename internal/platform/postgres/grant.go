@@ -0,0 +1,77 @@
+package pgsql
+
+import (
+	"time"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// NewGrantDB returns a new GrantDB instance
+func NewGrantDB(c *pg.DB, l echo.Logger) *GrantDB {
+	return &GrantDB{c, l}
+}
+
+// GrantDB represents the client for role_grants table
+type GrantDB struct {
+	cl  *pg.DB
+	log echo.Logger
+}
+
+// Create creates a new role grant
+func (g *GrantDB) Create(db orm.DB, gr model.RoleGrant) (*model.RoleGrant, error) {
+	if err := conn(g.cl, db).Insert(&gr); err != nil {
+		g.log.Warnf("GrantDB Error: %v", err)
+		return nil, err
+	}
+	return &gr, nil
+}
+
+// View returns single role grant by ID
+func (g *GrantDB) View(db orm.DB, id int) (*model.RoleGrant, error) {
+	var gr = &model.RoleGrant{Base: model.Base{ID: id}}
+	err := conn(g.cl, db).Model(gr).WherePK().Where(notDeleted).Select()
+	if err != nil {
+		g.log.Warnf("GrantDB Error: %v", err)
+	}
+	return gr, err
+}
+
+// List returns all role grants given to a user, including expired ones
+func (g *GrantDB) List(db orm.DB, userID int) ([]model.RoleGrant, error) {
+	var grants []model.RoleGrant
+	err := conn(g.cl, db).Model(&grants).Where("user_id = ?", userID).Where(notDeleted).Order("ends_at desc").Select()
+	if err != nil {
+		g.log.Warnf("GrantDB Error: %v", err)
+		return nil, err
+	}
+	return grants, nil
+}
+
+// ListActive returns role grants of a user which are in effect at the given time.
+// Without a connection it runs unscoped, as grants are resolved before the request is scoped to a company
+func (g *GrantDB) ListActive(db orm.DB, userID int, t time.Time) ([]model.RoleGrant, error) {
+	var grants []model.RoleGrant
+	err := unscoped(g.cl, db, func(db orm.DB) error {
+		return db.Model(&grants).Where("user_id = ?", userID).Where(notDeleted).
+			Where("starts_at <= ?", t).Where("ends_at > ?", t).Select()
+	})
+	if err != nil {
+		g.log.Warnf("GrantDB Error: %v", err)
+		return nil, err
+	}
+	return grants, nil
+}
+
+// Delete revokes a role grant
+func (g *GrantDB) Delete(db orm.DB, gr *model.RoleGrant) error {
+	gr.Delete()
+	_, err := conn(g.cl, db).Model(gr).Column("deleted_at").WherePK().Update()
+	if err != nil {
+		g.log.Warnf("GrantDB Error: %v", err)
+	}
+	return err
+}
@@ -0,0 +1,147 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/go-pg/pg"
+)
+
+func testGrantDB(t *testing.T, c *pg.DB, l echo.Logger) {
+	grantDB := pgsql.NewGrantDB(c, l)
+	cases := []struct {
+		name string
+		fn   func(*testing.T, *pgsql.GrantDB, *pg.DB)
+	}{
+		{
+			name: "create",
+			fn:   testGrantCreate,
+		},
+		{
+			name: "view",
+			fn:   testGrantView,
+		},
+		{
+			name: "listActive",
+			fn:   testGrantListActive,
+		},
+		{
+			name: "delete",
+			fn:   testGrantDelete,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, grantDB, c)
+		})
+	}
+}
+
+func testGrantCreate(t *testing.T, db *pgsql.GrantDB, c *pg.DB) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		grant    model.RoleGrant
+		wantData *model.RoleGrant
+	}{
+		{
+			name: "Success",
+			grant: model.RoleGrant{
+				Base:        model.Base{ID: 1},
+				UserID:      1,
+				AccessLevel: model.LocationAdminRole,
+				CompanyID:   1,
+				LocationID:  1,
+				StartsAt:    mock.TestTime(2000),
+				EndsAt:      mock.TestTime(2100),
+			},
+			wantData: &model.RoleGrant{
+				Base:        model.Base{ID: 1},
+				UserID:      1,
+				AccessLevel: model.LocationAdminRole,
+				CompanyID:   1,
+				LocationID:  1,
+				StartsAt:    mock.TestTime(2000),
+				EndsAt:      mock.TestTime(2100),
+			},
+		},
+		{
+			name:    "Fail on insert duplicate ID",
+			wantErr: true,
+			grant: model.RoleGrant{
+				Base:   model.Base{ID: 1},
+				UserID: 1,
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			gr, err := db.Create(nil, tt.grant)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantData != nil {
+				tt.wantData.CreatedAt = gr.CreatedAt
+				tt.wantData.UpdatedAt = gr.UpdatedAt
+				assert.Equal(t, tt.wantData, gr)
+			}
+		})
+	}
+}
+
+func testGrantView(t *testing.T, db *pgsql.GrantDB, c *pg.DB) {
+	cases := []struct {
+		name    string
+		wantErr bool
+		id      int
+	}{
+		{
+			name:    "Grant does not exist",
+			wantErr: true,
+			id:      1000,
+		},
+		{
+			name: "Success",
+			id:   1,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			gr, err := db.View(nil, tt.id)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Equal(t, tt.id, gr.ID)
+			}
+		})
+	}
+}
+
+func testGrantListActive(t *testing.T, db *pgsql.GrantDB, c *pg.DB) {
+	active, err := db.ListActive(nil, 1, mock.TestTime(2050))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(active))
+
+	expired, err := db.ListActive(nil, 1, mock.TestTime(2101))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(expired))
+
+	all, err := db.List(nil, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(all))
+}
+
+func testGrantDelete(t *testing.T, db *pgsql.GrantDB, c *pg.DB) {
+	gr, err := db.View(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, db.Delete(nil, gr))
+	_, err = db.View(nil, 1)
+	assert.NotNil(t, err)
+	active, err := db.ListActive(nil, 1, mock.TestTime(2050))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(active))
+}
@@ -0,0 +1,197 @@
+package pgsql
+
+import (
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// NewGroupDB returns a new GroupDB instance
+func NewGroupDB(c *pg.DB, l echo.Logger) *GroupDB {
+	return &GroupDB{c, l}
+}
+
+// GroupDB represents the client for groups, group_members and group_posts tables
+type GroupDB struct {
+	cl  *pg.DB
+	log echo.Logger
+}
+
+// Create creates a new group
+func (g *GroupDB) Create(db orm.DB, gr model.Group) (*model.Group, error) {
+	if err := conn(g.cl, db).Insert(&gr); err != nil {
+		g.log.Warnf("GroupDB Error: %v", err)
+		return nil, err
+	}
+	return &gr, nil
+}
+
+// View returns single group by ID with the number of its members
+func (g *GroupDB) View(db orm.DB, id int) (*model.Group, error) {
+	var gr = &model.Group{Base: model.Base{ID: id}}
+	err := conn(g.cl, db).Model(gr).WherePK().Where(notDeleted).Select()
+	if err == nil {
+		err = g.countMembers(db, gr)
+	}
+	if err != nil {
+		g.log.Warnf("GroupDB Error: %v", err)
+	}
+	return gr, err
+}
+
+// List returns groups of a company by name, with the number of their members.
+// Unlisted groups are returned only to users invited, requesting or belonging to them, unless filter sets All
+func (g *GroupDB) List(db orm.DB, f *model.GroupFilter, p *model.Pagination) ([]model.Group, error) {
+	var grs []model.Group
+	q := conn(g.cl, db).Model(&grs).Where("company_id = ?", f.CompanyID).Where(notDeleted)
+	if !f.All {
+		q.Where("(visibility <> ? OR id IN (SELECT group_id FROM group_members WHERE user_id = ?))", model.GroupInviteOnly, f.UserID)
+	}
+	err := q.Order("name", "id").Limit(p.Limit).Offset(p.Offset).Select()
+	if err == nil && len(grs) > 0 {
+		index := make([]*model.Group, len(grs))
+		for i := range grs {
+			index[i] = &grs[i]
+		}
+		err = g.countMembers(db, index...)
+	}
+	if err != nil {
+		g.log.Warnf("GroupDB Error: %v", err)
+		return nil, err
+	}
+	return grs, nil
+}
+
+// countMembers sets the number of active members of the groups
+func (g *GroupDB) countMembers(db orm.DB, grs ...*model.Group) error {
+	ids := make([]int, len(grs))
+	for i, gr := range grs {
+		ids[i] = gr.ID
+	}
+	var counts []struct {
+		GroupID int
+		Members int
+	}
+	_, err := conn(g.cl, db).Query(&counts, `SELECT group_id, count(*) AS members
+	FROM group_members WHERE group_id IN (?) AND status = ? GROUP BY group_id`, pg.In(ids), model.MembershipActive)
+	if err != nil {
+		return err
+	}
+	members := make(map[int]int, len(counts))
+	for _, c := range counts {
+		members[c.GroupID] = c.Members
+	}
+	for _, gr := range grs {
+		gr.Members = members[gr.ID]
+	}
+	return nil
+}
+
+// Update updates group's details and owner
+func (g *GroupDB) Update(db orm.DB, gr *model.Group) error {
+	_, err := conn(g.cl, db).Model(gr).Column("name", "description", "visibility", "owner_id", "updated_at").
+		WherePK().Update()
+	if err != nil {
+		g.log.Warnf("GroupDB Error: %v", err)
+	}
+	return err
+}
+
+// Delete sets deleted_at for a group
+func (g *GroupDB) Delete(db orm.DB, gr *model.Group) error {
+	gr.Delete()
+	_, err := conn(g.cl, db).Model(gr).Column("deleted_at").WherePK().Update()
+	if err != nil {
+		g.log.Warnf("GroupDB Error: %v", err)
+	}
+	return err
+}
+
+// Member returns user's membership in a group.
+// If the user is neither a member, nor invited or requesting to join, nil is returned without error
+func (g *GroupDB) Member(db orm.DB, groupID, userID int) (*model.GroupMember, error) {
+	var m = &model.GroupMember{GroupID: groupID, UserID: userID}
+	err := conn(g.cl, db).Select(m)
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		g.log.Warnf("GroupDB Error: %v", err)
+		return nil, err
+	}
+	return m, nil
+}
+
+// Members returns memberships of a group in the given status, earliest first
+func (g *GroupDB) Members(db orm.DB, groupID int, status model.MembershipStatus, p *model.Pagination) ([]model.GroupMember, error) {
+	var ms []model.GroupMember
+	err := conn(g.cl, db).Model(&ms).Where("group_id = ? AND status = ?", groupID, status).
+		Order("created_at", "user_id").Limit(p.Limit).Offset(p.Offset).Select()
+	if err != nil {
+		g.log.Warnf("GroupDB Error: %v", err)
+	}
+	return ms, err
+}
+
+// SetMember creates or updates user's membership in a group
+func (g *GroupDB) SetMember(db orm.DB, m *model.GroupMember) error {
+	_, err := conn(g.cl, db).Model(m).
+		OnConflict("(group_id, user_id) DO UPDATE").
+		Set("role = EXCLUDED.role, status = EXCLUDED.status, updated_at = EXCLUDED.updated_at").
+		Insert()
+	if err != nil {
+		g.log.Warnf("GroupDB Error: %v", err)
+	}
+	return err
+}
+
+// RemoveMember removes user's membership, request or invitation
+func (g *GroupDB) RemoveMember(db orm.DB, m *model.GroupMember) error {
+	err := conn(g.cl, db).Delete(m)
+	if err != nil {
+		g.log.Warnf("GroupDB Error: %v", err)
+	}
+	return err
+}
+
+// CreatePost creates a new post in a group
+func (g *GroupDB) CreatePost(db orm.DB, p model.GroupPost) (*model.GroupPost, error) {
+	if err := conn(g.cl, db).Insert(&p); err != nil {
+		g.log.Warnf("GroupDB Error: %v", err)
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ViewPost returns single group post by ID
+func (g *GroupDB) ViewPost(db orm.DB, id int) (*model.GroupPost, error) {
+	var p = &model.GroupPost{Base: model.Base{ID: id}}
+	err := conn(g.cl, db).Model(p).WherePK().Where(notDeleted).Select()
+	if err != nil {
+		g.log.Warnf("GroupDB Error: %v", err)
+	}
+	return p, err
+}
+
+// Posts returns posts of a group, newest first
+func (g *GroupDB) Posts(db orm.DB, groupID int, p *model.Pagination) ([]model.GroupPost, error) {
+	var ps []model.GroupPost
+	err := conn(g.cl, db).Model(&ps).Where("group_id = ?", groupID).Where(notDeleted).
+		Order("created_at DESC", "id DESC").Limit(p.Limit).Offset(p.Offset).Select()
+	if err != nil {
+		g.log.Warnf("GroupDB Error: %v", err)
+	}
+	return ps, err
+}
+
+// DeletePost sets deleted_at for a group post
+func (g *GroupDB) DeletePost(db orm.DB, p *model.GroupPost) error {
+	p.Delete()
+	_, err := conn(g.cl, db).Model(p).Column("deleted_at").WherePK().Update()
+	if err != nil {
+		g.log.Warnf("GroupDB Error: %v", err)
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+package pgsql_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/go-pg/pg"
+)
+
+func testGroupDB(t *testing.T, c *pg.DB, l echo.Logger) {
+	for _, id := range []int{100, 101, 102} {
+		u := &model.User{Base: model.Base{ID: id}, Username: fmt.Sprintf("grouped%d", id), Active: true, RoleID: 5, CompanyID: 1, LocationID: 1}
+		if err := c.Insert(u); err != nil {
+			t.Fatalf("Fail on seeding users: %v", err)
+		}
+	}
+	gdb := pgsql.NewGroupDB(c, l)
+	now := time.Now()
+
+	var grs []*model.Group
+	for _, gr := range []model.Group{
+		{CompanyID: 1, OwnerID: 100, Name: "runners", Visibility: model.GroupPublic},
+		{CompanyID: 1, OwnerID: 100, Name: "book club", Visibility: model.GroupPrivate},
+		{CompanyID: 1, OwnerID: 101, Name: "secret society", Visibility: model.GroupInviteOnly},
+		{CompanyID: 2, OwnerID: 102, Name: "elsewhere", Visibility: model.GroupPublic},
+	} {
+		created, err := gdb.Create(nil, gr)
+		if err != nil {
+			t.Fatalf("Fail on creating group: %v", err)
+		}
+		grs = append(grs, created)
+	}
+
+	for _, m := range []model.GroupMember{
+		{GroupID: grs[0].ID, UserID: 100, CompanyID: 1, Role: model.GroupRoleOwner, Status: model.MembershipActive},
+		{GroupID: grs[0].ID, UserID: 101, CompanyID: 1, Role: model.GroupRoleMember, Status: model.MembershipActive},
+		{GroupID: grs[0].ID, UserID: 102, CompanyID: 1, Role: model.GroupRoleMember, Status: model.MembershipRequested},
+		{GroupID: grs[2].ID, UserID: 101, CompanyID: 1, Role: model.GroupRoleOwner, Status: model.MembershipActive},
+		{GroupID: grs[2].ID, UserID: 102, CompanyID: 1, Role: model.GroupRoleMember, Status: model.MembershipInvited},
+	} {
+		m := m
+		m.CreatedAt, m.UpdatedAt = now, now
+		assert.Nil(t, gdb.SetMember(nil, &m))
+	}
+	assert.Nil(t, gdb.SetMember(nil, &model.GroupMember{GroupID: grs[0].ID, UserID: 101, CompanyID: 1,
+		Role: model.GroupRoleModerator, Status: model.MembershipActive, CreatedAt: now.Add(time.Hour), UpdatedAt: now.Add(time.Hour)}))
+
+	m, err := gdb.Member(nil, grs[0].ID, 101)
+	assert.Nil(t, err)
+	assert.Equal(t, model.GroupRoleModerator, m.Role)
+	assert.Equal(t, now.Unix(), m.CreatedAt.Unix())
+	m, err = gdb.Member(nil, grs[1].ID, 101)
+	assert.Nil(t, err)
+	assert.Nil(t, m)
+
+	requested, err := gdb.Members(nil, grs[0].ID, model.MembershipRequested, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(requested))
+	assert.Equal(t, 102, requested[0].UserID)
+	assert.Nil(t, gdb.RemoveMember(nil, &requested[0]))
+	m, err = gdb.Member(nil, grs[0].ID, 102)
+	assert.Nil(t, err)
+	assert.Nil(t, m)
+
+	view, err := gdb.View(nil, grs[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, view.Members)
+	view.Description = "Morning runs"
+	assert.Nil(t, gdb.Update(nil, view))
+	view, err = gdb.View(nil, grs[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "Morning runs", view.Description)
+
+	cases := []struct {
+		name        string
+		filter      model.GroupFilter
+		wantNames   []string
+		wantMembers []int
+	}{
+		{
+			name:        "Listed groups",
+			filter:      model.GroupFilter{CompanyID: 1, UserID: 100},
+			wantNames:   []string{"book club", "runners"},
+			wantMembers: []int{0, 2},
+		},
+		{
+			name:        "Invited user",
+			filter:      model.GroupFilter{CompanyID: 1, UserID: 102},
+			wantNames:   []string{"book club", "runners", "secret society"},
+			wantMembers: []int{0, 2, 1},
+		},
+		{
+			name:        "All groups",
+			filter:      model.GroupFilter{CompanyID: 1, All: true},
+			wantNames:   []string{"book club", "runners", "secret society"},
+			wantMembers: []int{0, 2, 1},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := gdb.List(nil, &tt.filter, &model.Pagination{Limit: 10})
+			assert.Nil(t, err)
+			var names []string
+			var members []int
+			for _, gr := range list {
+				names = append(names, gr.Name)
+				members = append(members, gr.Members)
+			}
+			assert.Equal(t, tt.wantNames, names)
+			assert.Equal(t, tt.wantMembers, members)
+		})
+	}
+
+	var posts []*model.GroupPost
+	for _, body := range []string{"first", "second"} {
+		p, err := gdb.CreatePost(nil, model.GroupPost{GroupID: grs[0].ID, CompanyID: 1, AuthorID: 101, Body: body})
+		if err != nil {
+			t.Fatalf("Fail on creating post: %v", err)
+		}
+		posts = append(posts, p)
+	}
+	assert.Nil(t, gdb.DeletePost(nil, posts[0]))
+	_, err = gdb.ViewPost(nil, posts[0].ID)
+	assert.NotNil(t, err)
+	list, err := gdb.Posts(nil, grs[0].ID, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(list))
+	assert.Equal(t, "second", list[0].Body)
+
+	assert.Nil(t, gdb.Delete(nil, grs[1]))
+	_, err = gdb.View(nil, grs[1].ID)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,136 @@
+package pgsql
+
+import (
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// NewMeetupDB returns a new MeetupDB instance
+func NewMeetupDB(c *pg.DB, l echo.Logger) *MeetupDB {
+	return &MeetupDB{c, l}
+}
+
+// MeetupDB represents the client for meetups and rsvps tables
+type MeetupDB struct {
+	cl  *pg.DB
+	log echo.Logger
+}
+
+// Create creates a new meetup
+func (e *MeetupDB) Create(db orm.DB, ev model.Meetup) (*model.Meetup, error) {
+	if err := conn(e.cl, db).Insert(&ev); err != nil {
+		e.log.Warnf("MeetupDB Error: %v", err)
+		return nil, err
+	}
+	return &ev, nil
+}
+
+// View returns single meetup by ID
+func (e *MeetupDB) View(db orm.DB, id int) (*model.Meetup, error) {
+	var ev = &model.Meetup{Base: model.Base{ID: id}}
+	err := conn(e.cl, db).Model(ev).WherePK().Where(notDeleted).Select()
+	if err != nil {
+		e.log.Warnf("MeetupDB Error: %v", err)
+	}
+	return ev, err
+}
+
+// Lock returns single meetup by ID, locking it until the end of the transaction.
+// It serializes responses to the meetup, so the capacity is not exceeded
+func (e *MeetupDB) Lock(db orm.DB, id int) (*model.Meetup, error) {
+	var ev = &model.Meetup{Base: model.Base{ID: id}}
+	err := conn(e.cl, db).Model(ev).WherePK().Where(notDeleted).For("UPDATE").Select()
+	if err != nil {
+		e.log.Warnf("MeetupDB Error: %v", err)
+	}
+	return ev, err
+}
+
+// List returns meetups of a company ending after the filter time, soonest first, with attendance counts
+func (e *MeetupDB) List(db orm.DB, f *model.MeetupFilter, p *model.Pagination) ([]model.Meetup, error) {
+	var evs []model.Meetup
+	q := conn(e.cl, db).Model(&evs).Where("company_id = ?", f.CompanyID).
+		Where("ends_at > ?", f.After).Where("canceled_at IS NULL").Where(notDeleted)
+	if f.LocationID > 0 {
+		q.Where("location_id = ?", f.LocationID)
+	}
+	err := q.Order("starts_at", "id").Limit(p.Limit).Offset(p.Offset).Select()
+	if err != nil || len(evs) == 0 {
+		if err != nil {
+			e.log.Warnf("MeetupDB Error: %v", err)
+		}
+		return evs, err
+	}
+
+	ids := make([]int, len(evs))
+	for i, ev := range evs {
+		ids[i] = ev.ID
+	}
+	var counts []struct {
+		MeetupID   int
+		Going      int
+		Waitlisted int
+	}
+	_, err = conn(e.cl, db).Query(&counts, `SELECT meetup_id,
+	count(*) FILTER (WHERE status = ?) AS going, count(*) FILTER (WHERE status = ?) AS waitlisted
+	FROM rsvps WHERE meetup_id IN (?) GROUP BY meetup_id`, model.RSVPGoing, model.RSVPWaitlisted, pg.In(ids))
+	if err != nil {
+		e.log.Warnf("MeetupDB Error: %v", err)
+		return nil, err
+	}
+	index := make(map[int]*model.Meetup, len(evs))
+	for i := range evs {
+		index[evs[i].ID] = &evs[i]
+	}
+	for _, c := range counts {
+		index[c.MeetupID].Going = c.Going
+		index[c.MeetupID].Waitlisted = c.Waitlisted
+	}
+	return evs, nil
+}
+
+// Update updates meetup's details and cancellation
+func (e *MeetupDB) Update(db orm.DB, ev *model.Meetup) error {
+	_, err := conn(e.cl, db).Model(ev).
+		Column("title", "description", "starts_at", "ends_at", "capacity", "canceled_at", "updated_at").
+		WherePK().Update()
+	if err != nil {
+		e.log.Warnf("MeetupDB Error: %v", err)
+	}
+	return err
+}
+
+// RSVPs returns responses to a meetup, in order of their last change
+func (e *MeetupDB) RSVPs(db orm.DB, meetupID int) ([]model.RSVP, error) {
+	var rsvps []model.RSVP
+	err := conn(e.cl, db).Model(&rsvps).Where("meetup_id = ?", meetupID).Order("updated_at", "user_id").Select()
+	if err != nil {
+		e.log.Warnf("MeetupDB Error: %v", err)
+	}
+	return rsvps, err
+}
+
+// SetRSVP creates or updates user's response to a meetup
+func (e *MeetupDB) SetRSVP(db orm.DB, r *model.RSVP) error {
+	_, err := conn(e.cl, db).Model(r).
+		OnConflict("(meetup_id, user_id) DO UPDATE").
+		Set("status = EXCLUDED.status, updated_at = EXCLUDED.updated_at").
+		Insert()
+	if err != nil {
+		e.log.Warnf("MeetupDB Error: %v", err)
+	}
+	return err
+}
+
+// Location returns single location by ID
+func (e *MeetupDB) Location(db orm.DB, id int) (*model.Location, error) {
+	var l = &model.Location{Base: model.Base{ID: id}}
+	err := conn(e.cl, db).Model(l).WherePK().Where(notDeleted).Select()
+	if err != nil {
+		e.log.Warnf("MeetupDB Error: %v", err)
+	}
+	return l, err
+}
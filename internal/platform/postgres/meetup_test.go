@@ -0,0 +1,116 @@
+package pgsql_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/go-pg/pg"
+)
+
+func testMeetupDB(t *testing.T, c *pg.DB, l echo.Logger) {
+	for _, id := range []int{80, 81, 82} {
+		u := &model.User{Base: model.Base{ID: id}, Username: fmt.Sprintf("attendee%d", id), Active: true, RoleID: 5, CompanyID: 1, LocationID: 1}
+		if err := c.Insert(u); err != nil {
+			t.Fatalf("Fail on seeding users: %v", err)
+		}
+	}
+	if err := c.Insert(&model.Location{Base: model.Base{ID: 80}, Name: "annex", Active: true, Address: "annex_address", CompanyID: 1}); err != nil {
+		t.Fatalf("Fail on seeding locations: %v", err)
+	}
+	edb := pgsql.NewMeetupDB(c, l)
+	now := time.Now()
+
+	var evs []*model.Meetup
+	for _, ev := range []model.Meetup{
+		{CompanyID: 1, LocationID: 1, OrganizerID: 80, Title: "later", StartsAt: now.Add(48 * time.Hour), EndsAt: now.Add(50 * time.Hour), Capacity: 2},
+		{CompanyID: 1, LocationID: 80, OrganizerID: 80, Title: "sooner", StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)},
+		{CompanyID: 1, LocationID: 1, OrganizerID: 81, Title: "past", StartsAt: now.Add(-3 * time.Hour), EndsAt: now.Add(-2 * time.Hour)},
+		{CompanyID: 1, LocationID: 1, OrganizerID: 81, Title: "canceled", StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)},
+	} {
+		created, err := edb.Create(nil, ev)
+		if err != nil {
+			t.Fatalf("Fail on creating meetup: %v", err)
+		}
+		evs = append(evs, created)
+	}
+
+	evs[3].Cancel(now)
+	evs[3].Title = "canceled party"
+	assert.Nil(t, edb.Update(nil, evs[3]))
+	canceled, err := edb.View(nil, evs[3].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "canceled party", canceled.Title)
+	assert.NotNil(t, canceled.CanceledAt)
+
+	locked, err := edb.Lock(nil, evs[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "later", locked.Title)
+
+	for _, r := range []model.RSVP{
+		{MeetupID: evs[0].ID, UserID: 81, CompanyID: 1, Status: model.RSVPGoing, CreatedAt: now, UpdatedAt: now},
+		{MeetupID: evs[0].ID, UserID: 82, CompanyID: 1, Status: model.RSVPWaitlisted, CreatedAt: now, UpdatedAt: now.Add(time.Second)},
+		{MeetupID: evs[0].ID, UserID: 80, CompanyID: 1, Status: model.RSVPMaybe, CreatedAt: now, UpdatedAt: now.Add(2 * time.Second)},
+		{MeetupID: evs[0].ID, UserID: 80, CompanyID: 1, Status: model.RSVPGoing, CreatedAt: now.Add(time.Hour), UpdatedAt: now.Add(3 * time.Second)},
+	} {
+		r := r
+		assert.Nil(t, edb.SetRSVP(nil, &r))
+	}
+	rsvps, err := edb.RSVPs(nil, evs[0].ID)
+	assert.Nil(t, err)
+	var got []model.RSVPStatus
+	for _, r := range rsvps {
+		got = append(got, r.Status)
+	}
+	assert.Equal(t, []model.RSVPStatus{model.RSVPGoing, model.RSVPWaitlisted, model.RSVPGoing}, got)
+	assert.Equal(t, 80, rsvps[2].UserID)
+	assert.Equal(t, now.Unix(), rsvps[2].CreatedAt.Unix())
+
+	cases := []struct {
+		name      string
+		filter    model.MeetupFilter
+		wantIDs   []int
+		wantGoing []int
+	}{
+		{
+			name:      "Upcoming in company",
+			filter:    model.MeetupFilter{CompanyID: 1, After: now},
+			wantIDs:   []int{evs[1].ID, evs[0].ID},
+			wantGoing: []int{0, 2},
+		},
+		{
+			name:      "Upcoming at location",
+			filter:    model.MeetupFilter{CompanyID: 1, LocationID: 80, After: now},
+			wantIDs:   []int{evs[1].ID},
+			wantGoing: []int{0},
+		},
+		{
+			name:   "Other company",
+			filter: model.MeetupFilter{CompanyID: 2, After: now},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := edb.List(nil, &tt.filter, &model.Pagination{Limit: 10})
+			assert.Nil(t, err)
+			var ids, going []int
+			for _, ev := range list {
+				ids = append(ids, ev.ID)
+				going = append(going, ev.Going)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			assert.Equal(t, tt.wantGoing, going)
+		})
+	}
+
+	loc, err := edb.Location(nil, 80)
+	assert.Nil(t, err)
+	assert.Equal(t, "annex_address", loc.Address)
+	_, err = edb.Location(nil, 999)
+	assert.NotNil(t, err)
+}
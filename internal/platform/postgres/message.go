@@ -0,0 +1,175 @@
+package pgsql
+
+import (
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// NewMessageDB returns a new MessageDB instance
+func NewMessageDB(c *pg.DB, l echo.Logger) *MessageDB {
+	return &MessageDB{c, l}
+}
+
+// MessageDB represents the client for conversations, participants and messages tables
+type MessageDB struct {
+	cl  *pg.DB
+	log echo.Logger
+}
+
+// CreateConversation creates a new conversation between the users.
+// If one-to-one conversation between the users was started concurrently, that one is returned instead
+func (m *MessageDB) CreateConversation(db orm.DB, cv model.Conversation, userIDs []int) (*model.Conversation, error) {
+	res, err := conn(m.cl, db).Model(&cv).
+		OnConflict("(direct_key) WHERE deleted_at IS NULL AND direct_key IS NOT NULL DO NOTHING").Insert()
+	if err != nil {
+		m.log.Warnf("MessageDB Error: %v", err)
+		return nil, err
+	}
+	if res.RowsAffected() == 0 {
+		return m.FindDirect(db, userIDs[0], userIDs[1])
+	}
+	cv.Participants = make([]model.Participant, len(userIDs))
+	for i, id := range userIDs {
+		cv.Participants[i] = model.Participant{ConversationID: cv.ID, UserID: id, CompanyID: cv.CompanyID}
+	}
+	if err := conn(m.cl, db).Insert(&cv.Participants); err != nil {
+		m.log.Warnf("MessageDB Error: %v", err)
+		return nil, err
+	}
+	return &cv, nil
+}
+
+// ViewConversation returns single conversation by ID, along with its participants
+func (m *MessageDB) ViewConversation(db orm.DB, id int) (*model.Conversation, error) {
+	var cv = &model.Conversation{Base: model.Base{ID: id}}
+	err := conn(m.cl, db).Model(cv).WherePK().Where(notDeleted).Select()
+	if err == nil {
+		err = conn(m.cl, db).Model(&cv.Participants).Where("conversation_id = ?", id).Order("user_id").Select()
+	}
+	if err != nil {
+		m.log.Warnf("MessageDB Error: %v", err)
+	}
+	return cv, err
+}
+
+// FindDirect returns one-to-one conversation between two users.
+// If the users have no conversation yet, nil is returned without error
+func (m *MessageDB) FindDirect(db orm.DB, userID, otherID int) (*model.Conversation, error) {
+	var cv = new(model.Conversation)
+	err := conn(m.cl, db).Model(cv).Where(`"conversation".deleted_at IS NULL AND "conversation".is_group IS NOT TRUE`).
+		Where(`"conversation".id IN (SELECT conversation_id FROM participants WHERE user_id = ?)`, userID).
+		Where(`"conversation".id IN (SELECT conversation_id FROM participants WHERE user_id = ?)`, otherID).
+		Limit(1).Select()
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+	if err == nil {
+		err = conn(m.cl, db).Model(&cv.Participants).Where("conversation_id = ?", cv.ID).Order("user_id").Select()
+	}
+	if err != nil {
+		m.log.Warnf("MessageDB Error: %v", err)
+		return nil, err
+	}
+	return cv, nil
+}
+
+// ListConversations returns conversations of a user with the number of messages unread by the user,
+// most recently active first
+func (m *MessageDB) ListConversations(db orm.DB, userID int, p *model.Pagination) ([]model.Conversation, error) {
+	var cvs []model.Conversation
+	err := conn(m.cl, db).Model(&cvs).
+		Join(`JOIN participants AS p ON p.conversation_id = "conversation".id AND p.user_id = ?`, userID).
+		Where(`"conversation".deleted_at IS NULL`).OrderExpr(`"conversation".updated_at DESC, "conversation".id DESC`).
+		Limit(p.Limit).Offset(p.Offset).Select()
+	if err != nil || len(cvs) == 0 {
+		if err != nil {
+			m.log.Warnf("MessageDB Error: %v", err)
+		}
+		return cvs, err
+	}
+
+	ids := make([]int, len(cvs))
+	for i, cv := range cvs {
+		ids[i] = cv.ID
+	}
+	var ps []model.Participant
+	if err := conn(m.cl, db).Model(&ps).Where("conversation_id IN (?)", pg.In(ids)).Order("user_id").Select(); err != nil {
+		m.log.Warnf("MessageDB Error: %v", err)
+		return nil, err
+	}
+	var unread []struct {
+		ConversationID int
+		Unread         int
+	}
+	_, err = conn(m.cl, db).Query(&unread, `SELECT m.conversation_id, count(*) AS unread
+	FROM messages AS m JOIN participants AS p ON p.conversation_id = m.conversation_id AND p.user_id = ?
+	WHERE m.conversation_id IN (?) AND m.id > coalesce(p.last_read_id, 0) AND m.sender_id <> ? AND m.deleted_at IS NULL
+	GROUP BY m.conversation_id`, userID, pg.In(ids), userID)
+	if err != nil {
+		m.log.Warnf("MessageDB Error: %v", err)
+		return nil, err
+	}
+
+	index := make(map[int]*model.Conversation, len(cvs))
+	for i := range cvs {
+		index[cvs[i].ID] = &cvs[i]
+	}
+	for _, pt := range ps {
+		index[pt.ConversationID].Participants = append(index[pt.ConversationID].Participants, pt)
+	}
+	for _, u := range unread {
+		index[u.ConversationID].Unread = u.Unread
+	}
+	return cvs, nil
+}
+
+// CreateMessage creates a new message, marking its conversation as recently active
+func (m *MessageDB) CreateMessage(db orm.DB, msg model.Message) (*model.Message, error) {
+	if err := conn(m.cl, db).Insert(&msg); err != nil {
+		m.log.Warnf("MessageDB Error: %v", err)
+		return nil, err
+	}
+	_, err := conn(m.cl, db).Model((*model.Conversation)(nil)).Set("updated_at = ?", msg.CreatedAt).
+		Where("id = ?", msg.ConversationID).Update()
+	if err != nil {
+		m.log.Warnf("MessageDB Error: %v", err)
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// ViewMessage returns single message by ID
+func (m *MessageDB) ViewMessage(db orm.DB, id int) (*model.Message, error) {
+	var msg = &model.Message{Base: model.Base{ID: id}}
+	err := conn(m.cl, db).Model(msg).WherePK().Where(notDeleted).Select()
+	if err != nil {
+		m.log.Warnf("MessageDB Error: %v", err)
+	}
+	return msg, err
+}
+
+// History returns messages of a conversation, newest first
+func (m *MessageDB) History(db orm.DB, conversationID int, cur *model.MessageCursor) ([]model.Message, error) {
+	var msgs []model.Message
+	q := conn(m.cl, db).Model(&msgs).Where("conversation_id = ?", conversationID).Where(notDeleted)
+	if cur.Before > 0 {
+		q.Where("id < ?", cur.Before)
+	}
+	if err := q.Order("id DESC").Limit(cur.Limit).Select(); err != nil {
+		m.log.Warnf("MessageDB Error: %v", err)
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// UpdateParticipant updates participant's read receipt
+func (m *MessageDB) UpdateParticipant(db orm.DB, p *model.Participant) error {
+	_, err := conn(m.cl, db).Model(p).Column("last_read_id", "read_at").WherePK().Update()
+	if err != nil {
+		m.log.Warnf("MessageDB Error: %v", err)
+	}
+	return err
+}
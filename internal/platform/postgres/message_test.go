@@ -0,0 +1,107 @@
+package pgsql_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/go-pg/pg"
+)
+
+func testMessageDB(t *testing.T, c *pg.DB, l echo.Logger) {
+	for _, id := range []int{40, 41, 42} {
+		u := &model.User{Base: model.Base{ID: id}, Username: fmt.Sprintf("chatter%d", id), Active: true, RoleID: 5, CompanyID: 1, LocationID: 1}
+		if err := c.Insert(u); err != nil {
+			t.Fatalf("Fail on seeding users: %v", err)
+		}
+	}
+	mdb := pgsql.NewMessageDB(c, l)
+
+	direct, err := mdb.CreateConversation(nil, model.Conversation{CompanyID: 1, CreatorID: 40, DirectKey: "40:41"}, []int{40, 41})
+	if err != nil {
+		t.Fatalf("Fail on creating conversation: %v", err)
+	}
+	group, err := mdb.CreateConversation(nil, model.Conversation{CompanyID: 1, CreatorID: 41, Title: "team", IsGroup: true}, []int{41, 40, 42})
+	if err != nil {
+		t.Fatalf("Fail on creating conversation: %v", err)
+	}
+
+	t.Run("find direct", func(t *testing.T) {
+		cv, err := mdb.FindDirect(nil, 41, 40)
+		assert.Nil(t, err)
+		if assert.NotNil(t, cv) {
+			assert.Equal(t, direct.ID, cv.ID)
+			assert.Equal(t, []int{40, 41}, cv.UserIDs())
+		}
+		cv, err = mdb.FindDirect(nil, 40, 42)
+		assert.Nil(t, err)
+		assert.Nil(t, cv)
+	})
+
+	t.Run("create direct again", func(t *testing.T) {
+		cv, err := mdb.CreateConversation(nil, model.Conversation{CompanyID: 1, CreatorID: 41, DirectKey: "40:41"}, []int{41, 40})
+		assert.Nil(t, err)
+		if assert.NotNil(t, cv) {
+			assert.Equal(t, direct.ID, cv.ID)
+		}
+	})
+
+	var ids []int
+	t.Run("create message", func(t *testing.T) {
+		for i, sender := range []int{40, 41, 40} {
+			msg, err := mdb.CreateMessage(nil, model.Message{ConversationID: direct.ID, SenderID: sender, CompanyID: 1,
+				Body: fmt.Sprintf("message %d", i), Attachments: []model.Attachment{{Name: "a.txt", URL: "https://example.com/a.txt"}}})
+			if err != nil {
+				t.Fatalf("Fail on creating message: %v", err)
+			}
+			ids = append(ids, msg.ID)
+		}
+		msg, err := mdb.ViewMessage(nil, ids[0])
+		assert.Nil(t, err)
+		assert.Equal(t, []model.Attachment{{Name: "a.txt", URL: "https://example.com/a.txt"}}, msg.Attachments)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		msgs, err := mdb.History(nil, direct.ID, &model.MessageCursor{Limit: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, []int{ids[2], ids[1]}, messageIDs(msgs))
+		msgs, err = mdb.History(nil, direct.ID, &model.MessageCursor{Before: ids[1], Limit: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, []int{ids[0]}, messageIDs(msgs))
+	})
+
+	t.Run("unread", func(t *testing.T) {
+		cvs, err := mdb.ListConversations(nil, 41, &model.Pagination{Limit: 10})
+		assert.Nil(t, err)
+		if assert.Len(t, cvs, 2) {
+			// Direct conversation has the most recent message
+			assert.Equal(t, direct.ID, cvs[0].ID)
+			assert.Equal(t, 2, cvs[0].Unread)
+			assert.Equal(t, group.ID, cvs[1].ID)
+			assert.Equal(t, []int{40, 41, 42}, cvs[1].UserIDs())
+		}
+
+		cv, err := mdb.ViewConversation(nil, direct.ID)
+		assert.Nil(t, err)
+		p := &cv.Participants[1]
+		p.Read(ids[0], mock.TestTime(2018))
+		assert.Nil(t, mdb.UpdateParticipant(nil, p))
+
+		cvs, err = mdb.ListConversations(nil, 41, &model.Pagination{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, cvs[0].Unread)
+	})
+}
+
+func messageIDs(msgs []model.Message) []int {
+	var ids []int
+	for _, m := range msgs {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
@@ -0,0 +1,107 @@
+package pgsql
+
+import (
+	"time"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// NewNotificationDB returns a new NotificationDB instance
+func NewNotificationDB(c *pg.DB, l echo.Logger) *NotificationDB {
+	return &NotificationDB{c, l}
+}
+
+// NotificationDB represents the client for notifications and notification_preferences tables
+type NotificationDB struct {
+	cl  *pg.DB
+	log echo.Logger
+}
+
+// Create creates a new notification
+func (n *NotificationDB) Create(db orm.DB, nt model.Notification) (*model.Notification, error) {
+	if err := conn(n.cl, db).Insert(&nt); err != nil {
+		n.log.Warnf("NotificationDB Error: %v", err)
+		return nil, err
+	}
+	return &nt, nil
+}
+
+// View returns single notification by ID
+func (n *NotificationDB) View(db orm.DB, id int) (*model.Notification, error) {
+	var nt = &model.Notification{Base: model.Base{ID: id}}
+	err := conn(n.cl, db).Model(nt).WherePK().Where(notDeleted).Select()
+	if err != nil {
+		n.log.Warnf("NotificationDB Error: %v", err)
+	}
+	return nt, err
+}
+
+// List returns notifications of a user matching the filter, newest first
+func (n *NotificationDB) List(db orm.DB, userID int, f *model.NotificationFilter, p *model.Pagination) ([]model.Notification, error) {
+	var nts []model.Notification
+	q := conn(n.cl, db).Model(&nts).Where("user_id = ?", userID).Where(notDeleted)
+	if f.Unread {
+		q.Where("read_at IS NULL")
+	}
+	if f.Type != "" {
+		q.Where("type = ?", f.Type)
+	}
+	if err := q.Order("id DESC").Limit(p.Limit).Offset(p.Offset).Select(); err != nil {
+		n.log.Warnf("NotificationDB Error: %v", err)
+		return nil, err
+	}
+	return nts, nil
+}
+
+// CountUnread returns the number of unread notifications of a user
+func (n *NotificationDB) CountUnread(db orm.DB, userID int) (int, error) {
+	cnt, err := conn(n.cl, db).Model((*model.Notification)(nil)).Where("user_id = ? AND read_at IS NULL", userID).
+		Where(notDeleted).Count()
+	if err != nil {
+		n.log.Warnf("NotificationDB Error: %v", err)
+	}
+	return cnt, err
+}
+
+// Update updates notification's read time
+func (n *NotificationDB) Update(db orm.DB, nt *model.Notification) error {
+	_, err := conn(n.cl, db).Model(nt).Column("read_at").WherePK().Update()
+	if err != nil {
+		n.log.Warnf("NotificationDB Error: %v", err)
+	}
+	return err
+}
+
+// ReadAll marks all unread notifications of a user as read, returning how many were marked
+func (n *NotificationDB) ReadAll(db orm.DB, userID int, t time.Time) (int, error) {
+	res, err := conn(n.cl, db).Model((*model.Notification)(nil)).Set("read_at = ?", t).
+		Where("user_id = ? AND read_at IS NULL", userID).Where(notDeleted).Update()
+	if err != nil {
+		n.log.Warnf("NotificationDB Error: %v", err)
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// Preferences returns notification preferences set by a user
+func (n *NotificationDB) Preferences(db orm.DB, userID int) ([]model.NotificationPreference, error) {
+	var prefs []model.NotificationPreference
+	if err := conn(n.cl, db).Model(&prefs).Where("user_id = ?", userID).Order("type").Select(); err != nil {
+		n.log.Warnf("NotificationDB Error: %v", err)
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// SetPreference creates or replaces user's preference for a notification type
+func (n *NotificationDB) SetPreference(db orm.DB, p model.NotificationPreference) error {
+	_, err := conn(n.cl, db).Model(&p).OnConflict("(user_id, type) DO UPDATE").Set("enabled = EXCLUDED.enabled").Insert()
+	if err != nil {
+		n.log.Warnf("NotificationDB Error: %v", err)
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/go-pg/pg"
+)
+
+func testNotificationDB(t *testing.T, c *pg.DB, l echo.Logger) {
+	u := &model.User{Base: model.Base{ID: 50}, Username: "notified50", Active: true, RoleID: 5, CompanyID: 1, LocationID: 1}
+	if err := c.Insert(u); err != nil {
+		t.Fatalf("Fail on seeding users: %v", err)
+	}
+	ndb := pgsql.NewNotificationDB(c, l)
+
+	var ids []int
+	t.Run("create", func(t *testing.T) {
+		for _, typ := range []model.NotificationType{model.NotificationFriendRequest, model.NotificationPasswordChanged, model.NotificationFriendRequest} {
+			nt, err := ndb.Create(nil, model.Notification{UserID: 50, CompanyID: 1, Type: typ, Data: map[string]interface{}{"user_id": 2}})
+			if err != nil {
+				t.Fatalf("Fail on creating notification: %v", err)
+			}
+			ids = append(ids, nt.ID)
+		}
+		nt, err := ndb.View(nil, ids[0])
+		assert.Nil(t, err)
+		assert.Equal(t, model.NotificationFriendRequest, nt.Type)
+		assert.Equal(t, map[string]interface{}{"user_id": float64(2)}, nt.Data)
+		assert.Nil(t, nt.ReadAt)
+	})
+
+	t.Run("read", func(t *testing.T) {
+		nt, err := ndb.View(nil, ids[1])
+		if err != nil {
+			t.Fatalf("Fail on viewing notification: %v", err)
+		}
+		nt.Read(mock.TestTime(2018))
+		assert.Nil(t, ndb.Update(nil, nt))
+		nt, err = ndb.View(nil, ids[1])
+		assert.Nil(t, err)
+		assert.NotNil(t, nt.ReadAt)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		cases := []struct {
+			name    string
+			filter  model.NotificationFilter
+			wantIDs []int
+		}{
+			{
+				name:    "All",
+				wantIDs: []int{ids[2], ids[1], ids[0]},
+			},
+			{
+				name:    "Unread",
+				filter:  model.NotificationFilter{Unread: true},
+				wantIDs: []int{ids[2], ids[0]},
+			},
+			{
+				name:    "Type",
+				filter:  model.NotificationFilter{Type: model.NotificationPasswordChanged},
+				wantIDs: []int{ids[1]},
+			},
+		}
+		for _, tt := range cases {
+			t.Run(tt.name, func(t *testing.T) {
+				nts, err := ndb.List(nil, 50, &tt.filter, &model.Pagination{Limit: 10})
+				assert.Nil(t, err)
+				var got []int
+				for _, nt := range nts {
+					got = append(got, nt.ID)
+				}
+				assert.Equal(t, tt.wantIDs, got)
+			})
+		}
+	})
+
+	t.Run("read all", func(t *testing.T) {
+		cnt, err := ndb.CountUnread(nil, 50)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, cnt)
+		n, err := ndb.ReadAll(nil, 50, mock.TestTime(2018))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, n)
+		cnt, err = ndb.CountUnread(nil, 50)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, cnt)
+	})
+
+	t.Run("preferences", func(t *testing.T) {
+		assert.Nil(t, ndb.SetPreference(nil, model.NotificationPreference{UserID: 50, Type: model.NotificationFriendRequest, CompanyID: 1, Enabled: true}))
+		assert.Nil(t, ndb.SetPreference(nil, model.NotificationPreference{UserID: 50, Type: model.NotificationFriendRequest, CompanyID: 1, Enabled: false}))
+		assert.Nil(t, ndb.SetPreference(nil, model.NotificationPreference{UserID: 50, Type: model.NotificationRoleChanged, CompanyID: 1, Enabled: true}))
+		prefs, err := ndb.Preferences(nil, 50)
+		assert.Nil(t, err)
+		assert.Equal(t, []model.NotificationPreference{
+			{UserID: 50, Type: model.NotificationFriendRequest, CompanyID: 1, Enabled: false},
+			{UserID: 50, Type: model.NotificationRoleChanged, CompanyID: 1, Enabled: true},
+		}, prefs)
+	})
+}
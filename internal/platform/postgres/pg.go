@@ -1,10 +1,12 @@
 package pgsql
 
 import (
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
 	// DB adapter
 	"github.com/artistomin/friend4me/internal"
 	_ "github.com/lib/pq"
@@ -37,11 +39,66 @@ func New(cfg *config.Database) (*pg.DB, error) {
 		})
 	}
 	if cfg.CreateSchema {
-		createSchema(db, &model.Company{}, &model.Location{}, &model.Role{}, &model.User{})
+		createSchema(db, &model.Company{}, &model.Location{}, &model.Role{}, &model.User{}, &model.RoleGrant{}, &model.Friendship{}, &model.Block{}, &model.MutualFriends{}, &model.Conversation{}, &model.Participant{}, &model.Message{}, &model.Notification{}, &model.NotificationPreference{}, &model.Activity{}, &model.Meetup{}, &model.RSVP{}, &model.Tag{}, &model.UserTag{}, &model.Group{}, &model.GroupMember{}, &model.GroupPost{}, &model.Erasure{}, &model.CompanyPreference{}, &model.UserPreference{})
+		checkErr(CreateSearchIndex(db))
+		checkErr(CreateEventLog(db))
+		checkErr(CreateUniqueIndexes(db))
+		checkErr(CreateSuggestionIndexes(db))
+		checkErr(EnableRLS(db))
 	}
 	return db, nil
 }
 
+// uniqueIndexes keep concurrent requests from creating rows which may exist only once
+var uniqueIndexes = []string{
+	// Pending request or friendship between two users, whichever of them asked
+	fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS friendships_pair_idx
+	ON friendships (least(requester_id, addressee_id), greatest(requester_id, addressee_id))
+	WHERE deleted_at IS NULL AND status IN ('%s', '%s')`, model.FriendshipPending, model.FriendshipAccepted),
+	`CREATE UNIQUE INDEX IF NOT EXISTS blocks_target_idx ON blocks (user_id, target_id, kind) WHERE deleted_at IS NULL`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS conversations_direct_idx ON conversations (direct_key)
+	WHERE deleted_at IS NULL AND direct_key IS NOT NULL`,
+	// Usernames and emails differing only in case are taken as the same, deleted users keep theirs until purged
+	`CREATE UNIQUE INDEX IF NOT EXISTS users_username_idx ON users (lower(username)) WHERE erased_at IS NULL`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS users_email_idx ON users (lower(email)) WHERE erased_at IS NULL`,
+}
+
+// CreateUniqueIndexes creates unique indexes, violations of which are reported as conflicts
+func CreateUniqueIndexes(db orm.DB) error {
+	for _, q := range uniqueIndexes {
+		if _, err := db.Exec(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether the error is caused by a row violating a unique index,
+// one of the given indexes unless none are given
+func isUniqueViolation(err error, indexes ...string) bool {
+	pgErr, ok := err.(pg.Error)
+	if !ok || pgErr.Field('C') != "23505" {
+		return false
+	}
+	if len(indexes) == 0 {
+		return true
+	}
+	for _, idx := range indexes {
+		if pgErr.Field('n') == idx {
+			return true
+		}
+	}
+	return false
+}
+
+// conn returns request scoped connection if it is set, otherwise the default one
+func conn(def *pg.DB, db orm.DB) orm.DB {
+	if db == nil {
+		return def
+	}
+	return db
+}
+
 func createSchema(db *pg.DB, models ...interface{}) {
 	for _, model := range models {
 		checkErr(db.CreateTable(model, nil))
@@ -66,6 +66,70 @@ func TestNew(t *testing.T) {
 			name: "UserDB",
 			fn:   testUserDB,
 		},
+		{
+			name: "GrantDB",
+			fn:   testGrantDB,
+		},
+		{
+			name: "FriendDB",
+			fn:   testFriendDB,
+		},
+		{
+			name: "BlockDB",
+			fn:   testBlockDB,
+		},
+		{
+			name: "SuggestionDB",
+			fn:   testSuggestionDB,
+		},
+		{
+			name: "MessageDB",
+			fn:   testMessageDB,
+		},
+		{
+			name: "NotificationDB",
+			fn:   testNotificationDB,
+		},
+		{
+			name: "PresenceDB",
+			fn:   testPresenceDB,
+		},
+		{
+			name: "ActivityDB",
+			fn:   testActivityDB,
+		},
+		{
+			name: "MeetupDB",
+			fn:   testMeetupDB,
+		},
+		{
+			name: "TagDB",
+			fn:   testTagDB,
+		},
+		{
+			name: "GroupDB",
+			fn:   testGroupDB,
+		},
+		{
+			name: "Searcher",
+			fn:   testSearcher,
+		},
+		{
+			name: "Broker",
+			fn:   testBroker,
+		},
+		{
+			name: "PrivacyDB",
+			fn:   testPrivacyDB,
+		},
+		{
+			name: "PreferenceDB",
+			fn:   testPreferenceDB,
+		},
+		{
+			name: "Tenant",
+			fn:   testTenant,
+		},
 	}
 
 	seedData(t, db)
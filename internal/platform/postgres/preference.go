@@ -0,0 +1,117 @@
+package pgsql
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// NewPreferenceDB returns a new PreferenceDB instance
+func NewPreferenceDB(c *pg.DB, l echo.Logger) *PreferenceDB {
+	return &PreferenceDB{c, l}
+}
+
+// PreferenceDB represents the client for preference table
+type PreferenceDB struct {
+	cl  *pg.DB
+	log echo.Logger
+}
+
+// Layers returns preferences set by user's company and by the user itself, nil where none are set
+func (p *PreferenceDB) Layers(db orm.DB, userID int) (model.Preferences, model.Preferences, error) {
+	var company, user model.Preferences
+	_, err := conn(p.cl, db).QueryOne(pg.Scan(&company, &user), `SELECT company_preferences.settings, user_preferences.settings
+	FROM users LEFT JOIN company_preferences ON company_preferences.company_id = users.company_id
+	LEFT JOIN user_preferences ON user_preferences.user_id = users.id
+	WHERE users.id = ? AND users.deleted_at IS NULL`, userID)
+	if err == pg.ErrNoRows {
+		return nil, nil, echo.ErrNotFound
+	}
+	if err != nil {
+		p.log.Warnf("PreferenceDB Error: %v", err)
+		return nil, nil, err
+	}
+	return company, user, nil
+}
+
+// Company returns preferences set for users of the company, nil if none are set
+func (p *PreferenceDB) Company(db orm.DB, companyID int) (model.Preferences, error) {
+	var cp model.CompanyPreference
+	err := conn(p.cl, db).Model(&cp).Where("company_id = ?", companyID).Select()
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		p.log.Warnf("PreferenceDB Error: %v", err)
+		return nil, err
+	}
+	return cp.Settings, nil
+}
+
+// UpdateUser merges preferences into the ones set by the user and removes the reset ones.
+// Preferences are merged by the database, so that concurrent updates of different preferences don't undo each other
+func (p *PreferenceDB) UpdateUser(db orm.DB, userID, companyID int, set model.Preferences, reset []string) (model.Preferences, error) {
+	var settings model.Preferences
+	_, err := conn(p.cl, db).QueryOne(pg.Scan(&settings), `INSERT INTO user_preferences (user_id, company_id, settings, updated_at)
+	VALUES (?, ?, ?, now()) ON CONFLICT (user_id) DO UPDATE
+	SET settings = (user_preferences.settings || EXCLUDED.settings) - ?::text[], updated_at = now()
+	RETURNING settings`, userID, companyID, nonNil(set), pg.Array(nonNilKeys(reset)))
+	if err != nil {
+		p.log.Warnf("PreferenceDB Error: %v", err)
+		return nil, err
+	}
+	return settings, nil
+}
+
+// UpdateCompany merges preferences into the ones set for users of the company and removes the reset ones
+func (p *PreferenceDB) UpdateCompany(db orm.DB, companyID int, set model.Preferences, reset []string) (model.Preferences, error) {
+	var settings model.Preferences
+	_, err := conn(p.cl, db).QueryOne(pg.Scan(&settings), `INSERT INTO company_preferences (company_id, settings, updated_at)
+	VALUES (?, ?, now()) ON CONFLICT (company_id) DO UPDATE
+	SET settings = (company_preferences.settings || EXCLUDED.settings) - ?::text[], updated_at = now()
+	RETURNING settings`, companyID, nonNil(set), pg.Array(nonNilKeys(reset)))
+	if err != nil {
+		p.log.Warnf("PreferenceDB Error: %v", err)
+		return nil, err
+	}
+	return settings, nil
+}
+
+// userPreference resolves a preference of users with the given alias to jsonb in SQL, as ResolvePreferences does:
+// user's own choice, else the one of its company, else default of the schema
+func userPreference(alias, key string) string {
+	def, _ := json.Marshal(model.PreferenceSchema[key].Default)
+	return fmt.Sprintf(`coalesce((SELECT settings->'%[2]s' FROM user_preferences WHERE user_id = %[1]s.id),
+	(SELECT settings->'%[2]s' FROM company_preferences WHERE company_id = %[1]s.company_id), '%[3]s'::jsonb)`, alias, key, def)
+}
+
+// searchable checks whether users with the given alias let others find them
+func searchable(alias string) string {
+	return userPreference(alias, "privacy.searchable") + " = 'true'::jsonb"
+}
+
+// shownEmail selects email of users with the given alias if they show it to others, NULL otherwise
+func shownEmail(alias string) string {
+	return fmt.Sprintf("CASE WHEN %s = 'true'::jsonb THEN %s.email END", userPreference(alias, "privacy.show_email"), alias)
+}
+
+// nonNil returns empty preferences in place of nil ones, which would be written as NULL and make the merge NULL
+func nonNil(p model.Preferences) model.Preferences {
+	if p == nil {
+		return model.Preferences{}
+	}
+	return p
+}
+
+// nonNilKeys returns empty keys in place of nil ones, for the same reason as nonNil
+func nonNilKeys(keys []string) []string {
+	if keys == nil {
+		return []string{}
+	}
+	return keys
+}
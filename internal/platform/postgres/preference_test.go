@@ -0,0 +1,97 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/go-pg/pg"
+)
+
+func testPreferenceDB(t *testing.T, c *pg.DB, l echo.Logger) {
+	u := &model.User{Base: model.Base{ID: 500}, FirstName: "Jane", Username: "preferring",
+		Email: "preferring@mail.com", Active: true, RoleID: 5, CompanyID: 1, LocationID: 1}
+	if err := c.Insert(u); err != nil {
+		t.Fatalf("Fail on seeding user: %v", err)
+	}
+	preferenceDB := pgsql.NewPreferenceDB(c, l)
+	cases := []struct {
+		name string
+		fn   func(*testing.T, *pgsql.PreferenceDB)
+	}{
+		{
+			name: "layers",
+			fn:   testPreferenceLayers,
+		},
+		{
+			name: "updateCompany",
+			fn:   testPreferenceUpdateCompany,
+		},
+		{
+			name: "updateUser",
+			fn:   testPreferenceUpdateUser,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, preferenceDB)
+		})
+	}
+}
+
+func testPreferenceLayers(t *testing.T, db *pgsql.PreferenceDB) {
+	if _, _, err := db.Layers(nil, 1000); err != echo.ErrNotFound {
+		t.Errorf("Expected not found for unknown user, got %v", err)
+	}
+	company, user, err := db.Layers(nil, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, company)
+	assert.Nil(t, user)
+	cp, err := db.Company(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, cp)
+}
+
+func testPreferenceUpdateCompany(t *testing.T, db *pgsql.PreferenceDB) {
+	settings, err := db.UpdateCompany(nil, 1, model.Preferences{"language": "de", "theme": "dark"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, model.Preferences{"language": "de", "theme": "dark"}, settings)
+	settings, err = db.UpdateCompany(nil, 1, nil, []string{"theme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, model.Preferences{"language": "de"}, settings)
+	cp, err := db.Company(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, model.Preferences{"language": "de"}, cp)
+}
+
+func testPreferenceUpdateUser(t *testing.T, db *pgsql.PreferenceDB) {
+	settings, err := db.UpdateUser(nil, 500, 1, model.Preferences{"timezone": "Europe/Berlin", "privacy.searchable": false}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, model.Preferences{"timezone": "Europe/Berlin", "privacy.searchable": false}, settings)
+	settings, err = db.UpdateUser(nil, 500, 1, model.Preferences{"theme": "light"}, []string{"privacy.searchable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, model.Preferences{"timezone": "Europe/Berlin", "theme": "light"}, settings)
+	company, user, err := db.Layers(nil, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, model.Preferences{"language": "de"}, company)
+	assert.Equal(t, settings, user)
+}
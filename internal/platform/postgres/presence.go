@@ -0,0 +1,61 @@
+package pgsql
+
+import (
+	"time"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// NewPresenceDB returns a new PresenceDB instance
+func NewPresenceDB(c *pg.DB, l echo.Logger) *PresenceDB {
+	return &PresenceDB{c, l}
+}
+
+// PresenceDB represents the client for presence data of users table
+type PresenceDB struct {
+	cl  *pg.DB
+	log echo.Logger
+}
+
+// Touch stores last seen times of users in a single statement. Times older than stored ones are ignored.
+// Without a connection it runs unscoped, as users of all companies are synced at once
+func (p *PresenceDB) Touch(db orm.DB, seen map[int]time.Time) error {
+	ids := make([]int, 0, len(seen))
+	times := make([]time.Time, 0, len(seen))
+	for id, t := range seen {
+		ids = append(ids, id)
+		times = append(times, t)
+	}
+	err := unscoped(p.cl, db, func(db orm.DB) error {
+		_, err := db.Exec(`UPDATE users AS u SET last_seen_at = s.seen
+		FROM unnest(?::int[], ?::timestamptz[]) AS s(id, seen)
+		WHERE u.id = s.id AND (u.last_seen_at IS NULL OR u.last_seen_at < s.seen)`, pg.Array(ids), pg.Array(times))
+		return err
+	})
+	if err != nil {
+		p.log.Warnf("PresenceDB Error: %v", err)
+	}
+	return err
+}
+
+// Watchers returns IDs of friends of a user, or none if the user hides its presence.
+// Without a connection it runs unscoped, for presence changes published in the background
+func (p *PresenceDB) Watchers(db orm.DB, userID int) ([]int, error) {
+	var ids []int
+	err := unscoped(p.cl, db, func(db orm.DB) error {
+		_, err := db.Query(&ids, `SELECT CASE WHEN f.requester_id = ?0 THEN f.addressee_id ELSE f.requester_id END
+		FROM friendships AS f JOIN users AS u ON u.id = ?0
+		WHERE f.status = ?1 AND f.deleted_at IS NULL AND (f.requester_id = ?0 OR f.addressee_id = ?0)
+		AND u.hide_presence IS NOT TRUE ORDER BY 1`, userID, model.FriendshipAccepted)
+		return err
+	})
+	if err != nil {
+		p.log.Warnf("PresenceDB Error: %v", err)
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,57 @@
+package pgsql_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/go-pg/pg"
+)
+
+func testPresenceDB(t *testing.T, c *pg.DB, l echo.Logger) {
+	for _, id := range []int{60, 61, 62, 63} {
+		u := &model.User{Base: model.Base{ID: id}, Username: fmt.Sprintf("present%d", id), Active: true, RoleID: 5, CompanyID: 1, LocationID: 1,
+			HidePresence: id == 63}
+		if err := c.Insert(u); err != nil {
+			t.Fatalf("Fail on seeding users: %v", err)
+		}
+	}
+	for _, f := range [][2]int{{60, 61}, {62, 60}, {63, 60}} {
+		if err := c.Insert(&model.Friendship{RequesterID: f[0], AddresseeID: f[1], CompanyID: 1, Status: model.FriendshipAccepted}); err != nil {
+			t.Fatalf("Fail on seeding friendships: %v", err)
+		}
+	}
+	if err := c.Insert(&model.Friendship{RequesterID: 61, AddresseeID: 62, CompanyID: 1, Status: model.FriendshipPending}); err != nil {
+		t.Fatalf("Fail on seeding friendships: %v", err)
+	}
+	pdb := pgsql.NewPresenceDB(c, l)
+
+	t.Run("touch", func(t *testing.T) {
+		assert.Nil(t, pdb.Touch(nil, map[int]time.Time{60: mock.TestTime(2018), 61: mock.TestTime(2018)}))
+		assert.Nil(t, pdb.Touch(nil, map[int]time.Time{60: mock.TestTime(2017), 61: mock.TestTime(2019)}))
+		for id, want := range map[int]time.Time{60: mock.TestTime(2018), 61: mock.TestTime(2019)} {
+			u := queryUser(t, c, id)
+			if assert.NotNil(t, u.LastSeenAt) {
+				assert.True(t, want.Equal(*u.LastSeenAt))
+			}
+		}
+	})
+
+	t.Run("watchers", func(t *testing.T) {
+		ids, err := pdb.Watchers(nil, 60)
+		assert.Nil(t, err)
+		assert.Equal(t, []int{61, 62, 63}, ids)
+		ids, err = pdb.Watchers(nil, 61)
+		assert.Nil(t, err)
+		assert.Equal(t, []int{60}, ids)
+		ids, err = pdb.Watchers(nil, 63)
+		assert.Nil(t, err)
+		assert.Empty(t, ids)
+	})
+}
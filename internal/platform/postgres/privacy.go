@@ -0,0 +1,165 @@
+package pgsql
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// NewPrivacyDB returns a new PrivacyDB instance
+func NewPrivacyDB(c *pg.DB, l echo.Logger) *PrivacyDB {
+	return &PrivacyDB{c, l}
+}
+
+// PrivacyDB represents the client for data subject requests
+type PrivacyDB struct {
+	cl  *pg.DB
+	log echo.Logger
+}
+
+// Subject returns a user that was not erased yet, deleted or not
+func (p *PrivacyDB) Subject(db orm.DB, id int) (*model.User, error) {
+	var user = new(model.User)
+	err := conn(p.cl, db).Model(user).Column("user.*", "Role").
+		Where(`"user"."id" = ?`, id).Where(`"user"."erased_at" IS NULL`).Select()
+	if err == pg.ErrNoRows {
+		return nil, echo.ErrNotFound
+	}
+	if err != nil {
+		p.log.Warnf("PrivacyDB Error: %v", err)
+		return nil, err
+	}
+	return user, nil
+}
+
+// Export assembles data held about the user. Blocks of the user by others are left out,
+// as they are personal data of those who blocked it
+func (p *PrivacyDB) Export(db orm.DB, u *model.User) (*model.DataExport, error) {
+	d := &model.DataExport{
+		GeneratedAt: time.Now(),
+		Profile:     u,
+		Session: model.DataSession{
+			LastLogin:    u.LastLogin,
+			LastSeenAt:   u.LastSeenAt,
+			RefreshToken: u.Token != "",
+		},
+	}
+	_, err := conn(p.cl, db).Query(&u.Tags, `SELECT tags.name FROM tags JOIN user_tags ON user_tags.tag_id = tags.id
+	WHERE user_tags.user_id = ? ORDER BY tags.name`, u.ID)
+	if err != nil {
+		p.log.Warnf("PrivacyDB Error: %v", err)
+		return nil, err
+	}
+	_, err = conn(p.cl, db).Query(pg.Scan(&d.Settings), "SELECT settings FROM user_preferences WHERE user_id = ?", u.ID)
+	if err != nil {
+		p.log.Warnf("PrivacyDB Error: %v", err)
+		return nil, err
+	}
+	for _, s := range []struct {
+		dst   interface{}
+		where string
+	}{
+		{&d.Preferences, "user_id = ?"},
+		{&d.Friendships, "requester_id = ?0 OR addressee_id = ?0"},
+		{&d.Blocks, "user_id = ?"},
+		{&d.Groups, "user_id = ?"},
+		{&d.RSVPs, "user_id = ?"},
+		{&d.Conversations, "user_id = ?"},
+		{&d.Messages, "sender_id = ?"},
+		{&d.Posts, "author_id = ?"},
+		{&d.Notifications, "user_id = ?"},
+		{&d.Activities, "actor_id = ?"},
+		{&d.Grants, "user_id = ?0 OR delegator_id = ?0"},
+		{&d.Erasures, "user_id = ?"},
+	} {
+		if err := conn(p.cl, db).Model(s.dst).Where(s.where, u.ID).Select(); err != nil {
+			p.log.Warnf("PrivacyDB Error: %v", err)
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// RequestErasure records request to erase the user, unless one is pending already
+func (p *PrivacyDB) RequestErasure(db orm.DB, e *model.Erasure) error {
+	n, err := conn(p.cl, db).Model((*model.Erasure)(nil)).Where("user_id = ? AND erased_at IS NULL", e.UserID).Count()
+	if err != nil {
+		p.log.Warnf("PrivacyDB Error: %v", err)
+		return err
+	}
+	if n > 0 {
+		return echo.NewHTTPError(http.StatusConflict, "Erasure was already requested.")
+	}
+	if err := conn(p.cl, db).Insert(e); err != nil {
+		p.log.Warnf("PrivacyDB Error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Erasures returns pending erasure requests, of a single company unless company id is 0, oldest first
+func (p *PrivacyDB) Erasures(db orm.DB, companyID int, pag *model.Pagination) ([]model.Erasure, error) {
+	var erasures []model.Erasure
+	q := conn(p.cl, db).Model(&erasures).Where("erased_at IS NULL").Order("id").Limit(pag.Limit).Offset(pag.Offset)
+	if companyID > 0 {
+		q.Where("company_id = ?", companyID)
+	}
+	if err := q.Select(); err != nil {
+		p.log.Warnf("PrivacyDB Error: %v", err)
+		return nil, err
+	}
+	return erasures, nil
+}
+
+// Erase anonymizes the user and deletes it, if it was not deleted already, completing pending erasure request
+// or recording a new erasure.
+// Rows of the user in purgedTables are deleted, and its messages and group posts are emptied and deleted,
+// while the rows themselves are kept for conversations and groups to stay intact.
+// Stored realtime events of the user, and those carrying its messages or friend requests to others, are deleted.
+// Without a request scoped transaction, the user is erased in a transaction of its own
+func (p *PrivacyDB) Erase(db orm.DB, u *model.User, e *model.Erasure) error {
+	if db == nil {
+		return p.cl.RunInTransaction(func(tx *pg.Tx) error {
+			return p.Erase(tx, u, e)
+		})
+	}
+	type statement struct {
+		query  string
+		params []interface{}
+	}
+	statements := []statement{
+		{anonymizeUsers, []interface{}{pg.In([]int{u.ID})}},
+		{"UPDATE messages SET body = NULL, attachments = NULL, deleted_at = coalesce(deleted_at, now()) WHERE sender_id = ?",
+			[]interface{}{u.ID}},
+		{"UPDATE group_posts SET body = NULL, deleted_at = coalesce(deleted_at, now()) WHERE author_id = ?",
+			[]interface{}{u.ID}},
+		{`DELETE FROM realtime_events WHERE user_id = ?0 OR ?0::text IN (payload->>'sender_id', payload->>'requester_id',
+		payload#>>'{data,requester_id}', payload#>>'{data,addressee_id}')`, []interface{}{u.ID}},
+	}
+	for _, t := range purgedTables {
+		statements = append(statements, statement{fmt.Sprintf("DELETE FROM %s WHERE %s = ?", t.table, t.column), []interface{}{u.ID}})
+	}
+	for _, st := range statements {
+		if _, err := db.Exec(st.query, st.params...); err != nil {
+			p.log.Warnf("PrivacyDB Error: %v", err)
+			return err
+		}
+	}
+	now := time.Now()
+	e.ErasedAt = &now
+	_, err := db.QueryOne(e, `UPDATE erasures SET erased_by = ?, erased_at = ?, updated_at = ? WHERE user_id = ? AND erased_at IS NULL
+	RETURNING *`, e.ErasedBy, now, now, u.ID)
+	if err == pg.ErrNoRows {
+		err = db.Insert(e)
+	}
+	if err != nil {
+		p.log.Warnf("PrivacyDB Error: %v", err)
+	}
+	return err
+}
@@ -0,0 +1,181 @@
+package pgsql_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/go-pg/pg"
+)
+
+func testPrivacyDB(t *testing.T, c *pg.DB, l echo.Logger) {
+	for _, id := range []int{400, 401, 402} {
+		u := &model.User{Base: model.Base{ID: id}, FirstName: "Jane", Username: fmt.Sprintf("subject%d", id),
+			Email: fmt.Sprintf("subject%d@mail.com", id), Active: true, RoleID: 5, CompanyID: 1, LocationID: 1,
+			Avatar: &model.Avatar{ID: "a", URLs: map[string]string{"small": "/uploads/avatars/a/small.jpg"}}}
+		if err := c.Insert(u); err != nil {
+			t.Fatalf("Fail on seeding users: %v", err)
+		}
+	}
+	seed := []interface{}{
+		&model.Friendship{RequesterID: 401, AddresseeID: 400, CompanyID: 1, Status: model.FriendshipAccepted},
+		&model.Block{UserID: 400, TargetID: 402, CompanyID: 1, Kind: model.BlockMute},
+		&model.Block{UserID: 402, TargetID: 400, CompanyID: 1, Kind: model.BlockFull},
+	}
+	for _, s := range seed {
+		if err := c.Insert(s); err != nil {
+			t.Fatalf("Fail on seeding relationships: %v", err)
+		}
+	}
+	privacyDB := pgsql.NewPrivacyDB(c, l)
+	cases := []struct {
+		name string
+		fn   func(*testing.T, *pgsql.PrivacyDB, *pg.DB)
+	}{
+		{
+			name: "export",
+			fn:   testPrivacyExport,
+		},
+		{
+			name: "requestErasure",
+			fn:   testPrivacyRequestErasure,
+		},
+		{
+			name: "erase",
+			fn:   testPrivacyErase,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, privacyDB, c)
+		})
+	}
+}
+
+func testPrivacyExport(t *testing.T, db *pgsql.PrivacyDB, c *pg.DB) {
+	if _, err := db.Subject(nil, 1000); err == nil {
+		t.Error("Expected error for unknown user")
+	}
+	u, err := db.Subject(nil, 400)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "subject400", u.Username)
+	assert.Equal(t, "/uploads/avatars/a/small.jpg", u.Avatar.URLs["small"])
+	assert.NotNil(t, u.Role)
+	d, err := db.Export(nil, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, u, d.Profile)
+	assert.Len(t, d.Friendships, 1)
+	assert.Len(t, d.Blocks, 1)
+	assert.Equal(t, 402, d.Blocks[0].TargetID)
+	assert.False(t, d.GeneratedAt.IsZero())
+}
+
+func testPrivacyRequestErasure(t *testing.T, db *pgsql.PrivacyDB, c *pg.DB) {
+	cases := []struct {
+		name    string
+		wantErr bool
+		req     *model.Erasure
+	}{
+		{
+			name: "Request",
+			req:  &model.Erasure{UserID: 400, CompanyID: 1, RequestedBy: 400, Reason: "Leaving"},
+		},
+		{
+			name:    "Already requested",
+			wantErr: true,
+			req:     &model.Erasure{UserID: 400, CompanyID: 1, RequestedBy: 400},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.RequestErasure(nil, tt.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+	erasures, err := db.Erasures(nil, 1, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Len(t, erasures, 1)
+	erasures, err = db.Erasures(nil, 2, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Len(t, erasures, 0)
+
+	u, err := db.Subject(nil, 400)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := db.Export(nil, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, d.Erasures, 1)
+	assert.Equal(t, "Leaving", d.Erasures[0].Reason)
+}
+
+func testPrivacyErase(t *testing.T, db *pgsql.PrivacyDB, c *pg.DB) {
+	u, err := db.Subject(nil, 400)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ev := range []string{
+		`(400, 1, 'notification', '{"id": 1}')`,
+		`(401, 1, 'message', '{"sender_id": 400, "body": "Jane here"}')`,
+		`(401, 1, 'notification', '{"type": "friend_request", "data": {"requester_id": 400}}')`,
+		`(401, 1, 'message', '{"sender_id": 402, "body": "kept"}')`,
+	} {
+		if _, err := c.Exec("INSERT INTO realtime_events (user_id, company_id, type, payload) VALUES " + ev); err != nil {
+			t.Fatalf("Fail on seeding realtime events: %v", err)
+		}
+	}
+	e := &model.Erasure{UserID: 400, CompanyID: 1, RequestedBy: 1, ErasedBy: 1}
+	if err := db.Erase(nil, u, e); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 400, e.RequestedBy, "pending request is completed")
+	assert.NotNil(t, e.ErasedAt)
+
+	erased := queryUser(t, c, 400)
+	assert.Equal(t, "erased400", erased.Username)
+	assert.Equal(t, "", erased.FirstName)
+	assert.Equal(t, "", erased.Email)
+	assert.Nil(t, erased.Avatar)
+	assert.NotNil(t, erased.DeletedAt)
+	assert.NotNil(t, erased.ErasedAt)
+	_, err = db.Subject(nil, 400)
+	assert.NotNil(t, err)
+
+	for _, q := range []string{
+		"SELECT count(*) FROM friendships WHERE requester_id = 400 OR addressee_id = 400",
+		"SELECT count(*) FROM blocks WHERE user_id = 400 OR target_id = 400",
+		"SELECT count(*) FROM realtime_events WHERE user_id = 400 OR payload::text LIKE '%400%'",
+	} {
+		var n int
+		if _, err := c.QueryOne(pg.Scan(&n), q); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 0, n, q)
+	}
+	var kept int
+	if _, err := c.QueryOne(pg.Scan(&kept), "SELECT count(*) FROM realtime_events WHERE user_id = 401"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, kept, "events of others are kept")
+	erasures, err := db.Erasures(nil, 0, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Len(t, erasures, 0)
+
+	u, err = db.Subject(nil, 401)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = &model.Erasure{UserID: 401, CompanyID: 1, RequestedBy: 1, ErasedBy: 1}
+	assert.Nil(t, db.Erase(nil, u, e))
+	assert.NotEqual(t, 0, e.ID, "erasure without request is recorded")
+}
@@ -0,0 +1,104 @@
+package pgsql
+
+import (
+	"strings"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+const (
+	// searchDocument is the weighted full-text document of a user: names weigh most, then username, email and address
+	searchDocument = `user_search_document("user".first_name, "user".last_name, "user".username, "user".email, "user".address)`
+
+	// searchName is the text typos in names are matched against, by trigram similarity
+	searchName = `user_search_name("user".first_name, "user".last_name, "user".username)`
+
+	// searchHighlight wraps words matching the query in mark tags. Matches are found in raw user text and marked
+	// with control characters, stripped from the text beforehand. The headline is HTML escaped only then,
+	// so that mark tags are the only markup in it and never land inside an entity. Opening angle brackets
+	// are held as another control character meanwhile, as ts_headline drops whatever it takes for a tag
+	searchHighlight = `replace(replace(replace(replace(replace(replace(replace(replace(ts_headline('simple',
+		translate(concat_ws(' ', "user".first_name, "user".last_name, "user".username, "user".email),
+			'<' || chr(1) || chr(2) || chr(3), chr(3)),
+		search.query, 'StartSel=' || chr(1) || ', StopSel=' || chr(2) || ', HighlightAll=true'),
+		'&', '&amp;'), chr(3), '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'),
+		chr(1), '<mark>'), chr(2), '</mark>')`
+)
+
+// CreateSearchIndex creates functions building user search documents, and full-text and trigram indexes over them.
+// Postgres keeps the indexes up to date as users change. Trigram matching needs pg_trgm extension, created if missing
+func CreateSearchIndex(db orm.DB) error {
+	queries := []string{
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		`CREATE OR REPLACE FUNCTION user_search_document(first_name text, last_name text, username text, email text, address text)
+		RETURNS tsvector AS $$
+			SELECT setweight(to_tsvector('simple', coalesce(first_name, '') || ' ' || coalesce(last_name, '')), 'A') ||
+				setweight(to_tsvector('simple', regexp_replace(coalesce(username, ''), '[^[:alnum:]]+', ' ', 'g')), 'B') ||
+				setweight(to_tsvector('simple', regexp_replace(coalesce(email, ''), '[^[:alnum:]]+', ' ', 'g')), 'C') ||
+				setweight(to_tsvector('simple', coalesce(address, '')), 'D')
+		$$ LANGUAGE SQL IMMUTABLE`,
+		`CREATE OR REPLACE FUNCTION user_search_name(first_name text, last_name text, username text) RETURNS text AS $$
+			SELECT lower(coalesce(first_name, '') || ' ' || coalesce(last_name, '') || ' ' || coalesce(username, ''))
+		$$ LANGUAGE SQL IMMUTABLE`,
+		`CREATE INDEX IF NOT EXISTS users_search_idx ON users
+		USING GIN (user_search_document(first_name, last_name, username, email, address))`,
+		`CREATE INDEX IF NOT EXISTS users_search_name_idx ON users
+		USING GIN (user_search_name(first_name, last_name, username) gin_trgm_ops)`,
+	}
+	for _, q := range queries {
+		if _, err := db.Exec(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewSearcher returns a new Searcher instance
+func NewSearcher(c *pg.DB, l echo.Logger) *Searcher {
+	return &Searcher{c, l}
+}
+
+// Searcher searches users with Postgres full-text search, using indexes created by CreateSearchIndex
+type Searcher struct {
+	cl  *pg.DB
+	log echo.Logger
+}
+
+// SearchUsers returns users having words starting with every term, or with names resembling the terms.
+// Users are ranked by weight of the matching fields, and by similarity of their names
+func (s *Searcher) SearchUsers(db orm.DB, terms []string, qp *model.ListQuery, p *model.Pagination) ([]model.SearchHit, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	prefixes := make([]string, len(terms))
+	for i, t := range terms {
+		prefixes[i] = t + ":*"
+	}
+	text := strings.Join(terms, " ")
+	var hits []model.SearchHit
+	q := conn(s.cl, db).Model((*model.User)(nil)).Column("user.*").
+		ColumnExpr("ts_rank("+searchDocument+", search.query) + word_similarity(?, "+searchName+") AS rank", text).
+		ColumnExpr(searchHighlight+" AS highlight").
+		Join("CROSS JOIN to_tsquery('simple', ?) AS search(query)", strings.Join(prefixes, " & ")).
+		Where(`"user".deleted_at IS NULL`).
+		Where("("+searchDocument+" @@ search.query OR ? <% "+searchName+")", text)
+	if qp != nil {
+		q.Where(qp.Query, qp.ID)
+		if len(qp.Exclude) > 0 {
+			q.Where(`"user".id NOT IN (?)`, pg.In(qp.Exclude))
+		}
+		if qp.Searchable {
+			q.Where(searchable(`"user"`))
+		}
+	}
+	err := q.OrderExpr(`rank DESC, "user".id`).Limit(p.Limit).Offset(p.Offset).Select(&hits)
+	if err != nil {
+		s.log.Warnf("Searcher Error: %v", err)
+		return nil, err
+	}
+	return hits, nil
+}
@@ -0,0 +1,100 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/go-pg/pg"
+)
+
+func testSearcher(t *testing.T, c *pg.DB, l echo.Logger) {
+	for _, u := range []model.User{
+		{Base: model.Base{ID: 110}, FirstName: "Anna", LastName: "Smith", Username: "asmith", Email: "anna@mail.com", CompanyID: 1, LocationID: 1, RoleID: 5},
+		{Base: model.Base{ID: 111}, FirstName: "Annabel", LastName: "Jones", Username: "bel", Email: "bel@mail.com", CompanyID: 1, LocationID: 1, RoleID: 5},
+		{Base: model.Base{ID: 112}, FirstName: "Bob", LastName: "Smithers", Username: "bob", Email: "bob@mail.com", CompanyID: 2, LocationID: 1, RoleID: 5},
+		{Base: model.Base{ID: 113}, FirstName: "<img src=x onerror=alert(1)>Mallory", LastName: "Moe", Username: "mallory", Email: "mallory@mail.com", CompanyID: 2, LocationID: 1, RoleID: 5},
+		{Base: model.Base{ID: 114}, FirstName: "Tom & Jerry", LastName: "Quot", Username: "tj", Email: "tj@mail.com", CompanyID: 2, LocationID: 1, RoleID: 5},
+	} {
+		u := u
+		if err := c.Insert(&u); err != nil {
+			t.Fatalf("Fail on seeding users: %v", err)
+		}
+	}
+	if err := c.Insert(&model.UserPreference{UserID: 111, CompanyID: 1, Settings: model.Preferences{"privacy.searchable": false}}); err != nil {
+		t.Fatalf("Fail on seeding preferences: %v", err)
+	}
+	s := pgsql.NewSearcher(c, l)
+	cases := []struct {
+		name          string
+		terms         []string
+		qp            *model.ListQuery
+		wantIDs       []int
+		wantHighlight string
+	}{
+		{
+			name: "No terms",
+		},
+		{
+			name:          "Prefixes of every term",
+			terms:         []string{"ann", "smi"},
+			wantIDs:       []int{110},
+			wantHighlight: "<mark>Anna</mark> <mark>Smith</mark>",
+		},
+		{
+			name:    "Within scope",
+			terms:   []string{"ann"},
+			qp:      &model.ListQuery{ID: 1, Query: "company_id = ?"},
+			wantIDs: []int{110, 111},
+		},
+		{
+			name:    "Searchable users",
+			terms:   []string{"ann"},
+			qp:      &model.ListQuery{ID: 1, Query: "company_id = ?", Searchable: true},
+			wantIDs: []int{110},
+		},
+		{
+			name:    "Excluded users",
+			terms:   []string{"smi"},
+			qp:      &model.ListQuery{Exclude: []int{110}},
+			wantIDs: []int{112},
+		},
+		{
+			name:          "Markup in names",
+			terms:         []string{"mallory"},
+			wantIDs:       []int{113},
+			wantHighlight: "&lt;img src=x onerror=alert(1)&gt;<mark>Mallory</mark>",
+		},
+		{
+			name:          "Terms named like entities",
+			terms:         []string{"quot"},
+			wantIDs:       []int{114},
+			wantHighlight: "Tom &amp; Jerry <mark>Quot</mark> tj tj@mail.com",
+		},
+		{
+			name:    "Typo in name",
+			terms:   []string{"smithh"},
+			qp:      &model.ListQuery{ID: 1, Query: "company_id = ?"},
+			wantIDs: []int{110},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := s.SearchUsers(nil, tt.terms, tt.qp, &model.Pagination{Limit: 10})
+			assert.Nil(t, err)
+			var ids []int
+			for _, h := range hits {
+				ids = append(ids, h.ID)
+				assert.True(t, h.Rank > 0)
+				assert.NotContains(t, h.Highlight, "<img")
+			}
+			assert.ElementsMatch(t, tt.wantIDs, ids)
+			if tt.wantHighlight != "" {
+				assert.Contains(t, hits[0].Highlight, tt.wantHighlight)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package pgsql
+
+import (
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// Weights of the reasons friend suggestions are ranked by
+const (
+	mutualWeight   = 3
+	locationWeight = 2
+	companyWeight  = 1
+)
+
+// acceptedFriendship matches friendships counted as edges between friends
+const acceptedFriendship = `status = 'accepted' AND deleted_at IS NULL`
+
+// friendEdges lists accepted friendships in both directions, of the affected users (the given ones and their friends)
+// and of their friends, which is all mutual friend counts of the affected users depend on.
+// Friendships further away are not read, so that refreshes cost as much as the users have friends
+const friendEdges = `affected AS (
+	SELECT unnest(?0::int[]) AS id
+	UNION SELECT addressee_id FROM friendships WHERE requester_id = ANY(?0::int[]) AND ` + acceptedFriendship + `
+	UNION SELECT requester_id FROM friendships WHERE addressee_id = ANY(?0::int[]) AND ` + acceptedFriendship + `
+), reached AS (
+	SELECT id FROM affected
+	UNION SELECT addressee_id FROM friendships WHERE requester_id IN (SELECT id FROM affected) AND ` + acceptedFriendship + `
+	UNION SELECT requester_id FROM friendships WHERE addressee_id IN (SELECT id FROM affected) AND ` + acceptedFriendship + `
+), edges AS (
+	SELECT requester_id AS user_id, addressee_id AS friend_id FROM friendships
+	WHERE requester_id IN (SELECT id FROM reached) AND ` + acceptedFriendship + `
+	UNION ALL
+	SELECT addressee_id, requester_id FROM friendships
+	WHERE addressee_id IN (SELECT id FROM reached) AND ` + acceptedFriendship + `
+)`
+
+// suggestionIndexes let Refresh look up friendships of given users rather than scan all of them
+var suggestionIndexes = []string{
+	`CREATE INDEX IF NOT EXISTS friendships_requester_idx ON friendships (requester_id) WHERE ` + acceptedFriendship,
+	`CREATE INDEX IF NOT EXISTS friendships_addressee_idx ON friendships (addressee_id) WHERE ` + acceptedFriendship,
+}
+
+// CreateSuggestionIndexes creates indexes friend suggestions are refreshed with
+func CreateSuggestionIndexes(db orm.DB) error {
+	for _, q := range suggestionIndexes {
+		if _, err := db.Exec(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewSuggestionDB returns a new SuggestionDB instance
+func NewSuggestionDB(c *pg.DB, l echo.Logger) *SuggestionDB {
+	return &SuggestionDB{c, l}
+}
+
+// SuggestionDB represents the client for friend suggestions, backed by mutual_friends cache table
+type SuggestionDB struct {
+	cl  *pg.DB
+	log echo.Logger
+}
+
+// List returns users suggested as friends to the user, best matches first.
+// Candidates have friends in common with the user, or share its location.
+// Friends, users with pending friend requests, blocked users and users who are not searchable are left out
+func (s *SuggestionDB) List(db orm.DB, u *model.User, p *model.Pagination) ([]model.FriendSuggestion, error) {
+	var sgs []model.FriendSuggestion
+	_, err := conn(s.cl, db).Query(&sgs, `SELECT `+publicColumns("c")+`, coalesce(m.mutual, 0) AS mutual,
+	c.location_id = ?location AS same_location, c.company_id = ?company AS same_company,
+	coalesce(m.mutual, 0) * ?mutual_weight + (c.location_id = ?location)::int * ?location_weight +
+	(c.company_id = ?company)::int * ?company_weight AS score
+	FROM users AS c LEFT JOIN mutual_friends AS m ON m.user_id = ?user AND m.candidate_id = c.id
+	WHERE c.id <> ?user AND c.deleted_at IS NULL AND c.active AND (m.mutual > 0 OR c.location_id = ?location)
+	AND `+searchable("c")+`
+	AND NOT EXISTS (SELECT 1 FROM friendships AS f WHERE f.deleted_at IS NULL AND f.status IN ('pending', 'accepted') AND
+		((f.requester_id = ?user AND f.addressee_id = c.id) OR (f.addressee_id = ?user AND f.requester_id = c.id)))
+	AND NOT EXISTS (SELECT 1 FROM blocks AS b WHERE b.deleted_at IS NULL AND b.kind = 'block' AND
+		((b.user_id = ?user AND b.target_id = c.id) OR (b.target_id = ?user AND b.user_id = c.id)))
+	ORDER BY score DESC, c.id LIMIT ?limit OFFSET ?offset`, &suggestionParams{
+		User:           u.ID,
+		Location:       u.LocationID,
+		Company:        u.CompanyID,
+		MutualWeight:   mutualWeight,
+		LocationWeight: locationWeight,
+		CompanyWeight:  companyWeight,
+		Limit:          p.Limit,
+		Offset:         p.Offset,
+	})
+	if err != nil {
+		s.log.Warnf("SuggestionDB Error: %v", err)
+		return nil, err
+	}
+	return sgs, nil
+}
+
+// Mutual returns the number of friends two users have in common, as cached by Refresh
+func (s *SuggestionDB) Mutual(db orm.DB, userID, candidateID int) (int, error) {
+	var n int
+	_, err := conn(s.cl, db).QueryOne(pg.Scan(&n), `SELECT coalesce((SELECT mutual FROM mutual_friends
+	WHERE user_id = ? AND candidate_id = ?), 0)`, userID, candidateID)
+	if err != nil {
+		s.log.Warnf("SuggestionDB Error: %v", err)
+	}
+	return n, err
+}
+
+type suggestionParams struct {
+	User           int
+	Location       int
+	Company        int
+	MutualWeight   int
+	LocationWeight int
+	CompanyWeight  int
+	Limit          int
+	Offset         int
+}
+
+// mutualCounts counts friends in common of the affected users and their candidates
+const mutualCounts = `counts AS (
+	SELECT a.user_id, b.friend_id AS candidate_id, u.company_id, count(*) AS mutual
+	FROM edges AS a JOIN edges AS b ON b.user_id = a.friend_id JOIN users AS u ON u.id = a.user_id
+	WHERE a.user_id IN (SELECT id FROM affected) AND b.friend_id <> a.user_id
+	GROUP BY a.user_id, b.friend_id, u.company_id
+)`
+
+// Refresh recomputes cached mutual friend counts of the users and of their friends.
+// It has to be called after friendships of the users change. Counts are upserted rather than
+// deleted and inserted anew, so that concurrent refreshes of the same users do not conflict
+func (s *SuggestionDB) Refresh(db orm.DB, userIDs ...int) error {
+	ids := pg.Array(userIDs)
+	_, err := conn(s.cl, db).Exec(`WITH `+friendEdges+`, `+mutualCounts+`
+	INSERT INTO mutual_friends (user_id, candidate_id, company_id, mutual)
+	SELECT user_id, candidate_id, company_id, mutual FROM counts ORDER BY user_id, candidate_id
+	ON CONFLICT (user_id, candidate_id) DO UPDATE SET company_id = EXCLUDED.company_id, mutual = EXCLUDED.mutual`, ids)
+	if err != nil {
+		s.log.Warnf("SuggestionDB Error: %v", err)
+		return err
+	}
+	_, err = conn(s.cl, db).Exec(`WITH `+friendEdges+`, `+mutualCounts+`
+	DELETE FROM mutual_friends AS m WHERE m.user_id IN (SELECT id FROM affected)
+	AND NOT EXISTS (SELECT 1 FROM counts AS n WHERE n.user_id = m.user_id AND n.candidate_id = m.candidate_id)`, ids)
+	if err != nil {
+		s.log.Warnf("SuggestionDB Error: %v", err)
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package pgsql_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/go-pg/pg"
+)
+
+func testSuggestionDB(t *testing.T, c *pg.DB, l echo.Logger) {
+	seed := []interface{}{
+		&model.Location{Base: model.Base{ID: 3}, Name: "suggestion_location", Active: true, CompanyID: 1},
+	}
+	for id, loc := range map[int]int{30: 3, 31: 1, 32: 1, 33: 1, 34: 3} {
+		seed = append(seed, &model.User{Base: model.Base{ID: id}, Username: fmt.Sprintf("suggested%d", id), Email: fmt.Sprintf("suggested%d@mail.com", id),
+			Active: true, RoleID: 5, CompanyID: 1, LocationID: loc})
+	}
+	for i, pair := range [][2]int{{30, 31}, {31, 32}, {31, 33}} {
+		seed = append(seed, &model.Friendship{Base: model.Base{ID: 30 + i}, RequesterID: pair[0], AddresseeID: pair[1], CompanyID: 1, Status: model.FriendshipAccepted})
+	}
+	seed = append(seed, &model.Block{Base: model.Base{ID: 30}, UserID: 33, TargetID: 30, CompanyID: 1, Kind: model.BlockFull})
+	for _, v := range seed {
+		if err := c.Insert(v); err != nil {
+			t.Fatalf("Fail on seeding suggestion data: %v", err)
+		}
+	}
+
+	sdb := pgsql.NewSuggestionDB(c, l)
+	me := &model.User{Base: model.Base{ID: 30}, CompanyID: 1, LocationID: 3}
+	p := &model.Pagination{Limit: 10}
+
+	if err := sdb.Refresh(nil, 30, 31); err != nil {
+		t.Fatalf("Fail on refreshing suggestions: %v", err)
+	}
+	var mutual []model.MutualFriends
+	if err := c.Model(&mutual).Where("user_id = ?", 30).Order("candidate_id").Select(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []model.MutualFriends{
+		{UserID: 30, CandidateID: 32, CompanyID: 1, Mutual: 1},
+		{UserID: 30, CandidateID: 33, CompanyID: 1, Mutual: 1},
+	}, mutual)
+
+	sgs, err := sdb.List(nil, me, p)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{32, 34}, suggestedIDs(sgs))
+	assert.Equal(t, 1, sgs[0].Mutual)
+	assert.Equal(t, 4, sgs[0].Score)
+	assert.False(t, sgs[0].SameLocation)
+	assert.True(t, sgs[1].SameLocation)
+	assert.Equal(t, 3, sgs[1].Score)
+	assert.Equal(t, "", sgs[0].Email)
+
+	n, err := sdb.Mutual(nil, 30, 32)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	n, err = sdb.Mutual(nil, 30, 34)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+
+	// Users may show their email, or keep themselves out of suggestions
+	shown := &model.UserPreference{UserID: 32, CompanyID: 1, Settings: model.Preferences{"privacy.show_email": true}}
+	hidden := &model.UserPreference{UserID: 34, CompanyID: 1, Settings: model.Preferences{"privacy.searchable": false}}
+	for _, v := range []interface{}{shown, hidden} {
+		if err := c.Insert(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sgs, err = sdb.List(nil, me, p)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{32}, suggestedIDs(sgs))
+	assert.Equal(t, "suggested32@mail.com", sgs[0].Email)
+	if err := c.Delete(hidden); err != nil {
+		t.Fatal(err)
+	}
+
+	// Friends of a former friend are no longer suggested
+	if _, err := c.Model(&model.Friendship{}).Set("deleted_at = ?", time.Now()).Where("id = ?", 30).Update(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sdb.Refresh(nil, 30, 31); err != nil {
+		t.Fatalf("Fail on refreshing suggestions: %v", err)
+	}
+	sgs, err = sdb.List(nil, me, p)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{34}, suggestedIDs(sgs))
+}
+
+func suggestedIDs(sgs []model.FriendSuggestion) []int {
+	var ids []int
+	for _, s := range sgs {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
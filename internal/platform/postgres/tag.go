@@ -0,0 +1,143 @@
+package pgsql
+
+import (
+	"net/http"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/labstack/echo"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// NewTagDB returns a new TagDB instance
+func NewTagDB(c *pg.DB, l echo.Logger) *TagDB {
+	return &TagDB{c, l}
+}
+
+// TagDB represents the client for tags and user_tags tables
+type TagDB struct {
+	cl  *pg.DB
+	log echo.Logger
+}
+
+// Create adds a tag to company's vocabulary
+func (t *TagDB) Create(db orm.DB, tag model.Tag) (*model.Tag, error) {
+	n, err := conn(t.cl, db).Model((*model.Tag)(nil)).Where("company_id = ? and name = ?", tag.CompanyID, tag.Name).
+		Where(notDeleted).Count()
+	if err != nil {
+		t.log.Warnf("TagDB Error: %v", err)
+		return nil, err
+	}
+	if n != 0 {
+		return nil, echo.NewHTTPError(http.StatusConflict, "Tag already exists.")
+	}
+	if err := conn(t.cl, db).Insert(&tag); err != nil {
+		t.log.Warnf("TagDB Error: %v", err)
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// View returns single tag by ID
+func (t *TagDB) View(db orm.DB, id int) (*model.Tag, error) {
+	var tag = &model.Tag{Base: model.Base{ID: id}}
+	err := conn(t.cl, db).Model(tag).WherePK().Where(notDeleted).Select()
+	if err != nil {
+		t.log.Warnf("TagDB Error: %v", err)
+	}
+	return tag, err
+}
+
+// List returns tag vocabulary of a company, alphabetically
+func (t *TagDB) List(db orm.DB, companyID int) ([]model.Tag, error) {
+	var tags []model.Tag
+	err := conn(t.cl, db).Model(&tags).Where("company_id = ?", companyID).Where(notDeleted).Order("name").Select()
+	if err != nil {
+		t.log.Warnf("TagDB Error: %v", err)
+	}
+	return tags, err
+}
+
+// Delete removes a tag from company's vocabulary and from users having it
+func (t *TagDB) Delete(db orm.DB, tag *model.Tag) error {
+	tag.Delete()
+	_, err := conn(t.cl, db).Model(tag).Column("deleted_at").WherePK().Update()
+	if err == nil {
+		_, err = conn(t.cl, db).Model((*model.UserTag)(nil)).Where("tag_id = ?", tag.ID).Delete()
+	}
+	if err != nil {
+		t.log.Warnf("TagDB Error: %v", err)
+	}
+	return err
+}
+
+// FindByNames returns tags of company's vocabulary with the given names
+func (t *TagDB) FindByNames(db orm.DB, companyID int, names []string) ([]model.Tag, error) {
+	var tags []model.Tag
+	if len(names) == 0 {
+		return tags, nil
+	}
+	err := conn(t.cl, db).Model(&tags).Where("company_id = ?", companyID).Where("name IN (?)", pg.In(names)).
+		Where(notDeleted).Order("name").Select()
+	if err != nil {
+		t.log.Warnf("TagDB Error: %v", err)
+	}
+	return tags, err
+}
+
+// UserTags returns tags of a user, alphabetically
+func (t *TagDB) UserTags(db orm.DB, userID int) ([]model.Tag, error) {
+	var tags []model.Tag
+	err := conn(t.cl, db).Model(&tags).Join("JOIN user_tags AS ut ON ut.tag_id = tag.id AND ut.user_id = ?", userID).
+		Where("tag.deleted_at IS NULL").Order("tag.name").Select()
+	if err != nil {
+		t.log.Warnf("TagDB Error: %v", err)
+	}
+	return tags, err
+}
+
+// SetUserTags replaces tags of a user
+func (t *TagDB) SetUserTags(db orm.DB, u *model.User, tags []model.Tag) error {
+	_, err := conn(t.cl, db).Model((*model.UserTag)(nil)).Where("user_id = ?", u.ID).Delete()
+	if err == nil && len(tags) > 0 {
+		uts := make([]model.UserTag, len(tags))
+		for i, tag := range tags {
+			uts[i] = model.UserTag{UserID: u.ID, TagID: tag.ID, CompanyID: u.CompanyID}
+		}
+		err = conn(t.cl, db).Insert(&uts)
+	}
+	if err != nil {
+		t.log.Warnf("TagDB Error: %v", err)
+	}
+	return err
+}
+
+// Discover returns active users within the scope sharing tags with the user, most shared tags first.
+// Query without condition only excludes users
+func (t *TagDB) Discover(db orm.DB, userID int, qp *model.ListQuery, p *model.Pagination) ([]model.Match, error) {
+	var ms []model.Match
+	q := conn(t.cl, db).Model((*model.User)(nil)).ColumnExpr(publicColumns(`"user"`)+", m.shared_tags, m.overlap").
+		Join(`JOIN (SELECT ut.user_id, count(*) AS overlap, array_agg(tag.name ORDER BY tag.name) AS shared_tags
+		FROM user_tags AS ut JOIN user_tags AS mine ON mine.tag_id = ut.tag_id AND mine.user_id = ?0
+		JOIN tags AS tag ON tag.id = ut.tag_id AND tag.deleted_at IS NULL
+		WHERE ut.user_id <> ?0 GROUP BY ut.user_id) AS m ON m.user_id = "user".id`, userID).
+		Where(`"user".deleted_at IS NULL AND "user".active`)
+	if qp != nil {
+		if qp.Query != "" {
+			q.Where(qp.Query, qp.ID)
+		}
+		if len(qp.Exclude) > 0 {
+			q.Where(`"user".id NOT IN (?)`, pg.In(qp.Exclude))
+		}
+		if qp.Searchable {
+			q.Where(searchable(`"user"`))
+		}
+	}
+	err := q.OrderExpr(`m.overlap DESC, "user".id`).Limit(p.Limit).Offset(p.Offset).Select(&ms)
+	if err != nil {
+		t.log.Warnf("TagDB Error: %v", err)
+		return nil, err
+	}
+	return ms, nil
+}
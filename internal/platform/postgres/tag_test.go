@@ -0,0 +1,126 @@
+package pgsql_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/go-pg/pg"
+)
+
+func testTagDB(t *testing.T, c *pg.DB, l echo.Logger) {
+	users := map[int]*model.User{}
+	for _, id := range []int{90, 91, 92, 93, 94} {
+		u := &model.User{Base: model.Base{ID: id}, Username: fmt.Sprintf("curious%d", id), Active: id != 94, RoleID: 5, CompanyID: 1, LocationID: 1}
+		if err := c.Insert(u); err != nil {
+			t.Fatalf("Fail on seeding users: %v", err)
+		}
+		users[id] = u
+	}
+	tdb := pgsql.NewTagDB(c, l)
+
+	tags := map[string]model.Tag{}
+	for _, name := range []string{"hiking", "chess", "cooking", "jazz"} {
+		tag, err := tdb.Create(nil, model.Tag{CompanyID: 1, Name: name})
+		if err != nil {
+			t.Fatalf("Fail on creating tag: %v", err)
+		}
+		tags[name] = *tag
+	}
+	_, err := tdb.Create(nil, model.Tag{CompanyID: 1, Name: "chess"})
+	assert.NotNil(t, err)
+	other, err := tdb.Create(nil, model.Tag{CompanyID: 2, Name: "chess"})
+	assert.Nil(t, err)
+
+	found, err := tdb.FindByNames(nil, 1, []string{"jazz", "chess", "unknown"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"chess", "jazz"}, model.TagNames(found))
+
+	view, err := tdb.View(nil, other.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, view.CompanyID)
+
+	set := func(id int, names ...string) {
+		var ts []model.Tag
+		for _, n := range names {
+			ts = append(ts, tags[n])
+		}
+		assert.Nil(t, tdb.SetUserTags(nil, users[id], ts))
+	}
+	set(90, "hiking", "chess", "jazz")
+	set(91, "chess")
+	set(92, "hiking", "jazz", "cooking")
+	set(93, "cooking")
+	set(94, "hiking", "chess", "jazz")
+	set(91, "chess", "hiking")
+
+	if err := c.Insert(&model.UserPreference{UserID: 92, CompanyID: 1, Settings: model.Preferences{"privacy.searchable": false}}); err != nil {
+		t.Fatalf("Fail on seeding preferences: %v", err)
+	}
+
+	mine, err := tdb.UserTags(nil, 91)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"chess", "hiking"}, model.TagNames(mine))
+
+	cases := []struct {
+		name        string
+		query       *model.ListQuery
+		wantIDs     []int
+		wantOverlap []int
+		wantShared  [][]string
+	}{
+		{
+			name:        "Ranked by overlap",
+			wantIDs:     []int{91, 92},
+			wantOverlap: []int{2, 2},
+			wantShared:  [][]string{{"chess", "hiking"}, {"hiking", "jazz"}},
+		},
+		{
+			name:        "Excluded users",
+			query:       &model.ListQuery{Query: "company_id = ?", ID: 1, Exclude: []int{91}},
+			wantIDs:     []int{92},
+			wantOverlap: []int{2},
+			wantShared:  [][]string{{"hiking", "jazz"}},
+		},
+		{
+			name:        "Searchable users",
+			query:       &model.ListQuery{Query: "company_id = ?", ID: 1, Searchable: true},
+			wantIDs:     []int{91},
+			wantOverlap: []int{2},
+			wantShared:  [][]string{{"chess", "hiking"}},
+		},
+		{
+			name:  "Out of scope",
+			query: &model.ListQuery{Query: "location_id = ?", ID: 2},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ms, err := tdb.Discover(nil, 90, tt.query, &model.Pagination{Limit: 10})
+			assert.Nil(t, err)
+			var ids, overlap []int
+			var shared [][]string
+			for _, m := range ms {
+				ids = append(ids, m.ID)
+				overlap = append(overlap, m.Overlap)
+				shared = append(shared, m.SharedTags)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			assert.Equal(t, tt.wantOverlap, overlap)
+			assert.Equal(t, tt.wantShared, shared)
+		})
+	}
+
+	jazz := tags["jazz"]
+	assert.Nil(t, tdb.Delete(nil, &jazz))
+	list, err := tdb.List(nil, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"chess", "cooking", "hiking"}, model.TagNames(list))
+	mine, err = tdb.UserTags(nil, 90)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"chess", "hiking"}, model.TagNames(mine))
+}
@@ -0,0 +1,110 @@
+package pgsql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// tenantSetting is the session variable RLS policies compare tenant columns against
+const tenantSetting = "app.company_id"
+
+// unscopedSetting is the session variable which lifts RLS policies when set to on
+const unscopedSetting = "app.unscoped"
+
+// tenantTables maps tables isolated by company to their company column
+var tenantTables = []struct{ table, column string }{
+	{"companies", "id"},
+	{"locations", "company_id"},
+	{"users", "company_id"},
+	{"role_grants", "company_id"},
+	{"friendships", "company_id"},
+	{"blocks", "company_id"},
+	{"mutual_friends", "company_id"},
+	{"conversations", "company_id"},
+	{"participants", "company_id"},
+	{"messages", "company_id"},
+	{"notifications", "company_id"},
+	{"notification_preferences", "company_id"},
+	{"activities", "company_id"},
+	{"meetups", "company_id"},
+	{"rsvps", "company_id"},
+	{"tags", "company_id"},
+	{"user_tags", "company_id"},
+	{"groups", "company_id"},
+	{"group_members", "company_id"},
+	{"group_posts", "company_id"},
+	{"erasures", "company_id"},
+	{"company_preferences", "company_id"},
+	{"user_preferences", "company_id"},
+	{"realtime_events", "company_id"},
+}
+
+// NewTenant returns a new Tenant instance
+func NewTenant(c *pg.DB) *Tenant {
+	return &Tenant{c}
+}
+
+// Tenant begins transactions isolated to a single company
+type Tenant struct {
+	cl *pg.DB
+}
+
+// Begin starts a transaction in which RLS policies restrict rows to the given company.
+// Company ID 0 starts an explicitly unscoped transaction, used for admins
+func (t *Tenant) Begin(companyID int) (model.TenantTx, error) {
+	tx, err := t.cl.Begin()
+	if err != nil {
+		return nil, err
+	}
+	setting, value := tenantSetting, strconv.Itoa(companyID)
+	if companyID == 0 {
+		setting, value = unscopedSetting, "on"
+	}
+	if _, err := tx.Exec("SELECT set_config(?, ?, true)", setting, value); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// unscoped runs maintenance work on the given connection, or if it is not set,
+// in a new transaction in which RLS policies do not restrict rows to any company
+func unscoped(cl *pg.DB, db orm.DB, fn func(orm.DB) error) error {
+	if db != nil {
+		return fn(db)
+	}
+	return cl.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec("SELECT set_config(?, 'on', true)", unscopedSetting); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+// EnableRLS installs row level security policies on tenant tables.
+// Rows are visible only to transactions scoped to their company, or explicitly unscoped ones.
+// Connections with neither set see no rows at all.
+// Policies do not apply to superusers, so the application has to connect as a regular role
+func EnableRLS(db orm.DB) error {
+	for _, t := range tenantTables {
+		check := fmt.Sprintf("current_setting('%s', true) = 'on' OR %s = nullif(current_setting('%s', true), '')::int",
+			unscopedSetting, t.column, tenantSetting)
+		queries := []string{
+			fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", t.table),
+			fmt.Sprintf("ALTER TABLE %s FORCE ROW LEVEL SECURITY", t.table),
+			fmt.Sprintf("DROP POLICY IF EXISTS tenant_isolation ON %s", t.table),
+			fmt.Sprintf("CREATE POLICY tenant_isolation ON %s USING (%s) WITH CHECK (%s)", t.table, check, check),
+		}
+		for _, q := range queries {
+			if _, err := db.Exec(q); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
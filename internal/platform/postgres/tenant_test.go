@@ -0,0 +1,135 @@
+package pgsql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/artistomin/friend4me/internal/platform/postgres"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/go-pg/pg"
+)
+
+func testTenant(t *testing.T, c *pg.DB, l echo.Logger) {
+	seed := []interface{}{
+		&model.Company{Base: model.Base{ID: 2}, Name: "other_company", Active: true},
+		&model.Location{Base: model.Base{ID: 2}, Name: "other_location", Active: true, CompanyID: 2},
+		&model.User{Base: model.Base{ID: 20}, Username: "other", Email: "other@mail.com", Active: true, RoleID: 5, CompanyID: 2, LocationID: 2},
+	}
+	for _, v := range seed {
+		if err := c.Insert(v); err != nil {
+			t.Fatalf("Fail on seeding tenant data: %v", err)
+		}
+	}
+
+	// Policies are not applied to superusers, so the checks run as a regular role
+	for _, q := range []string{
+		"CREATE ROLE tenant_app LOGIN PASSWORD 'tenant_app'",
+		"GRANT ALL ON ALL TABLES IN SCHEMA public TO tenant_app",
+		"GRANT ALL ON ALL SEQUENCES IN SCHEMA public TO tenant_app",
+	} {
+		if _, err := c.Exec(q); err != nil {
+			t.Fatalf("Fail on creating application role: %v", err)
+		}
+	}
+	opts := *c.Options()
+	opts.User, opts.Password = "tenant_app", "tenant_app"
+	app := pg.Connect(&opts)
+	defer app.Close()
+
+	tenant := pgsql.NewTenant(app)
+	userDB := pgsql.NewUserDB(app, l)
+
+	t.Run("scoped", func(t *testing.T) {
+		tx, err := tenant.Begin(1)
+		if err != nil {
+			t.Fatalf("Fail on beginning transaction: %v", err)
+		}
+		defer tx.Rollback()
+
+		// Query without company filter
+		var users []model.User
+		assert.Nil(t, tx.Model(&users).Select())
+		assert.NotEmpty(t, users)
+		for _, u := range users {
+			assert.Equal(t, 1, u.CompanyID)
+		}
+
+		listed, err := userDB.List(tx, nil, nil, &model.Pagination{Limit: 100})
+		assert.Nil(t, err)
+		for _, u := range listed {
+			assert.Equal(t, 1, u.CompanyID)
+		}
+
+		_, err = userDB.View(tx, 20)
+		assert.NotNil(t, err)
+
+		var companies []model.Company
+		assert.Nil(t, tx.Model(&companies).Select())
+		assert.Len(t, companies, 1)
+
+		var locations int
+		_, err = tx.QueryOne(pg.Scan(&locations), "SELECT count(*) FROM locations WHERE company_id = 2")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, locations)
+	})
+
+	t.Run("write to other company", func(t *testing.T) {
+		tx, err := tenant.Begin(1)
+		if err != nil {
+			t.Fatalf("Fail on beginning transaction: %v", err)
+		}
+		defer tx.Rollback()
+		err = tx.Insert(&model.User{Base: model.Base{ID: 21}, Username: "intruder", RoleID: 5, CompanyID: 2, LocationID: 2})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("update other company", func(t *testing.T) {
+		tx, err := tenant.Begin(1)
+		if err != nil {
+			t.Fatalf("Fail on beginning transaction: %v", err)
+		}
+		defer tx.Rollback()
+		res, err := tx.Exec("UPDATE users SET active = false WHERE id = 20")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, res.RowsAffected())
+	})
+
+	t.Run("no scope", func(t *testing.T) {
+		// Connections which did not set a scope see nothing
+		var users []model.User
+		assert.Nil(t, app.Model(&users).Select())
+		assert.Empty(t, users)
+		_, err := userDB.View(nil, 1)
+		assert.NotNil(t, err)
+		err = app.Insert(&model.User{Base: model.Base{ID: 22}, Username: "unscoped", RoleID: 5, CompanyID: 1, LocationID: 1})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("maintenance", func(t *testing.T) {
+		grants, err := pgsql.NewGrantDB(app, l).ListActive(nil, 20, time.Now())
+		assert.Nil(t, err)
+		assert.Empty(t, grants)
+		watchers, err := pgsql.NewPresenceDB(app, l).Watchers(nil, 20)
+		assert.Nil(t, err)
+		assert.Empty(t, watchers)
+		assert.Nil(t, pgsql.NewPresenceDB(app, l).Touch(nil, map[int]time.Time{20: time.Now()}))
+		var seen int
+		_, err = c.QueryOne(pg.Scan(&seen), "SELECT count(*) FROM users WHERE id = 20 AND last_seen_at IS NOT NULL")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, seen)
+	})
+
+	t.Run("unscoped", func(t *testing.T) {
+		tx, err := tenant.Begin(0)
+		if err != nil {
+			t.Fatalf("Fail on beginning transaction: %v", err)
+		}
+		defer tx.Rollback()
+		var companies []model.Company
+		assert.Nil(t, tx.Model(&companies).Select())
+		assert.Len(t, companies, 2)
+	})
+}
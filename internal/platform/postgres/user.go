@@ -1,10 +1,15 @@
 package pgsql
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/artistomin/friend4me/internal"
 	"github.com/labstack/echo"
 
 	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
 )
 
 // NewUserDB returns a new UserDB instance
@@ -19,12 +24,12 @@ type UserDB struct {
 }
 
 // View returns single user by ID
-func (u *UserDB) View(id int) (*model.User, error) {
+func (u *UserDB) View(db orm.DB, id int) (*model.User, error) {
 	var user = new(model.User)
 	sql := `SELECT "user".*, "role"."id" AS "role__id", "role"."access_level" AS "role__access_level", "role"."name" AS "role__name" 
 	FROM "users" AS "user" LEFT JOIN "roles" AS "role" ON "role"."id" = "user"."role_id" 
 	WHERE ("user"."id" = ? and deleted_at is null)`
-	_, err := u.cl.QueryOne(user, sql, id)
+	_, err := conn(u.cl, db).QueryOne(user, sql, id)
 	if err != nil {
 		u.log.Warnf("AccountDB Error: %v", err)
 	}
@@ -32,12 +37,12 @@ func (u *UserDB) View(id int) (*model.User, error) {
 }
 
 // FindByUsername queries for single user by username
-func (u *UserDB) FindByUsername(uname string) (*model.User, error) {
+func (u *UserDB) FindByUsername(db orm.DB, uname string) (*model.User, error) {
 	var user = new(model.User)
 	sql := `SELECT "user".*, "role"."id" AS "role__id", "role"."access_level" AS "role__access_level", "role"."name" AS "role__name" 
 	FROM "users" AS "user" LEFT JOIN "roles" AS "role" ON "role"."id" = "user"."role_id" 
 	WHERE ("user"."username" = ? and deleted_at is null)`
-	_, err := u.cl.QueryOne(user, sql, uname)
+	_, err := conn(u.cl, db).QueryOne(user, sql, uname)
 	if err != nil {
 		u.log.Warnf("UserDB Error: %v", err)
 	}
@@ -45,47 +50,336 @@ func (u *UserDB) FindByUsername(uname string) (*model.User, error) {
 }
 
 // FindByToken queries for single user by token
-func (u *UserDB) FindByToken(token string) (*model.User, error) {
+func (u *UserDB) FindByToken(db orm.DB, token string) (*model.User, error) {
 	var user = new(model.User)
 	sql := `SELECT "user".*, "role"."id" AS "role__id", "role"."access_level" AS "role__access_level", "role"."name" AS "role__name" 
 	FROM "users" AS "user" LEFT JOIN "roles" AS "role" ON "role"."id" = "user"."role_id" 
 	WHERE ("user"."token" = ? and deleted_at is null)`
-	_, err := u.cl.QueryOne(user, sql, token)
+	_, err := conn(u.cl, db).QueryOne(user, sql, token)
 	if err != nil {
 		u.log.Warnf("UserDB Error: %v", err)
 	}
 	return user, err
 }
 
-// List returns list of all users retreivable for the current user, depending on role
-func (u *UserDB) List(qp *model.ListQuery, p *model.Pagination) ([]model.User, error) {
+// List returns list of all users retreivable for the current user, depending on role, narrowed by the filter.
+// Users are paged by keyset when pagination has a cursor, and counted when asked to
+func (u *UserDB) List(db orm.DB, qp *model.ListQuery, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
 	var users []model.User
-	q := u.cl.Model(&users).Column("user.*", "Role").Limit(p.Limit).Offset(p.Offset).Where(notDeleted).Order("user.id desc")
+	q := conn(u.cl, db).Model(&users).Column("user.*", "Role").Limit(p.Limit).Where(notDeleted)
 	if qp != nil {
 		q.Where(qp.Query, qp.ID)
+		if len(qp.Exclude) > 0 {
+			q.Where(`"user"."id" NOT IN (?)`, pg.In(qp.Exclude))
+		}
+	}
+	if f == nil {
+		f = new(model.UserFilter)
+	}
+	filterUsers(q, f)
+	if p.Count {
+		total, err := q.Count()
+		if err != nil {
+			u.log.Warnf("UserDB Error: %v", err)
+			return nil, err
+		}
+		p.Total = total
+	}
+	order := f.Order()
+	reverse := p.Cursor != nil && p.Cursor.Prev
+	if p.Cursor != nil {
+		keyset(q, order, p.Cursor)
+	} else {
+		q.Offset(p.Offset)
+	}
+	for _, s := range order {
+		q.Order("user." + s.Field + " " + direction(s, reverse))
 	}
 	if err := q.Select(); err != nil {
 		u.log.Warnf("UserDB Error: %v", err)
 		return nil, err
 	}
+	if reverse {
+		for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+			users[i], users[j] = users[j], users[i]
+		}
+	}
 	return users, nil
 }
 
+// direction returns sort direction of the field. NULLs come last, and first when the order is reversed
+// to list rows preceding a cursor nearest first
+func direction(s model.UserSort, reverse bool) string {
+	dir, nulls := "ASC", " NULLS LAST"
+	if s.Desc != reverse {
+		dir = "DESC"
+	}
+	if reverse {
+		nulls = " NULLS FIRST"
+	}
+	return dir + nulls
+}
+
+// keyset narrows user list query to rows following the cursor in the order, or preceding it for previous page cursors.
+// A row follows the cursor when it has the same values in leading fields and a following value in the next one
+func keyset(q *orm.Query, order []model.UserSort, cur *model.Cursor) {
+	q.WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+		for i := range order {
+			if cur.Values[i] == nil && !cur.Prev {
+				// NULLs come last, nothing follows them
+				continue
+			}
+			i := i
+			q.WhereOrGroup(func(q *orm.Query) (*orm.Query, error) {
+				for j, s := range order[:i] {
+					if v := cur.Values[j]; v != nil {
+						q.Where("? = ?", pg.F("user."+s.Field), *v)
+					} else {
+						q.Where("? IS NULL", pg.F("user."+s.Field))
+					}
+				}
+				col, v, greater := pg.F("user."+order[i].Field), cur.Values[i], order[i].Desc == cur.Prev
+				switch {
+				case v == nil:
+					q.Where("? IS NOT NULL", col)
+				case cur.Prev && greater:
+					q.Where("? > ?", col, *v)
+				case cur.Prev:
+					q.Where("? < ?", col, *v)
+				case greater:
+					q.Where("(? > ? OR ? IS NULL)", col, *v, col)
+				default:
+					q.Where("(? < ? OR ? IS NULL)", col, *v, col)
+				}
+				return q, nil
+			})
+		}
+		return q, nil
+	})
+}
+
+// likeEscaper escapes LIKE wildcards of user supplied search terms
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// filterUsers narrows user list query by the filter
+func filterUsers(q *orm.Query, f *model.UserFilter) {
+	if len(f.IDs) > 0 {
+		q.Where(`"user"."id" IN (?)`, pg.In(f.IDs))
+	}
+	if f.Active != nil {
+		q.Where(`"user"."active" = ?`, *f.Active)
+	}
+	if f.RoleID > 0 {
+		q.Where(`"user"."role_id" = ?`, f.RoleID)
+	}
+	if f.CompanyID > 0 {
+		q.Where(`"user"."company_id" = ?`, f.CompanyID)
+	}
+	if f.LocationID > 0 {
+		q.Where(`"user"."location_id" = ?`, f.LocationID)
+	}
+	if f.CreatedAfter != nil {
+		q.Where(`"user"."created_at" >= ?`, *f.CreatedAfter)
+	}
+	if f.CreatedBefore != nil {
+		q.Where(`"user"."created_at" < ?`, *f.CreatedBefore)
+	}
+	if f.LastLoginAfter != nil {
+		q.Where(`"user"."last_login" >= ?`, *f.LastLoginAfter)
+	}
+	if f.LastLoginBefore != nil {
+		q.Where(`"user"."last_login" < ?`, *f.LastLoginBefore)
+	}
+	if f.Search != "" {
+		q.Where(`("user"."first_name" ILIKE ?0 OR "user"."last_name" ILIKE ?0 OR "user"."username" ILIKE ?0 OR "user"."email" ILIKE ?0)`,
+			"%"+likeEscaper.Replace(f.Search)+"%")
+	}
+}
+
 // Delete sets deleted_at for a user
-func (u *UserDB) Delete(user *model.User) error {
+func (u *UserDB) Delete(db orm.DB, user *model.User) error {
 	user.Delete()
-	_, err := u.cl.Model(user).Column("deleted_at").WherePK().Update()
+	_, err := conn(u.cl, db).Model(user).Column("deleted_at").WherePK().Update()
 	if err != nil {
 		u.log.Warnf("UserDB Error: %v", err)
 	}
 	return err
 }
 
+// ListDeleted returns deleted users retreivable for the current user, depending on role, narrowed by the filter.
+// Users are listed most recently deleted first
+func (u *UserDB) ListDeleted(db orm.DB, qp *model.ListQuery, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
+	var users []model.User
+	q := conn(u.cl, db).Model(&users).Column("user.*", "Role").Where(`"user"."deleted_at" IS NOT NULL AND "user"."erased_at" IS NULL`).
+		Order("user.deleted_at DESC", "user.id DESC").Limit(p.Limit).Offset(p.Offset)
+	if qp != nil {
+		q.Where(qp.Query, qp.ID)
+	}
+	if f != nil {
+		filterUsers(q, f)
+	}
+	if err := q.Select(); err != nil {
+		u.log.Warnf("UserDB Error: %v", err)
+		return nil, err
+	}
+	return users, nil
+}
+
+// Restore undeletes a user. Unique indexes keep usernames and emails of deleted users from being taken meanwhile
+func (u *UserDB) Restore(db orm.DB, user *model.User) error {
+	user.DeletedAt = nil
+	if _, err := conn(u.cl, db).Model(user).Set("deleted_at = NULL, updated_at = now()").WherePK().Update(); err != nil {
+		u.log.Warnf("UserDB Error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// purgedTables maps tables holding rows that belong to users to their user columns, deleted together with purged users.
+// Messages, posts, groups and meetups are shared with other users, and are kept
+var purgedTables = []userColumn{
+	{"user_tags", "user_id"},
+	{"role_grants", "user_id"},
+	{"friendships", "requester_id"},
+	{"friendships", "addressee_id"},
+	{"blocks", "user_id"},
+	{"blocks", "target_id"},
+	{"mutual_friends", "user_id"},
+	{"mutual_friends", "candidate_id"},
+	{"group_members", "user_id"},
+	{"rsvps", "user_id"},
+	{"notifications", "user_id"},
+	{"notification_preferences", "user_id"},
+	{"user_preferences", "user_id"},
+	{"activities", "actor_id"},
+}
+
+// sharedTables maps tables holding rows shared with other users to columns referring to users who made them.
+// Purged users still referred to are anonymized rather than deleted, so that the rows keep referring to a user
+var sharedTables = []userColumn{
+	{"conversations", "creator_id"},
+	{"messages", "sender_id"},
+	{"groups", "owner_id"},
+	{"group_posts", "author_id"},
+	{"meetups", "organizer_id"},
+	{"role_grants", "delegator_id"},
+	{"erasures", "requested_by"},
+	{"erasures", "erased_by"},
+}
+
+// userColumn represents a table column referring to users
+type userColumn struct{ table, column string }
+
+// anonymizeUsers clears personal fields of users, renaming them after their IDs and marking them erased
+const anonymizeUsers = `UPDATE users SET first_name = NULL, last_name = NULL, username = 'erased' || id, password = NULL, email = NULL,
+	mobile = NULL, phone = NULL, address = NULL, bio = NULL, avatar = NULL, token = NULL, last_login = NULL, last_seen_at = NULL,
+	active = FALSE, hide_presence = TRUE, deleted_at = coalesce(deleted_at, now()), erased_at = now(), updated_at = now()
+	WHERE id IN (?)`
+
+// Purge permanently removes users deleted before the given time, along with their rows in purgedTables
+// and their participation in conversations, returning IDs and avatars of purged users, oldest first.
+// Erased users are kept anonymized, and so are purged users still referred to by rows in sharedTables.
+// Without a request scoped transaction, users of all companies are purged in an unscoped transaction of their own
+func (u *UserDB) Purge(db orm.DB, before time.Time) ([]model.User, error) {
+	if db == nil {
+		var users []model.User
+		err := unscoped(u.cl, nil, func(tx orm.DB) error {
+			var err error
+			users, err = u.Purge(tx, before)
+			return err
+		})
+		return users, err
+	}
+	var users []model.User
+	if _, err := db.Query(&users, "SELECT id, avatar FROM users WHERE deleted_at < ? AND erased_at IS NULL ORDER BY id", before); err != nil {
+		u.log.Warnf("UserDB Error: %v", err)
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+	ids := make([]int, len(users))
+	for i := range users {
+		ids[i] = users[i].ID
+	}
+	// Erased users stay in their conversations, purged ones leave them
+	tables := make([]userColumn, len(purgedTables), len(purgedTables)+1)
+	copy(tables, purgedTables)
+	tables = append(tables, userColumn{"participants", "user_id"})
+	for _, t := range tables {
+		if _, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s IN (?)", t.table, t.column), pg.In(ids)); err != nil {
+			u.log.Warnf("UserDB Error: %v", err)
+			return nil, err
+		}
+	}
+	referred := make([]string, len(sharedTables))
+	for i, t := range sharedTables {
+		referred[i] = fmt.Sprintf("EXISTS (SELECT 1 FROM %s WHERE %s = users.id)", t.table, t.column)
+	}
+	var kept []int
+	if _, err := db.Query(&kept, "SELECT id FROM users WHERE id IN (?) AND ("+strings.Join(referred, " OR ")+")", pg.In(ids)); err != nil {
+		u.log.Warnf("UserDB Error: %v", err)
+		return nil, err
+	}
+	if len(kept) > 0 {
+		if _, err := db.Exec(anonymizeUsers, pg.In(kept)); err != nil {
+			u.log.Warnf("UserDB Error: %v", err)
+			return nil, err
+		}
+	}
+	if _, err := db.Exec("DELETE FROM users WHERE id IN (?) AND erased_at IS NULL", pg.In(ids)); err != nil {
+		u.log.Warnf("UserDB Error: %v", err)
+		return nil, err
+	}
+	return users, nil
+}
+
 // Update updates user's contact info
-func (u *UserDB) Update(user *model.User) (*model.User, error) {
-	_, err := u.cl.Model(user).WherePK().Update()
+func (u *UserDB) Update(db orm.DB, user *model.User) (*model.User, error) {
+	_, err := conn(u.cl, db).Model(user).WherePK().Update()
 	if err != nil {
 		u.log.Warnf("UserDB Error: %v", err)
 	}
 	return user, err
 }
+
+// Bulk applies the change to users by their ids in a single statement.
+// Columns are set explicitly, as updating the model would store false as NULL
+func (u *UserDB) Bulk(db orm.DB, ids []int, ch model.BulkChange) error {
+	q := conn(u.cl, db).Model((*model.User)(nil)).Set("updated_at = now()").
+		Where("id IN (?)", pg.In(ids)).Where(notDeleted)
+	switch ch.Action {
+	case model.BulkActivate:
+		q.Set("active = TRUE")
+	case model.BulkDeactivate:
+		q.Set("active = FALSE")
+	case model.BulkMoveLocation:
+		q.Set("location_id = ?", ch.LocationID)
+	case model.BulkChangeRole:
+		q.Set("role_id = ?", ch.RoleID)
+	case model.BulkDelete:
+		q.Set("deleted_at = now()")
+	default:
+		return model.ErrGeneric
+	}
+	_, err := q.Update()
+	if err != nil {
+		u.log.Warnf("UserDB Error: %v", err)
+	}
+	return err
+}
+
+// publicColumns selects columns of model.PublicUser from the users table with the given alias
+func publicColumns(alias string) string {
+	return fmt.Sprintf("%[1]s.id, %[1]s.first_name, %[1]s.last_name, %[2]s AS email, %[1]s.bio, %[1]s.avatar", alias, shownEmail(alias))
+}
+
+// Location returns single location by ID
+func (u *UserDB) Location(db orm.DB, id int) (*model.Location, error) {
+	var l = &model.Location{Base: model.Base{ID: id}}
+	err := conn(u.cl, db).Model(l).WherePK().Where(notDeleted).Select()
+	if err != nil {
+		u.log.Warnf("UserDB Error: %v", err)
+	}
+	return l, err
+}
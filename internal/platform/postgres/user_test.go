@@ -1,7 +1,9 @@
 package pgsql_test
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/artistomin/friend4me/internal/platform/postgres"
 	"github.com/labstack/echo"
@@ -42,6 +44,18 @@ func testUserDB(t *testing.T, c *pg.DB, l echo.Logger) {
 			name: "update",
 			fn:   testUserUpdate,
 		},
+		{
+			name: "bulk",
+			fn:   testUserBulk,
+		},
+		{
+			name: "deleted",
+			fn:   testUserDeleted,
+		},
+		{
+			name: "location",
+			fn:   testUserLocation,
+		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -88,7 +102,7 @@ func testUserView(t *testing.T, db *pgsql.UserDB, c *pg.DB) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			user, err := db.View(tt.id)
+			user, err := db.View(nil, tt.id)
 			assert.Equal(t, tt.wantErr, err != nil)
 			if tt.wantData != nil {
 				tt.wantData.CreatedAt = user.CreatedAt
@@ -136,7 +150,7 @@ func testUserFindByUsername(t *testing.T, db *pgsql.UserDB, c *pg.DB) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			user, err := db.FindByUsername(tt.username)
+			user, err := db.FindByUsername(nil, tt.username)
 			assert.Equal(t, tt.wantErr, err != nil)
 
 			if tt.wantData != nil {
@@ -187,7 +201,7 @@ func testUserFindByToken(t *testing.T, db *pgsql.UserDB, c *pg.DB) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			user, err := db.FindByToken(tt.token)
+			user, err := db.FindByToken(nil, tt.token)
 			assert.Equal(t, tt.wantErr, err != nil)
 
 			if tt.wantData != nil {
@@ -202,11 +216,14 @@ func testUserFindByToken(t *testing.T, db *pgsql.UserDB, c *pg.DB) {
 
 func testUserList(t *testing.T, db *pgsql.UserDB, c *pg.DB) {
 	cases := []struct {
-		name     string
-		wantErr  bool
-		qp       *model.ListQuery
-		pg       *model.Pagination
-		wantData []model.User
+		name      string
+		wantErr   bool
+		qp        *model.ListQuery
+		filter    *model.UserFilter
+		pg        *model.Pagination
+		wantIDs   []int
+		wantTotal int
+		wantData  []model.User
 	}{
 		{
 			name:    "Invalid pagination values",
@@ -265,11 +282,130 @@ func testUserList(t *testing.T, db *pgsql.UserDB, c *pg.DB) {
 				},
 			},
 		},
+		{
+			name: "Excluded users",
+			pg: &model.Pagination{
+				Limit:  100,
+				Offset: 0,
+			},
+			qp: &model.ListQuery{
+				ID:      1,
+				Query:   "company_id = ?",
+				Exclude: []int{2},
+			},
+			wantData: []model.User{
+				{
+					Email:      "johndoe@mail.com",
+					FirstName:  "John",
+					LastName:   "Doe",
+					Username:   "johndoe",
+					RoleID:     1,
+					CompanyID:  1,
+					LocationID: 1,
+					Password:   "hunter2",
+					Base: model.Base{
+						ID: 1,
+					},
+					Role: &model.Role{
+						ID:          1,
+						AccessLevel: 1,
+						Name:        "SUPER_ADMIN",
+					},
+					Token: "loginrefresh",
+				},
+			},
+		},
+		{
+			name:    "Search",
+			pg:      &model.Pagination{Limit: 100},
+			qp:      &model.ListQuery{ID: 1, Query: "company_id = ?"},
+			filter:  &model.UserFilter{Search: "JONES@"},
+			wantIDs: []int{2},
+		},
+		{
+			name:    "By ids",
+			pg:      &model.Pagination{Limit: 100},
+			filter:  &model.UserFilter{IDs: []int{2, 1000}},
+			wantIDs: []int{2},
+		},
+		{
+			name:    "Search wildcards literally",
+			pg:      &model.Pagination{Limit: 100},
+			filter:  &model.UserFilter{Search: "%"},
+			wantIDs: nil,
+		},
+		{
+			name:    "Filtered out of scope",
+			pg:      &model.Pagination{Limit: 100},
+			qp:      &model.ListQuery{ID: 1, Query: "company_id = ?"},
+			filter:  &model.UserFilter{CompanyID: 2},
+			wantIDs: nil,
+		},
+		{
+			name: "Sorted",
+			pg:   &model.Pagination{Limit: 100},
+			qp:   &model.ListQuery{ID: 1, Query: "company_id = ?"},
+			filter: &model.UserFilter{
+				RoleID: 1,
+				Sort:   []model.UserSort{{Field: "last_name", Desc: true}, {Field: "password"}},
+			},
+			wantIDs: []int{2, 1},
+		},
+		{
+			name:      "Counted",
+			pg:        &model.Pagination{Limit: 1, Count: true},
+			qp:        &model.ListQuery{ID: 1, Query: "company_id = ?"},
+			filter:    &model.UserFilter{},
+			wantIDs:   []int{2},
+			wantTotal: 2,
+		},
+		{
+			name:    "Next page",
+			pg:      &model.Pagination{Limit: 1, Cursor: &model.Cursor{Order: "-id", Values: []*string{mock.Str2Ptr("2")}}},
+			qp:      &model.ListQuery{ID: 1, Query: "company_id = ?"},
+			filter:  &model.UserFilter{},
+			wantIDs: []int{1},
+		},
+		{
+			name:    "Previous page",
+			pg:      &model.Pagination{Limit: 5, Cursor: &model.Cursor{Order: "-id", Values: []*string{mock.Str2Ptr("1")}, Prev: true}},
+			qp:      &model.ListQuery{ID: 1, Query: "company_id = ?"},
+			filter:  &model.UserFilter{},
+			wantIDs: []int{2},
+		},
+		{
+			name: "Next page of sorted",
+			pg: &model.Pagination{Limit: 5, Cursor: &model.Cursor{Order: "last_login,last_name,-id",
+				Values: []*string{nil, mock.Str2Ptr("Doe"), mock.Str2Ptr("1")}}},
+			qp: &model.ListQuery{ID: 1, Query: "company_id = ?"},
+			filter: &model.UserFilter{
+				Sort: []model.UserSort{{Field: "last_login"}, {Field: "last_name"}},
+			},
+			wantIDs: []int{2},
+		},
+		{
+			name: "Created range",
+			pg:   &model.Pagination{Limit: 100},
+			qp:   &model.ListQuery{ID: 1, Query: "company_id = ?"},
+			filter: &model.UserFilter{
+				CreatedBefore: mock.TestTimePtr(2000),
+				Sort:          []model.UserSort{{Field: "id"}},
+			},
+			wantIDs: nil,
+		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			users, err := db.List(tt.qp, tt.pg)
+			users, err := db.List(nil, tt.qp, tt.filter, tt.pg)
 			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.filter != nil {
+				var ids []int
+				for _, v := range users {
+					ids = append(ids, v.ID)
+				}
+				assert.Equal(t, tt.wantIDs, ids)
+				assert.Equal(t, tt.wantTotal, tt.pg.Total)
+			}
 			if tt.wantData != nil {
 				for i, v := range users {
 					tt.wantData[i].CreatedAt = v.CreatedAt
@@ -323,7 +459,7 @@ func testUserDelete(t *testing.T, db *pgsql.UserDB, c *pg.DB) {
 			if tt.wantData != nil {
 				userBefore = queryUser(t, c, tt.usr.Base.ID)
 			}
-			err := db.Delete(tt.usr)
+			err := db.Delete(nil, tt.usr)
 			assert.Equal(t, tt.wantErr, err != nil)
 
 			if tt.wantData != nil {
@@ -395,7 +531,7 @@ func testUserUpdate(t *testing.T, db *pgsql.UserDB, c *pg.DB) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			resp, err := db.Update(tt.usr)
+			resp, err := db.Update(nil, tt.usr)
 			assert.Equal(t, tt.wantErr, err != nil)
 			if tt.wantData != nil {
 				tt.wantData.UpdatedAt = resp.UpdatedAt
@@ -407,3 +543,151 @@ func testUserUpdate(t *testing.T, db *pgsql.UserDB, c *pg.DB) {
 		})
 	}
 }
+
+func testUserBulk(t *testing.T, db *pgsql.UserDB, c *pg.DB) {
+	for _, id := range []int{200, 201, 202} {
+		u := &model.User{Base: model.Base{ID: id}, Username: fmt.Sprintf("bulk%d", id), Active: true, RoleID: 5, CompanyID: 1, LocationID: 1}
+		if err := c.Insert(u); err != nil {
+			t.Fatalf("Fail on seeding users: %v", err)
+		}
+	}
+	cases := []struct {
+		name    string
+		change  model.BulkChange
+		wantErr bool
+		check   func(*testing.T, *model.User)
+	}{
+		{
+			name:    "Unknown action",
+			change:  model.BulkChange{Action: "archive"},
+			wantErr: true,
+		},
+		{
+			name:   "Deactivate",
+			change: model.BulkChange{Action: model.BulkDeactivate},
+			check: func(t *testing.T, u *model.User) {
+				assert.False(t, u.Active)
+			},
+		},
+		{
+			name:   "Move location",
+			change: model.BulkChange{Action: model.BulkMoveLocation, LocationID: 2},
+			check: func(t *testing.T, u *model.User) {
+				assert.Equal(t, 2, u.LocationID)
+			},
+		},
+		{
+			name:   "Change role",
+			change: model.BulkChange{Action: model.BulkChangeRole, RoleID: 4},
+			check: func(t *testing.T, u *model.User) {
+				assert.Equal(t, 4, u.RoleID)
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.Bulk(nil, []int{200, 201}, tt.change)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.check == nil {
+				return
+			}
+			for _, id := range []int{200, 201} {
+				u, err := db.View(nil, id)
+				assert.Nil(t, err)
+				tt.check(t, u)
+			}
+		})
+	}
+	active := false
+	list, err := db.List(nil, nil, &model.UserFilter{IDs: []int{200, 201, 202}, Active: &active}, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(list))
+
+	assert.Nil(t, db.Bulk(nil, []int{200}, model.BulkChange{Action: model.BulkDelete}))
+	_, err = db.View(nil, 200)
+	assert.NotNil(t, err)
+	u, err := db.View(nil, 202)
+	assert.Nil(t, err)
+	assert.True(t, u.Active)
+	assert.Equal(t, 1, u.LocationID)
+}
+
+func testUserDeleted(t *testing.T, db *pgsql.UserDB, c *pg.DB) {
+	for _, id := range []int{300, 301, 302, 303} {
+		u := &model.User{Base: model.Base{ID: id}, Username: fmt.Sprintf("deleted%d", id), Email: fmt.Sprintf("deleted%d@mail.com", id),
+			Active: true, RoleID: 5, CompanyID: 1, LocationID: 1}
+		if err := c.Insert(u); err != nil {
+			t.Fatalf("Fail on seeding users: %v", err)
+		}
+		if id != 302 {
+			assert.Nil(t, db.Delete(nil, u))
+		}
+	}
+	if _, err := c.Exec("UPDATE users SET deleted_at = now() - interval '40 days' WHERE id IN (301, 303)"); err != nil {
+		t.Fatalf("Fail on backdating deletion: %v", err)
+	}
+	if err := c.Insert(&model.UserTag{UserID: 301, TagID: 1, CompanyID: 1}); err != nil {
+		t.Fatalf("Fail on seeding tags: %v", err)
+	}
+	if err := c.Insert(&model.Participant{ConversationID: 1, UserID: 301, CompanyID: 1}); err != nil {
+		t.Fatalf("Fail on seeding participants: %v", err)
+	}
+	if err := c.Insert(&model.Message{ConversationID: 1, SenderID: 303, CompanyID: 1, Body: "kept"}); err != nil {
+		t.Fatalf("Fail on seeding messages: %v", err)
+	}
+	if _, err := c.Exec(`UPDATE users SET avatar = '{"id": "a301", "urls": {"small": "/small.jpg"}}' WHERE id = 301`); err != nil {
+		t.Fatalf("Fail on setting avatar: %v", err)
+	}
+
+	list, err := db.ListDeleted(nil, &model.ListQuery{ID: 1, Query: "company_id = ?"}, &model.UserFilter{IDs: []int{300, 301, 302}}, &model.Pagination{Limit: 10})
+	assert.Nil(t, err)
+	var ids []int
+	for _, u := range list {
+		ids = append(ids, u.ID)
+	}
+	assert.Equal(t, []int{300, 301}, ids)
+
+	assert.Nil(t, db.Restore(nil, &list[0]))
+	u, err := db.View(nil, 300)
+	assert.Nil(t, err)
+	assert.Nil(t, u.DeletedAt)
+
+	// Deleted users keep their usernames, which no one else may take meanwhile
+	_, err = c.Exec("UPDATE users SET username = 'DELETED301' WHERE id = 302")
+	assert.NotNil(t, err)
+
+	purged, err := db.Purge(nil, time.Now().AddDate(0, 0, -30))
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(purged)) {
+		assert.Equal(t, 301, purged[0].ID)
+		assert.Equal(t, &model.Avatar{ID: "a301", URLs: map[string]string{"small": "/small.jpg"}}, purged[0].Avatar)
+		assert.Equal(t, 303, purged[1].ID)
+	}
+	// Users who sent messages are anonymized instead, so that messages keep their sender
+	anonymized := queryUser(t, c, 303)
+	assert.Equal(t, "erased303", anonymized.Username)
+	assert.Equal(t, "", anonymized.Email)
+	assert.NotNil(t, anonymized.ErasedAt)
+	var body string
+	if _, err := c.QueryOne(pg.Scan(&body), "SELECT body FROM messages WHERE sender_id = 303"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "kept", body)
+	count, err := c.Model((*model.User)(nil)).Where("id = 301").Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+	count, err = c.Model((*model.UserTag)(nil)).Where("user_id = 301").Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+	count, err = c.Model((*model.Participant)(nil)).Where("user_id = 301").Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func testUserLocation(t *testing.T, db *pgsql.UserDB, c *pg.DB) {
+	l, err := db.Location(nil, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, l.CompanyID)
+	_, err = db.Location(nil, 999)
+	assert.NotNil(t, err)
+}
@@ -18,3 +18,12 @@ func List(u *model.AuthUser) (*model.ListQuery, error) {
 		return nil, echo.ErrForbidden
 	}
 }
+
+// Discover prepares data for user discovery queries.
+// Unlike List, it lets regular users find people within their company
+func Discover(u *model.AuthUser) *model.ListQuery {
+	if q, err := List(u); err == nil {
+		return q
+	}
+	return &model.ListQuery{Query: "company_id = ?", ID: u.CompanyID}
+}
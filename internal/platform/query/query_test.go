@@ -64,3 +64,31 @@ func TestList(t *testing.T) {
 		})
 	}
 }
+
+func TestDiscover(t *testing.T) {
+	cases := []struct {
+		name     string
+		user     *model.AuthUser
+		wantData *model.ListQuery
+	}{
+		{
+			name: "Admin user",
+			user: &model.AuthUser{Role: model.AdminRole, CompanyID: 1},
+		},
+		{
+			name:     "Location admin user",
+			user:     &model.AuthUser{Role: model.LocationAdminRole, CompanyID: 1, LocationID: 2},
+			wantData: &model.ListQuery{Query: "location_id = ?", ID: 2},
+		},
+		{
+			name:     "Normal user",
+			user:     &model.AuthUser{Role: model.UserRole, CompanyID: 3, LocationID: 2},
+			wantData: &model.ListQuery{Query: "company_id = ?", ID: 3},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantData, query.Discover(tt.user))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Package storage contains file storages: a local directory, and S3 or a service compatible with it
+package storage
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidKey is returned for keys pointing outside of the storage
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+// NewLocal creates storage keeping files under dir, which are served at baseURL
+func NewLocal(dir, baseURL string) *Local {
+	return &Local{dir: filepath.Clean(dir), baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Local represents storage in a local directory
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+// Put writes the file, replacing file under the key atomically, so that it is never served partially written
+func (l *Local) Put(key, contentType string, data []byte) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), ".upload")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Delete removes the file, if it exists
+func (l *Local) Delete(key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// URL returns URL the file is served at
+func (l *Local) URL(key string) string {
+	return l.baseURL + "/" + key
+}
+
+// path returns path of the file under the key, rejecting keys that would escape the directory
+func (l *Local) path(key string) (string, error) {
+	path := filepath.Join(l.dir, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, l.dir+string(filepath.Separator)) {
+		return "", ErrInvalidKey
+	}
+	return path, nil
+}
@@ -0,0 +1,42 @@
+package storage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal/platform/storage"
+)
+
+func TestLocal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	l := storage.NewLocal(dir, "/uploads/")
+
+	assert.Nil(t, l.Put("avatars/1/a/small.jpg", "image/jpeg", []byte("jpeg")))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "avatars", "1", "a", "small.jpg"))
+	assert.Nil(t, err)
+	assert.Equal(t, "jpeg", string(data))
+
+	assert.Nil(t, l.Put("avatars/1/a/small.jpg", "image/jpeg", []byte("replaced")))
+	data, _ = ioutil.ReadFile(filepath.Join(dir, "avatars", "1", "a", "small.jpg"))
+	assert.Equal(t, "replaced", string(data))
+	files, _ := ioutil.ReadDir(filepath.Join(dir, "avatars", "1", "a"))
+	assert.Len(t, files, 1)
+
+	assert.Equal(t, "/uploads/avatars/1/a/small.jpg", l.URL("avatars/1/a/small.jpg"))
+
+	assert.Nil(t, l.Delete("avatars/1/a/small.jpg"))
+	_, err = os.Stat(filepath.Join(dir, "avatars", "1", "a", "small.jpg"))
+	assert.True(t, os.IsNotExist(err))
+	assert.Nil(t, l.Delete("avatars/1/a/small.jpg"))
+
+	assert.Equal(t, storage.ErrInvalidKey, l.Put("../outside.jpg", "image/jpeg", []byte("jpeg")))
+	assert.Equal(t, storage.ErrInvalidKey, l.Delete("avatars/../../outside.jpg"))
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NewS3 creates storage in the bucket of S3, or of a service compatible with it such as MinIO, at endpoint.
+// Files are served at publicURL, defaulting to the bucket's URL at endpoint, so the bucket has to be readable
+// by anyone, or publicURL has to point to a CDN in front of it
+func NewS3(endpoint, region, bucket, accessKey, secretKey, publicURL string) *S3 {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if publicURL == "" {
+		publicURL = endpoint + "/" + bucket
+	}
+	return &S3{
+		endpoint:  endpoint,
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// S3 represents storage in an S3 bucket, addressed path-style as S3 compatible services expect.
+// Requests are signed with AWS Signature Version 4
+type S3 struct {
+	endpoint  string
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	publicURL string
+	client    *http.Client
+}
+
+// Put uploads the file. Keys are never reused for other content, so the file is cached by clients for good
+func (s *S3) Put(key, contentType string, data []byte) error {
+	return s.do(http.MethodPut, key, data, map[string]string{
+		"Content-Type":  contentType,
+		"Cache-Control": "public, max-age=31536000, immutable",
+	})
+}
+
+// Delete removes the file, succeeding when it does not exist
+func (s *S3) Delete(key string) error {
+	return s.do(http.MethodDelete, key, nil, nil)
+}
+
+// URL returns URL the file is served at
+func (s *S3) URL(key string) string {
+	return s.publicURL + "/" + key
+}
+
+func (s *S3) do(method, key string, body []byte, header map[string]string) error {
+	u, err := url.Parse(s.endpoint + "/" + s.bucket + "/" + key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	s.sign(req, body, time.Now().UTC())
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 && !(method == http.MethodDelete && res.StatusCode == http.StatusNotFound) {
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("storage: %s %s: %s %s", method, key, res.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// sign adds Authorization header to the request, signing the host and every header set on it
+func (s *S3) sign(r *http.Request, body []byte, t time.Time) {
+	payload := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(payload[:])
+	date := t.Format("20060102")
+	stamp := t.Format("20060102T150405Z")
+	r.Header.Set("X-Amz-Date", stamp)
+	r.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	values := map[string]string{"host": r.URL.Host}
+	names := []string{"host"}
+	for k, v := range r.Header {
+		name := strings.ToLower(k)
+		names = append(names, name)
+		values[name] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	sort.Strings(names)
+	var headers bytes.Buffer
+	for _, n := range names {
+		headers.WriteString(n + ":" + values[n] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		r.Method, r.URL.EscapedPath(), r.URL.RawQuery, headers.String(), signed, payloadHash,
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{date, s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	r.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signed, hex.EncodeToString(hmacSHA256(key, toSign))))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,73 @@
+package storage_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal/platform/storage"
+)
+
+var authorization = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=access/\d{8}/eu-west-1/s3/aws4_request, ` +
+	`SignedHeaders=([a-z0-9;-]+), Signature=[0-9a-f]{64}$`)
+
+// s3StandIn is an S3 compatible service keeping objects in memory, rejecting requests that are not signed
+type s3StandIn struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	hash := sha256.Sum256(body)
+	m := authorization.FindStringSubmatch(r.Header.Get("Authorization"))
+	if m == nil || r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(hash[:]) ||
+		!regexp.MustCompile(`(^|;)host;`).MatchString(m[1]) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		s.objects[r.URL.Path] = body
+		s.types[r.URL.Path] = r.Header.Get("Content-Type")
+	case http.MethodDelete:
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3(t *testing.T) {
+	standIn := &s3StandIn{objects: map[string][]byte{}, types: map[string]string{}}
+	ts := httptest.NewServer(standIn)
+	defer ts.Close()
+
+	s := storage.NewS3(ts.URL+"/", "eu-west-1", "avatars", "access", "secret", "")
+	assert.Nil(t, s.Put("avatars/1/a/small.jpg", "image/jpeg", []byte("jpeg")))
+	assert.Equal(t, "jpeg", string(standIn.objects["/avatars/avatars/1/a/small.jpg"]))
+	assert.Equal(t, "image/jpeg", standIn.types["/avatars/avatars/1/a/small.jpg"])
+	assert.Equal(t, ts.URL+"/avatars/avatars/1/a/small.jpg", s.URL("avatars/1/a/small.jpg"))
+
+	assert.Nil(t, s.Delete("avatars/1/a/small.jpg"))
+	assert.Len(t, standIn.objects, 0)
+
+	cdn := storage.NewS3(ts.URL, "eu-west-1", "avatars", "access", "secret", "https://cdn.example.com/")
+	assert.Equal(t, "https://cdn.example.com/avatars/1/a/small.jpg", cdn.URL("avatars/1/a/small.jpg"))
+
+	wrongRegion := storage.NewS3(ts.URL, "us-east-1", "avatars", "access", "secret", "")
+	assert.NotNil(t, wrongRegion.Put("avatars/1/a/small.jpg", "image/jpeg", []byte("jpeg")))
+
+	unreachable := storage.NewS3("http://127.0.0.1:1", "eu-west-1", "avatars", "access", "secret", "")
+	assert.NotNil(t, unreachable.Delete("avatars/1/a/small.jpg"))
+}
@@ -0,0 +1,233 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+)
+
+// PreferenceKind represents type of preference values
+type PreferenceKind string
+
+const (
+	// PreferenceBool preferences are switched on or off
+	PreferenceBool PreferenceKind = "bool"
+
+	// PreferenceString preferences are strings passing preference's check
+	PreferenceString PreferenceKind = "string"
+
+	// PreferenceEnum preferences are one of preference's values
+	PreferenceEnum PreferenceKind = "enum"
+)
+
+// Preference describes a preference users can set, with its default when neither the user nor its company set it
+type Preference struct {
+	Kind    PreferenceKind
+	Default interface{}
+	Values  []string
+	Check   func(string) bool
+	Hint    string
+}
+
+// languageTag matches language tags such as en or pt-BR
+var languageTag = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+
+func validTimezone(tz string) bool {
+	_, err := time.LoadLocation(tz)
+	return err == nil && tz != "" && tz != "Local"
+}
+
+// PreferenceSchema lists preferences by their keys, grouped by a dot, e.g. privacy.searchable.
+// Which notifications users receive is chosen per type with NotificationPreference instead.
+// Users who are not searchable are left out of friend suggestions and of discovery by regular users,
+// emails are shown to other users only if show_email is set, and friend_requests limits who may send friend requests
+var PreferenceSchema = map[string]Preference{
+	"language":                {Kind: PreferenceString, Default: "en", Check: languageTag.MatchString, Hint: "a language tag such as en or pt-BR"},
+	"timezone":                {Kind: PreferenceString, Default: "UTC", Check: validTimezone, Hint: "an IANA time zone such as Europe/Berlin"},
+	"theme":                   {Kind: PreferenceEnum, Default: "system", Values: []string{"system", "light", "dark"}},
+	"privacy.searchable":      {Kind: PreferenceBool, Default: true},
+	"privacy.show_email":      {Kind: PreferenceBool, Default: false},
+	"privacy.friend_requests": {Kind: PreferenceEnum, Default: "everyone", Values: []string{"everyone", "friends_of_friends", "nobody"}},
+}
+
+// Validate checks whether the value, as decoded from JSON, suits the preference
+func (p Preference) Validate(v interface{}) error {
+	switch p.Kind {
+	case PreferenceBool:
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("must be true or false")
+		}
+		return nil
+	case PreferenceEnum:
+		s, _ := v.(string)
+		for _, allowed := range p.Values {
+			if s == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(p.Values, ", "))
+	}
+	if s, ok := v.(string); ok && (p.Check == nil || p.Check(s)) {
+		return nil
+	}
+	return fmt.Errorf("must be %s", p.Hint)
+}
+
+// Preferences holds preference values by keys of PreferenceSchema
+type Preferences map[string]interface{}
+
+// DefaultPreferences returns defaults of all preferences in the schema
+func DefaultPreferences() Preferences {
+	p := make(Preferences, len(PreferenceSchema))
+	for key, pref := range PreferenceSchema {
+		p[key] = pref.Default
+	}
+	return p
+}
+
+// ResolvePreferences returns all preferences, each taken from the last of the layers setting it, or its default.
+// Layers go from the most general to the most specific, e.g. company's preferences followed by user's
+func ResolvePreferences(layers ...Preferences) Preferences {
+	p := DefaultPreferences()
+	for _, layer := range layers {
+		for key, v := range layer {
+			// Values left over from older schemas are ignored
+			if pref, ok := PreferenceSchema[key]; ok && pref.Validate(v) == nil {
+				p[key] = v
+			}
+		}
+	}
+	return p
+}
+
+// Bool returns value of a bool preference, its default if it is not set
+func (p Preferences) Bool(key string) bool {
+	if v, ok := p[key].(bool); ok {
+		return v
+	}
+	v, _ := PreferenceSchema[key].Default.(bool)
+	return v
+}
+
+// String returns value of a string or enum preference, its default if it is not set
+func (p Preferences) String(key string) string {
+	if v, ok := p[key].(string); ok {
+		return v
+	}
+	v, _ := PreferenceSchema[key].Default.(string)
+	return v
+}
+
+// Nested returns preferences as JSON objects nesting preferences of a group under its name
+func (p Preferences) Nested() map[string]interface{} {
+	n := make(map[string]interface{})
+	for key, v := range p {
+		parts := strings.SplitN(key, ".", 2)
+		if len(parts) == 1 {
+			n[key] = v
+			continue
+		}
+		group, ok := n[parts[0]].(map[string]interface{})
+		if !ok {
+			group = make(map[string]interface{})
+			n[parts[0]] = group
+		}
+		group[parts[1]] = v
+	}
+	return n
+}
+
+// ParsePreferencePatch validates JSON merge patch of preferences, nesting preferences of a group under its name.
+// It returns preferences to set, and keys of preferences reset by null, sorted, to be inherited again
+func ParsePreferencePatch(patch map[string]interface{}) (Preferences, []string, error) {
+	set, reset := make(Preferences), []string{}
+	if err := parsePreferencePatch(patch, "", set, &reset); err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(reset)
+	return set, reset, nil
+}
+
+func parsePreferencePatch(patch map[string]interface{}, prefix string, set Preferences, reset *[]string) error {
+	for name, v := range patch {
+		key := prefix + name
+		pref, ok := PreferenceSchema[key]
+		// Preferences of a group are only set nested under its name
+		if strings.Contains(name, ".") {
+			ok = false
+		}
+		if !ok {
+			group, isGroup := v.(map[string]interface{})
+			if isGroup && prefix == "" && preferenceGroup(name) {
+				if err := parsePreferencePatch(group, key+".", set, reset); err != nil {
+					return err
+				}
+				continue
+			}
+			if v == nil && prefix == "" && preferenceGroup(name) {
+				for k := range PreferenceSchema {
+					if strings.HasPrefix(k, key+".") {
+						*reset = append(*reset, k)
+					}
+				}
+				continue
+			}
+			return fmt.Errorf("unknown preference %s", key)
+		}
+		if v == nil {
+			*reset = append(*reset, key)
+			continue
+		}
+		if err := pref.Validate(v); err != nil {
+			return fmt.Errorf("%s %v", key, err)
+		}
+		set[key] = v
+	}
+	return nil
+}
+
+// preferenceGroup checks whether preferences of the schema are grouped under the name
+func preferenceGroup(name string) bool {
+	for key := range PreferenceSchema {
+		if strings.HasPrefix(key, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// CompanyPreference represents preferences set by admins of a company for its users, taking place of schema defaults
+type CompanyPreference struct {
+	CompanyID int         `json:"company_id" sql:",pk"`
+	Settings  Preferences `json:"settings"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// UserPreference represents preferences set by a user, taking place of its company's and schema defaults
+type UserPreference struct {
+	UserID    int         `json:"user_id" sql:",pk"`
+	CompanyID int         `json:"company_id"`
+	Settings  Preferences `json:"settings"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// PreferenceDB represents preferences database interface (repository).
+// Layers returns preferences set by user's company and by the user itself, nil where none are set.
+// Updates set the preferences and remove the reset ones in a single statement, returning preferences set afterwards
+type PreferenceDB interface {
+	Layers(orm.DB, int) (Preferences, Preferences, error)
+	Company(orm.DB, int) (Preferences, error)
+	UpdateUser(orm.DB, int, int, Preferences, []string) (Preferences, error)
+	UpdateCompany(orm.DB, int, Preferences, []string) (Preferences, error)
+}
+
+// PreferenceService resolves preferences of users for other services,
+// e.g. to check whether a user accepts friend requests from the requesting user
+type PreferenceService interface {
+	Preferences(echo.Context, int) (Preferences, error)
+}
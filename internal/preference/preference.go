@@ -0,0 +1,68 @@
+// Package preference contains user preferences application services
+package preference
+
+import (
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// New creates new preference application service
+func New(pdb model.PreferenceDB, rbac model.RBACService, auth model.AuthService) *Service {
+	return &Service{pdb: pdb, rbac: rbac, auth: auth}
+}
+
+// Service represents preference application service
+type Service struct {
+	pdb  model.PreferenceDB
+	rbac model.RBACService
+	auth model.AuthService
+}
+
+// Preferences returns all preferences of the user: its own choices, falling back to ones set by its company,
+// and then to defaults of the schema
+func (s *Service) Preferences(c echo.Context, userID int) (model.Preferences, error) {
+	company, user, err := s.pdb.Layers(model.Conn(c), userID)
+	if err != nil {
+		return nil, err
+	}
+	return model.ResolvePreferences(company, user), nil
+}
+
+// Me returns all preferences of requesting user
+func (s *Service) Me(c echo.Context) (model.Preferences, error) {
+	return s.Preferences(c, s.auth.User(c).ID)
+}
+
+// Update sets requesting user's preferences and resets the ones to inherit again, returning all of its preferences
+func (s *Service) Update(c echo.Context, set model.Preferences, reset []string) (model.Preferences, error) {
+	au := s.auth.User(c)
+	if _, err := s.pdb.UpdateUser(model.Conn(c), au.ID, au.CompanyID, set, reset); err != nil {
+		return nil, err
+	}
+	return s.Preferences(c, au.ID)
+}
+
+// Company returns preferences set for users of the company, in place of schema defaults
+func (s *Service) Company(c echo.Context, companyID int) (model.Preferences, error) {
+	if err := s.rbac.EnforceCompany(c, companyID); err != nil {
+		return nil, err
+	}
+	p, err := s.pdb.Company(model.Conn(c), companyID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		p = model.Preferences{}
+	}
+	return p, nil
+}
+
+// UpdateCompany sets preferences for users of the company and resets the ones to take defaults of the schema again.
+// Users keep preferences they set themselves
+func (s *Service) UpdateCompany(c echo.Context, companyID int, set model.Preferences, reset []string) (model.Preferences, error) {
+	if err := s.rbac.EnforceCompany(c, companyID); err != nil {
+		return nil, err
+	}
+	return s.pdb.UpdateCompany(model.Conn(c), companyID, set, reset)
+}
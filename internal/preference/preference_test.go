@@ -0,0 +1,203 @@
+package preference_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+	"github.com/artistomin/friend4me/internal/preference"
+)
+
+func authUser() *mock.Auth {
+	return &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: 3, CompanyID: 1}
+		}}
+}
+
+func TestPreferences(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		wantData map[string]interface{}
+		pdb      *mockdb.Preference
+	}{
+		{
+			name:    "Fail on layers",
+			wantErr: true,
+			pdb: &mockdb.Preference{
+				LayersFn: func(orm.DB, int) (model.Preferences, model.Preferences, error) {
+					return nil, nil, echo.ErrNotFound
+				}},
+		},
+		{
+			name: "Defaults",
+			pdb: &mockdb.Preference{
+				LayersFn: func(orm.DB, int) (model.Preferences, model.Preferences, error) {
+					return nil, nil, nil
+				}},
+			wantData: map[string]interface{}{"language": "en", "theme": "system", "privacy.searchable": true},
+		},
+		{
+			name: "User overrides company",
+			pdb: &mockdb.Preference{
+				LayersFn: func(db orm.DB, id int) (model.Preferences, model.Preferences, error) {
+					if id != 3 {
+						return nil, nil, model.ErrGeneric
+					}
+					return model.Preferences{"language": "de", "theme": "dark"},
+						model.Preferences{"theme": "light", "privacy.searchable": false}, nil
+				}},
+			wantData: map[string]interface{}{"language": "de", "theme": "light", "privacy.searchable": false},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := preference.New(tt.pdb, nil, authUser())
+			p, err := s.Me(nil)
+			assert.Equal(t, tt.wantErr, err != nil)
+			for key, want := range tt.wantData {
+				assert.Equal(t, want, p[key], key)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		wantData model.Preferences
+		pdb      *mockdb.Preference
+	}{
+		{
+			name:    "Fail on update",
+			wantErr: true,
+			pdb: &mockdb.Preference{
+				UpdateUserFn: func(orm.DB, int, int, model.Preferences, []string) (model.Preferences, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name: "Success",
+			pdb: &mockdb.Preference{
+				UpdateUserFn: func(db orm.DB, userID, companyID int, set model.Preferences, reset []string) (model.Preferences, error) {
+					if userID != 3 || companyID != 1 || len(reset) != 1 {
+						return nil, model.ErrGeneric
+					}
+					return set, nil
+				},
+				LayersFn: func(orm.DB, int) (model.Preferences, model.Preferences, error) {
+					return nil, model.Preferences{"theme": "dark"}, nil
+				}},
+			wantData: model.ResolvePreferences(model.Preferences{"theme": "dark"}),
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := preference.New(tt.pdb, nil, authUser())
+			p, err := s.Update(nil, model.Preferences{"theme": "dark"}, []string{"language"})
+			assert.Equal(t, tt.wantData, p)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestCompany(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		wantData model.Preferences
+		rbac     *mock.RBAC
+		pdb      *mockdb.Preference
+	}{
+		{
+			name:    "Fail on RBAC",
+			wantErr: true,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return echo.ErrForbidden
+				}},
+		},
+		{
+			name: "Nothing set",
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return nil
+				}},
+			pdb: &mockdb.Preference{
+				CompanyFn: func(orm.DB, int) (model.Preferences, error) {
+					return nil, nil
+				}},
+			wantData: model.Preferences{},
+		},
+		{
+			name: "Success",
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return nil
+				}},
+			pdb: &mockdb.Preference{
+				CompanyFn: func(db orm.DB, id int) (model.Preferences, error) {
+					return model.Preferences{"timezone": "Europe/Berlin"}, nil
+				}},
+			wantData: model.Preferences{"timezone": "Europe/Berlin"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := preference.New(tt.pdb, tt.rbac, authUser())
+			p, err := s.Company(nil, 1)
+			assert.Equal(t, tt.wantData, p)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestUpdateCompany(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		wantData model.Preferences
+		rbac     *mock.RBAC
+	}{
+		{
+			name:    "Fail on RBAC",
+			wantErr: true,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return echo.ErrForbidden
+				}},
+		},
+		{
+			name: "Success",
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return nil
+				}},
+			wantData: model.Preferences{"language": "fr"},
+		},
+	}
+	pdb := &mockdb.Preference{
+		UpdateCompanyFn: func(db orm.DB, companyID int, set model.Preferences, reset []string) (model.Preferences, error) {
+			if companyID != 2 {
+				return nil, model.ErrGeneric
+			}
+			return set, nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := preference.New(pdb, tt.rbac, authUser())
+			p, err := s.UpdateCompany(nil, 2, model.Preferences{"language": "fr"}, nil)
+			assert.Equal(t, tt.wantData, p)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+func TestPreferenceValidate(t *testing.T) {
+	cases := []struct {
+		key     string
+		value   interface{}
+		wantErr bool
+	}{
+		{key: "language", value: "pt-BR"},
+		{key: "language", value: "Portuguese", wantErr: true},
+		{key: "language", value: 1.0, wantErr: true},
+		{key: "timezone", value: "Europe/Berlin"},
+		{key: "timezone", value: "Mars/Olympus", wantErr: true},
+		{key: "timezone", value: "Local", wantErr: true},
+		{key: "theme", value: "dark"},
+		{key: "theme", value: "blue", wantErr: true},
+		{key: "privacy.searchable", value: false},
+		{key: "privacy.searchable", value: "false", wantErr: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.key, func(t *testing.T) {
+			err := model.PreferenceSchema[tt.key].Validate(tt.value)
+			assert.Equal(t, tt.wantErr, err != nil, "%v", tt.value)
+		})
+	}
+}
+
+func TestResolvePreferences(t *testing.T) {
+	p := model.ResolvePreferences(
+		model.Preferences{"language": "de", "timezone": "Europe/Berlin", "removed": true},
+		model.Preferences{"language": "fr", "theme": "sepia"},
+		nil,
+	)
+	assert.Len(t, p, len(model.PreferenceSchema))
+	assert.Equal(t, "fr", p.String("language"))
+	assert.Equal(t, "Europe/Berlin", p.String("timezone"))
+	assert.Equal(t, "system", p.String("theme"))
+	assert.True(t, p.Bool("privacy.searchable"))
+
+	assert.Equal(t, "en", model.Preferences{}.String("language"))
+	assert.False(t, model.Preferences{}.Bool("privacy.show_email"))
+}
+
+func TestPreferencesNested(t *testing.T) {
+	p := model.Preferences{"language": "en", "privacy.searchable": true, "privacy.show_email": false}
+	assert.Equal(t, map[string]interface{}{
+		"language": "en",
+		"privacy":  map[string]interface{}{"searchable": true, "show_email": false},
+	}, p.Nested())
+}
+
+func TestParsePreferencePatch(t *testing.T) {
+	cases := []struct {
+		name      string
+		patch     map[string]interface{}
+		wantErr   bool
+		wantSet   model.Preferences
+		wantReset []string
+	}{
+		{
+			name:    "Unknown preference",
+			patch:   map[string]interface{}{"font": "serif"},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown preference of a group",
+			patch:   map[string]interface{}{"privacy": map[string]interface{}{"invisible": true}},
+			wantErr: true,
+		},
+		{
+			name:    "Email notifications are chosen per type",
+			patch:   map[string]interface{}{"notifications": map[string]interface{}{"email": false}},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid value",
+			patch:   map[string]interface{}{"privacy": map[string]interface{}{"friend_requests": "strangers"}},
+			wantErr: true,
+		},
+		{
+			name:    "Group as value",
+			patch:   map[string]interface{}{"privacy": true},
+			wantErr: true,
+		},
+		{
+			name:    "Dotted key",
+			patch:   map[string]interface{}{"privacy.searchable": false},
+			wantErr: true,
+		},
+		{
+			name: "Set and reset",
+			patch: map[string]interface{}{
+				"language": "de",
+				"timezone": nil,
+				"privacy":  map[string]interface{}{"show_email": true, "searchable": nil},
+			},
+			wantSet:   model.Preferences{"language": "de", "privacy.show_email": true},
+			wantReset: []string{"privacy.searchable", "timezone"},
+		},
+		{
+			name:      "Reset group",
+			patch:     map[string]interface{}{"privacy": nil},
+			wantSet:   model.Preferences{},
+			wantReset: []string{"privacy.friend_requests", "privacy.searchable", "privacy.show_email"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			set, reset, err := model.ParsePreferencePatch(tt.patch)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantSet, set)
+			assert.Equal(t, tt.wantReset, reset)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// PresenceStatus represents user's online status
+type PresenceStatus string
+
+const (
+	// PresenceOnline is status of connected users, and of users active within PresenceAwayAfter
+	PresenceOnline PresenceStatus = "online"
+
+	// PresenceAway is status of users active within PresenceOfflineAfter
+	PresenceAway PresenceStatus = "away"
+
+	// PresenceOffline is status of users inactive for longer
+	PresenceOffline PresenceStatus = "offline"
+)
+
+// Inactivity periods after which users are considered away and offline
+const (
+	PresenceAwayAfter    = 5 * time.Minute
+	PresenceOfflineAfter = 30 * time.Minute
+)
+
+// Presence represents user's online status and the time it was last seen active
+type Presence struct {
+	UserID     int            `json:"user_id"`
+	Status     PresenceStatus `json:"status"`
+	LastSeenAt *time.Time     `json:"last_seen_at,omitempty"`
+}
+
+// PresenceAt returns status of a user last seen at the given time, or never if lastSeen is nil
+func PresenceAt(lastSeen *time.Time, connected bool, now time.Time) PresenceStatus {
+	switch {
+	case connected:
+		return PresenceOnline
+	case lastSeen == nil:
+		return PresenceOffline
+	case now.Sub(*lastSeen) < PresenceAwayAfter:
+		return PresenceOnline
+	case now.Sub(*lastSeen) < PresenceOfflineAfter:
+		return PresenceAway
+	}
+	return PresenceOffline
+}
+
+// PresenceDB represents presence database interface (repository).
+// Watchers returns IDs of users notified about user's presence changes, none if the user hides its presence
+type PresenceDB interface {
+	Touch(orm.DB, map[int]time.Time) error
+	Watchers(orm.DB, int) ([]int, error)
+}
+
+// PresenceTracker tracks activity of users.
+// Presence returns nil for users hiding their presence
+type PresenceTracker interface {
+	Seen(int, time.Time)
+	Presence(*User, time.Time) *Presence
+}
@@ -0,0 +1,159 @@
+// Package presence contains user presence tracking services
+package presence
+
+import (
+	"sync"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// New creates new presence tracking service.
+// Realtime connections are tracked by subscribing to events through the service instead of the broker
+func New(pdb model.PresenceDB, broker model.Broker) *Service {
+	return &Service{
+		Broker: broker,
+		pdb:    pdb,
+		seen:   make(map[int]time.Time),
+		stored: make(map[int]time.Time),
+		conns:  make(map[int]int),
+		status: make(map[int]model.PresenceStatus),
+	}
+}
+
+// Service tracks presence of users from their API activity and realtime connections.
+// Activity is kept in memory, and last seen times are stored in batches by Sync
+type Service struct {
+	model.Broker
+	pdb model.PresenceDB
+
+	mu     sync.Mutex
+	seen   map[int]time.Time
+	stored map[int]time.Time
+	conns  map[int]int
+	status map[int]model.PresenceStatus
+}
+
+// Seen records activity of a user at the given time
+func (s *Service) Seen(userID int, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t.After(s.seen[userID]) {
+		s.seen[userID] = t
+	}
+}
+
+// Subscribe subscribes to events of a user, tracking the user as connected until the subscription ends
+func (s *Service) Subscribe(userID int) *model.Subscription {
+	sub := s.Broker.Subscribe(userID)
+	s.mu.Lock()
+	s.conns[userID]++
+	s.mu.Unlock()
+	s.Seen(userID, time.Now())
+	return sub
+}
+
+// Unsubscribe ends a subscription, tracking the user as disconnected once it has no other subscriptions
+func (s *Service) Unsubscribe(sub *model.Subscription) {
+	s.Broker.Unsubscribe(sub)
+	s.mu.Lock()
+	if s.conns[sub.UserID]--; s.conns[sub.UserID] <= 0 {
+		delete(s.conns, sub.UserID)
+	}
+	s.mu.Unlock()
+	s.Seen(sub.UserID, time.Now())
+}
+
+// Presence returns presence of a user, combining activity tracked by this instance with its stored last seen time.
+// Nil is returned if the user hides its presence
+func (s *Service) Presence(u *model.User, now time.Time) *model.Presence {
+	if u.HidePresence {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	last := u.LastSeenAt
+	if t, ok := s.seen[u.ID]; ok && (last == nil || t.After(*last)) {
+		last = &t
+	}
+	return &model.Presence{UserID: u.ID, Status: model.PresenceAt(last, s.conns[u.ID] > 0, now), LastSeenAt: last}
+}
+
+// Sync stores last seen times of users active since the previous sync,
+// and publishes presence changes to watchers of the users.
+// Users who went offline are no longer tracked
+func (s *Service) Sync(now time.Time) error {
+	touch := make(map[int]time.Time)
+	changed := make(map[int]model.Presence)
+	s.mu.Lock()
+	for id, t := range s.seen {
+		connected := s.conns[id] > 0
+		if connected {
+			t = now
+			s.seen[id] = t
+		}
+		if t.After(s.stored[id]) {
+			touch[id] = t
+		}
+		last := t
+		st := model.PresenceAt(&last, connected, now)
+		if st != s.status[id] {
+			s.status[id] = st
+			changed[id] = model.Presence{UserID: id, Status: st, LastSeenAt: &last}
+		}
+		if st == model.PresenceOffline {
+			delete(s.seen, id)
+			delete(s.stored, id)
+			delete(s.status, id)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(touch) > 0 {
+		if err := s.pdb.Touch(nil, touch); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		for id, t := range touch {
+			if _, ok := s.seen[id]; ok {
+				s.stored[id] = t
+			}
+		}
+		s.mu.Unlock()
+	}
+	for _, p := range changed {
+		if err := s.publish(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publish pushes presence change of a user to its watchers
+func (s *Service) publish(p model.Presence) error {
+	watchers, err := s.pdb.Watchers(nil, p.UserID)
+	if err != nil {
+		return err
+	}
+	for _, id := range watchers {
+		e, err := model.NewEvent(model.EventPresence, id, p)
+		if err != nil {
+			return err
+		}
+		if err := s.Broker.Publish(nil, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run syncs presence every interval, logging failures. It never returns
+func (s *Service) Run(interval time.Duration, l echo.Logger) {
+	for now := range time.Tick(interval) {
+		if err := s.Sync(now); err != nil {
+			l.Warnf("Presence Error: %v", err)
+		}
+	}
+}
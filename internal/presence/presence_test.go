@@ -0,0 +1,143 @@
+package presence_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+	"github.com/artistomin/friend4me/internal/presence"
+	"github.com/artistomin/friend4me/internal/realtime"
+)
+
+// recorder returns presence database mock recording stored times, with user 1 watched by users 2 and 3
+func recorder(touched *[]map[int]time.Time) *mockdb.Presence {
+	return &mockdb.Presence{
+		TouchFn: func(db orm.DB, seen map[int]time.Time) error {
+			*touched = append(*touched, seen)
+			return nil
+		},
+		WatchersFn: func(db orm.DB, id int) ([]int, error) {
+			if id == 1 {
+				return []int{2, 3}, nil
+			}
+			return nil, nil
+		}}
+}
+
+func TestSync(t *testing.T) {
+	var touched []map[int]time.Time
+	var pushed []model.Event
+	broker := &mock.Broker{
+		PublishFn: func(db orm.DB, e model.Event) error {
+			pushed = append(pushed, e)
+			return nil
+		}}
+	s := presence.New(recorder(&touched), broker)
+	now := mock.TestTime(2018)
+
+	s.Seen(1, now)
+	s.Seen(1, now.Add(-time.Minute))
+	assert.Nil(t, s.Sync(now))
+	assert.Equal(t, []map[int]time.Time{{1: now}}, touched)
+	if assert.Len(t, pushed, 2) {
+		assert.Equal(t, model.EventPresence, pushed[0].Type)
+		assert.Equal(t, []int{2, 3}, []int{pushed[0].UserID, pushed[1].UserID})
+		assert.JSONEq(t, `{"user_id":1,"status":"online","last_seen_at":"2018-05-19T01:02:03.000000004Z"}`, string(pushed[0].Payload))
+	}
+
+	// Nothing changed, so nothing is stored or published
+	assert.Nil(t, s.Sync(now.Add(time.Minute)))
+	assert.Len(t, touched, 1)
+	assert.Len(t, pushed, 2)
+
+	assert.Nil(t, s.Sync(now.Add(10*time.Minute)))
+	assert.Len(t, touched, 1)
+	if assert.Len(t, pushed, 4) {
+		assert.Contains(t, string(pushed[2].Payload), `"status":"away"`)
+	}
+
+	assert.Nil(t, s.Sync(now.Add(time.Hour)))
+	if assert.Len(t, pushed, 6) {
+		assert.Contains(t, string(pushed[4].Payload), `"status":"offline"`)
+	}
+	assert.Equal(t, model.PresenceOffline, s.Presence(&model.User{Base: model.Base{ID: 1}}, now.Add(time.Hour)).Status)
+}
+
+func TestSyncTouchFails(t *testing.T) {
+	pdb := &mockdb.Presence{
+		TouchFn: func(orm.DB, map[int]time.Time) error {
+			return model.ErrGeneric
+		}}
+	s := presence.New(pdb, nil)
+	s.Seen(1, mock.TestTime(2018))
+	assert.NotNil(t, s.Sync(mock.TestTime(2018)))
+}
+
+func TestConnections(t *testing.T) {
+	var touched []map[int]time.Time
+	s := presence.New(recorder(&touched), realtime.NewHub(1, 0))
+	u := &model.User{Base: model.Base{ID: 1}}
+
+	sub1 := s.Subscribe(1)
+	sub2 := s.Subscribe(1)
+	later := time.Now().Add(time.Hour)
+	assert.Equal(t, model.PresenceOnline, s.Presence(u, later).Status)
+
+	s.Unsubscribe(sub1)
+	assert.Equal(t, model.PresenceOnline, s.Presence(u, later).Status)
+
+	s.Unsubscribe(sub2)
+	assert.Equal(t, model.PresenceOffline, s.Presence(u, later).Status)
+
+	// Connected users are kept seen while connected
+	s.Subscribe(1)
+	assert.Nil(t, s.Sync(later))
+	assert.Equal(t, later, touched[len(touched)-1][1])
+}
+
+func TestPresence(t *testing.T) {
+	s := presence.New(nil, nil)
+	now := mock.TestTime(2018)
+	stored := now.Add(-10 * time.Minute)
+	cases := []struct {
+		name string
+		user *model.User
+		seen *time.Time
+		want *model.Presence
+	}{
+		{
+			name: "Hidden",
+			user: &model.User{Base: model.Base{ID: 1}, HidePresence: true, LastSeenAt: &stored},
+		},
+		{
+			name: "Never seen",
+			user: &model.User{Base: model.Base{ID: 2}},
+			want: &model.Presence{UserID: 2, Status: model.PresenceOffline},
+		},
+		{
+			name: "Stored last seen",
+			user: &model.User{Base: model.Base{ID: 3}, LastSeenAt: &stored},
+			want: &model.Presence{UserID: 3, Status: model.PresenceAway, LastSeenAt: &stored},
+		},
+		{
+			name: "Seen since stored",
+			user: &model.User{Base: model.Base{ID: 4}, LastSeenAt: &stored},
+			seen: &now,
+			want: &model.Presence{UserID: 4, Status: model.PresenceOnline, LastSeenAt: &now},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.seen != nil {
+				s.Seen(tt.user.ID, *tt.seen)
+			}
+			assert.Equal(t, tt.want, s.Presence(tt.user, now))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+)
+
+func TestPresenceAt(t *testing.T) {
+	now := mock.TestTime(2018)
+	ago := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+	cases := []struct {
+		name      string
+		lastSeen  *time.Time
+		connected bool
+		want      model.PresenceStatus
+	}{
+		{
+			name: "Never seen",
+			want: model.PresenceOffline,
+		},
+		{
+			name:      "Connected",
+			lastSeen:  ago(time.Hour),
+			connected: true,
+			want:      model.PresenceOnline,
+		},
+		{
+			name:     "Recently active",
+			lastSeen: ago(time.Minute),
+			want:     model.PresenceOnline,
+		},
+		{
+			name:     "Away",
+			lastSeen: ago(10 * time.Minute),
+			want:     model.PresenceAway,
+		},
+		{
+			name:     "Offline",
+			lastSeen: ago(time.Hour),
+			want:     model.PresenceOffline,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, model.PresenceAt(tt.lastSeen, tt.connected, now))
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// Erasure represents request to erase personal data of a user, and audit record of its erasure once done.
+// Erased users are kept anonymized, so that messages, posts, groups and meetups they left with others still refer to them
+type Erasure struct {
+	Base
+	UserID      int        `json:"user_id"`
+	CompanyID   int        `json:"company_id"`
+	RequestedBy int        `json:"requested_by"`
+	Reason      string     `json:"reason,omitempty"`
+	ErasedBy    int        `json:"erased_by,omitempty"`
+	ErasedAt    *time.Time `json:"erased_at,omitempty"`
+}
+
+// DataSession represents sign-in state of a user. Login history is not retained: only the last login,
+// when the user was last seen and whether it holds a refresh token are kept, so there are no earlier logins to export
+type DataSession struct {
+	LastLogin    *time.Time `json:"last_login,omitempty"`
+	LastSeenAt   *time.Time `json:"last_seen_at,omitempty"`
+	RefreshToken bool       `json:"refresh_token"`
+}
+
+// DataExport represents everything held about a user, handed out on a data subject access request.
+// Grants hold role grants given to the user or delegated by it, Activities what it did as shown to its friends,
+// and Erasures audit records of erasure requests about it
+type DataExport struct {
+	GeneratedAt   time.Time                `json:"generated_at"`
+	Profile       *User                    `json:"profile"`
+	Session       DataSession              `json:"session"`
+	Preferences   []NotificationPreference `json:"notification_preferences"`
+	Settings      Preferences              `json:"settings"`
+	Friendships   []Friendship             `json:"friendships"`
+	Blocks        []Block                  `json:"blocks"`
+	Groups        []GroupMember            `json:"groups"`
+	RSVPs         []RSVP                   `json:"rsvps"`
+	Conversations []Participant            `json:"conversations"`
+	Messages      []Message                `json:"messages"`
+	Posts         []GroupPost              `json:"posts"`
+	Notifications []Notification           `json:"notifications"`
+	Activities    []Activity               `json:"activities"`
+	Grants        []RoleGrant              `json:"grants"`
+	Erasures      []Erasure                `json:"erasures"`
+}
+
+// DataFile represents a section of data export, archived as a JSON file
+type DataFile struct {
+	Name string
+	Data interface{}
+}
+
+// Files returns sections of the export in the order they are archived, by names of their files
+func (d *DataExport) Files() []DataFile {
+	return []DataFile{
+		{"profile.json", d.Profile},
+		{"session.json", d.Session},
+		{"notification_preferences.json", d.Preferences},
+		{"settings.json", d.Settings},
+		{"friendships.json", d.Friendships},
+		{"blocks.json", d.Blocks},
+		{"groups.json", d.Groups},
+		{"rsvps.json", d.RSVPs},
+		{"conversations.json", d.Conversations},
+		{"messages.json", d.Messages},
+		{"posts.json", d.Posts},
+		{"notifications.json", d.Notifications},
+		{"activities.json", d.Activities},
+		{"grants.json", d.Grants},
+		{"erasures.json", d.Erasures},
+	}
+}
+
+// PrivacyDB represents database interface of data subject requests (repository).
+// Subject returns a user that was not erased yet, deleted or not. Erasures lists pending erasure requests,
+// of a single company unless company id is 0, and Erase completes pending request of the user or records a new erasure
+type PrivacyDB interface {
+	Subject(orm.DB, int) (*User, error)
+	Export(orm.DB, *User) (*DataExport, error)
+	RequestErasure(orm.DB, *Erasure) error
+	Erasures(orm.DB, int, *Pagination) ([]Erasure, error)
+	Erase(orm.DB, *User, *Erasure) error
+}
@@ -0,0 +1,93 @@
+// Package privacy contains data subject request application services: exporting and erasing personal data
+package privacy
+
+import (
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/avatar"
+)
+
+// New creates new privacy application service
+func New(pdb model.PrivacyDB, st model.Storage, rbac model.RBACService, auth model.AuthService) *Service {
+	return &Service{pdb: pdb, st: st, rbac: rbac, auth: auth}
+}
+
+// Service represents privacy application service
+type Service struct {
+	pdb  model.PrivacyDB
+	st   model.Storage
+	rbac model.RBACService
+	auth model.AuthService
+}
+
+// Export returns everything held about requesting user
+func (s *Service) Export(c echo.Context) (*model.DataExport, error) {
+	u, err := s.pdb.Subject(model.Conn(c), s.auth.User(c).ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.pdb.Export(model.Conn(c), u)
+}
+
+// RequestErasure records request of requesting user to have its personal data erased, for an admin to carry out
+func (s *Service) RequestErasure(c echo.Context, reason string) (*model.Erasure, error) {
+	au := s.auth.User(c)
+	e := &model.Erasure{
+		UserID:      au.ID,
+		CompanyID:   au.CompanyID,
+		RequestedBy: au.ID,
+		Reason:      reason,
+	}
+	if err := s.pdb.RequestErasure(model.Conn(c), e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Erasures returns pending erasure requests, of requesting user's company unless it is an admin, oldest first
+func (s *Service) Erasures(c echo.Context, p *model.Pagination) ([]model.Erasure, error) {
+	if err := s.rbac.EnforceRole(c, model.CompanyAdminRole); err != nil {
+		return nil, err
+	}
+	au := s.auth.User(c)
+	companyID := au.CompanyID
+	if au.Role <= model.AdminRole {
+		companyID = 0
+	}
+	return s.pdb.Erasures(model.Conn(c), companyID, p)
+}
+
+// Erase anonymizes the user and deletes its avatar once the erasure commits, completing its pending erasure request if there is one.
+// Only admins of the user's company, with a role higher than the user's, may erase it
+func (s *Service) Erase(c echo.Context, userID int, reason string) (*model.Erasure, error) {
+	u, err := s.pdb.Subject(model.Conn(c), userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.rbac.EnforceCompany(c, u.CompanyID); err != nil {
+		return nil, err
+	}
+	level := model.AccessRole(u.RoleID)
+	if u.Role != nil {
+		level = u.Role.AccessLevel
+	}
+	if err := s.rbac.IsLowerRole(c, level); err != nil {
+		return nil, err
+	}
+	au := s.auth.User(c)
+	e := &model.Erasure{
+		UserID:      u.ID,
+		CompanyID:   u.CompanyID,
+		RequestedBy: au.ID,
+		Reason:      reason,
+		ErasedBy:    au.ID,
+	}
+	if err := s.pdb.Erase(model.Conn(c), u, e); err != nil {
+		return nil, err
+	}
+	if err := model.AfterCommit(c, func() error { return avatar.Remove(s.st, u.ID, u.Avatar) }); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
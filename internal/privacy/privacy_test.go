@@ -0,0 +1,270 @@
+package privacy_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+	"github.com/artistomin/friend4me/internal/privacy"
+)
+
+func authUser(id int, role model.AccessRole) *mock.Auth {
+	return &mock.Auth{
+		UserFn: func(echo.Context) *model.AuthUser {
+			return &model.AuthUser{ID: id, CompanyID: 1, Role: role}
+		}}
+}
+
+func TestExport(t *testing.T) {
+	cases := []struct {
+		name    string
+		wantErr bool
+		pdb     *mockdb.Privacy
+	}{
+		{
+			name:    "Fail on subject",
+			wantErr: true,
+			pdb: &mockdb.Privacy{
+				SubjectFn: func(orm.DB, int) (*model.User, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name:    "Fail on export",
+			wantErr: true,
+			pdb: &mockdb.Privacy{
+				SubjectFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}}, nil
+				},
+				ExportFn: func(orm.DB, *model.User) (*model.DataExport, error) {
+					return nil, model.ErrGeneric
+				}},
+		},
+		{
+			name: "Success",
+			pdb: &mockdb.Privacy{
+				SubjectFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}}, nil
+				},
+				ExportFn: func(db orm.DB, u *model.User) (*model.DataExport, error) {
+					if u.ID != 3 {
+						return nil, model.ErrGeneric
+					}
+					return &model.DataExport{Profile: u}, nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := privacy.New(tt.pdb, nil, nil, authUser(3, model.UserRole))
+			d, err := s.Export(nil)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Equal(t, 3, d.Profile.ID)
+			}
+		})
+	}
+}
+
+func TestRequestErasure(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		wantData *model.Erasure
+		pdb      *mockdb.Privacy
+	}{
+		{
+			name:    "Already requested",
+			wantErr: true,
+			pdb: &mockdb.Privacy{
+				RequestErasureFn: func(orm.DB, *model.Erasure) error {
+					return model.ErrGeneric
+				}},
+		},
+		{
+			name: "Success",
+			pdb: &mockdb.Privacy{
+				RequestErasureFn: func(db orm.DB, e *model.Erasure) error {
+					e.ID = 1
+					return nil
+				}},
+			wantData: &model.Erasure{Base: model.Base{ID: 1}, UserID: 3, CompanyID: 1, RequestedBy: 3, Reason: "Leaving"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := privacy.New(tt.pdb, nil, nil, authUser(3, model.UserRole))
+			e, err := s.RequestErasure(nil, "Leaving")
+			assert.Equal(t, tt.wantData, e)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestErasures(t *testing.T) {
+	cases := []struct {
+		name        string
+		role        model.AccessRole
+		rbac        *mock.RBAC
+		wantErr     bool
+		wantCompany int
+	}{
+		{
+			name:    "Fail on RBAC",
+			role:    model.UserRole,
+			wantErr: true,
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(echo.Context, model.AccessRole) error {
+					return echo.ErrForbidden
+				}},
+		},
+		{
+			name: "Company admin",
+			role: model.CompanyAdminRole,
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(echo.Context, model.AccessRole) error {
+					return nil
+				}},
+			wantCompany: 1,
+		},
+		{
+			name: "Admin",
+			role: model.AdminRole,
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(echo.Context, model.AccessRole) error {
+					return nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			company := -1
+			pdb := &mockdb.Privacy{
+				ErasuresFn: func(db orm.DB, companyID int, p *model.Pagination) ([]model.Erasure, error) {
+					company = companyID
+					return []model.Erasure{{UserID: 2}}, nil
+				}}
+			s := privacy.New(pdb, nil, tt.rbac, authUser(1, tt.role))
+			erasures, err := s.Erasures(nil, &model.Pagination{Limit: 10})
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Equal(t, tt.wantCompany, company)
+				assert.Equal(t, []model.Erasure{{UserID: 2}}, erasures)
+			}
+		})
+	}
+}
+
+func TestErase(t *testing.T) {
+	subject := func(db orm.DB, id int) (*model.User, error) {
+		return &model.User{Base: model.Base{ID: id}, CompanyID: 1, Role: &model.Role{AccessLevel: model.UserRole},
+			Avatar: &model.Avatar{ID: "a"}}, nil
+	}
+	allowed := &mock.RBAC{
+		EnforceCompanyFn: func(echo.Context, int) error {
+			return nil
+		},
+		IsLowerRoleFn: func(c echo.Context, r model.AccessRole) error {
+			if r != model.UserRole {
+				return echo.ErrForbidden
+			}
+			return nil
+		}}
+	cases := []struct {
+		name     string
+		wantErr  bool
+		wantData *model.Erasure
+		pdb      *mockdb.Privacy
+		rbac     *mock.RBAC
+		storeErr error
+	}{
+		{
+			name:    "Fail on subject",
+			wantErr: true,
+			pdb: &mockdb.Privacy{
+				SubjectFn: func(orm.DB, int) (*model.User, error) {
+					return nil, echo.ErrNotFound
+				}},
+		},
+		{
+			name:    "Other company",
+			wantErr: true,
+			pdb:     &mockdb.Privacy{SubjectFn: subject},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return echo.ErrForbidden
+				}},
+		},
+		{
+			name:    "Higher role",
+			wantErr: true,
+			pdb:     &mockdb.Privacy{SubjectFn: subject},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(echo.Context, int) error {
+					return nil
+				},
+				IsLowerRoleFn: func(echo.Context, model.AccessRole) error {
+					return echo.ErrForbidden
+				}},
+		},
+		{
+			name:    "Fail on erase",
+			wantErr: true,
+			rbac:    allowed,
+			pdb: &mockdb.Privacy{
+				SubjectFn: subject,
+				EraseFn: func(orm.DB, *model.User, *model.Erasure) error {
+					return model.ErrGeneric
+				}},
+		},
+		{
+			name:     "Fail on avatar removal",
+			wantErr:  true,
+			rbac:     allowed,
+			storeErr: model.ErrGeneric,
+			pdb: &mockdb.Privacy{
+				SubjectFn: subject,
+				EraseFn: func(orm.DB, *model.User, *model.Erasure) error {
+					return nil
+				}},
+		},
+		{
+			name: "Success",
+			rbac: allowed,
+			pdb: &mockdb.Privacy{
+				SubjectFn: subject,
+				EraseFn: func(db orm.DB, u *model.User, e *model.Erasure) error {
+					if u.ID != 2 {
+						return model.ErrGeneric
+					}
+					e.ID = 1
+					return nil
+				}},
+			wantData: &model.Erasure{Base: model.Base{ID: 1}, UserID: 2, CompanyID: 1, RequestedBy: 1, ErasedBy: 1, Reason: "Court order"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted []string
+			st := &mock.Storage{
+				DeleteFn: func(key string) error {
+					deleted = append(deleted, key)
+					return tt.storeErr
+				}}
+			s := privacy.New(tt.pdb, st, tt.rbac, authUser(1, model.CompanyAdminRole))
+			e, err := s.Erase(nil, 2, "Court order")
+			assert.Equal(t, tt.wantData, e)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantData != nil {
+				assert.Equal(t, (&model.Avatar{ID: "a"}).Keys(2), deleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+func TestDataExportFiles(t *testing.T) {
+	d := &model.DataExport{
+		Profile: &model.User{Username: "johndoe"},
+		Blocks:  []model.Block{{TargetID: 2}},
+	}
+	files := d.Files()
+	assert.Len(t, files, 15)
+	names := map[string]bool{}
+	for _, f := range files {
+		assert.False(t, names[f.Name], "duplicate file %s", f.Name)
+		names[f.Name] = true
+	}
+	assert.Equal(t, "profile.json", files[0].Name)
+	assert.Equal(t, d.Profile, files[0].Data)
+	assert.Equal(t, "blocks.json", files[5].Name)
+	assert.Equal(t, d.Blocks, files[5].Data)
+}
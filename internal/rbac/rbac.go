@@ -1,18 +1,38 @@
 package rbac
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/artistomin/friend4me/internal"
 	"github.com/labstack/echo"
+	"github.com/labstack/gommon/log"
+)
+
+// Actions that can be evaluated by Explain
+const (
+	ActionRole          = "enforce_role"
+	ActionUser          = "enforce_user"
+	ActionCompany       = "enforce_company"
+	ActionLocation      = "enforce_location"
+	ActionAccountCreate = "account_create"
+	ActionLowerRole     = "is_lower_role"
+	ActionParticipant   = "enforce_participant"
+	ActionGroup         = "enforce_group"
 )
 
 // New creates new RBAC service
-func New(udb model.UserDB) *Service {
-	return &Service{udb}
+// If grant db is not nil, active temporary role grants are applied to user's own role.
+// If logger is not nil, every decision is logged to it
+func New(udb model.UserDB, gdb model.GrantDB, l echo.Logger) *Service {
+	return &Service{udb: udb, gdb: gdb, log: l}
 }
 
 // Service is RBAC application service
 type Service struct {
 	udb model.UserDB
+	gdb model.GrantDB
+	log echo.Logger
 }
 
 func checkBool(b bool) error {
@@ -22,66 +42,213 @@ func checkBool(b bool) error {
 	return echo.ErrForbidden
 }
 
-// EnforceRole authorizes request by AccessRole
-func (s *Service) EnforceRole(c echo.Context, r model.AccessRole) error {
-	return checkBool(!(c.Get("role").(int8) > int8(r)))
+// subject returns the requesting user as set by JWT middleware
+func subject(c echo.Context) *model.AuthUser {
+	id, _ := c.Get("id").(int)
+	companyID, _ := c.Get("company_id").(int)
+	locationID, _ := c.Get("location_id").(int)
+	grantID, _ := c.Get("grant_id").(int)
+	return &model.AuthUser{
+		ID:         id,
+		CompanyID:  companyID,
+		LocationID: locationID,
+		Role:       model.AccessRole(c.Get("role").(int8)),
+		GrantID:    grantID,
+	}
 }
 
-// EnforceUser checks whether the request to change user data is done by the same user
-func (s *Service) EnforceUser(c echo.Context, ID int) error {
+// Effective returns the user with role and scope elevated by its active temporary role grants
+func (s *Service) Effective(u *model.AuthUser) (*model.AuthUser, error) {
+	if s.gdb == nil {
+		return u, nil
+	}
+	now := time.Now()
+	grants, err := s.gdb.ListActive(nil, u.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	eff := *u
+	eff.ApplyGrants(grants, now)
+	return &eff, nil
+}
+
+// evaluator records every decision made for a single action
+type evaluator struct {
+	u     *model.AuthUser
+	trace *model.AuthzTrace
+}
+
+func newEvaluator(action string, u *model.AuthUser) *evaluator {
+	return &evaluator{u: u, trace: &model.AuthzTrace{Action: action, Subject: u.ID, GrantID: u.GrantID}}
+}
+
+func (e *evaluator) record(d model.AuthzDecision) bool {
+	d.Role = e.u.Role
+	e.trace.Decisions = append(e.trace.Decisions, d)
+	return d.Allowed
+}
+
+func (e *evaluator) role(r model.AccessRole) bool {
+	return e.record(model.AuthzDecision{Check: "role", Required: r, Allowed: !(e.u.Role > r)})
+}
+
+func (e *evaluator) lowerRole(r model.AccessRole) bool {
+	return e.record(model.AuthzDecision{Check: "lower_role", Required: r, Allowed: e.u.Role < r})
+}
+
+func (e *evaluator) scope(scope string, have, want int) bool {
+	return e.record(model.AuthzDecision{Check: "scope", Scope: scope, Have: have, Want: want, Allowed: have == want})
+}
+
+func (e *evaluator) user(id int) bool {
 	// TODO: Implement querying db and checking the requested user's company_id/location_id
 	// to allow company/location admins to view the user
-	if s.isAdmin(c) {
-		return nil
+	if e.role(model.AdminRole) {
+		return true
 	}
-	return checkBool(c.Get("id").(int) == ID)
+	return e.scope("user", e.u.ID, id)
 }
 
-// EnforceCompany checks whether the request to apply change to company data
-// is done by the user belonging to the that company and that the user has role CompanyAdmin.
-// If user has admin role, the check for company doesnt need to pass.
-func (s *Service) EnforceCompany(c echo.Context, ID int) error {
-	if s.isAdmin(c) {
-		return nil
+func (e *evaluator) company(id int) bool {
+	if e.role(model.AdminRole) {
+		return true
 	}
-	if err := s.EnforceRole(c, model.CompanyAdminRole); err != nil {
-		return err
+	if !e.role(model.CompanyAdminRole) {
+		return false
 	}
-	return checkBool(c.Get("company_id").(int) == ID)
+	return e.scope("company", e.u.CompanyID, id)
 }
 
-// EnforceLocation checks whether the request to change location data
-// is done by the user belonging to the requested location
-func (s *Service) EnforceLocation(c echo.Context, ID int) error {
-	if s.isCompanyAdmin(c) {
-		return nil
+func (e *evaluator) location(id int) bool {
+	// Must query company ID in database for the given user
+	if e.role(model.CompanyAdminRole) {
+		return true
+	}
+	if !e.role(model.LocationAdminRole) {
+		return false
 	}
-	if err := s.EnforceRole(c, model.LocationAdminRole); err != nil {
-		return err
+	return e.scope("location", e.u.LocationID, id)
+}
+
+// participant allows only the listed users, regardless of their role
+func (e *evaluator) participant(ids []int) bool {
+	for _, id := range ids {
+		if id == e.u.ID {
+			return e.scope("participant", e.u.ID, id)
+		}
 	}
-	return checkBool((c.Get("location_id").(int) == ID))
+	return e.scope("participant", e.u.ID, 0)
 }
 
-func (s *Service) isAdmin(c echo.Context) bool {
-	return !(c.Get("role").(int8) > int8(model.AdminRole))
+// group allows members having at least the required role within the group,
+// falling back to moderation by admins of the group's company
+func (e *evaluator) group(companyID int, have, want model.GroupRole) bool {
+	if e.record(model.AuthzDecision{Check: "group_role", Scope: "group", Have: have.Level(), Want: want.Level(), Allowed: have.Level() >= want.Level()}) {
+		return true
+	}
+	return e.company(companyID)
 }
 
-func (s *Service) isCompanyAdmin(c echo.Context) bool {
-	// Must query company ID in database for the given user
-	return !(c.Get("role").(int8) > int8(model.CompanyAdminRole))
+func (e *evaluator) accountCreate(roleID, companyID, locationID int) bool {
+	return e.location(locationID) && e.lowerRole(model.AccessRole(roleID))
+}
+
+// done finalizes the trace, logs it and converts the outcome to error
+func (s *Service) done(e *evaluator, allowed bool) error {
+	e.trace.Allowed = allowed
+	if s.log != nil {
+		s.log.Printj(log.JSON{"rbac": e.trace})
+	}
+	return checkBool(allowed)
+}
+
+// EnforceRole authorizes request by AccessRole
+func (s *Service) EnforceRole(c echo.Context, r model.AccessRole) error {
+	e := newEvaluator(ActionRole, subject(c))
+	return s.done(e, e.role(r))
+}
+
+// EnforceUser checks whether the request to change user data is done by the same user
+func (s *Service) EnforceUser(c echo.Context, ID int) error {
+	e := newEvaluator(ActionUser, subject(c))
+	return s.done(e, e.user(ID))
+}
+
+// EnforceCompany checks whether the request to apply change to company data
+// is done by the user belonging to the that company and that the user has role CompanyAdmin.
+// If user has admin role, the check for company doesnt need to pass.
+func (s *Service) EnforceCompany(c echo.Context, ID int) error {
+	e := newEvaluator(ActionCompany, subject(c))
+	return s.done(e, e.company(ID))
+}
+
+// EnforceLocation checks whether the request to change location data
+// is done by the user belonging to the requested location
+func (s *Service) EnforceLocation(c echo.Context, ID int) error {
+	e := newEvaluator(ActionLocation, subject(c))
+	return s.done(e, e.location(ID))
 }
 
 // AccountCreate performs auth check when creating a new account
 // Location admin cannot create accounts, needs to be fixed on EnforceLocation function
 func (s *Service) AccountCreate(c echo.Context, roleID, companyID, locationID int) error {
-	if err := s.EnforceLocation(c, locationID); err != nil {
-		return err
-	}
-	return s.IsLowerRole(c, model.AccessRole(roleID))
+	e := newEvaluator(ActionAccountCreate, subject(c))
+	return s.done(e, e.accountCreate(roleID, companyID, locationID))
 }
 
 // IsLowerRole checks whether the requesting user has higher role than the user it wants to change
 // Used for account creation/deletion
 func (s *Service) IsLowerRole(c echo.Context, r model.AccessRole) error {
-	return checkBool(c.Get("role").(int8) < int8(r))
+	e := newEvaluator(ActionLowerRole, subject(c))
+	return s.done(e, e.lowerRole(r))
+}
+
+// EnforceParticipant checks whether the request is done by one of the participants,
+// e.g. of a conversation. Admins are not exempt from the check
+func (s *Service) EnforceParticipant(c echo.Context, participants []int) error {
+	e := newEvaluator(ActionParticipant, subject(c))
+	return s.done(e, e.participant(participants))
+}
+
+// EnforceGroup checks whether the request is done by a group member having at least the required role within the group.
+// Users without such role pass the check only if they are admins of the group's company
+func (s *Service) EnforceGroup(c echo.Context, companyID int, role, required model.GroupRole) error {
+	e := newEvaluator(ActionGroup, subject(c))
+	return s.done(e, e.group(companyID, role, required))
+}
+
+// Explain evaluates action for a hypothetical subject and resource, returning every decision made.
+// Subject's active role grants are taken into account. Only admins can request explanations
+func (s *Service) Explain(c echo.Context, sub model.AuthUser, action string, res model.AuthzResource) (*model.AuthzTrace, error) {
+	if err := s.EnforceRole(c, model.AdminRole); err != nil {
+		return nil, err
+	}
+	eff, err := s.Effective(&sub)
+	if err != nil {
+		return nil, err
+	}
+	e := newEvaluator(action, eff)
+	var allowed bool
+	switch action {
+	case ActionRole:
+		allowed = e.role(res.Role)
+	case ActionUser:
+		allowed = e.user(res.ID)
+	case ActionCompany:
+		allowed = e.company(res.CompanyID)
+	case ActionLocation:
+		allowed = e.location(res.LocationID)
+	case ActionAccountCreate:
+		allowed = e.accountCreate(int(res.Role), res.CompanyID, res.LocationID)
+	case ActionLowerRole:
+		allowed = e.lowerRole(res.Role)
+	case ActionParticipant:
+		allowed = e.participant(res.Participants)
+	case ActionGroup:
+		allowed = e.group(res.CompanyID, res.GroupRole, res.RequiredGroupRole)
+	default:
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "unknown action")
+	}
+	e.trace.Allowed = allowed
+	return e.trace, nil
 }
@@ -1,7 +1,11 @@
 package rbac_test
 
 import (
+	"bytes"
 	"testing"
+	"time"
+
+	"github.com/go-pg/pg/orm"
 
 	"github.com/labstack/echo"
 
@@ -9,11 +13,12 @@ import (
 
 	"github.com/artistomin/friend4me/internal"
 	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
 	"github.com/artistomin/friend4me/internal/rbac"
 )
 
 func TestNew(t *testing.T) {
-	rbacService := rbac.New(nil)
+	rbacService := rbac.New(nil, nil, nil)
 	if rbacService == nil {
 		t.Error("RBAC Service not initialized")
 	}
@@ -42,7 +47,7 @@ func TestEnforceRole(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			rbacSvc := rbac.New(nil)
+			rbacSvc := rbac.New(nil, nil, nil)
 			res := rbacSvc.EnforceRole(tt.args.ctx, tt.args.role)
 			assert.Equal(t, tt.wantErr, res == echo.ErrForbidden)
 		})
@@ -77,7 +82,7 @@ func TestEnforceUser(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			rbacSvc := rbac.New(nil)
+			rbacSvc := rbac.New(nil, nil, nil)
 			res := rbacSvc.EnforceUser(tt.args.ctx, tt.args.id)
 			assert.Equal(t, tt.wantErr, res == echo.ErrForbidden)
 		})
@@ -117,7 +122,7 @@ func TestEnforceCompany(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			rbacSvc := rbac.New(nil)
+			rbacSvc := rbac.New(nil, nil, nil)
 			res := rbacSvc.EnforceCompany(tt.args.ctx, tt.args.id)
 			assert.Equal(t, tt.wantErr, res == echo.ErrForbidden)
 		})
@@ -157,7 +162,7 @@ func TestEnforceLocation(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			rbacSvc := rbac.New(nil)
+			rbacSvc := rbac.New(nil, nil, nil)
 			res := rbacSvc.EnforceLocation(tt.args.ctx, tt.args.id)
 			assert.Equal(t, tt.wantErr, res == echo.ErrForbidden)
 		})
@@ -209,7 +214,7 @@ func TestAccountCreate(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			rbacSvc := rbac.New(nil)
+			rbacSvc := rbac.New(nil, nil, nil)
 			res := rbacSvc.AccountCreate(tt.args.ctx, tt.args.roleID, tt.args.company_id, tt.args.location_id)
 			assert.Equal(t, tt.wantErr, res == echo.ErrForbidden)
 		})
@@ -218,7 +223,7 @@ func TestAccountCreate(t *testing.T) {
 
 func TestIsLowerRole(t *testing.T) {
 	ctx := mock.EchoCtxWithKeys([]string{"role"}, int8(3))
-	rbacSvc := rbac.New(nil)
+	rbacSvc := rbac.New(nil, nil, nil)
 	if rbacSvc.IsLowerRole(ctx, model.AccessRole(4)) != nil {
 		t.Error("The requested user is higher role than the user requesting it")
 	}
@@ -226,3 +231,247 @@ func TestIsLowerRole(t *testing.T) {
 		t.Error("The requested user is lower role than the user requesting it")
 	}
 }
+
+func TestEnforceParticipant(t *testing.T) {
+	cases := []struct {
+		name    string
+		ctx     echo.Context
+		wantErr bool
+	}{
+		{
+			name:    "Not a participant, but admin",
+			ctx:     mock.EchoCtxWithKeys([]string{"id", "role"}, 5, int8(1)),
+			wantErr: true,
+		},
+		{
+			name: "Participant",
+			ctx:  mock.EchoCtxWithKeys([]string{"id", "role"}, 3, int8(5)),
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rbacSvc := rbac.New(nil, nil, nil)
+			err := rbacSvc.EnforceParticipant(tt.ctx, []int{2, 3})
+			assert.Equal(t, tt.wantErr, err == echo.ErrForbidden)
+		})
+	}
+}
+
+func TestEnforceGroup(t *testing.T) {
+	cases := []struct {
+		name     string
+		ctx      echo.Context
+		role     model.GroupRole
+		required model.GroupRole
+		wantErr  bool
+	}{
+		{
+			name:     "Not a member",
+			ctx:      mock.EchoCtxWithKeys([]string{"id", "company_id", "role"}, 3, 1, int8(5)),
+			required: model.GroupRoleMember,
+			wantErr:  true,
+		},
+		{
+			name:     "Member moderating",
+			ctx:      mock.EchoCtxWithKeys([]string{"id", "company_id", "role"}, 3, 1, int8(5)),
+			role:     model.GroupRoleMember,
+			required: model.GroupRoleModerator,
+			wantErr:  true,
+		},
+		{
+			name:     "Owner moderating",
+			ctx:      mock.EchoCtxWithKeys([]string{"id", "company_id", "role"}, 3, 1, int8(5)),
+			role:     model.GroupRoleOwner,
+			required: model.GroupRoleModerator,
+		},
+		{
+			name:     "Company admin of the group's company",
+			ctx:      mock.EchoCtxWithKeys([]string{"id", "company_id", "role"}, 3, 1, int8(3)),
+			required: model.GroupRoleOwner,
+		},
+		{
+			name:     "Company admin of another company",
+			ctx:      mock.EchoCtxWithKeys([]string{"id", "company_id", "role"}, 3, 2, int8(3)),
+			required: model.GroupRoleModerator,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rbacSvc := rbac.New(nil, nil, nil)
+			err := rbacSvc.EnforceGroup(tt.ctx, 1, tt.role, tt.required)
+			assert.Equal(t, tt.wantErr, err == echo.ErrForbidden)
+		})
+	}
+}
+
+func TestDecisionLogging(t *testing.T) {
+	e := echo.New()
+	buf := new(bytes.Buffer)
+	e.Logger.SetOutput(buf)
+	rbacSvc := rbac.New(nil, nil, e.Logger)
+	ctx := mock.EchoCtxWithKeys([]string{"id", "company_id", "role"}, 3, 7, int8(3))
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.EnforceCompany(ctx, 9))
+	assert.Contains(t, buf.String(), `"action":"enforce_company"`)
+	assert.Contains(t, buf.String(), `"allowed":false`)
+	assert.Contains(t, buf.String(), `"scope":"company"`)
+}
+
+func TestExplain(t *testing.T) {
+	type args struct {
+		ctx    echo.Context
+		sub    model.AuthUser
+		action string
+		res    model.AuthzResource
+	}
+	cases := []struct {
+		name     string
+		args     args
+		wantErr  bool
+		wantData *model.AuthzTrace
+	}{
+		{
+			name:    "Requested by non-admin",
+			args:    args{ctx: mock.EchoCtxWithKeys([]string{"role"}, int8(3)), action: rbac.ActionRole},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown action",
+			args:    args{ctx: mock.EchoCtxWithKeys([]string{"role"}, int8(1)), action: "fly"},
+			wantErr: true,
+		},
+		{
+			name: "Company admin of a different company",
+			args: args{
+				ctx:    mock.EchoCtxWithKeys([]string{"role"}, int8(2)),
+				sub:    model.AuthUser{ID: 5, CompanyID: 2, Role: model.CompanyAdminRole},
+				action: rbac.ActionCompany,
+				res:    model.AuthzResource{CompanyID: 3},
+			},
+			wantData: &model.AuthzTrace{
+				Action:  rbac.ActionCompany,
+				Subject: 5,
+				Allowed: false,
+				Decisions: []model.AuthzDecision{
+					{Check: "role", Role: model.CompanyAdminRole, Required: model.AdminRole},
+					{Check: "role", Role: model.CompanyAdminRole, Required: model.CompanyAdminRole, Allowed: true},
+					{Check: "scope", Role: model.CompanyAdminRole, Scope: "company", Have: 2, Want: 3},
+				},
+			},
+		},
+		{
+			name: "Location admin creating user in own location",
+			args: args{
+				ctx:    mock.EchoCtxWithKeys([]string{"role"}, int8(1)),
+				sub:    model.AuthUser{ID: 8, LocationID: 4, Role: model.LocationAdminRole},
+				action: rbac.ActionAccountCreate,
+				res:    model.AuthzResource{Role: model.UserRole, LocationID: 4},
+			},
+			wantData: &model.AuthzTrace{
+				Action:  rbac.ActionAccountCreate,
+				Subject: 8,
+				Allowed: true,
+				Decisions: []model.AuthzDecision{
+					{Check: "role", Role: model.LocationAdminRole, Required: model.CompanyAdminRole},
+					{Check: "role", Role: model.LocationAdminRole, Required: model.LocationAdminRole, Allowed: true},
+					{Check: "scope", Role: model.LocationAdminRole, Scope: "location", Have: 4, Want: 4, Allowed: true},
+					{Check: "lower_role", Role: model.LocationAdminRole, Required: model.UserRole, Allowed: true},
+				},
+			},
+		},
+		{
+			name: "Company admin moderating a group",
+			args: args{
+				ctx:    mock.EchoCtxWithKeys([]string{"role"}, int8(1)),
+				sub:    model.AuthUser{ID: 6, CompanyID: 2, Role: model.CompanyAdminRole},
+				action: rbac.ActionGroup,
+				res:    model.AuthzResource{CompanyID: 2, RequiredGroupRole: model.GroupRoleModerator},
+			},
+			wantData: &model.AuthzTrace{
+				Action:  rbac.ActionGroup,
+				Subject: 6,
+				Allowed: true,
+				Decisions: []model.AuthzDecision{
+					{Check: "group_role", Role: model.CompanyAdminRole, Scope: "group", Want: 2},
+					{Check: "role", Role: model.CompanyAdminRole, Required: model.AdminRole},
+					{Check: "role", Role: model.CompanyAdminRole, Required: model.CompanyAdminRole, Allowed: true},
+					{Check: "scope", Role: model.CompanyAdminRole, Scope: "company", Have: 2, Want: 2, Allowed: true},
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rbacSvc := rbac.New(nil, nil, nil)
+			trace, err := rbacSvc.Explain(tt.args.ctx, tt.args.sub, tt.args.action, tt.args.res)
+			assert.Equal(t, tt.wantData, trace)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestEffective(t *testing.T) {
+	cases := []struct {
+		name     string
+		gdb      *mockdb.Grant
+		user     *model.AuthUser
+		wantData *model.AuthUser
+		wantErr  bool
+	}{
+		{
+			name:     "Without grants",
+			user:     &model.AuthUser{ID: 1, Role: model.UserRole},
+			wantData: &model.AuthUser{ID: 1, Role: model.UserRole},
+		},
+		{
+			name: "Fail on listing grants",
+			gdb: &mockdb.Grant{
+				ListActiveFn: func(orm.DB, int, time.Time) ([]model.RoleGrant, error) {
+					return nil, model.ErrGeneric
+				},
+			},
+			user:    &model.AuthUser{ID: 1, Role: model.UserRole},
+			wantErr: true,
+		},
+		{
+			name: "Active grant applied",
+			gdb: &mockdb.Grant{
+				ListActiveFn: func(db orm.DB, id int, now time.Time) ([]model.RoleGrant, error) {
+					return []model.RoleGrant{{
+						Base:        model.Base{ID: 7},
+						UserID:      id,
+						AccessLevel: model.LocationAdminRole,
+						CompanyID:   1,
+						LocationID:  3,
+						StartsAt:    now.Add(-time.Hour),
+						EndsAt:      now.Add(time.Hour),
+					}}, nil
+				},
+			},
+			user:     &model.AuthUser{ID: 1, CompanyID: 1, LocationID: 2, Role: model.UserRole},
+			wantData: &model.AuthUser{ID: 1, CompanyID: 1, LocationID: 3, Role: model.LocationAdminRole, GrantID: 7},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var gdb model.GrantDB
+			if tt.gdb != nil {
+				gdb = tt.gdb
+			}
+			rbacSvc := rbac.New(nil, gdb, nil)
+			u, err := rbacSvc.Effective(tt.user)
+			assert.Equal(t, tt.wantData, u)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestEnforceLocationWithGrant(t *testing.T) {
+	ctx := mock.EchoCtxWithKeys([]string{"id", "location_id", "role", "grant_id"}, 3, 6, int8(4), 7)
+	e := echo.New()
+	buf := new(bytes.Buffer)
+	e.Logger.SetOutput(buf)
+	rbacSvc := rbac.New(nil, nil, e.Logger)
+	assert.Nil(t, rbacSvc.EnforceLocation(ctx, 6))
+	assert.Contains(t, buf.String(), `"grant_id":7`)
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"encoding/json"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// Realtime event types
+const (
+	EventMessage       = "message"
+	EventFriendRequest = "friend_request"
+	EventNotification  = "notification"
+	EventPresence      = "presence"
+	EventHeartbeat     = "heartbeat"
+)
+
+// Event represents realtime event pushed to connected users.
+// Event IDs increase over time, and are assigned by the broker when published
+type Event struct {
+	ID      int64           `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	UserID  int             `json:"user_id"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// NewEvent creates new event for the user, encoding payload to JSON
+func NewEvent(typ string, userID int, payload interface{}) (Event, error) {
+	e := Event{Type: typ, UserID: userID}
+	if payload == nil {
+		return e, nil
+	}
+	b, err := json.Marshal(payload)
+	e.Payload = b
+	return e, err
+}
+
+// Subscription receives events published to a user.
+// Events is closed when the subscription ends, e.g. when the subscriber cannot keep up with events
+type Subscription struct {
+	UserID int
+	Events chan Event
+}
+
+// Broker represents realtime event broker interface.
+// Events published in a transaction may be delivered only once it commits.
+// Since returns recent events of the user published after the given event ID, oldest first
+type Broker interface {
+	Publish(orm.DB, Event) error
+	Subscribe(int) *Subscription
+	Unsubscribe(*Subscription)
+	Since(int, int64) []Event
+}
@@ -0,0 +1,141 @@
+// Package realtime contains in-process realtime event broker
+package realtime
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+const (
+	// logRetention is how long events are kept for users without subscriptions
+	logRetention = 10 * time.Minute
+
+	// pruneInterval is time between pruning logs of users without subscriptions
+	pruneInterval = time.Minute
+)
+
+// NewHub creates new in-process event broker.
+// Each subscription buffers up to buffer events, and up to history recent events are kept per user for resumption
+func NewHub(buffer, history int) *Hub {
+	return &Hub{
+		buffer:  buffer,
+		history: history,
+		subs:    make(map[int]map[*model.Subscription]struct{}),
+		log:     make(map[int][]model.Event),
+		logged:  make(map[int]time.Time),
+	}
+}
+
+// Hub fans out events to all subscriptions of a user, e.g. to every connection of the user.
+// Slow subscribers whose buffer is full are dropped, rather than blocking publishers.
+// IDs assigned by Hub start over when the process restarts, so durable brokers
+// should assign IDs themselves and use Hub only to fan out events
+type Hub struct {
+	buffer  int
+	history int
+
+	mu     sync.Mutex
+	lastID int64
+	subs   map[int]map[*model.Subscription]struct{}
+	log    map[int][]model.Event
+	logged map[int]time.Time
+	pruned time.Time
+}
+
+// Publish delivers event to subscriptions of its user, assigning it an ID unless it already has one.
+// Hub is not transactional, db is ignored
+func (h *Hub) Publish(db orm.DB, e model.Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if e.ID == 0 {
+		h.lastID++
+		e.ID = h.lastID
+	} else if e.ID > h.lastID {
+		h.lastID = e.ID
+	}
+	if h.history > 0 {
+		log := append(h.log[e.UserID], e)
+		if len(log) > h.history {
+			log = append([]model.Event(nil), log[len(log)-h.history:]...)
+		}
+		h.log[e.UserID] = log
+		now := time.Now()
+		h.logged[e.UserID] = now
+		h.prune(now)
+	}
+	for s := range h.subs[e.UserID] {
+		select {
+		case s.Events <- e:
+		default:
+			h.remove(s)
+		}
+	}
+	return nil
+}
+
+// Since returns logged events of the user published after the event with the given ID
+func (h *Hub) Since(userID int, after int64) []model.Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var events []model.Event
+	for _, e := range h.log[userID] {
+		if e.ID > after {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// Subscribe subscribes to events of the user
+func (h *Hub) Subscribe(userID int) *model.Subscription {
+	s := &model.Subscription{UserID: userID, Events: make(chan model.Event, h.buffer)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[*model.Subscription]struct{})
+	}
+	h.subs[userID][s] = struct{}{}
+	return s
+}
+
+// Unsubscribe ends the subscription. It is safe to unsubscribe dropped subscriptions
+func (h *Hub) Unsubscribe(s *model.Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(s)
+}
+
+// prune forgets logged events of users without subscriptions, to whom nothing was published for logRetention.
+// Must be called with lock held
+func (h *Hub) prune(now time.Time) {
+	if now.Sub(h.pruned) < pruneInterval {
+		return
+	}
+	h.pruned = now
+	for userID, at := range h.logged {
+		if _, ok := h.subs[userID]; !ok && now.Sub(at) > logRetention {
+			delete(h.log, userID)
+			delete(h.logged, userID)
+		}
+	}
+}
+
+// remove closes the subscription and forgets it. Must be called with lock held
+func (h *Hub) remove(s *model.Subscription) {
+	subs, ok := h.subs[s.UserID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[s]; !ok {
+		return
+	}
+	delete(subs, s)
+	close(s.Events)
+	if len(subs) == 0 {
+		delete(h.subs, s.UserID)
+	}
+}
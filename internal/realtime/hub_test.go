@@ -0,0 +1,71 @@
+package realtime_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/realtime"
+)
+
+func TestPublish(t *testing.T) {
+	h := realtime.NewHub(2, 0)
+	phone, laptop := h.Subscribe(1), h.Subscribe(1)
+	other := h.Subscribe(2)
+
+	e := model.Event{Type: model.EventMessage, UserID: 1}
+	assert.Nil(t, h.Publish(nil, e))
+	e.ID = 1
+	assert.Equal(t, e, <-phone.Events)
+	assert.Equal(t, e, <-laptop.Events)
+	assert.Len(t, other.Events, 0)
+
+	h.Unsubscribe(laptop)
+	_, ok := <-laptop.Events
+	assert.False(t, ok)
+	assert.Nil(t, h.Publish(nil, e))
+	assert.Equal(t, e, <-phone.Events)
+
+	// Unsubscribing twice is safe
+	h.Unsubscribe(laptop)
+}
+
+func TestPublishSlowSubscriber(t *testing.T) {
+	h := realtime.NewHub(1, 0)
+	slow := h.Subscribe(1)
+	e := model.Event{Type: model.EventNotification, UserID: 1}
+	assert.Nil(t, h.Publish(nil, e))
+	assert.Nil(t, h.Publish(nil, e))
+
+	// Buffered event is still delivered, then the subscription ends
+	e.ID = 1
+	assert.Equal(t, e, <-slow.Events)
+	_, ok := <-slow.Events
+	assert.False(t, ok)
+	h.Unsubscribe(slow)
+}
+
+func TestSince(t *testing.T) {
+	h := realtime.NewHub(0, 2)
+	for _, id := range []int{1, 2, 1, 1} {
+		h.Publish(nil, model.Event{Type: model.EventNotification, UserID: id})
+	}
+	// Events published by another instance keep their IDs
+	h.Publish(nil, model.Event{ID: 10, Type: model.EventNotification, UserID: 2})
+	h.Publish(nil, model.Event{Type: model.EventNotification, UserID: 2})
+
+	assert.Equal(t, []int64{3, 4}, eventIDs(h.Since(1, 0)))
+	assert.Equal(t, []int64{4}, eventIDs(h.Since(1, 3)))
+	assert.Nil(t, h.Since(1, 4))
+	assert.Equal(t, []int64{10, 11}, eventIDs(h.Since(2, 2)))
+	assert.Nil(t, h.Since(3, 0))
+}
+
+func eventIDs(events []model.Event) []int64 {
+	var ids []int64
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
@@ -0,0 +1,27 @@
+package model_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+func TestNewEvent(t *testing.T) {
+	e, err := model.NewEvent(model.EventMessage, 2, &model.Message{Body: "hi"})
+	assert.Nil(t, err)
+	assert.Equal(t, model.EventMessage, e.Type)
+	assert.Equal(t, 2, e.UserID)
+	var m model.Message
+	assert.Nil(t, json.Unmarshal(e.Payload, &m))
+	assert.Equal(t, "hi", m.Body)
+
+	e, err = model.NewEvent(model.EventHeartbeat, 2, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, e.Payload)
+
+	_, err = model.NewEvent(model.EventMessage, 2, make(chan int))
+	assert.NotNil(t, err)
+}
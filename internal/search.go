@@ -0,0 +1,38 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// MaxSearchTerms is the maximum number of terms a search query is split into
+const MaxSearchTerms = 8
+
+// SearchHit represents user found by search, scored by Rank.
+// Highlight holds HTML escaped name, username and email of the user with matching words wrapped in <mark> tags
+type SearchHit struct {
+	User
+	Rank      float64 `json:"rank"`
+	Highlight string  `json:"highlight"`
+}
+
+// SearchTerms splits search query into lowercase terms, each matching words starting with it.
+// Anything but letters and digits separates terms, so queries can not use syntax of search engines
+func SearchTerms(q string) []string {
+	terms := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) > MaxSearchTerms {
+		terms = terms[:MaxSearchTerms]
+	}
+	return terms
+}
+
+// Searcher represents user search engine.
+// Users are matched by names, username, email and address, best matches first, tolerating typos in names.
+// Postgres full-text search implements it, so external engines can be plugged in its place
+type Searcher interface {
+	SearchUsers(orm.DB, []string, *ListQuery, *Pagination) ([]SearchHit, error)
+}
@@ -0,0 +1,39 @@
+// Package search contains user search application services
+package search
+
+import (
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+
+	"github.com/artistomin/friend4me/internal/platform/query"
+)
+
+// New creates new search application service
+func New(searcher model.Searcher, bdb model.BlockDB, auth model.AuthService) *Service {
+	return &Service{searcher: searcher, bdb: bdb, auth: auth}
+}
+
+// Service represents search application service
+type Service struct {
+	searcher model.Searcher
+	bdb      model.BlockDB
+	auth     model.AuthService
+}
+
+// Users returns users matching the search text, best matches first, within the scope the requesting user may list users in.
+// Users who blocked the requesting user, or were blocked by it, are left out for everyone except admins.
+// Only admins may search, so users who are not searchable are found as well
+func (s *Service) Users(c echo.Context, text string, p *model.Pagination) ([]model.SearchHit, error) {
+	u := s.auth.User(c)
+	q, err := query.List(u)
+	if err != nil {
+		return nil, err
+	}
+	if q != nil {
+		if q.Exclude, err = s.bdb.Related(model.Conn(c), u.ID); err != nil {
+			return nil, err
+		}
+	}
+	return s.searcher.SearchUsers(model.Conn(c), model.SearchTerms(text), q, p)
+}
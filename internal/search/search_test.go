@@ -0,0 +1,69 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+	"github.com/artistomin/friend4me/internal/search"
+)
+
+func TestUsers(t *testing.T) {
+	cases := []struct {
+		name     string
+		role     model.AccessRole
+		wantErr  error
+		wantData []model.SearchHit
+	}{
+		{
+			name:    "Regular user",
+			role:    model.UserRole,
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name:     "Admin",
+			role:     model.AdminRole,
+			wantData: []model.SearchHit{{User: model.User{Base: model.Base{ID: 3}}, Rank: 0.5}},
+		},
+		{
+			name:     "Company admin",
+			role:     model.CompanyAdminRole,
+			wantData: []model.SearchHit{{User: model.User{Base: model.Base{ID: 4}}, Rank: 0.5}},
+		},
+	}
+	bdb := &mockdb.Block{
+		RelatedFn: func(db orm.DB, id int) ([]int, error) {
+			return []int{5}, nil
+		}}
+	searcher := &mock.Searcher{
+		SearchUsersFn: func(db orm.DB, terms []string, q *model.ListQuery, p *model.Pagination) ([]model.SearchHit, error) {
+			if len(terms) != 2 || terms[0] != "ann" || terms[1] != "smi" {
+				return nil, model.ErrGeneric
+			}
+			if q == nil {
+				return []model.SearchHit{{User: model.User{Base: model.Base{ID: 3}}, Rank: 0.5}}, nil
+			}
+			if q.ID != 2 || len(q.Exclude) != 1 || q.Searchable {
+				return nil, model.ErrGeneric
+			}
+			return []model.SearchHit{{User: model.User{Base: model.Base{ID: 4}}, Rank: 0.5}}, nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &mock.Auth{
+				UserFn: func(echo.Context) *model.AuthUser {
+					return &model.AuthUser{ID: 1, CompanyID: 2, Role: tt.role}
+				}}
+			hits, err := search.New(searcher, bdb, auth).Users(nil, "Ann Smi", &model.Pagination{Limit: 10})
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantData, hits)
+		})
+	}
+}
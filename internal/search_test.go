@@ -0,0 +1,27 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+func TestSearchTerms(t *testing.T) {
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{query: "  ", want: []string{}},
+		{query: "ann smi", want: []string{"ann", "smi"}},
+		{query: "Anna:* & !Smith | o'Neil", want: []string{"anna", "smith", "o", "neil"}},
+		{query: "jürgen@mail.com", want: []string{"jürgen", "mail", "com"}},
+		{query: "a b c d e f g h i j", want: []string{"a", "b", "c", "d", "e", "f", "g", "h"}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.want, model.SearchTerms(tt.query))
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"github.com/go-pg/pg/orm"
+)
+
+// MutualFriends caches the number of friends two users have in common.
+// It is refreshed whenever friendships of either of the users change
+type MutualFriends struct {
+	UserID      int `sql:",pk"`
+	CandidateID int `sql:",pk"`
+	CompanyID   int
+	Mutual      int
+}
+
+// FriendSuggestion represents public profile of a user suggested as a friend, along with the reasons for the suggestion
+type FriendSuggestion struct {
+	PublicUser
+	Mutual       int  `json:"mutual_friends"`
+	SameLocation bool `json:"same_location"`
+	SameCompany  bool `json:"same_company"`
+	Score        int  `json:"score"`
+}
+
+// SuggestionDB represents friend suggestion database interface (repository).
+// Mutual returns the number of friends two users have in common
+type SuggestionDB interface {
+	List(orm.DB, *User, *Pagination) ([]FriendSuggestion, error)
+	Mutual(orm.DB, int, int) (int, error)
+	Refresh(orm.DB, ...int) error
+}
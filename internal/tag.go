@@ -0,0 +1,57 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// MaxUserTags is the maximum number of interest tags a user can have
+const MaxUserTags = 20
+
+// Tag represents interest tag from company's vocabulary
+type Tag struct {
+	Base
+	CompanyID int    `json:"company_id"`
+	Name      string `json:"name"`
+}
+
+// UserTag links user to an interest tag
+type UserTag struct {
+	UserID    int `sql:",pk"`
+	TagID     int `sql:",pk"`
+	CompanyID int
+}
+
+// NormalizeTag returns tag name in the form it is stored and matched in
+func NormalizeTag(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// TagNames returns names of the tags
+func TagNames(tags []Tag) []string {
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// Match represents public profile of a user discovered through shared interests
+type Match struct {
+	PublicUser
+	SharedTags []string `json:"shared_tags" sql:",array"`
+	Overlap    int      `json:"overlap"`
+}
+
+// TagDB represents interest tag database interface (repository)
+type TagDB interface {
+	Create(orm.DB, Tag) (*Tag, error)
+	View(orm.DB, int) (*Tag, error)
+	List(orm.DB, int) ([]Tag, error)
+	Delete(orm.DB, *Tag) error
+	FindByNames(orm.DB, int, []string) ([]Tag, error)
+	UserTags(orm.DB, int) ([]Tag, error)
+	SetUserTags(orm.DB, *User, []Tag) error
+	Discover(orm.DB, int, *ListQuery, *Pagination) ([]Match, error)
+}
@@ -0,0 +1,55 @@
+// Package tag contains interest tag vocabulary application services
+package tag
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+// New creates new tag vocabulary application service
+func New(tdb model.TagDB, rbac model.RBACService, auth model.AuthService) *Service {
+	return &Service{tdb: tdb, rbac: rbac, auth: auth}
+}
+
+// Service represents tag vocabulary application service
+type Service struct {
+	tdb  model.TagDB
+	rbac model.RBACService
+	auth model.AuthService
+}
+
+// List returns tag vocabulary of requesting user's company
+func (s *Service) List(c echo.Context) ([]model.Tag, error) {
+	return s.tdb.List(model.Conn(c), s.auth.User(c).CompanyID)
+}
+
+// Create adds a tag to company's vocabulary. Zero company ID means requesting user's company.
+// Vocabulary is curated by company admins
+func (s *Service) Create(c echo.Context, companyID int, name string) (*model.Tag, error) {
+	if companyID == 0 {
+		companyID = s.auth.User(c).CompanyID
+	}
+	if err := s.rbac.EnforceCompany(c, companyID); err != nil {
+		return nil, err
+	}
+	name = model.NormalizeTag(name)
+	if name == "" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "tag name is empty")
+	}
+	return s.tdb.Create(model.Conn(c), model.Tag{CompanyID: companyID, Name: name})
+}
+
+// Delete removes a tag from company's vocabulary and from users interested in it
+func (s *Service) Delete(c echo.Context, id int) error {
+	tag, err := s.tdb.View(model.Conn(c), id)
+	if err != nil {
+		return err
+	}
+	if err := s.rbac.EnforceCompany(c, tag.CompanyID); err != nil {
+		return err
+	}
+	return s.tdb.Delete(model.Conn(c), tag)
+}
@@ -0,0 +1,108 @@
+package tag_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+
+	"github.com/labstack/echo"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
+	"github.com/artistomin/friend4me/internal/mock/mockdb"
+	"github.com/artistomin/friend4me/internal/tag"
+)
+
+var auth = &mock.Auth{
+	UserFn: func(echo.Context) *model.AuthUser {
+		return &model.AuthUser{ID: 1, CompanyID: 1}
+	}}
+
+// companyAdmin allows administering company 1 only
+var companyAdmin = &mock.RBAC{
+	EnforceCompanyFn: func(c echo.Context, id int) error {
+		if id != 1 {
+			return echo.ErrForbidden
+		}
+		return nil
+	}}
+
+func TestList(t *testing.T) {
+	tdb := &mockdb.Tag{
+		ListFn: func(db orm.DB, companyID int) ([]model.Tag, error) {
+			if companyID != 1 {
+				return nil, model.ErrGeneric
+			}
+			return []model.Tag{{CompanyID: 1, Name: "chess"}}, nil
+		}}
+	tags, err := tag.New(tdb, nil, auth).List(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []model.Tag{{CompanyID: 1, Name: "chess"}}, tags)
+}
+
+func TestCreate(t *testing.T) {
+	cases := []struct {
+		name      string
+		companyID int
+		tag       string
+		wantErr   bool
+		wantData  *model.Tag
+	}{
+		{
+			name:      "Another company",
+			companyID: 2,
+			tag:       "chess",
+			wantErr:   true,
+		},
+		{
+			name:    "Empty name",
+			tag:     "  ",
+			wantErr: true,
+		},
+		{
+			name:     "Own company",
+			tag:      " Board  Games",
+			wantData: &model.Tag{Base: model.Base{ID: 1}, CompanyID: 1, Name: "board games"},
+		},
+		{
+			name:      "Explicit company",
+			companyID: 1,
+			tag:       "chess",
+			wantData:  &model.Tag{Base: model.Base{ID: 1}, CompanyID: 1, Name: "chess"},
+		},
+	}
+	tdb := &mockdb.Tag{
+		CreateFn: func(db orm.DB, t model.Tag) (*model.Tag, error) {
+			t.ID = 1
+			return &t, nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			created, err := tag.New(tdb, companyAdmin, auth).Create(nil, tt.companyID, tt.tag)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantData, created)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	var deleted []int
+	tdb := &mockdb.Tag{
+		ViewFn: func(db orm.DB, id int) (*model.Tag, error) {
+			if id == 3 {
+				return nil, model.ErrGeneric
+			}
+			return &model.Tag{Base: model.Base{ID: id}, CompanyID: id}, nil
+		},
+		DeleteFn: func(db orm.DB, t *model.Tag) error {
+			deleted = append(deleted, t.ID)
+			return nil
+		}}
+	s := tag.New(tdb, companyAdmin, auth)
+	assert.Equal(t, model.ErrGeneric, s.Delete(nil, 3))
+	assert.Equal(t, echo.ErrForbidden, s.Delete(nil, 2))
+	assert.Nil(t, s.Delete(nil, 1))
+	assert.Equal(t, []int{1}, deleted)
+}
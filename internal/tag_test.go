@@ -0,0 +1,19 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artistomin/friend4me/internal"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	assert.Equal(t, "board games", model.NormalizeTag("  Board   GAMES "))
+	assert.Equal(t, "", model.NormalizeTag(" \t"))
+}
+
+func TestTagNames(t *testing.T) {
+	assert.Equal(t, []string{"chess", "hiking"}, model.TagNames([]model.Tag{{Name: "chess"}, {Name: "hiking"}}))
+	assert.Equal(t, []string{}, model.TagNames(nil))
+}
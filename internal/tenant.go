@@ -0,0 +1,68 @@
+package model
+
+import (
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+)
+
+// connKey is the echo context key holding request's tenant scoped database connection
+const connKey = "db"
+
+// committedKey is the echo context key holding functions to run once request's transaction commits
+const committedKey = "after_commit"
+
+// TenantTx represents database transaction isolated to a single company
+type TenantTx interface {
+	orm.DB
+	Commit() error
+	Rollback() error
+}
+
+// TenantDB represents tenant aware database interface
+type TenantDB interface {
+	Begin(int) (TenantTx, error)
+}
+
+// Conn returns database connection scoped to requesting user's company, as set by tenant middleware.
+// Nil is returned for requests which are not scoped (e.g. login), in which case repositories use their default connection
+func Conn(c echo.Context) orm.DB {
+	if c == nil {
+		return nil
+	}
+	db, _ := c.Get(connKey).(orm.DB)
+	return db
+}
+
+// SetConn stores tenant scoped database connection in request context
+func SetConn(c echo.Context, db orm.DB) {
+	c.Set(connKey, db)
+}
+
+// HoldCommitted makes AfterCommit hold functions back in request context until the transaction commits.
+// The returned function runs them, and is called by tenant middleware once the commit succeeded
+func HoldCommitted(c echo.Context) func() []error {
+	var fns []func() error
+	c.Set(committedKey, &fns)
+	return func() []error {
+		var errs []error
+		for _, fn := range fns {
+			if err := fn(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errs
+	}
+}
+
+// AfterCommit runs fn once request's transaction commits, and never if it is rolled back,
+// e.g. to delete files which the committed rows stopped referring to.
+// Requests without a transaction run fn right away, returning its error
+func AfterCommit(c echo.Context, fn func() error) error {
+	if c != nil {
+		if fns, ok := c.Get(committedKey).(*[]func() error); ok {
+			*fns = append(*fns, fn)
+			return nil
+		}
+	}
+	return fn()
+}
@@ -1,7 +1,11 @@
 package model
 
 import (
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/go-pg/pg/orm"
 )
 
 // User represents user domain model
@@ -24,6 +28,29 @@ type User struct {
 	RoleID     int `json:"-"`
 	CompanyID  int `json:"company_id"`
 	LocationID int `json:"location_id"`
+
+	LastSeenAt   *time.Time `json:"-"`
+	HidePresence bool       `json:"hide_presence"`
+	Presence     *Presence  `json:"presence,omitempty" sql:"-"`
+
+	Bio    string   `json:"bio,omitempty"`
+	Tags   []string `json:"tags,omitempty" sql:"-"`
+	Avatar *Avatar  `json:"avatar,omitempty"`
+
+	ErasedAt *time.Time `json:"-"`
+}
+
+// PublicUser represents profile of a user shown to other users who are not its admins.
+// Contact details, logins, role and the rest of the account are left out. Email is shown only if the user
+// set privacy.show_email preference
+type PublicUser struct {
+	ID        int      `json:"id"`
+	FirstName string   `json:"first_name"`
+	LastName  string   `json:"last_name"`
+	Email     string   `json:"email,omitempty"`
+	Bio       string   `json:"bio,omitempty"`
+	Tags      []string `json:"tags,omitempty" sql:"-"`
+	Avatar    *Avatar  `json:"avatar,omitempty"`
 }
 
 // AuthUser represents data stored in JWT token for user
@@ -34,6 +61,7 @@ type AuthUser struct {
 	Username   string
 	Email      string
 	Role       AccessRole
+	GrantID    int
 }
 
 // UpdateLastLogin updates last login field
@@ -42,18 +70,126 @@ func (u *User) UpdateLastLogin() {
 	u.LastLogin = &t
 }
 
-// AccountDB represents account related database interface (repository)
+// UserSortFields lists fields user lists can be sorted by
+var UserSortFields = map[string]bool{
+	"id":         true,
+	"first_name": true,
+	"last_name":  true,
+	"username":   true,
+	"email":      true,
+	"created_at": true,
+	"last_login": true,
+}
+
+// UserSort represents ordering of user list by a field
+type UserSort struct {
+	Field string
+	Desc  bool
+}
+
+// UserFilter holds user list filters and ordering. Filters narrow the scope the requesting user lists users in.
+// Search matches part of first name, last name, username or email. Users are listed newest first unless Sort is set
+type UserFilter struct {
+	IDs             []int
+	Active          *bool
+	RoleID          int
+	CompanyID       int
+	LocationID      int
+	CreatedAfter    *time.Time
+	CreatedBefore   *time.Time
+	LastLoginAfter  *time.Time
+	LastLoginBefore *time.Time
+	Search          string
+	Sort            []UserSort
+}
+
+// Order returns whitelisted sort fields, followed by id unless sorted by it already, so the order is total
+func (f *UserFilter) Order() []UserSort {
+	var order []UserSort
+	for _, s := range f.Sort {
+		if !UserSortFields[s.Field] {
+			continue
+		}
+		order = append(order, s)
+		if s.Field == "id" {
+			return order
+		}
+	}
+	return append(order, UserSort{Field: "id", Desc: true})
+}
+
+// OrderKey names the order in the form accepted by sort query parameter, e.g. -last_login,first_name,-id
+func (f *UserFilter) OrderKey() string {
+	var fields []string
+	for _, s := range f.Order() {
+		if s.Desc {
+			fields = append(fields, "-"+s.Field)
+			continue
+		}
+		fields = append(fields, s.Field)
+	}
+	return strings.Join(fields, ",")
+}
+
+// Cursor returns cursor pointing at the user, listed in filter's order
+func (f *UserFilter) Cursor(u *User, prev bool) *Cursor {
+	c := &Cursor{Order: f.OrderKey(), Prev: prev}
+	for _, s := range f.Order() {
+		c.Values = append(c.Values, u.sortValue(s.Field))
+	}
+	return c
+}
+
+// sortValue returns value of the field as stored in the database, nil for NULL.
+// Zero values are stored as NULL
+func (u *User) sortValue(field string) *string {
+	var v string
+	switch field {
+	case "id":
+		v = strconv.Itoa(u.ID)
+	case "first_name":
+		v = u.FirstName
+	case "last_name":
+		v = u.LastName
+	case "username":
+		v = u.Username
+	case "email":
+		v = u.Email
+	case "created_at":
+		if !u.CreatedAt.IsZero() {
+			v = u.CreatedAt.Format(time.RFC3339Nano)
+		}
+	case "last_login":
+		if u.LastLogin != nil {
+			v = u.LastLogin.Format(time.RFC3339Nano)
+		}
+	}
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+// AccountDB represents account related database interface (repository).
+// Import creates users one by one, returning errors of users that could not be created by their index
 type AccountDB interface {
-	Create(User) (*User, error)
-	ChangePassword(*User) error
+	Create(orm.DB, User) (*User, error)
+	ChangePassword(orm.DB, *User) error
+	Import(orm.DB, []User, ImportMode) ([]error, error)
 }
 
-// UserDB represents user database interface (repository)
+// UserDB represents user database interface (repository).
+// Deleted users are kept until purged, which removes the ones deleted before the given time for good
 type UserDB interface {
-	View(int) (*User, error)
-	FindByUsername(string) (*User, error)
-	FindByToken(string) (*User, error)
-	List(*ListQuery, *Pagination) ([]User, error)
-	Delete(*User) error
-	Update(*User) (*User, error)
+	View(orm.DB, int) (*User, error)
+	FindByUsername(orm.DB, string) (*User, error)
+	FindByToken(orm.DB, string) (*User, error)
+	List(orm.DB, *ListQuery, *UserFilter, *Pagination) ([]User, error)
+	Delete(orm.DB, *User) error
+	Update(orm.DB, *User) (*User, error)
+	Bulk(orm.DB, []int, BulkChange) error
+	ListDeleted(orm.DB, *ListQuery, *UserFilter, *Pagination) ([]User, error)
+	Restore(orm.DB, *User) error
+	Purge(orm.DB, time.Time) ([]User, error)
+	Location(orm.DB, int) (*Location, error)
 }
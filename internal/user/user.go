@@ -2,53 +2,319 @@
 package user
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/artistomin/friend4me/internal"
 	"github.com/labstack/echo"
 
+	"github.com/artistomin/friend4me/internal/avatar"
 	"github.com/artistomin/friend4me/internal/platform/query"
 	"github.com/artistomin/friend4me/internal/platform/structs"
 )
 
 // New creates new user application service
-func New(udb model.UserDB, rbac model.RBACService, auth model.AuthService) *Service {
-	return &Service{udb: udb, rbac: rbac, auth: auth}
+func New(udb model.UserDB, bdb model.BlockDB, tdb model.TagDB, rbac model.RBACService, notifier model.Notifier, activity model.ActivityLogger, auth model.AuthService, st model.Storage) *Service {
+	return &Service{udb: udb, bdb: bdb, tdb: tdb, rbac: rbac, notifier: notifier, activity: activity, auth: auth, st: st}
 }
 
 // Service represents user application service
 type Service struct {
-	udb  model.UserDB
-	rbac model.RBACService
-	auth model.AuthService
+	udb      model.UserDB
+	bdb      model.BlockDB
+	tdb      model.TagDB
+	rbac     model.RBACService
+	notifier model.Notifier
+	activity model.ActivityLogger
+	auth     model.AuthService
+	st       model.Storage
 }
 
-// List returns list of users
-func (s *Service) List(c echo.Context, p *model.Pagination) ([]model.User, error) {
+// List returns list of users matching the filter, within the scope the requesting user may list.
+// Users who blocked the requesting user, or were blocked by it, are left out for everyone except admins.
+// Pagination cursors have to come from a list in the same order
+func (s *Service) List(c echo.Context, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
+	if f == nil {
+		f = new(model.UserFilter)
+	}
+	if p.Cursor != nil && (p.Cursor.Order != f.OrderKey() || len(p.Cursor.Values) != len(f.Order())) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "cursor does not match sort order")
+	}
+	q, err := s.scope(c)
+	if err != nil {
+		return nil, err
+	}
+	return s.udb.List(model.Conn(c), q, f, p)
+}
+
+// Export lists users as List does, in batches of model.ExportBatch read one after another in filter's order.
+// write is called with every batch until all users are listed, so the whole list is never held in memory
+func (s *Service) Export(c echo.Context, f *model.UserFilter, write func([]model.User) error) error {
+	if f == nil {
+		f = new(model.UserFilter)
+	}
+	q, err := s.scope(c)
+	if err != nil {
+		return err
+	}
+	p := &model.Pagination{Limit: model.ExportBatch}
+	for {
+		users, err := s.udb.List(model.Conn(c), q, f, p)
+		if err != nil {
+			return err
+		}
+		if len(users) > 0 {
+			if err := write(users); err != nil {
+				return err
+			}
+		}
+		if len(users) < p.Limit {
+			return nil
+		}
+		p.Cursor = f.Cursor(&users[len(users)-1], false)
+	}
+}
+
+// scope returns query limiting users to the ones the requesting user may list
+func (s *Service) scope(c echo.Context) (*model.ListQuery, error) {
 	u := s.auth.User(c)
 	q, err := query.List(u)
 	if err != nil {
 		return nil, err
 	}
-	return s.udb.List(q, p)
+	if q != nil {
+		if q.Exclude, err = s.bdb.Related(model.Conn(c), u.ID); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
 }
 
-// View returns single user
+// View returns single user with its interest tags.
+// Users blocked by the requested user, or blocking it, cannot view it unless they are admins
 func (s *Service) View(c echo.Context, id int) (*model.User, error) {
 	if err := s.rbac.EnforceUser(c, id); err != nil {
 		return nil, err
 	}
-	return s.udb.View(id)
+	if u := s.auth.User(c); u.ID != id && u.Role > model.AdminRole {
+		blocked, err := s.bdb.Blocked(model.Conn(c), u.ID, id)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, echo.ErrNotFound
+		}
+	}
+	usr, err := s.udb.View(model.Conn(c), id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.withTags(c, usr); err != nil {
+		return nil, err
+	}
+	return usr, nil
+}
+
+// Discover returns users sharing interest tags with requesting user, most shared tags first.
+// Regular users discover people within their company, admins within the scope they administer.
+// Users who blocked the requesting user, or were blocked by it, are left out,
+// and so are users who are not searchable when a regular user discovers people
+func (s *Service) Discover(c echo.Context, p *model.Pagination) ([]model.Match, error) {
+	u := s.auth.User(c)
+	q := query.Discover(u)
+	if q == nil {
+		q = new(model.ListQuery)
+	}
+	q.Searchable = u.Role == model.UserRole
+	var err error
+	if q.Exclude, err = s.bdb.Related(model.Conn(c), u.ID); err != nil {
+		return nil, err
+	}
+	return s.tdb.Discover(model.Conn(c), u.ID, q, p)
 }
 
 // Delete deletes a user
 func (s *Service) Delete(c echo.Context, id int) error {
-	u, err := s.udb.View(id)
+	u, err := s.udb.View(model.Conn(c), id)
 	if err != nil {
 		return err
 	}
 	if err := s.rbac.IsLowerRole(c, u.Role.AccessLevel); err != nil {
 		return err
 	}
-	return s.udb.Delete(u)
+	return s.udb.Delete(model.Conn(c), u)
+}
+
+// Bulk applies the change to users given by ids, or to users matching the filter when ids are empty,
+// within the scope the requesting user may list. Users out of the scope are reported as not found,
+// and users whose role is not lower than requesting user's, or who would be moved to a location
+// of another company, are left unchanged.
+// Allowed users are changed at once and told about the change, returning outcome for every user
+func (s *Service) Bulk(c echo.Context, ids []int, f *model.UserFilter, ch model.BulkChange) ([]model.BulkResult, error) {
+	var target *model.Location
+	switch ch.Action {
+	case model.BulkMoveLocation:
+		l, err := s.udb.Location(model.Conn(c), ch.LocationID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.rbac.EnforceCompany(c, l.CompanyID); err != nil {
+			return nil, err
+		}
+		if err := s.rbac.EnforceLocation(c, l.ID); err != nil {
+			return nil, err
+		}
+		target = l
+	case model.BulkChangeRole:
+		if err := s.rbac.IsLowerRole(c, model.AccessRole(ch.RoleID)); err != nil {
+			return nil, err
+		}
+	}
+	q, err := query.List(s.auth.User(c))
+	if err != nil {
+		return nil, err
+	}
+	p := &model.Pagination{Limit: model.MaxBulkUsers + 1}
+	if len(ids) > 0 {
+		f = &model.UserFilter{IDs: ids}
+		p.Limit = len(ids)
+	}
+	users, err := s.udb.List(model.Conn(c), q, f, p)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) > model.MaxBulkUsers {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "filter matches more than 1000 users")
+	}
+	if len(ids) == 0 {
+		for _, u := range users {
+			ids = append(ids, u.ID)
+		}
+	}
+	found := make(map[int]*model.User, len(users))
+	for i := range users {
+		found[users[i].ID] = &users[i]
+	}
+	var allowed []int
+	results := make([]model.BulkResult, len(ids))
+	for i, id := range ids {
+		results[i].ID = id
+		u, ok := found[id]
+		switch {
+		case !ok:
+			results[i].Error = "user not found"
+		case s.rbac.IsLowerRole(c, accessLevel(u)) != nil:
+			results[i].Error = "not allowed to change this user"
+		case target != nil && u.CompanyID != target.CompanyID:
+			results[i].Error = "location belongs to another company"
+		default:
+			results[i].OK = true
+			allowed = append(allowed, id)
+		}
+	}
+	if len(allowed) > 0 {
+		if err := s.udb.Bulk(model.Conn(c), allowed, ch); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range allowed {
+		if err := s.announce(c, found[id], ch); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// announce tells about a bulk change the way changes of single users do: users given a role are notified of it,
+// and users moved to a location are shown on their friends' feeds as having joined it
+func (s *Service) announce(c echo.Context, u *model.User, ch model.BulkChange) error {
+	switch ch.Action {
+	case model.BulkChangeRole:
+		return s.notifier.Notify(c, model.Notification{
+			UserID:    u.ID,
+			CompanyID: u.CompanyID,
+			Type:      model.NotificationRoleChanged,
+			Data:      map[string]interface{}{"access_level": ch.RoleID},
+		})
+	case model.BulkMoveLocation:
+		return s.activity.Log(c, model.Activity{
+			ActorID:   u.ID,
+			CompanyID: u.CompanyID,
+			Type:      model.ActivityJoinedLocation,
+			ObjectID:  ch.LocationID,
+		})
+	}
+	return nil
+}
+
+// accessLevel returns access level of user's role
+func accessLevel(u *model.User) model.AccessRole {
+	if u.Role != nil {
+		return u.Role.AccessLevel
+	}
+	return model.AccessRole(u.RoleID)
+}
+
+// ListDeleted returns deleted users within the scope the requesting user may list, most recently deleted first
+func (s *Service) ListDeleted(c echo.Context, p *model.Pagination) ([]model.User, error) {
+	q, err := query.List(s.auth.User(c))
+	if err != nil {
+		return nil, err
+	}
+	return s.udb.ListDeleted(model.Conn(c), q, nil, p)
+}
+
+// Restore undeletes a user within the scope the requesting user may list, having a lower role than requesting user's
+func (s *Service) Restore(c echo.Context, id int) (*model.User, error) {
+	q, err := query.List(s.auth.User(c))
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.udb.ListDeleted(model.Conn(c), q, &model.UserFilter{IDs: []int{id}}, &model.Pagination{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, echo.ErrNotFound
+	}
+	u := &users[0]
+	if err := s.rbac.IsLowerRole(c, accessLevel(u)); err != nil {
+		return nil, err
+	}
+	if err := s.udb.Restore(model.Conn(c), u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Purge permanently removes users deleted longer than retention ago, and their avatars once they are removed,
+// returning the number of purged users
+func (s *Service) Purge(now time.Time, retention time.Duration) (int, error) {
+	users, err := s.udb.Purge(nil, now.Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	var first error
+	for _, u := range users {
+		if err := avatar.Remove(s.st, u.ID, u.Avatar); err != nil && first == nil {
+			first = err
+		}
+	}
+	return len(users), first
+}
+
+// RunPurge purges deleted users every interval, logging purged users and failures. It never returns
+func (s *Service) RunPurge(retention, interval time.Duration, l echo.Logger) {
+	for now := range time.Tick(interval) {
+		n, err := s.Purge(now, retention)
+		if err != nil {
+			l.Warnf("Purge Error: %v", err)
+			continue
+		}
+		if n > 0 {
+			l.Infof("Purged %d deleted users", n)
+		}
+	}
 }
 
 // Update contains user's information used for updating
@@ -59,17 +325,79 @@ type Update struct {
 	Mobile    *string
 	Phone     *string
 	Address   *string
+
+	HidePresence *bool
+
+	Bio *string
+	// Tags replace user's interest tags, unless nil
+	Tags []string `structs:"-"`
 }
 
-// Update updates user's contact information
+// Update updates user's contact information, profile and presence privacy, recording the profile update.
+// Interest tags have to be in the vocabulary of user's company
 func (s *Service) Update(c echo.Context, u *Update) (*model.User, error) {
 	if err := s.rbac.EnforceUser(c, u.ID); err != nil {
 		return nil, err
 	}
-	usr, err := s.udb.View(u.ID)
+	usr, err := s.udb.View(model.Conn(c), u.ID)
 	if err != nil {
 		return nil, err
 	}
 	structs.Merge(usr, u)
-	return s.udb.Update(usr)
+	usr, err = s.udb.Update(model.Conn(c), usr)
+	if err != nil {
+		return nil, err
+	}
+	if u.Tags != nil {
+		if err := s.setTags(c, usr, u.Tags); err != nil {
+			return nil, err
+		}
+	} else if err := s.withTags(c, usr); err != nil {
+		return nil, err
+	}
+	if err := s.activity.Log(c, model.Activity{
+		ActorID:   usr.ID,
+		CompanyID: usr.CompanyID,
+		Type:      model.ActivityUpdatedProfile,
+	}); err != nil {
+		return nil, err
+	}
+	return usr, nil
+}
+
+// withTags loads names of user's interest tags
+func (s *Service) withTags(c echo.Context, usr *model.User) error {
+	tags, err := s.tdb.UserTags(model.Conn(c), usr.ID)
+	if err != nil {
+		return err
+	}
+	usr.Tags = model.TagNames(tags)
+	return nil
+}
+
+// setTags replaces user's interest tags with the named tags of its company's vocabulary
+func (s *Service) setTags(c echo.Context, usr *model.User, names []string) error {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, n := range names {
+		if n = model.NormalizeTag(n); n != "" && !seen[n] {
+			seen[n] = true
+			normalized = append(normalized, n)
+		}
+	}
+	if len(normalized) > model.MaxUserTags {
+		return echo.NewHTTPError(http.StatusBadRequest, "too many tags")
+	}
+	tags, err := s.tdb.FindByNames(model.Conn(c), usr.CompanyID, normalized)
+	if err != nil {
+		return err
+	}
+	if len(tags) != len(normalized) {
+		return echo.NewHTTPError(http.StatusBadRequest, "unknown tag")
+	}
+	if err := s.tdb.SetUserTags(model.Conn(c), usr, tags); err != nil {
+		return err
+	}
+	usr.Tags = model.TagNames(tags)
+	return nil
 }
@@ -1,7 +1,12 @@
 package user_test
 
 import (
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/go-pg/pg/orm"
 
 	"github.com/labstack/echo"
 
@@ -13,6 +18,30 @@ import (
 	"github.com/artistomin/friend4me/internal/user"
 )
 
+// tagged mocks tag database where user 1 is interested in chess, and hiking is in the vocabulary
+var tagged = &mockdb.Tag{
+	UserTagsFn: func(db orm.DB, id int) ([]model.Tag, error) {
+		if id == 1 {
+			return []model.Tag{{Base: model.Base{ID: 1}, Name: "chess"}}, nil
+		}
+		return nil, nil
+	},
+	FindByNamesFn: func(db orm.DB, companyID int, names []string) ([]model.Tag, error) {
+		var tags []model.Tag
+		for _, n := range names {
+			if n == "chess" || n == "hiking" {
+				tags = append(tags, model.Tag{CompanyID: companyID, Name: n})
+			}
+		}
+		return tags, nil
+	},
+	SetUserTagsFn: func(db orm.DB, u *model.User, tags []model.Tag) error {
+		if u.ID != 1 {
+			return model.ErrGeneric
+		}
+		return nil
+	}}
+
 func TestView(t *testing.T) {
 	type args struct {
 		c  echo.Context
@@ -24,7 +53,9 @@ func TestView(t *testing.T) {
 		wantData *model.User
 		wantErr  error
 		udb      *mockdb.User
+		bdb      *mockdb.Block
 		rbac     *mock.RBAC
+		auth     *mock.Auth
 	}{
 		{
 			name: "Fail on RBAC",
@@ -47,13 +78,18 @@ func TestView(t *testing.T) {
 				FirstName: "John",
 				LastName:  "Doe",
 				Username:  "JohnDoe",
+				Tags:      []string{"chess"},
 			},
 			rbac: &mock.RBAC{
 				EnforceUserFn: func(c echo.Context, id int) error {
 					return nil
 				}},
+			auth: &mock.Auth{
+				UserFn: func(c echo.Context) *model.AuthUser {
+					return &model.AuthUser{ID: 1, Role: model.UserRole}
+				}},
 			udb: &mockdb.User{
-				ViewFn: func(id int) (*model.User, error) {
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
 					if id == 1 {
 						return &model.User{
 							Base: model.Base{
@@ -69,10 +105,44 @@ func TestView(t *testing.T) {
 					return nil, nil
 				}},
 		},
+		{
+			name: "Blocked by requested user",
+			args: args{id: 1},
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(c echo.Context, id int) error {
+					return nil
+				}},
+			auth: &mock.Auth{
+				UserFn: func(c echo.Context) *model.AuthUser {
+					return &model.AuthUser{ID: 2, Role: model.LocationAdminRole}
+				}},
+			bdb: &mockdb.Block{
+				BlockedFn: func(db orm.DB, userID, otherID int) (bool, error) {
+					return userID == 2 && otherID == 1, nil
+				}},
+			wantErr: echo.ErrNotFound,
+		},
+		{
+			name: "Admin is not affected by blocks",
+			args: args{id: 1},
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(c echo.Context, id int) error {
+					return nil
+				}},
+			auth: &mock.Auth{
+				UserFn: func(c echo.Context) *model.AuthUser {
+					return &model.AuthUser{ID: 2, Role: model.AdminRole}
+				}},
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
+					return &model.User{Base: model.Base{ID: id}}, nil
+				}},
+			wantData: &model.User{Base: model.Base{ID: 1}, Tags: []string{"chess"}},
+		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := user.New(tt.udb, tt.rbac, nil)
+			s := user.New(tt.udb, tt.bdb, tagged, tt.rbac, nil, nil, tt.auth, nil)
 			usr, err := s.View(tt.args.c, tt.args.id)
 			assert.Equal(t, tt.wantData, usr)
 			assert.Equal(t, tt.wantErr, err)
@@ -82,8 +152,9 @@ func TestView(t *testing.T) {
 
 func TestList(t *testing.T) {
 	type args struct {
-		c   echo.Context
-		pgn *model.Pagination
+		c      echo.Context
+		filter *model.UserFilter
+		pgn    *model.Pagination
 	}
 	cases := []struct {
 		name     string
@@ -91,6 +162,7 @@ func TestList(t *testing.T) {
 		wantData []model.User
 		wantErr  bool
 		udb      *mockdb.User
+		bdb      *mockdb.Block
 		auth     *mock.Auth
 	}{
 		{
@@ -125,7 +197,7 @@ func TestList(t *testing.T) {
 					}
 				}},
 			udb: &mockdb.User{
-				ListFn: func(*model.ListQuery, *model.Pagination) ([]model.User, error) {
+				ListFn: func(orm.DB, *model.ListQuery, *model.UserFilter, *model.Pagination) ([]model.User, error) {
 					return []model.User{
 						{
 							Base: model.Base{
@@ -175,11 +247,39 @@ func TestList(t *testing.T) {
 					Username:  "hunterlogan",
 				}},
 		},
+		{
+			name: "Blocked users are excluded",
+			args: args{c: nil, filter: &model.UserFilter{Search: "doe"}, pgn: &model.Pagination{
+				Limit:  100,
+				Offset: 0,
+			}},
+			auth: &mock.Auth{
+				UserFn: func(c echo.Context) *model.AuthUser {
+					return &model.AuthUser{
+						ID:         1,
+						CompanyID:  2,
+						LocationID: 3,
+						Role:       model.CompanyAdminRole,
+					}
+				}},
+			bdb: &mockdb.Block{
+				RelatedFn: func(db orm.DB, id int) ([]int, error) {
+					return []int{5, 6}, nil
+				}},
+			udb: &mockdb.User{
+				ListFn: func(db orm.DB, q *model.ListQuery, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
+					if q.ID != 2 || len(q.Exclude) != 2 || f.Search != "doe" {
+						return nil, model.ErrGeneric
+					}
+					return []model.User{{Base: model.Base{ID: 4}}}, nil
+				}},
+			wantData: []model.User{{Base: model.Base{ID: 4}}},
+		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := user.New(tt.udb, nil, tt.auth)
-			usrs, err := s.List(tt.args.c, tt.args.pgn)
+			s := user.New(tt.udb, tt.bdb, nil, nil, nil, nil, tt.auth, nil)
+			usrs, err := s.List(tt.args.c, tt.args.filter, tt.args.pgn)
 			assert.Equal(t, tt.wantData, usrs)
 			assert.Equal(t, tt.wantErr, err != nil)
 		})
@@ -187,6 +287,351 @@ func TestList(t *testing.T) {
 
 }
 
+func TestExport(t *testing.T) {
+	// users mocks database of 1200 admin users, listed by descending ids
+	users := &mockdb.User{
+		ListFn: func(db orm.DB, q *model.ListQuery, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
+			if q != nil || p.Limit != model.ExportBatch || p.Offset != 0 {
+				return nil, model.ErrGeneric
+			}
+			last := 1201
+			if p.Cursor != nil {
+				if p.Cursor.Order != "-id" || p.Cursor.Prev {
+					return nil, model.ErrGeneric
+				}
+				last, _ = strconv.Atoi(*p.Cursor.Values[0])
+			}
+			var list []model.User
+			for id := last - 1; id > 0 && len(list) < p.Limit; id-- {
+				list = append(list, model.User{Base: model.Base{ID: id}})
+			}
+			return list, nil
+		}}
+	cases := []struct {
+		name        string
+		role        model.AccessRole
+		write       func([]model.User) error
+		wantBatches []int
+		wantErr     error
+	}{
+		{
+			name:    "Fail on query List",
+			role:    model.UserRole,
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name:    "Fail on write",
+			role:    model.AdminRole,
+			write:   func([]model.User) error { return model.ErrGeneric },
+			wantErr: model.ErrGeneric,
+		},
+		{
+			name:        "Success",
+			role:        model.AdminRole,
+			wantBatches: []int{500, 500, 200},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &mock.Auth{
+				UserFn: func(c echo.Context) *model.AuthUser {
+					return &model.AuthUser{ID: 1, CompanyID: 2, LocationID: 3, Role: tt.role}
+				}}
+			var batches []int
+			next := 1200
+			write := tt.write
+			if write == nil {
+				write = func(list []model.User) error {
+					if list[0].ID != next {
+						return model.ErrGeneric
+					}
+					next -= len(list)
+					batches = append(batches, len(list))
+					return nil
+				}
+			}
+			s := user.New(users, nil, nil, nil, nil, nil, auth, nil)
+			err := s.Export(nil, nil, write)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantBatches, batches)
+		})
+	}
+}
+
+func TestBulk(t *testing.T) {
+	// users mocks database where user 3 is a location admin, users 2 and 4 regular users of company 1,
+	// and user 6 a regular user of company 2
+	users := func(db orm.DB, q *model.ListQuery, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
+		if q == nil || q.ID != 1 {
+			return nil, model.ErrGeneric
+		}
+		if len(f.IDs) == 0 {
+			if f.LocationID != 2 || p.Limit != model.MaxBulkUsers+1 {
+				return nil, model.ErrGeneric
+			}
+			return []model.User{{Base: model.Base{ID: 2}, RoleID: 5}, {Base: model.Base{ID: 4}, RoleID: 5}}, nil
+		}
+		var list []model.User
+		for _, id := range f.IDs {
+			switch id {
+			case 2, 4:
+				list = append(list, model.User{Base: model.Base{ID: id}, CompanyID: 1, Role: &model.Role{AccessLevel: model.UserRole}})
+			case 3:
+				list = append(list, model.User{Base: model.Base{ID: id}, CompanyID: 1, Role: &model.Role{AccessLevel: model.LocationAdminRole}})
+			case 6:
+				list = append(list, model.User{Base: model.Base{ID: id}, CompanyID: 2, Role: &model.Role{AccessLevel: model.UserRole}})
+			}
+		}
+		return list, nil
+	}
+	// locations mocks database where locations 2 and 3 belong to company 1, and location 7 to company 2
+	locations := func(db orm.DB, id int) (*model.Location, error) {
+		switch id {
+		case 2, 3:
+			return &model.Location{Base: model.Base{ID: id}, CompanyID: 1}, nil
+		case 7:
+			return &model.Location{Base: model.Base{ID: id}, CompanyID: 2}, nil
+		}
+		return nil, model.ErrGeneric
+	}
+	rbac := &mock.RBAC{
+		EnforceCompanyFn: func(c echo.Context, id int) error {
+			if id != 1 {
+				return echo.ErrForbidden
+			}
+			return nil
+		},
+		EnforceLocationFn: func(c echo.Context, id int) error {
+			if id != 2 {
+				return echo.ErrForbidden
+			}
+			return nil
+		},
+		IsLowerRoleFn: func(c echo.Context, r model.AccessRole) error {
+			if r <= model.LocationAdminRole {
+				return echo.ErrForbidden
+			}
+			return nil
+		}}
+	cases := []struct {
+		name         string
+		ids          []int
+		filter       *model.UserFilter
+		change       model.BulkChange
+		role         model.AccessRole
+		wantData     []model.BulkResult
+		wantErr      error
+		wantChanged  []int
+		wantNotified []int
+		wantLogged   []int
+	}{
+		{
+			name:    "Fail on query List",
+			ids:     []int{2},
+			change:  model.BulkChange{Action: model.BulkDeactivate},
+			role:    model.UserRole,
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name:    "Location out of scope",
+			ids:     []int{2},
+			change:  model.BulkChange{Action: model.BulkMoveLocation, LocationID: 3},
+			role:    model.CompanyAdminRole,
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name:    "Fail on loading location",
+			ids:     []int{2},
+			change:  model.BulkChange{Action: model.BulkMoveLocation, LocationID: 9},
+			role:    model.CompanyAdminRole,
+			wantErr: model.ErrGeneric,
+		},
+		{
+			name:    "Location of another company",
+			ids:     []int{2},
+			change:  model.BulkChange{Action: model.BulkMoveLocation, LocationID: 7},
+			role:    model.CompanyAdminRole,
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name:    "Role not lower",
+			ids:     []int{2},
+			change:  model.BulkChange{Action: model.BulkChangeRole, RoleID: 3},
+			role:    model.CompanyAdminRole,
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name:   "By ids",
+			ids:    []int{2, 3, 5, 6, 4},
+			change: model.BulkChange{Action: model.BulkMoveLocation, LocationID: 2},
+			role:   model.CompanyAdminRole,
+			wantData: []model.BulkResult{
+				{ID: 2, OK: true},
+				{ID: 3, Error: "not allowed to change this user"},
+				{ID: 5, Error: "user not found"},
+				{ID: 6, Error: "location belongs to another company"},
+				{ID: 4, OK: true},
+			},
+			wantChanged: []int{2, 4},
+			wantLogged:  []int{2, 4},
+		},
+		{
+			name:         "Change role",
+			ids:          []int{2, 3, 4},
+			change:       model.BulkChange{Action: model.BulkChangeRole, RoleID: 5},
+			role:         model.CompanyAdminRole,
+			wantData:     []model.BulkResult{{ID: 2, OK: true}, {ID: 3, Error: "not allowed to change this user"}, {ID: 4, OK: true}},
+			wantChanged:  []int{2, 4},
+			wantNotified: []int{2, 4},
+		},
+		{
+			name:        "By filter",
+			filter:      &model.UserFilter{LocationID: 2},
+			change:      model.BulkChange{Action: model.BulkDelete},
+			role:        model.CompanyAdminRole,
+			wantData:    []model.BulkResult{{ID: 2, OK: true}, {ID: 4, OK: true}},
+			wantChanged: []int{2, 4},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var changed []int
+			udb := &mockdb.User{
+				ListFn:     users,
+				LocationFn: locations,
+				BulkFn: func(db orm.DB, ids []int, ch model.BulkChange) error {
+					if ch != tt.change {
+						return model.ErrGeneric
+					}
+					changed = ids
+					return nil
+				}}
+			auth := &mock.Auth{
+				UserFn: func(c echo.Context) *model.AuthUser {
+					return &model.AuthUser{ID: 1, CompanyID: 1, LocationID: 1, Role: tt.role}
+				}}
+			var notified, logged []int
+			notifier := &mock.Notifier{
+				NotifyFn: func(c echo.Context, n model.Notification) error {
+					if n.Type != model.NotificationRoleChanged || n.Data["access_level"] != tt.change.RoleID {
+						return model.ErrGeneric
+					}
+					notified = append(notified, n.UserID)
+					return nil
+				}}
+			activity := &mock.ActivityLogger{
+				LogFn: func(c echo.Context, a model.Activity) error {
+					if a.Type != model.ActivityJoinedLocation || a.ObjectID != tt.change.LocationID {
+						return model.ErrGeneric
+					}
+					logged = append(logged, a.ActorID)
+					return nil
+				}}
+			s := user.New(udb, nil, nil, rbac, notifier, activity, auth, nil)
+			res, err := s.Bulk(nil, tt.ids, tt.filter, tt.change)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantData, res)
+			assert.Equal(t, tt.wantChanged, changed)
+			assert.Equal(t, tt.wantNotified, notified)
+			assert.Equal(t, tt.wantLogged, logged)
+		})
+	}
+}
+
+func TestRestore(t *testing.T) {
+	// deleted mocks database where user 2 is a deleted regular user and user 3 a deleted company admin of company 1
+	deleted := func(db orm.DB, q *model.ListQuery, f *model.UserFilter, p *model.Pagination) ([]model.User, error) {
+		if q == nil || q.ID != 1 || len(f.IDs) != 1 || p.Limit != 1 {
+			return nil, model.ErrGeneric
+		}
+		switch f.IDs[0] {
+		case 2:
+			return []model.User{{Base: model.Base{ID: 2, DeletedAt: mock.TestTimePtr(2018)}, Role: &model.Role{AccessLevel: model.UserRole}}}, nil
+		case 3:
+			return []model.User{{Base: model.Base{ID: 3, DeletedAt: mock.TestTimePtr(2018)}, Role: &model.Role{AccessLevel: model.CompanyAdminRole}}}, nil
+		}
+		return nil, nil
+	}
+	cases := []struct {
+		name     string
+		id       int
+		role     model.AccessRole
+		wantData *model.User
+		wantErr  error
+	}{
+		{
+			name:    "Fail on query List",
+			id:      2,
+			role:    model.UserRole,
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name:    "Not deleted or out of scope",
+			id:      4,
+			role:    model.CompanyAdminRole,
+			wantErr: echo.ErrNotFound,
+		},
+		{
+			name:    "Role not lower",
+			id:      3,
+			role:    model.CompanyAdminRole,
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name:     "Success",
+			id:       2,
+			role:     model.CompanyAdminRole,
+			wantData: &model.User{Base: model.Base{ID: 2}, Role: &model.Role{AccessLevel: model.UserRole}},
+		},
+	}
+	rbac := &mock.RBAC{
+		IsLowerRoleFn: func(c echo.Context, r model.AccessRole) error {
+			if r <= model.CompanyAdminRole {
+				return echo.ErrForbidden
+			}
+			return nil
+		}}
+	udb := &mockdb.User{
+		ListDeletedFn: deleted,
+		RestoreFn: func(db orm.DB, u *model.User) error {
+			u.DeletedAt = nil
+			return nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &mock.Auth{
+				UserFn: func(c echo.Context) *model.AuthUser {
+					return &model.AuthUser{ID: 1, CompanyID: 1, Role: tt.role}
+				}}
+			s := user.New(udb, nil, nil, rbac, nil, nil, auth, nil)
+			usr, err := s.Restore(nil, tt.id)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantData, usr)
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	var before time.Time
+	var deleted []string
+	st := &mock.Storage{
+		DeleteFn: func(key string) error {
+			deleted = append(deleted, key)
+			return nil
+		}}
+	s := user.New(&mockdb.User{
+		PurgeFn: func(db orm.DB, t time.Time) ([]model.User, error) {
+			before = t
+			return []model.User{{Base: model.Base{ID: 1}}, {Base: model.Base{ID: 2}, Avatar: &model.Avatar{ID: "a1"}}, {Base: model.Base{ID: 3}}}, nil
+		}}, nil, nil, nil, nil, nil, nil, st)
+	n, err := s.Purge(mock.TestTime(2018), 30*24*time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, mock.TestTime(2018).AddDate(0, 0, -30), before)
+	// Avatars of purged users are deleted
+	assert.Equal(t, (&model.Avatar{ID: "a1"}).Keys(2), deleted)
+}
+
 func TestDelete(t *testing.T) {
 	type args struct {
 		c  echo.Context
@@ -204,7 +649,7 @@ func TestDelete(t *testing.T) {
 			args:    args{id: 1},
 			wantErr: model.ErrGeneric,
 			udb: &mockdb.User{
-				ViewFn: func(id int) (*model.User, error) {
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
 					if id != 1 {
 						return nil, nil
 					}
@@ -216,7 +661,7 @@ func TestDelete(t *testing.T) {
 			name: "Fail on RBAC",
 			args: args{id: 1},
 			udb: &mockdb.User{
-				ViewFn: func(id int) (*model.User, error) {
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
 					return &model.User{
 						Base: model.Base{
 							ID:        id,
@@ -241,7 +686,7 @@ func TestDelete(t *testing.T) {
 			name: "Success",
 			args: args{id: 1},
 			udb: &mockdb.User{
-				ViewFn: func(id int) (*model.User, error) {
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
 					return &model.User{
 						Base: model.Base{
 							ID:        id,
@@ -257,7 +702,7 @@ func TestDelete(t *testing.T) {
 						},
 					}, nil
 				},
-				DeleteFn: func(usr *model.User) error {
+				DeleteFn: func(db orm.DB, usr *model.User) error {
 					return nil
 				},
 			},
@@ -269,7 +714,7 @@ func TestDelete(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := user.New(tt.udb, tt.rbac, nil)
+			s := user.New(tt.udb, nil, nil, tt.rbac, nil, nil, nil, nil)
 			err := s.Delete(tt.args.c, tt.args.id)
 			if err != tt.wantErr {
 				t.Errorf("Expected error %v, received %v", tt.wantErr, err)
@@ -313,7 +758,7 @@ func TestUpdate(t *testing.T) {
 				}},
 			wantErr: model.ErrGeneric,
 			udb: &mockdb.User{
-				ViewFn: func(id int) (*model.User, error) {
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
 					if id != 1 {
 						return nil, nil
 					}
@@ -349,9 +794,10 @@ func TestUpdate(t *testing.T) {
 				Phone:      "234567",
 				Address:    "Work Address",
 				Email:      "golang@go.org",
+				Tags:       []string{"chess"},
 			},
 			udb: &mockdb.User{
-				ViewFn: func(id int) (*model.User, error) {
+				ViewFn: func(db orm.DB, id int) (*model.User, error) {
 					if id == 1 {
 						return &model.User{
 							Base: model.Base{
@@ -372,7 +818,7 @@ func TestUpdate(t *testing.T) {
 					}
 					return nil, model.ErrGeneric
 				},
-				UpdateFn: func(usr *model.User) (*model.User, error) {
+				UpdateFn: func(db orm.DB, usr *model.User) (*model.User, error) {
 					usr.UpdatedAt = mock.TestTime(2000)
 					return usr, nil
 				},
@@ -381,10 +827,124 @@ func TestUpdate(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := user.New(tt.udb, tt.rbac, nil)
+			activity := &mock.ActivityLogger{
+				LogFn: func(c echo.Context, a model.Activity) error {
+					if a.ActorID != 1 || a.Type != model.ActivityUpdatedProfile {
+						return model.ErrGeneric
+					}
+					return nil
+				}}
+			s := user.New(tt.udb, nil, tagged, tt.rbac, nil, activity, nil, nil)
 			usr, err := s.Update(tt.args.c, tt.args.upd)
 			assert.Equal(t, tt.wantData, usr)
 			assert.Equal(t, tt.wantErr, err)
 		})
 	}
 }
+
+func TestUpdateTags(t *testing.T) {
+	bio := "Chess and mountains"
+	cases := []struct {
+		name     string
+		upd      *user.Update
+		wantErr  bool
+		wantData *model.User
+	}{
+		{
+			name:    "Unknown tag",
+			upd:     &user.Update{ID: 1, Tags: []string{"chess", "knitting"}},
+			wantErr: true,
+		},
+		{
+			name:    "Too many tags",
+			upd:     &user.Update{ID: 1, Tags: strings.Split("a b c d e f g h i j k l m n o p q r s t u", " ")},
+			wantErr: true,
+		},
+		{
+			name:     "Normalized tags",
+			upd:      &user.Update{ID: 1, Bio: &bio, Tags: []string{" Hiking", "chess", "HIKING", ""}},
+			wantData: &model.User{Base: model.Base{ID: 1}, CompanyID: 1, Bio: bio, Tags: []string{"hiking", "chess"}},
+		},
+		{
+			name:     "Cleared tags",
+			upd:      &user.Update{ID: 1, Tags: []string{}},
+			wantData: &model.User{Base: model.Base{ID: 1}, CompanyID: 1, Tags: []string{}},
+		},
+	}
+	udb := &mockdb.User{
+		ViewFn: func(db orm.DB, id int) (*model.User, error) {
+			return &model.User{Base: model.Base{ID: id}, CompanyID: 1}, nil
+		},
+		UpdateFn: func(db orm.DB, usr *model.User) (*model.User, error) {
+			return usr, nil
+		}}
+	rbac := &mock.RBAC{
+		EnforceUserFn: func(echo.Context, int) error {
+			return nil
+		}}
+	activity := &mock.ActivityLogger{
+		LogFn: func(echo.Context, model.Activity) error {
+			return nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := user.New(udb, nil, tagged, rbac, nil, activity, nil, nil)
+			usr, err := s.Update(nil, tt.upd)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantData, usr)
+		})
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	cases := []struct {
+		name      string
+		user      *model.AuthUser
+		wantErr   bool
+		wantQuery *model.ListQuery
+	}{
+		{
+			name:      "Regular user",
+			user:      &model.AuthUser{ID: 1, Role: model.UserRole, CompanyID: 2},
+			wantQuery: &model.ListQuery{Query: "company_id = ?", ID: 2, Exclude: []int{7}, Searchable: true},
+		},
+		{
+			name:      "Admin",
+			user:      &model.AuthUser{ID: 1, Role: model.AdminRole, CompanyID: 2},
+			wantQuery: &model.ListQuery{Exclude: []int{7}},
+		},
+		{
+			name:    "Fail on blocks",
+			user:    &model.AuthUser{ID: 3, Role: model.UserRole},
+			wantErr: true,
+		},
+	}
+	bdb := &mockdb.Block{
+		RelatedFn: func(db orm.DB, id int) ([]int, error) {
+			if id != 1 {
+				return nil, model.ErrGeneric
+			}
+			return []int{7}, nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *model.ListQuery
+			tdb := &mockdb.Tag{
+				DiscoverFn: func(db orm.DB, id int, q *model.ListQuery, p *model.Pagination) ([]model.Match, error) {
+					got = q
+					return []model.Match{{PublicUser: model.PublicUser{ID: 5}, SharedTags: []string{"chess"}, Overlap: 1}}, nil
+				}}
+			auth := &mock.Auth{
+				UserFn: func(echo.Context) *model.AuthUser {
+					return tt.user
+				}}
+			s := user.New(nil, bdb, tdb, nil, nil, nil, auth, nil)
+			ms, err := s.Discover(nil, &model.Pagination{Limit: 10})
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantQuery, got)
+			if !tt.wantErr {
+				assert.Len(t, ms, 1)
+			}
+		})
+	}
+}
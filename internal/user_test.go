@@ -3,7 +3,10 @@ package model_test
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/artistomin/friend4me/internal"
+	"github.com/artistomin/friend4me/internal/mock"
 )
 
 func TestUpdateLastLogin(t *testing.T) {
@@ -15,3 +18,38 @@ func TestUpdateLastLogin(t *testing.T) {
 		t.Errorf("Last login time was not changed")
 	}
 }
+
+func TestUserFilterOrder(t *testing.T) {
+	cases := []struct {
+		name       string
+		sort       []model.UserSort
+		wantKey    string
+		wantValues []*string
+	}{
+		{
+			name:       "Default",
+			wantKey:    "-id",
+			wantValues: []*string{mock.Str2Ptr("3")},
+		},
+		{
+			name:       "Unknown fields are left out",
+			sort:       []model.UserSort{{Field: "password"}, {Field: "last_login", Desc: true}, {Field: "first_name"}},
+			wantKey:    "-last_login,first_name,-id",
+			wantValues: []*string{nil, mock.Str2Ptr("John"), mock.Str2Ptr("3")},
+		},
+		{
+			name:       "Sorted by id",
+			sort:       []model.UserSort{{Field: "created_at"}, {Field: "id"}, {Field: "email"}},
+			wantKey:    "created_at,id",
+			wantValues: []*string{mock.Str2Ptr("2001-05-19T01:02:03.000000004Z"), mock.Str2Ptr("3")},
+		},
+	}
+	u := &model.User{Base: model.Base{ID: 3, CreatedAt: mock.TestTime(2001)}, FirstName: "John"}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &model.UserFilter{Sort: tt.sort}
+			assert.Equal(t, tt.wantKey, f.OrderKey())
+			assert.Equal(t, &model.Cursor{Order: tt.wantKey, Values: tt.wantValues, Prev: true}, f.Cursor(u, true))
+		})
+	}
+}